@@ -0,0 +1,120 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.vocdoni.io/dvote/httprouter"
+)
+
+// Error is an error with a stable, machine-readable code and the HTTP status
+//  that should be returned to the client when it reaches the API handlers.
+// The predefined errors in errors.go are meant to be copied with With, Withf
+//  or WithErr, so the code and status are kept while the message is refined.
+type Error struct {
+	Err        error
+	Code       int
+	HTTPstatus int
+	// Fields holds the per-field failures of a request that did not validate
+	Fields []FieldError
+}
+
+// FieldError describes why a single request field is invalid.
+// Field is the JSON path of the field, such as questions[0].choices[1].value
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Response is the JSON body sent to the client for a failed request
+type Response struct {
+	Error  string       `json:"error"`
+	Code   int          `json:"code"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// Error returns the error message
+func (e Error) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("error %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error, so errors.Is and errors.As can inspect it
+func (e Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an Error with the same code
+func (e Error) Is(target error) bool {
+	t, ok := target.(Error)
+	return ok && t.Code == e.Code
+}
+
+// With returns a copy of the error with the given detail appended to its message
+func (e Error) With(detail string) Error {
+	return Error{
+		Err:        fmt.Errorf("%w: %s", e.Err, detail),
+		Code:       e.Code,
+		HTTPstatus: e.HTTPstatus,
+		Fields:     e.Fields,
+	}
+}
+
+// Withf returns a copy of the error with the formatted detail appended to its message
+func (e Error) Withf(format string, args ...interface{}) Error {
+	return e.With(fmt.Sprintf(format, args...))
+}
+
+// WithErr returns a copy of the error wrapping err, appending its message
+func (e Error) WithErr(err error) Error {
+	return Error{
+		Err:        fmt.Errorf("%s: %w", e.Err, err),
+		Code:       e.Code,
+		HTTPstatus: e.HTTPstatus,
+		Fields:     e.Fields,
+	}
+}
+
+// WithFields returns a copy of the error carrying the given field failures.
+// The first failure is appended to the message, the rest are only listed in Fields.
+func (e Error) WithFields(fields []FieldError) Error {
+	if len(fields) == 0 {
+		return e
+	}
+	detail := fmt.Sprintf("%s %s", fields[0].Field, fields[0].Message)
+	if len(fields) > 1 {
+		detail += fmt.Sprintf(" (and %d more)", len(fields)-1)
+	}
+	err := e.With(detail)
+	err.Fields = fields
+	return err
+}
+
+// From returns the first Error found in err's chain. If there is none,
+//  ErrGeneric is returned, so legacy errors keep being reported as bad requests.
+func From(err error) Error {
+	var apiErr Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return ErrGeneric
+}
+
+// Send writes err to the client as a Response, using the HTTP status and code
+//  of the first Error in its chain. The message is the full error chain, so the
+//  context added by fmt.Errorf wrapping is not lost.
+func Send(err error, ctx *httprouter.HTTPContext) error {
+	apiErr := From(err)
+	data, jerr := json.Marshal(Response{
+		Error:  err.Error(),
+		Code:   apiErr.Code,
+		Fields: apiErr.Fields,
+	})
+	if jerr != nil {
+		return fmt.Errorf("error marshaling JSON: %w", jerr)
+	}
+	return ctx.Send(data, apiErr.HTTPstatus)
+}
@@ -0,0 +1,32 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestErrorWrapping(t *testing.T) {
+	err := ErrElectionNotFound.Withf("%x", []byte{0xab})
+	qt.Assert(t, err.Error(), qt.Equals, "election not found: ab")
+	qt.Assert(t, errors.Is(err, ErrElectionNotFound), qt.IsTrue)
+	qt.Assert(t, errors.Is(err, ErrNotFound), qt.IsFalse)
+
+	// the code survives regular fmt.Errorf wrapping
+	wrapped := fmt.Errorf("could not get election: %w", err)
+	qt.Assert(t, From(wrapped).Code, qt.Equals, 4043)
+	qt.Assert(t, From(wrapped).HTTPstatus, qt.Equals, http.StatusNotFound)
+
+	// WithErr keeps the cause reachable
+	cause := fmt.Errorf("connection refused")
+	err = ErrGatewayUnavailable.WithErr(cause)
+	qt.Assert(t, errors.Is(err, cause), qt.IsTrue)
+	qt.Assert(t, err.Error(), qt.Equals, "gateway unavailable: connection refused")
+
+	// untyped errors fall back to the generic bad request
+	qt.Assert(t, From(fmt.Errorf("legacy")).Code, qt.Equals, ErrGeneric.Code)
+	qt.Assert(t, From(fmt.Errorf("legacy")).HTTPstatus, qt.Equals, http.StatusBadRequest)
+}
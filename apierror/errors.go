@@ -0,0 +1,92 @@
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Error codes are grouped by the HTTP status they map to: 4000-4999 are client
+//  errors, 5000-5999 are server or upstream (gateway) errors.
+// Codes are part of the API contract: never reuse or renumber an existing one.
+var (
+	// ErrGeneric is used for errors that carry no Error in their chain
+	ErrGeneric = Error{Code: 4000, HTTPstatus: http.StatusBadRequest,
+		Err: fmt.Errorf("request failed")}
+	ErrCantParseBody = Error{Code: 4001, HTTPstatus: http.StatusBadRequest,
+		Err: fmt.Errorf("could not decode request body")}
+	ErrInvalidURLParam = Error{Code: 4002, HTTPstatus: http.StatusBadRequest,
+		Err: fmt.Errorf("invalid url parameter")}
+	ErrInvalidField = Error{Code: 4003, HTTPstatus: http.StatusBadRequest,
+		Err: fmt.Errorf("invalid request field")}
+	ErrInvalidProofType = Error{Code: 4004, HTTPstatus: http.StatusBadRequest,
+		Err: fmt.Errorf("invalid election proof type")}
+	ErrInvalidDate = Error{Code: 4005, HTTPstatus: http.StatusBadRequest,
+		Err: fmt.Errorf("invalid date")}
+	ErrInvalidFilter = Error{Code: 4006, HTTPstatus: http.StatusBadRequest,
+		Err: fmt.Errorf("invalid filter")}
+	ErrInvalidStatus = Error{Code: 4007, HTTPstatus: http.StatusBadRequest,
+		Err: fmt.Errorf("invalid election status")}
+	ErrInvalidVote = Error{Code: 4008, HTTPstatus: http.StatusBadRequest,
+		Err: fmt.Errorf("invalid vote")}
+
+	ErrInvalidAuthToken = Error{Code: 4010, HTTPstatus: http.StatusUnauthorized,
+		Err: fmt.Errorf("invalid auth token")}
+	ErrInvalidSignature = Error{Code: 4011, HTTPstatus: http.StatusUnauthorized,
+		Err: fmt.Errorf("invalid signature")}
+
+	ErrForbidden = Error{Code: 4030, HTTPstatus: http.StatusForbidden,
+		Err: fmt.Errorf("forbidden")}
+	ErrElectionConfidential = Error{Code: 4031, HTTPstatus: http.StatusForbidden,
+		Err: fmt.Errorf("election is confidential, use the authenticated API")}
+
+	ErrNotFound = Error{Code: 4040, HTTPstatus: http.StatusNotFound,
+		Err: fmt.Errorf("not found")}
+	ErrIntegratorNotFound = Error{Code: 4041, HTTPstatus: http.StatusNotFound,
+		Err: fmt.Errorf("integrator not found")}
+	ErrOrganizationNotFound = Error{Code: 4042, HTTPstatus: http.StatusNotFound,
+		Err: fmt.Errorf("organization not found")}
+	ErrElectionNotFound = Error{Code: 4043, HTTPstatus: http.StatusNotFound,
+		Err: fmt.Errorf("election not found")}
+	ErrPlanNotFound = Error{Code: 4044, HTTPstatus: http.StatusNotFound,
+		Err: fmt.Errorf("plan not found")}
+	ErrTxNotFound = Error{Code: 4045, HTTPstatus: http.StatusNotFound,
+		Err: fmt.Errorf("transaction not found")}
+	ErrVoteNotFound = Error{Code: 4046, HTTPstatus: http.StatusNotFound,
+		Err: fmt.Errorf("vote not found")}
+	ErrResultsNotFound = Error{Code: 4047, HTTPstatus: http.StatusNotFound,
+		Err: fmt.Errorf("results not found")}
+	ErrCspKeyNotFound = Error{Code: 4048, HTTPstatus: http.StatusNotFound,
+		Err: fmt.Errorf("csp key not found")}
+	ErrCspRequestNotFound = Error{Code: 4049, HTTPstatus: http.StatusNotFound,
+		Err: fmt.Errorf("csp signature request not found")}
+
+	ErrAlreadyExists = Error{Code: 4090, HTTPstatus: http.StatusConflict,
+		Err: fmt.Errorf("already exists")}
+	ErrElectionNotReady = Error{Code: 4091, HTTPstatus: http.StatusConflict,
+		Err: fmt.Errorf("election is not accepting this operation in its current state")}
+
+	ErrRateLimited = Error{Code: 4290, HTTPstatus: http.StatusTooManyRequests,
+		Err: fmt.Errorf("rate limit exceeded")}
+
+	ErrInternal = Error{Code: 5000, HTTPstatus: http.StatusInternalServerError,
+		Err: fmt.Errorf("internal error")}
+	ErrDatabase = Error{Code: 5001, HTTPstatus: http.StatusInternalServerError,
+		Err: fmt.Errorf("database error")}
+	ErrCrypto = Error{Code: 5002, HTTPstatus: http.StatusInternalServerError,
+		Err: fmt.Errorf("cryptographic operation failed")}
+	ErrTxCache = Error{Code: 5003, HTTPstatus: http.StatusInternalServerError,
+		Err: fmt.Errorf("transaction cache error")}
+
+	ErrUnimplemented = Error{Code: 5010, HTTPstatus: http.StatusNotImplemented,
+		Err: fmt.Errorf("not implemented")}
+
+	ErrGateway = Error{Code: 5020, HTTPstatus: http.StatusBadGateway,
+		Err: fmt.Errorf("gateway request failed")}
+	ErrMetadata = Error{Code: 5021, HTTPstatus: http.StatusBadGateway,
+		Err: fmt.Errorf("could not fetch or store metadata")}
+
+	ErrGatewayUnavailable = Error{Code: 5030, HTTPstatus: http.StatusServiceUnavailable,
+		Err: fmt.Errorf("gateway unavailable")}
+	ErrFaucetFunds = Error{Code: 5031, HTTPstatus: http.StatusServiceUnavailable,
+		Err: fmt.Errorf("faucet does not have enough tokens")}
+)
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.vocdoni.io/api/types"
+)
+
+// ListAuditEvents lists a page of the audit events matching filter, newest first
+//  (GET /admin/audit), and returns the cursor of the next page
+func (c *Client) ListAuditEvents(filter types.AuditFilter) ([]types.AuditEvent, string, error) {
+	query := auditQuery(filter)
+	if filter.IntegratorID != 0 {
+		query.Set("integratorId", strconv.Itoa(filter.IntegratorID))
+	}
+	var resp []types.AuditEvent
+	next, err := c.Page("/admin/audit", query, &resp)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp, next, nil
+}
+
+// ListIntegratorAuditEvents lists a page of the audit events of the integrator
+//  matching filter, newest first (GET /priv/audit), and returns the cursor of the
+//  next page. filter.IntegratorID is ignored.
+func (c *Client) ListIntegratorAuditEvents(
+	filter types.AuditFilter) ([]types.AuditEvent, string, error) {
+	var resp []types.AuditEvent
+	next, err := c.Page("/priv/audit", auditQuery(filter), &resp)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp, next, nil
+}
+
+func auditQuery(filter types.AuditFilter) url.Values {
+	query := url.Values{}
+	set := func(name, value string) {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	set("actorType", filter.ActorType)
+	set("action", filter.Action)
+	set("outcome", filter.Outcome)
+	if !filter.From.IsZero() {
+		query.Set("from", filter.From.Format(time.RFC3339))
+	}
+	if !filter.To.IsZero() {
+		query.Set("to", filter.To.Format(time.RFC3339))
+	}
+	if filter.Count > 0 {
+		query.Set("count", strconv.Itoa(filter.Count))
+	}
+	if filter.Skip > 0 {
+		query.Set("skip", strconv.Itoa(filter.Skip))
+	}
+	set("cursor", filter.Cursor)
+	return query
+}
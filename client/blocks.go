@@ -0,0 +1,31 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.vocdoni.io/api/types"
+)
+
+// EstimateBlockDate gets the estimated date of a Vochain block, with its error
+//  bound (GET /pub/blocks/estimate?height=)
+func (c *Client) EstimateBlockDate(height uint32) (*types.BlockEstimate, error) {
+	return c.estimateBlock(fmt.Sprintf("height=%d", height))
+}
+
+// EstimateBlockHeight gets the estimated Vochain block at a date, with the error
+//  bound of its date (GET /pub/blocks/estimate?date=)
+func (c *Client) EstimateBlockHeight(date time.Time) (*types.BlockEstimate, error) {
+	return c.estimateBlock("date=" + url.QueryEscape(date.UTC().Format(types.DateLayout)))
+}
+
+func (c *Client) estimateBlock(query string) (*types.BlockEstimate, error) {
+	var estimate types.BlockEstimate
+	if err := c.Request(http.MethodGet, "/pub/blocks/estimate?"+query, nil,
+		&estimate); err != nil {
+		return nil, err
+	}
+	return &estimate, nil
+}
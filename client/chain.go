@@ -0,0 +1,66 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.vocdoni.io/api/types"
+)
+
+// ListChainEvents lists a page of the chain events matching filter, newest first
+//  (GET /admin/chain/events), and returns the cursor of the next page
+func (c *Client) ListChainEvents(filter types.ChainEventFilter) ([]types.ChainEvent, string, error) {
+	query := chainEventQuery(filter)
+	if len(filter.OrgEthAddress) > 0 {
+		query.Set("organizationId", fmt.Sprintf("%x", filter.OrgEthAddress))
+	}
+	var resp []types.ChainEvent
+	next, err := c.Page("/admin/chain/events", query, &resp)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp, next, nil
+}
+
+// ListOrganizationChainEvents lists a page of the chain events of an organization
+//  and its elections matching filter, newest first
+//  (GET /priv/organizations/{organizationId}/chain/events), and returns the cursor
+//  of the next page. filter.OrgEthAddress is ignored.
+func (c *Client) ListOrganizationChainEvents(organizationID []byte,
+	filter types.ChainEventFilter) ([]types.ChainEvent, string, error) {
+	var resp []types.ChainEvent
+	next, err := c.Page(fmt.Sprintf("/priv/organizations/%x/chain/events", organizationID),
+		chainEventQuery(filter), &resp)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp, next, nil
+}
+
+func chainEventQuery(filter types.ChainEventFilter) url.Values {
+	query := url.Values{}
+	if len(filter.ProcessID) > 0 {
+		query.Set("electionId", fmt.Sprintf("%x", filter.ProcessID))
+	}
+	if filter.Kind != "" {
+		query.Set("kind", filter.Kind)
+	}
+	if !filter.From.IsZero() {
+		query.Set("from", filter.From.Format(time.RFC3339))
+	}
+	if !filter.To.IsZero() {
+		query.Set("to", filter.To.Format(time.RFC3339))
+	}
+	if filter.Count > 0 {
+		query.Set("count", strconv.Itoa(filter.Count))
+	}
+	if filter.Skip > 0 {
+		query.Set("skip", strconv.Itoa(filter.Skip))
+	}
+	if filter.Cursor != "" {
+		query.Set("cursor", filter.Cursor)
+	}
+	return query
+}
@@ -0,0 +1,265 @@
+// Package client is the Go SDK for the Voting as a Service API.
+// A Client is bound to one bearer token: the superadmin token for the admin
+//  methods, an integrator key for the private methods, or an organization
+//  API token for the public ones. Use WithToken to derive clients for other tokens.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+)
+
+const (
+	// DefaultRetries is the number of times a failed idempotent request is retried
+	DefaultRetries = 3
+	// DefaultRetryWait is the wait before the first retry, doubled on each attempt
+	DefaultRetryWait = 500 * time.Millisecond
+	// DefaultPollInterval is the interval between transaction status checks in WaitForTx
+	DefaultPollInterval = 4 * time.Second
+	// DefaultTimeout is the timeout of a single HTTP request
+	DefaultTimeout = 30 * time.Second
+	// NextCursorHeader carries the cursor of the next page of a list response
+	NextCursorHeader = "X-Next-Cursor"
+)
+
+// Client performs requests to a VaaS API server
+type Client struct {
+	// Retries and RetryWait control how requests failing with a network error or
+	//  a 502/503 status are retried. Only GET, PUT and DELETE requests are retried.
+	Retries   int
+	RetryWait time.Duration
+	// PollInterval is the interval between transaction status checks
+	PollInterval time.Duration
+
+	url   string
+	token string
+	http  *http.Client
+}
+
+// New returns a client for the API at url, including the version route
+//  (such as https://server/v1), authenticating with token
+func New(url, token string) *Client {
+	return &Client{
+		Retries:      DefaultRetries,
+		RetryWait:    DefaultRetryWait,
+		PollInterval: DefaultPollInterval,
+		url:          strings.TrimSuffix(url, "/"),
+		token:        token,
+		http:         &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// WithToken returns a copy of the client authenticating with token
+func (c *Client) WithToken(token string) *Client {
+	client := *c
+	client.token = token
+	return &client
+}
+
+// Token returns the bearer token used by the client
+func (c *Client) Token() string {
+	return c.token
+}
+
+// Error is returned when the API answers a request with a non-200 status.
+// errors.Is(err, apierror.ErrX) reports whether the API returned the code of apierror.ErrX.
+type Error struct {
+	StatusCode int
+	Code       int
+	Message    string
+	Fields     []apierror.FieldError
+}
+
+// Error returns the message sent by the API
+func (e *Error) Error() string {
+	if e.Code == 0 {
+		return fmt.Sprintf("http status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("http status %d, code %d: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// Is reports whether target is an apierror.Error with the same code
+func (e *Error) Is(target error) bool {
+	t, ok := target.(apierror.Error)
+	return ok && e.Code != 0 && t.Code == e.Code
+}
+
+// StatusCode returns the HTTP status of an API request error: 200 if err is nil,
+//  and 0 if the request did not reach the API
+func StatusCode(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	if apiErr, ok := err.(*Error); ok {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// Request sends a request with the JSON encoded body (if not nil) to path, relative
+//  to the API url, and decodes the response into resp (if not nil). A *[]byte resp
+//  receives the raw response body.
+// It is exported so endpoints not yet wrapped by the client can be reached.
+func (c *Client) Request(method, path string, body, resp interface{}) error {
+	_, err := c.request(method, path, body, resp)
+	return err
+}
+
+// Page requests a page of a list endpoint at path with the given query, decoding
+//  it into resp, and returns the cursor of the next page, empty on the last one.
+func (c *Client) Page(path string, query url.Values, resp interface{}) (string, error) {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	header, err := c.request(http.MethodGet, path, nil, resp)
+	if err != nil {
+		return "", err
+	}
+	return header.Get(NextCursorHeader), nil
+}
+
+// listQuery returns the query parameters selecting the page opts
+func listQuery(opts types.ListOptions) url.Values {
+	query := url.Values{}
+	if opts.Count > 0 {
+		query.Set("count", strconv.Itoa(opts.Count))
+	}
+	if opts.Skip > 0 {
+		query.Set("skip", strconv.Itoa(opts.Skip))
+	}
+	for name, value := range map[string]string{
+		"cursor": opts.Cursor,
+		"sortBy": opts.SortBy,
+		"order":  opts.Order,
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	return query
+}
+
+func (c *Client) request(method, path string, body, resp interface{}) (http.Header, error) {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("could not encode request: %w", err)
+		}
+	}
+	retries := 0
+	if method == http.MethodGet || method == http.MethodPut || method == http.MethodDelete {
+		retries = c.Retries
+	}
+	wait := c.RetryWait
+	for attempt := 0; ; attempt++ {
+		respBody, header, err := c.do(method, path, data)
+		if err == nil {
+			if resp == nil || len(respBody) == 0 {
+				return header, nil
+			}
+			// endpoints not responding with JSON are returned as they are
+			if raw, ok := resp.(*[]byte); ok {
+				*raw = respBody
+				return header, nil
+			}
+			if err := json.Unmarshal(respBody, resp); err != nil {
+				return nil, fmt.Errorf("could not decode response %s: %w", respBody, err)
+			}
+			return header, nil
+		}
+		if attempt >= retries || !retryable(err) {
+			return nil, err
+		}
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+func (c *Client) do(method, path string, data []byte) ([]byte, http.Header, error) {
+	req, err := http.NewRequest(method, c.url+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s %s: could not read response: %w", method, path, err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return body, resp.Header, nil
+	}
+	apiErr := &Error{StatusCode: resp.StatusCode}
+	var errResp apierror.Response
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != "" {
+		apiErr.Code = errResp.Code
+		apiErr.Message = errResp.Error
+		apiErr.Fields = errResp.Fields
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	return nil, nil, apiErr
+}
+
+// retryable reports whether a request failing with err may succeed if retried
+func retryable(err error) bool {
+	apiErr, ok := err.(*Error)
+	if !ok {
+		// the request did not reach the API
+		return true
+	}
+	return apiErr.StatusCode == http.StatusBadGateway ||
+		apiErr.StatusCode == http.StatusServiceUnavailable
+}
+
+// TxMined reports whether the transaction with the given hash has been mined
+func (c *Client) TxMined(txHash []byte) (bool, error) {
+	var resp struct {
+		Mined *bool `json:"mined"`
+	}
+	if err := c.Request(http.MethodGet,
+		fmt.Sprintf("/priv/transactions/%x", txHash), nil, &resp); err != nil {
+		return false, err
+	}
+	return resp.Mined != nil && *resp.Mined, nil
+}
+
+// WaitForTx polls the transaction status every PollInterval until it is mined
+//  or ctx is done
+func (c *Client) WaitForTx(ctx context.Context, txHash []byte) error {
+	for {
+		mined, err := c.TxMined(txHash)
+		if err != nil {
+			return err
+		}
+		if mined {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("transaction %x not mined: %w", txHash, ctx.Err())
+		case <-time.After(c.PollInterval):
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+)
+
+func TestClient(t *testing.T) {
+	var calls, txChecks int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.Header.Get("Authorization") != "Bearer admin" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/admin/accounts":
+			var req types.CreateIntegratorRequest
+			qt.Check(t, json.NewDecoder(r.Body).Decode(&req), qt.IsNil)
+			qt.Check(t, req.Name, qt.Equals, "integrator")
+			json.NewEncoder(w).Encode(types.APIResponse{ID: 7, APIKey: "key"})
+		case "/v1/admin/accounts/8":
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(apierror.Response{Error: "integrator not found", Code: 4041})
+		case "/v1/admin/accounts/9":
+			// gateway down on the first call
+			if atomic.LoadInt32(&calls) < 5 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			json.NewEncoder(w).Encode(types.APIResponse{Name: "recovered"})
+		case "/v1/priv/transactions/abcd":
+			mined := atomic.AddInt32(&txChecks, 1) > 2
+			json.NewEncoder(w).Encode(map[string]bool{"mined": mined})
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL+"/v1/", "admin")
+	c.RetryWait = time.Millisecond
+	c.PollInterval = time.Millisecond
+
+	resp, err := c.CreateIntegrator(types.CreateIntegratorRequest{Name: "integrator"})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, resp.ID, qt.Equals, 7)
+
+	// typed errors
+	_, err = c.GetIntegrator(8)
+	qt.Assert(t, StatusCode(err), qt.Equals, http.StatusNotFound)
+	qt.Assert(t, errors.Is(err, apierror.ErrIntegratorNotFound), qt.IsTrue)
+	qt.Assert(t, errors.Is(err, apierror.ErrOrganizationNotFound), qt.IsFalse)
+	_, err = c.WithToken("wrong").GetIntegrator(8)
+	qt.Assert(t, StatusCode(err), qt.Equals, http.StatusUnauthorized)
+
+	// GET requests are retried when the API is unavailable
+	resp, err = c.GetIntegrator(9)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, resp.Name, qt.Equals, "recovered")
+
+	qt.Assert(t, c.WaitForTx(context.Background(), []byte{0xab, 0xcd}), qt.IsNil)
+	qt.Assert(t, atomic.LoadInt32(&txChecks), qt.Equals, int32(3))
+}
+
+func TestListPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		qt.Check(t, r.URL.Path, qt.Equals, "/v1/priv/organizations/0a0b/elections/active")
+		qt.Check(t, r.URL.Query().Get("title"), qt.Equals, "")
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			w.Header().Set(NextCursorHeader, "page2")
+			json.NewEncoder(w).Encode([]types.APIElectionSummary{{Title: "first"}})
+		case "page2":
+			json.NewEncoder(w).Encode([]types.APIElectionSummary{{Title: "second"}})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL+"/v1", "key")
+	elections, err := c.ListElections([]byte{0x0a, 0x0b}, "active")
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, elections, qt.HasLen, 2)
+	qt.Assert(t, elections[1].Title, qt.Equals, "second")
+
+	elections, next, err := c.ListElectionsPage([]byte{0x0a, 0x0b}, "active",
+		types.ElectionFilter{})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, elections, qt.HasLen, 1)
+	qt.Assert(t, next, qt.Equals, "page2")
+}
+
+func TestResultsExport(t *testing.T) {
+	signer := ethereum.NewSignKeys()
+	qt.Assert(t, signer.Generate(), qt.IsNil)
+	payload, err := json.Marshal(types.ResultsBundlePayload{ChainID: "test", Height: 1200,
+		VoteCount: 3,
+		Results:   &types.VochainResults{VoteCount: 3, Results: [][]string{{"1", "2"}}}})
+	qt.Assert(t, err, qt.IsNil)
+	signature, err := signer.SignEthereum(payload)
+	qt.Assert(t, err, qt.IsNil)
+	bundle := types.ResultsBundle{Payload: payload, Signer: signer.Address().Bytes(),
+		Signature: signature}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		qt.Check(t, r.URL.Path, qt.Equals, "/v1/priv/elections/0a0b/results/export")
+		switch r.URL.Query().Get("format") {
+		case types.ResultsFormatCSV:
+			w.Write([]byte("question,votes\n0,3\n"))
+		case types.ResultsFormatBundle:
+			json.NewEncoder(w).Encode(bundle)
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL+"/v1", "key")
+	data, err := c.ExportElectionResults([]byte{0x0a, 0x0b}, types.ResultsFormatCSV)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, string(data), qt.Equals, "question,votes\n0,3\n")
+	_, verified, err := c.GetResultsBundle([]byte{0x0a, 0x0b})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, verified.ChainID, qt.Equals, "test")
+	qt.Assert(t, verified.Height, qt.Equals, uint32(1200))
+	qt.Assert(t, verified.VoteCount, qt.Equals, uint32(3))
+	qt.Assert(t, verified.Results.Results, qt.DeepEquals, [][]string{{"1", "2"}})
+
+	// the signature covers the exact payload, and must match the signer
+	tampered := bundle
+	tampered.Payload = bytes.Replace(payload, []byte(`"2"`), []byte(`"5"`), 1)
+	_, err = VerifyResultsBundle(&tampered)
+	qt.Assert(t, err, qt.ErrorMatches, "results bundle signed by .*")
+	other := ethereum.NewSignKeys()
+	qt.Assert(t, other.Generate(), qt.IsNil)
+	wrongSigner := bundle
+	wrongSigner.Signer = other.Address().Bytes()
+	_, err = VerifyResultsBundle(&wrongSigner)
+	qt.Assert(t, err, qt.ErrorMatches, "results bundle signed by .*")
+	_, err = VerifyResultsBundle(&bundle)
+	qt.Assert(t, err, qt.IsNil)
+}
+
+func TestVoteReceipt(t *testing.T) {
+	signer := ethereum.NewSignKeys()
+	qt.Assert(t, signer.Generate(), qt.IsNil)
+	payload, err := json.Marshal(types.VoteReceiptPayload{ChainID: "test", Height: 12,
+		Nullifier: []byte{0x0c}, Votes: []int{1, 2}})
+	qt.Assert(t, err, qt.IsNil)
+	signature, err := signer.SignEthereum(payload)
+	qt.Assert(t, err, qt.IsNil)
+	receipt := types.VoteReceipt{Payload: payload, Signer: signer.Address().Bytes(),
+		Signature: signature}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		qt.Check(t, r.URL.Path, qt.Equals, "/v1/pub/nullifiers/0c/receipt")
+		json.NewEncoder(w).Encode(receipt)
+	}))
+	defer server.Close()
+
+	c := New(server.URL+"/v1", "")
+	_, verified, err := c.GetVoteReceipt("0c")
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, verified.Height, qt.Equals, uint32(12))
+	qt.Assert(t, verified.Votes, qt.DeepEquals, []int{1, 2})
+
+	tampered := receipt
+	tampered.Payload = bytes.Replace(payload, []byte(`[1,2]`), []byte(`[2,2]`), 1)
+	_, err = VerifyVoteReceipt(&tampered)
+	qt.Assert(t, err, qt.ErrorMatches, "vote receipt signed by .*")
+}
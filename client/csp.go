@@ -0,0 +1,82 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.vocdoni.io/api/types"
+)
+
+// HostIntegratorCsp makes the API the CSP of an integrator
+//  (POST /admin/accounts/{id}/csp). The response holds the CspPubKey and
+//  CspUrlPrefix set to the integrator.
+func (c *Client) HostIntegratorCsp(id int) (*types.APIResponse, error) {
+	var resp types.APIResponse
+	if err := c.Request(http.MethodPost,
+		fmt.Sprintf("/admin/accounts/%d/csp", id), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// HostOrganizationCsp creates the hosted CSP key of an organization, used as
+//  census root of the elections it creates afterwards
+//  (POST /priv/organizations/{organizationId}/csp)
+func (c *Client) HostOrganizationCsp(organizationID []byte) (*types.APIResponse, error) {
+	var resp types.APIResponse
+	if err := c.Request(http.MethodPost,
+		fmt.Sprintf("/priv/organizations/%x/csp", organizationID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// AddCspVoters adds voters to the list of an election authenticated by id list
+//  or email on the hosted CSP (POST /priv/elections/{electionId}/voters)
+func (c *Client) AddCspVoters(electionID []byte, voters []types.CspVoterEntry) (int, error) {
+	var resp types.APIResponse
+	if err := c.Request(http.MethodPost, fmt.Sprintf("/priv/elections/%x/voters", electionID),
+		types.AddCspVotersRequest{Voters: voters}, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Voters, nil
+}
+
+// CspAuth authenticates a voter to the hosted CSP of an election
+//  (POST /pub/csp/elections/{electionId}/{signType}/auth) and returns the token
+//  of its signature request
+func (c *Client) CspAuth(electionID []byte, signType string,
+	authData []string) ([]byte, error) {
+	var resp types.CspMessage
+	if err := c.Request(http.MethodPost,
+		fmt.Sprintf("/pub/csp/elections/%x/%s/auth", electionID, signType),
+		types.CspMessage{AuthData: authData}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Token, nil
+}
+
+// CspSign signs a voter payload with the token of its request to the hosted CSP
+//  of an election (POST /pub/csp/elections/{electionId}/{signType}/sign)
+func (c *Client) CspSign(electionID []byte, signType string,
+	token, payload []byte) ([]byte, error) {
+	var resp types.CspMessage
+	if err := c.Request(http.MethodPost,
+		fmt.Sprintf("/pub/csp/elections/%x/%s/sign", electionID, signType),
+		types.CspMessage{Token: token, Payload: payload}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Signature, nil
+}
+
+// CspSharedKey gets the shared key of an election from its hosted CSP
+//  (POST /pub/csp/elections/{electionId}/sharedkey)
+func (c *Client) CspSharedKey(electionID []byte, authData []string) ([]byte, error) {
+	var resp types.CspMessage
+	if err := c.Request(http.MethodPost,
+		fmt.Sprintf("/pub/csp/elections/%x/sharedkey", electionID),
+		types.CspMessage{AuthData: authData}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.SharedKey, nil
+}
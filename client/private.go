@@ -0,0 +1,271 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.vocdoni.io/api/types"
+)
+
+// CreateOrganization creates an organization for the integrator
+//  (POST /priv/account/organizations). The response holds the OrganizationID,
+//  its public APIToken and the TxHash creating it on the Vochain.
+func (c *Client) CreateOrganization(
+	req types.CreateOrganizationRequest) (*types.APIResponse, error) {
+	var resp types.APIResponse
+	if err := c.Request(http.MethodPost, "/priv/account/organizations", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListOrganizations lists all the integrator's organizations, following every page
+//  (GET /priv/account/organizations)
+func (c *Client) ListOrganizations() ([]types.APIOrganizationInfo, error) {
+	var organizations []types.APIOrganizationInfo
+	opts := types.ListOptions{}
+	for {
+		page, next, err := c.ListOrganizationsPage(opts)
+		if err != nil {
+			return nil, err
+		}
+		organizations = append(organizations, page...)
+		if next == "" {
+			return organizations, nil
+		}
+		opts.Cursor = next
+	}
+}
+
+// ListOrganizationsPage lists a page of the integrator's organizations
+//  (GET /priv/account/organizations) and returns the cursor of the next page
+func (c *Client) ListOrganizationsPage(
+	opts types.ListOptions) ([]types.APIOrganizationInfo, string, error) {
+	var resp types.APIResponse
+	next, err := c.Page("/priv/account/organizations", listQuery(opts), &resp)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp.Organizations, next, nil
+}
+
+// GetOrganization gets an organization of the integrator
+//  (GET /priv/account/organizations/{organizationId})
+func (c *Client) GetOrganization(organizationID []byte) (*types.APIResponse, error) {
+	var resp types.APIResponse
+	if err := c.Request(http.MethodGet,
+		fmt.Sprintf("/priv/account/organizations/%x", organizationID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteOrganization deletes an organization of the integrator
+//  (DELETE /priv/account/organizations/{organizationId})
+func (c *Client) DeleteOrganization(organizationID []byte) error {
+	return c.Request(http.MethodDelete,
+		fmt.Sprintf("/priv/account/organizations/%x", organizationID), nil, nil)
+}
+
+// ResetOrganizationKey generates a new public API token for an organization
+//  (PATCH /priv/account/organizations/{organizationId}/key) and returns it
+func (c *Client) ResetOrganizationKey(organizationID []byte) (string, error) {
+	var resp types.APIResponse
+	if err := c.Request(http.MethodPatch,
+		fmt.Sprintf("/priv/account/organizations/%x/key", organizationID), nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.APIToken, nil
+}
+
+// SetOrganizationMetadata updates an organization's metadata
+//  (PUT /priv/organizations/{organizationId}/metadata)
+func (c *Client) SetOrganizationMetadata(organizationID []byte,
+	req types.SetOrganizationMetadataRequest) (*types.APIResponse, error) {
+	var resp types.APIResponse
+	if err := c.Request(http.MethodPut,
+		fmt.Sprintf("/priv/organizations/%x/metadata", organizationID), req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CreateElection creates an election of the given proof type for an organization
+//  (POST /priv/organizations/{organizationId}/elections/{type}).
+// The response holds the ElectionID and the TxHash creating it on the Vochain.
+func (c *Client) CreateElection(organizationID []byte, proofType types.ProofType,
+	req types.CreateElectionRequest) (*types.APIResponse, error) {
+	var resp types.APIResponse
+	if err := c.Request(http.MethodPost, fmt.Sprintf("/priv/organizations/%x/elections/%s",
+		organizationID, proofType), req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListElections lists an organization's elections, including the confidential ones,
+//  following every page (GET /priv/organizations/{organizationId}/elections/{filter}).
+// An empty filter lists all of them.
+func (c *Client) ListElections(organizationID []byte,
+	filter string) ([]types.APIElectionSummary, error) {
+	return listElections(c.ListElectionsPage, organizationID, filter)
+}
+
+// ListElectionsPage lists a page of an organization's elections, including the
+//  confidential ones, with the given status filter and election filter
+//  (GET /priv/organizations/{organizationId}/elections/{status}), and returns the
+//  cursor of the next page
+func (c *Client) ListElectionsPage(organizationID []byte, status string,
+	filter types.ElectionFilter) ([]types.APIElectionSummary, string, error) {
+	path := fmt.Sprintf("/priv/organizations/%x/elections", organizationID)
+	if status != "" {
+		path += "/" + status
+	}
+	var resp []types.APIElectionSummary
+	next, err := c.Page(path, electionQuery(filter), &resp)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp, next, nil
+}
+
+// listElections follows every page of an election list
+func listElections(page func([]byte, string, types.ElectionFilter) ([]types.APIElectionSummary,
+	string, error), organizationID []byte, status string) ([]types.APIElectionSummary, error) {
+	var elections []types.APIElectionSummary
+	filter := types.ElectionFilter{}
+	for {
+		list, next, err := page(organizationID, status, filter)
+		if err != nil {
+			return nil, err
+		}
+		elections = append(elections, list...)
+		if next == "" {
+			return elections, nil
+		}
+		filter.Cursor = next
+	}
+}
+
+// electionQuery returns the query parameters selecting the elections of filter.
+//  ActiveAt, UpcomingAt and ProcessIDs are selected with the status of the path.
+func electionQuery(filter types.ElectionFilter) url.Values {
+	query := listQuery(filter.ListOptions)
+	if filter.ProofType != "" {
+		query.Set("proofType", filter.ProofType)
+	}
+	if filter.Title != "" {
+		query.Set("title", filter.Title)
+	}
+	for name, date := range map[string]time.Time{
+		"startAfter":  filter.StartAfter,
+		"startBefore": filter.StartBefore,
+		"endAfter":    filter.EndAfter,
+		"endBefore":   filter.EndBefore,
+	} {
+		if !date.IsZero() {
+			query.Set(name, date.Format(time.RFC3339))
+		}
+	}
+	return query
+}
+
+// GetElection gets an election, including confidential metadata
+//  (GET /priv/elections/{electionId})
+func (c *Client) GetElection(electionID []byte) (*types.APIElectionInfo, error) {
+	var resp types.APIElectionInfo
+	if err := c.Request(http.MethodGet,
+		fmt.Sprintf("/priv/elections/%x", electionID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SetElectionStatus sets the status (READY, PAUSED, ENDED or CANCELED) of an
+//  election (PUT /priv/elections/{electionId}/{status}) and returns the tx hash
+func (c *Client) SetElectionStatus(electionID []byte, status string) ([]byte, error) {
+	var resp types.APIResponse
+	if err := c.Request(http.MethodPut,
+		fmt.Sprintf("/priv/elections/%x/%s", electionID, status), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.TxHash, nil
+}
+
+// ScheduleAction schedules an action (pause or end) on an election at
+//  executeAt (POST /priv/elections/{electionId}/schedule)
+func (c *Client) ScheduleAction(electionID []byte, action string,
+	executeAt time.Time) (*types.ScheduledAction, error) {
+	req := types.ScheduleActionRequest{Action: action,
+		ExecuteAt: executeAt.UTC().Format(types.DateLayout)}
+	var resp types.ScheduledAction
+	if err := c.Request(http.MethodPost,
+		fmt.Sprintf("/priv/elections/%x/schedule", electionID), req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListScheduledActions lists the actions scheduled on an election, in order of
+//  execution (GET /priv/elections/{electionId}/schedule)
+func (c *Client) ListScheduledActions(electionID []byte) ([]types.ScheduledAction, error) {
+	var resp []types.ScheduledAction
+	if err := c.Request(http.MethodGet,
+		fmt.Sprintf("/priv/elections/%x/schedule", electionID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CancelScheduledAction cancels a pending action of an election
+//  (DELETE /priv/elections/{electionId}/schedule/{actionId})
+func (c *Client) CancelScheduledAction(electionID []byte, actionID int) error {
+	return c.Request(http.MethodDelete,
+		fmt.Sprintf("/priv/elections/%x/schedule/%d", electionID, actionID), nil, nil)
+}
+
+// CreateElectionTemplate stores an election template of an organization, from a
+//  definition or from one of its elections
+//  (POST /priv/organizations/{organizationId}/templates). Elections are created
+//  from it with the TemplateID of CreateElectionRequest.
+func (c *Client) CreateElectionTemplate(organizationID []byte,
+	req types.CreateElectionTemplateRequest) (*types.ElectionTemplate, error) {
+	var resp types.ElectionTemplate
+	if err := c.Request(http.MethodPost,
+		fmt.Sprintf("/priv/organizations/%x/templates", organizationID), req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListElectionTemplates lists the election templates of an organization
+//  (GET /priv/organizations/{organizationId}/templates)
+func (c *Client) ListElectionTemplates(organizationID []byte) ([]types.ElectionTemplate, error) {
+	var resp []types.ElectionTemplate
+	if err := c.Request(http.MethodGet,
+		fmt.Sprintf("/priv/organizations/%x/templates", organizationID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetElectionTemplate gets an election template of an organization
+//  (GET /priv/organizations/{organizationId}/templates/{templateId})
+func (c *Client) GetElectionTemplate(organizationID []byte,
+	templateID int) (*types.ElectionTemplate, error) {
+	var resp types.ElectionTemplate
+	if err := c.Request(http.MethodGet, fmt.Sprintf("/priv/organizations/%x/templates/%d",
+		organizationID, templateID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteElectionTemplate deletes an election template of an organization
+//  (DELETE /priv/organizations/{organizationId}/templates/{templateId})
+func (c *Client) DeleteElectionTemplate(organizationID []byte, templateID int) error {
+	return c.Request(http.MethodDelete, fmt.Sprintf("/priv/organizations/%x/templates/%d",
+		organizationID, templateID), nil, nil)
+}
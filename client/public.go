@@ -0,0 +1,167 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.vocdoni.io/api/types"
+)
+
+// ListElectionsPublic lists an organization's non-confidential elections, following
+//  every page (GET /pub/organizations/{organizationId}/elections/{filter}).
+// An empty filter lists all of them.
+func (c *Client) ListElectionsPublic(organizationID []byte,
+	filter string) ([]types.APIElectionSummary, error) {
+	return listElections(c.ListElectionsPublicPage, organizationID, filter)
+}
+
+// ListElectionsPublicPage lists a page of an organization's non-confidential
+//  elections with the given status filter and election filter
+//  (GET /pub/organizations/{organizationId}/elections/{status}), and returns the
+//  cursor of the next page
+func (c *Client) ListElectionsPublicPage(organizationID []byte, status string,
+	filter types.ElectionFilter) ([]types.APIElectionSummary, string, error) {
+	path := fmt.Sprintf("/pub/organizations/%x/elections", organizationID)
+	if status != "" {
+		path += "/" + status
+	}
+	var resp []types.APIElectionSummary
+	next, err := c.Page(path, electionQuery(filter), &resp)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp, next, nil
+}
+
+// GetElectionPublic gets a non-confidential election (GET /pub/elections/{electionId})
+func (c *Client) GetElectionPublic(electionID []byte) (*types.APIElectionInfo, error) {
+	var resp types.APIElectionInfo
+	if err := c.Request(http.MethodGet,
+		fmt.Sprintf("/pub/elections/%x", electionID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetElectionResults gets the results of a non-confidential election
+//  (GET /pub/elections/{electionId}/results)
+func (c *Client) GetElectionResults(electionID []byte) (*types.APIElectionResults, error) {
+	var resp types.APIElectionResults
+	if err := c.Request(http.MethodGet,
+		fmt.Sprintf("/pub/elections/%x/results", electionID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetElectionConfidential gets an election with the CSP signature of its ID,
+//  which is required for confidential elections
+//  (GET /pub/elections/{electionId}/auth/{signature})
+func (c *Client) GetElectionConfidential(electionID,
+	cspSignature []byte) (*types.APIElectionInfo, error) {
+	var resp types.APIElectionInfo
+	if err := c.Request(http.MethodGet, fmt.Sprintf("/pub/elections/%x/auth/%x",
+		electionID, cspSignature), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetOrganizationPublic gets an organization's public metadata
+//  (GET /pub/organizations/{organizationId})
+func (c *Client) GetOrganizationPublic(organizationID []byte) (*types.APIResponse, error) {
+	var resp types.APIResponse
+	if err := c.Request(http.MethodGet,
+		fmt.Sprintf("/pub/organizations/%x", organizationID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SubmitVote relays a signed vote transaction (POST /pub/elections/{electionId}/vote)
+//  and returns the vote nullifier
+func (c *Client) SubmitVote(electionID, signedVoteTx []byte) (string, error) {
+	req := types.SubmitVoteRequest{Vote: base64.StdEncoding.EncodeToString(signedVoteTx)}
+	var resp types.APIResponse
+	if err := c.Request(http.MethodPost,
+		fmt.Sprintf("/pub/elections/%x/vote", electionID), req, &resp); err != nil {
+		return "", err
+	}
+	return resp.Nullifier, nil
+}
+
+// BuildVote builds the vote of voterAddress
+//  (POST /pub/elections/{electionId}/vote/package). Without cspSignature, only
+//  the CSP bundle to sign is built.
+func (c *Client) BuildVote(electionID, voterAddress, cspSignature []byte,
+	votes []int) (*types.APIVotePackage, error) {
+	req := types.BuildVoteRequest{Votes: votes, VoterAddress: hex.EncodeToString(voterAddress)}
+	if len(cspSignature) > 0 {
+		req.CspSignature = hex.EncodeToString(cspSignature)
+	}
+	var resp types.APIVotePackage
+	if err := c.Request(http.MethodPost,
+		fmt.Sprintf("/pub/elections/%x/vote/package", electionID), req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SubmitSignedVote submits a vote transaction built by BuildVote with the
+//  signature of the voter (POST /pub/elections/{electionId}/vote/signed) and
+//  returns its nullifier
+func (c *Client) SubmitSignedVote(electionID, voteTx, signature []byte) (string, error) {
+	req := types.SubmitSignedVoteRequest{VoteTx: hex.EncodeToString(voteTx),
+		Signature: hex.EncodeToString(signature)}
+	var resp types.APIResponse
+	if err := c.Request(http.MethodPost,
+		fmt.Sprintf("/pub/elections/%x/vote/signed", electionID), req, &resp); err != nil {
+		return "", err
+	}
+	return resp.Nullifier, nil
+}
+
+// GetVote gets the status of the vote with the given nullifier
+//  (GET /pub/nullifiers/{nullifier}). Registered is set once the vote is mined.
+func (c *Client) GetVote(nullifier string) (*types.APIResponse, error) {
+	var resp types.APIResponse
+	if err := c.Request(http.MethodGet,
+		fmt.Sprintf("/pub/nullifiers/%s", nullifier), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetVoteReceipt gets the signed receipt of the vote with the given nullifier
+//  (GET /pub/nullifiers/{nullifier}/receipt) and verifies it, returning the
+//  receipt and its verified payload
+func (c *Client) GetVoteReceipt(
+	nullifier string) (*types.VoteReceipt, *types.VoteReceiptPayload, error) {
+	var receipt types.VoteReceipt
+	if err := c.Request(http.MethodGet,
+		fmt.Sprintf("/pub/nullifiers/%s/receipt", nullifier), nil, &receipt); err != nil {
+		return nil, nil, err
+	}
+	payload, err := VerifyVoteReceipt(&receipt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &receipt, payload, nil
+}
+
+// VerifyVoteReceipt checks that a vote receipt was signed by its signer and
+//  returns its payload. Like VerifyResultsBundle, callers must still check the
+//  signer is the address of the API they trust.
+func VerifyVoteReceipt(receipt *types.VoteReceipt) (*types.VoteReceiptPayload, error) {
+	if err := verifySigner(receipt.Payload, receipt.Signature, receipt.Signer); err != nil {
+		return nil, fmt.Errorf("vote receipt %w", err)
+	}
+	var payload types.VoteReceiptPayload
+	if err := json.Unmarshal(receipt.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("could not decode vote receipt payload: %w", err)
+	}
+	return &payload, nil
+}
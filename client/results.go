@@ -0,0 +1,72 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+)
+
+// ExportElectionResults exports the results of one of the integrator's elections
+//  in the given format, csv, json or bundle, and returns the exported file
+//  (GET /priv/elections/{electionId}/results/export)
+func (c *Client) ExportElectionResults(electionID []byte, format string) ([]byte, error) {
+	path := fmt.Sprintf("/priv/elections/%x/results/export?", electionID) +
+		url.Values{"format": {format}}.Encode()
+	var data []byte
+	if err := c.Request(http.MethodGet, path, nil, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetResultsBundle exports the signed results bundle of one of the integrator's
+//  elections and verifies it, returning the bundle and its verified payload
+func (c *Client) GetResultsBundle(
+	electionID []byte) (*types.ResultsBundle, *types.ResultsBundlePayload, error) {
+	data, err := c.ExportElectionResults(electionID, types.ResultsFormatBundle)
+	if err != nil {
+		return nil, nil, err
+	}
+	var bundle types.ResultsBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, nil, fmt.Errorf("could not decode results bundle: %w", err)
+	}
+	payload, err := VerifyResultsBundle(&bundle)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &bundle, payload, nil
+}
+
+// VerifyResultsBundle checks that a results bundle was signed by its signer and
+//  returns its payload. It works offline: callers must still check the signer is
+//  the address of the API they trust, and may check the results against the Vochain.
+func VerifyResultsBundle(bundle *types.ResultsBundle) (*types.ResultsBundlePayload, error) {
+	if err := verifySigner(bundle.Payload, bundle.Signature, bundle.Signer); err != nil {
+		return nil, fmt.Errorf("results bundle %w", err)
+	}
+	var payload types.ResultsBundlePayload
+	if err := json.Unmarshal(bundle.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("could not decode results bundle payload: %w", err)
+	}
+	return &payload, nil
+}
+
+// verifySigner checks that signature is the Ethereum signature of payload by expected
+func verifySigner(payload, signature, expected []byte) error {
+	// recovering the signer modifies the signature
+	signature = append([]byte{}, signature...)
+	signer, err := ethereum.AddrFromSignature(payload, signature)
+	if err != nil {
+		return fmt.Errorf("signature is invalid: %w", err)
+	}
+	if !bytes.Equal(signer.Bytes(), expected) {
+		return fmt.Errorf("signed by %x, not by %x", signer, expected)
+	}
+	return nil
+}
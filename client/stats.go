@@ -0,0 +1,51 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.vocdoni.io/api/types"
+)
+
+// GetElectionStats gets the turnout statistics of one of the integrator's
+//  elections per bucket of time, or of bucketBlocks blocks when it is not 0
+//  (GET /priv/elections/{electionId}/stats). A zero bucket uses the default.
+func (c *Client) GetElectionStats(electionID []byte, bucket time.Duration,
+	bucketBlocks uint32) (*types.ElectionStats, error) {
+	var stats types.ElectionStats
+	if err := c.Request(http.MethodGet, statsPath(electionID, bucket, bucketBlocks, ""),
+		nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// ExportElectionStats exports the turnout statistics of one of the integrator's
+//  elections as a CSV table, with the buckets of GetElectionStats
+func (c *Client) ExportElectionStats(electionID []byte, bucket time.Duration,
+	bucketBlocks uint32) ([]byte, error) {
+	var data []byte
+	if err := c.Request(http.MethodGet,
+		statsPath(electionID, bucket, bucketBlocks, types.ResultsFormatCSV), nil, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func statsPath(electionID []byte, bucket time.Duration, bucketBlocks uint32,
+	format string) string {
+	query := url.Values{}
+	if bucket > 0 {
+		query.Set("bucket", bucket.String())
+	}
+	if bucketBlocks > 0 {
+		query.Set("bucketBlocks", strconv.FormatUint(uint64(bucketBlocks), 10))
+	}
+	if format != "" {
+		query.Set("format", format)
+	}
+	return fmt.Sprintf("/priv/elections/%x/stats?", electionID) + query.Encode()
+}
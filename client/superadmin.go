@@ -0,0 +1,62 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.vocdoni.io/api/types"
+)
+
+// CreateIntegrator creates an integrator account (POST /admin/accounts).
+// The response holds its ID and secret APIKey.
+func (c *Client) CreateIntegrator(req types.CreateIntegratorRequest) (*types.APIResponse, error) {
+	var resp types.APIResponse
+	if err := c.Request(http.MethodPost, "/admin/accounts", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateIntegrator updates an integrator account (PUT /admin/accounts/{id})
+func (c *Client) UpdateIntegrator(id int, req types.UpdateIntegratorRequest) error {
+	return c.Request(http.MethodPut, fmt.Sprintf("/admin/accounts/%d", id), req, nil)
+}
+
+// ResetIntegratorKey generates a new API key for an integrator
+//  (PATCH /admin/accounts/{id}/key). The response holds the new APIKey.
+func (c *Client) ResetIntegratorKey(id int) (*types.APIResponse, error) {
+	var resp types.APIResponse
+	if err := c.Request(http.MethodPatch,
+		fmt.Sprintf("/admin/accounts/%d/key", id), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetIntegrator gets an integrator account (GET /admin/accounts/{id})
+func (c *Client) GetIntegrator(id int) (*types.APIResponse, error) {
+	var resp types.APIResponse
+	if err := c.Request(http.MethodGet,
+		fmt.Sprintf("/admin/accounts/%d", id), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteIntegrator deletes an integrator account (DELETE /admin/accounts/{id})
+func (c *Client) DeleteIntegrator(id int) error {
+	return c.Request(http.MethodDelete, fmt.Sprintf("/admin/accounts/%d", id), nil, nil)
+}
+
+// RestoreIntegrator restores a deleted integrator account, along with the
+//  organizations deleted with it (PUT /admin/accounts/{id}/restore)
+func (c *Client) RestoreIntegrator(id int) error {
+	return c.Request(http.MethodPut, fmt.Sprintf("/admin/accounts/%d/restore", id), nil, nil)
+}
+
+// RestoreOrganization restores a deleted organization of an integrator
+//  (PUT /admin/accounts/{id}/organizations/{organizationId}/restore)
+func (c *Client) RestoreOrganization(integratorID int, organizationID []byte) error {
+	return c.Request(http.MethodPut, fmt.Sprintf("/admin/accounts/%d/organizations/%x/restore",
+		integratorID, organizationID), nil, nil)
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.vocdoni.io/api/types"
+)
+
+// ListUsage lists a page of the daily usage counters matching filter, oldest day
+//  first (GET /admin/usage), and returns the cursor of the next page
+func (c *Client) ListUsage(filter types.UsageFilter) ([]types.UsageCounter, string, error) {
+	query := usageQuery(filter)
+	if filter.IntegratorID != 0 {
+		query.Set("integratorId", strconv.Itoa(filter.IntegratorID))
+	}
+	var resp []types.UsageCounter
+	next, err := c.Page("/admin/usage", query, &resp)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp, next, nil
+}
+
+// ListIntegratorUsage lists a page of the daily usage counters of the integrator
+//  matching filter, oldest day first (GET /priv/account/usage), and returns the
+//  cursor of the next page. filter.IntegratorID is ignored.
+func (c *Client) ListIntegratorUsage(
+	filter types.UsageFilter) ([]types.UsageCounter, string, error) {
+	var resp []types.UsageCounter
+	next, err := c.Page("/priv/account/usage", usageQuery(filter), &resp)
+	if err != nil {
+		return nil, "", err
+	}
+	return resp, next, nil
+}
+
+// ExportIntegratorUsage exports every daily usage counter of the integrator
+//  matching filter as a CSV table (GET /priv/account/usage?format=csv). The
+//  pagination fields of filter are ignored.
+func (c *Client) ExportIntegratorUsage(filter types.UsageFilter) ([]byte, error) {
+	query := usageQuery(types.UsageFilter{OrgEthAddress: filter.OrgEthAddress,
+		Metric: filter.Metric, From: filter.From, To: filter.To})
+	query.Set("format", types.ResultsFormatCSV)
+	var data []byte
+	if err := c.Request(http.MethodGet, "/priv/account/usage?"+query.Encode(), nil,
+		&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func usageQuery(filter types.UsageFilter) url.Values {
+	query := url.Values{}
+	if len(filter.OrgEthAddress) > 0 {
+		query.Set("organizationId", hex.EncodeToString(filter.OrgEthAddress))
+	}
+	if filter.Metric != "" {
+		query.Set("metric", filter.Metric)
+	}
+	if !filter.From.IsZero() {
+		query.Set("from", filter.From.Format(time.RFC3339))
+	}
+	if !filter.To.IsZero() {
+		query.Set("to", filter.To.Format(time.RFC3339))
+	}
+	if filter.Count > 0 {
+		query.Set("count", strconv.Itoa(filter.Count))
+	}
+	if filter.Skip > 0 {
+		query.Set("skip", strconv.Itoa(filter.Skip))
+	}
+	if filter.Cursor != "" {
+		query.Set("cursor", filter.Cursor)
+	}
+	return query
+}
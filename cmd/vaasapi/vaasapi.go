@@ -57,6 +57,39 @@ func newConfig() (*config.Vaas, config.Error) {
 	cfg.API.GlobalMetaKey = *flag.String("globalMetaKey", "",
 		"encryption key for organization metadata keys in the db. Leave empty for no encryption")
 	cfg.API.MaxCensusSize = *flag.Uint64("maxCensusSize", 2<<32, "maximum size of a voter census")
+	cfg.API.DeletedRetentionDays = *flag.Int("deletedRetentionDays", 30,
+		"days deleted integrators and organizations are kept before being purged (0 to keep them)")
+	cfg.API.ResultsCacheSeconds = *flag.Int("resultsCacheSeconds", 10,
+		"seconds the results of live elections are cached (0 to disable the cache)")
+	cfg.API.MetadataCacheSize = *flag.Int("metadataCacheSize", 1024,
+		"IPFS files the metadata cache keeps in memory (0 to disable the cache)")
+	cfg.API.RateLimitStore = *flag.String("rateLimitStore", "memory",
+		"rate limit buckets store: memory (per process) or postgres (shared by replicas)")
+	cfg.API.IntegratorRateLimit = *flag.Int("integratorRateLimit", 1200,
+		"requests per minute allowed to each integrator key (0 to disable the limit)")
+	cfg.API.IntegratorRateBurst = *flag.Int("integratorRateBurst", 200,
+		"requests allowed at once to each integrator key")
+	cfg.API.OrganizationRateLimit = *flag.Int("organizationRateLimit", 600,
+		"requests per minute allowed to each organization token without plan limits "+
+			"(0 to disable the limit)")
+	cfg.API.OrganizationRateBurst = *flag.Int("organizationRateBurst", 100,
+		"requests allowed at once to each organization token without plan limits")
+	cfg.API.IPRateLimit = *flag.Int("ipRateLimit", 120,
+		"requests per minute allowed to each client IP on public routes (0 to disable the limit)")
+	cfg.API.IPRateBurst = *flag.Int("ipRateBurst", 30,
+		"requests allowed at once to each client IP on public routes")
+	cfg.API.RateLimitTrustProxy = *flag.Bool("rateLimitTrustProxy", false,
+		"take the client IP from the X-Forwarded-For header of a reverse proxy")
+	cfg.API.AdjustEndBlocks = *flag.Bool("adjustEndBlocks", false,
+		"end the elections created from now on at their end date, not at their estimated end block")
+	cfg.API.PublicURL = *flag.String("publicUrl", "",
+		"scheme and host voters reach the API at, enabling the hosted CSP (empty to disable it)")
+	cfg.API.SMTP.Host = *flag.String("smtpHost", "",
+		"SMTP server sending the email codes of the hosted CSP (empty to log the codes)")
+	cfg.API.SMTP.Port = *flag.Int("smtpPort", 587, "SMTP server port")
+	cfg.API.SMTP.User = *flag.String("smtpUser", "", "SMTP user (empty for no auth)")
+	cfg.API.SMTP.Password = *flag.String("smtpPassword", "", "SMTP password")
+	cfg.API.SMTP.From = *flag.String("smtpFrom", "", "sender address of the emails")
 	cfg.API.Route = *flag.String("apiRoute", "/", "dvote API route")
 	cfg.API.ListenHost = *flag.String("listenHost", "0.0.0.0", "API endpoint listen address")
 	cfg.API.ListenPort = *flag.Int("listenPort", 8000, "API endpoint http port")
@@ -101,6 +134,24 @@ func newConfig() (*config.Vaas, config.Error) {
 	viper.BindPFlag("api.adminToken", flag.Lookup("adminToken"))
 	viper.BindPFlag("api.faucetPrivKey", flag.Lookup("faucetPrivKey"))
 	viper.BindPFlag("api.maxCensusSize", flag.Lookup("maxCensusSize"))
+	viper.BindPFlag("api.deletedRetentionDays", flag.Lookup("deletedRetentionDays"))
+	viper.BindPFlag("api.resultsCacheSeconds", flag.Lookup("resultsCacheSeconds"))
+	viper.BindPFlag("api.metadataCacheSize", flag.Lookup("metadataCacheSize"))
+	viper.BindPFlag("api.rateLimitStore", flag.Lookup("rateLimitStore"))
+	viper.BindPFlag("api.integratorRateLimit", flag.Lookup("integratorRateLimit"))
+	viper.BindPFlag("api.integratorRateBurst", flag.Lookup("integratorRateBurst"))
+	viper.BindPFlag("api.organizationRateLimit", flag.Lookup("organizationRateLimit"))
+	viper.BindPFlag("api.organizationRateBurst", flag.Lookup("organizationRateBurst"))
+	viper.BindPFlag("api.ipRateLimit", flag.Lookup("ipRateLimit"))
+	viper.BindPFlag("api.ipRateBurst", flag.Lookup("ipRateBurst"))
+	viper.BindPFlag("api.rateLimitTrustProxy", flag.Lookup("rateLimitTrustProxy"))
+	viper.BindPFlag("api.adjustEndBlocks", flag.Lookup("adjustEndBlocks"))
+	viper.BindPFlag("api.publicUrl", flag.Lookup("publicUrl"))
+	viper.BindPFlag("api.smtp.host", flag.Lookup("smtpHost"))
+	viper.BindPFlag("api.smtp.port", flag.Lookup("smtpPort"))
+	viper.BindPFlag("api.smtp.user", flag.Lookup("smtpUser"))
+	viper.BindPFlag("api.smtp.password", flag.Lookup("smtpPassword"))
+	viper.BindPFlag("api.smtp.from", flag.Lookup("smtpFrom"))
 	viper.BindPFlag("api.explorerVoteUrl", flag.Lookup("explorerVoteUrl"))
 	viper.BindPFlag("api.globalEntityKey", flag.Lookup("globalEntityKey"))
 	viper.BindPFlag("api.gatewayUrl", flag.Lookup("gatewayUrl"))
@@ -251,6 +302,14 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	if cfg.API.MetadataCacheSize > 0 {
+		filesKv, err := metadb.New(dvotedb.TypePebble,
+			filepath.Join(cfg.DataDir, "metadata-cache-kv"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		client.EnableFileCache(filesKv, cfg.API.MetadataCacheSize)
+	}
 
 	// Router
 	var httpRouter httprouter.HTTProuter
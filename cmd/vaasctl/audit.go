@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"go.vocdoni.io/api/types"
+)
+
+func listAuditEvents(c *ctl, args []string) error {
+	flags := flagSet("audit list")
+	filter := types.AuditFilter{}
+	flags.IntVar(&filter.IntegratorID, "integrator", 0, "integrator id")
+	flags.StringVar(&filter.ActorType, "actorType", "", "actor type (admin, integrator, organization)")
+	flags.StringVar(&filter.Action, "action", "", "action, such as integrator.resetKey")
+	flags.StringVar(&filter.Outcome, "outcome", "", "outcome (success, failure)")
+	since := flags.Duration("since", 0, "list only the events of this last period, such as 24h")
+	flags.IntVar(&filter.Count, "count", 100, "maximum number of events")
+	flags.StringVar(&filter.Cursor, "cursor", "",
+		"cursor of the page to list, printed with the previous one")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *since > 0 {
+		filter.From = time.Now().Add(-*since)
+	}
+	events, next, err := c.db.ListAuditEvents(&filter)
+	if err != nil {
+		return fmt.Errorf("could not list audit events: %w", err)
+	}
+	if next != "" {
+		defer fmt.Fprintf(os.Stderr, "more events with --cursor %s\n", next)
+	}
+	rows := make([][]string, 0, len(events))
+	for _, event := range events {
+		rows = append(rows, []string{
+			event.CreatedAt.Format(time.RFC3339),
+			event.ActorType,
+			event.ActorID,
+			strconv.Itoa(event.IntegratorID),
+			event.Action,
+			event.Target,
+			event.Outcome,
+			event.RequestID,
+		})
+	}
+	return c.print(events, []string{"TIME", "ACTOR", "ACTOR ID", "INTEGRATOR", "ACTION",
+		"TARGET", "OUTCOME", "REQUEST ID"}, rows)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"go.vocdoni.io/api/types"
+)
+
+func listChainEvents(c *ctl, args []string) error {
+	flags := flagSet("chain events")
+	filter := types.ChainEventFilter{}
+	address := flags.String("org", "", "organization eth address")
+	process := flags.String("process", "", "election process id")
+	flags.StringVar(&filter.Kind, "kind", "",
+		"event kind, such as status, voteCount or missing for the drift found")
+	since := flags.Duration("since", 0, "list only the events of this last period, such as 24h")
+	flags.IntVar(&filter.Count, "count", 100, "maximum number of events")
+	flags.StringVar(&filter.Cursor, "cursor", "",
+		"cursor of the page to list, printed with the previous one")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	var err error
+	if *address != "" {
+		if filter.OrgEthAddress, err = decodeHexFlag("org", *address); err != nil {
+			return err
+		}
+	}
+	if *process != "" {
+		if filter.ProcessID, err = decodeHexFlag("process", *process); err != nil {
+			return err
+		}
+	}
+	if *since > 0 {
+		filter.From = time.Now().Add(-*since)
+	}
+	events, next, err := c.db.ListChainEvents(&filter)
+	if err != nil {
+		return fmt.Errorf("could not list chain events: %w", err)
+	}
+	if next != "" {
+		defer fmt.Fprintf(os.Stderr, "more events with --cursor %s\n", next)
+	}
+	rows := make([][]string, 0, len(events))
+	for _, event := range events {
+		rows = append(rows, []string{
+			event.CreatedAt.Format(time.RFC3339),
+			hex.EncodeToString(event.OrgEthAddress),
+			hex.EncodeToString(event.ProcessID),
+			event.Kind,
+			event.OldValue,
+			event.NewValue,
+		})
+	}
+	return c.print(events, []string{"TIME", "ORGANIZATION", "PROCESS ID", "KIND", "OLD", "NEW"},
+		rows)
+}
+
+// formatMissingSince formats the time a row went missing on the Vochain, empty
+//  if it is not missing
+func formatMissingSince(since *time.Time) string {
+	if since == nil {
+		return ""
+	}
+	return since.Format(time.RFC3339)
+}
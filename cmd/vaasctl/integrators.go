@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/api/util"
+	dvoteUtil "go.vocdoni.io/dvote/util"
+)
+
+func listIntegrators(c *ctl, args []string) error {
+	if err := flagSet("integrators list").Parse(args); err != nil {
+		return err
+	}
+	keys, err := c.db.GetIntegratorApiKeysList()
+	if err != nil {
+		return fmt.Errorf("could not list integrators: %w", err)
+	}
+	integrators := make([]types.Integrator, 0, len(keys))
+	rows := make([][]string, 0, len(keys))
+	for _, key := range keys {
+		integrator, err := c.db.GetIntegratorByKey(key)
+		if err != nil {
+			return fmt.Errorf("could not get integrator: %w", err)
+		}
+		integrators = append(integrators, *integrator)
+		rows = append(rows, []string{
+			strconv.Itoa(integrator.ID),
+			integrator.Name,
+			integrator.Email,
+			integrator.CspUrlPrefix,
+			integrator.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return c.print(integrators, []string{"ID", "NAME", "EMAIL", "CSP URL", "CREATED"}, rows)
+}
+
+func getIntegrator(c *ctl, args []string) error {
+	flags := flagSet("integrators get")
+	id := flags.Int("id", 0, "integrator id")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	integrator, err := c.db.GetIntegrator(*id)
+	if err != nil {
+		return fmt.Errorf("could not get integrator %d: %w", *id, err)
+	}
+	count, err := c.db.CountOrganizations(integrator.SecretApiKey)
+	if err != nil {
+		return fmt.Errorf("could not count organizations: %w", err)
+	}
+	return c.printFields(integrator, map[string]string{
+		"id":            strconv.Itoa(integrator.ID),
+		"name":          integrator.Name,
+		"email":         integrator.Email,
+		"apiKey":        hex.EncodeToString(integrator.SecretApiKey),
+		"cspUrlPrefix":  integrator.CspUrlPrefix,
+		"cspPubKey":     hex.EncodeToString(integrator.CspPubKey),
+		"organizations": strconv.Itoa(count),
+		"createdAt":     integrator.CreatedAt.Format(time.RFC3339),
+		"updatedAt":     integrator.UpdatedAt.Format(time.RFC3339),
+	})
+}
+
+func createIntegrator(c *ctl, args []string) error {
+	flags := flagSet("integrators create")
+	req := types.CreateIntegratorRequest{}
+	flags.StringVar(&req.Name, "name", "", "integrator name")
+	flags.StringVar(&req.Email, "email", "", "integrator contact email")
+	flags.StringVar(&req.CspUrlPrefix, "cspUrlPrefix", "", "url prefix of the integrator CSP")
+	flags.StringVar(&req.CspPubKey, "cspPubKey", "", "hex compressed public key of the CSP")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := util.Validate(&req); err != nil {
+		return err
+	}
+	resp := types.APIResponse{APIKey: util.GenerateBearerToken()}
+	apiKey, err := hex.DecodeString(resp.APIKey)
+	if err != nil {
+		return err
+	}
+	cspPubKey, err := hex.DecodeString(dvoteUtil.TrimHex(req.CspPubKey))
+	if err != nil {
+		return fmt.Errorf("could not decode csp pub key %s: %w", req.CspPubKey, err)
+	}
+	if resp.ID, err = c.db.CreateIntegrator(apiKey,
+		cspPubKey, req.CspUrlPrefix, req.Name, req.Email); err != nil {
+		return fmt.Errorf("could not create integrator: %w", err)
+	}
+	return c.printFields(resp, map[string]string{
+		"id":     strconv.Itoa(resp.ID),
+		"apiKey": resp.APIKey,
+	})
+}
+
+func updateIntegrator(c *ctl, args []string) error {
+	flags := flagSet("integrators update")
+	id := flags.Int("id", 0, "integrator id")
+	name := flags.String("name", "", "new integrator name")
+	cspUrlPrefix := flags.String("cspUrlPrefix", "", "new url prefix of the integrator CSP")
+	cspPubKey := flags.String("cspPubKey", "", "new hex compressed public key of the CSP")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	pubKey, err := hex.DecodeString(dvoteUtil.TrimHex(*cspPubKey))
+	if err != nil {
+		return fmt.Errorf("could not decode csp pub key %s: %w", *cspPubKey, err)
+	}
+	// empty values are kept unchanged by the database
+	if _, err := c.db.UpdateIntegrator(*id, pubKey, *cspUrlPrefix, *name); err != nil {
+		return fmt.Errorf("could not update integrator %d: %w", *id, err)
+	}
+	return getIntegrator(c, []string{"--id", strconv.Itoa(*id)})
+}
+
+func deleteIntegrator(c *ctl, args []string) error {
+	flags := flagSet("integrators delete")
+	id := flags.Int("id", 0, "integrator id")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := c.db.DeleteIntegrator(*id); err != nil {
+		return fmt.Errorf("could not delete integrator %d: %w", *id, err)
+	}
+	return c.printFields(types.APIResponse{ID: *id}, map[string]string{
+		"id": strconv.Itoa(*id),
+	})
+}
+
+func restoreIntegrator(c *ctl, args []string) error {
+	flags := flagSet("integrators restore")
+	id := flags.Int("id", 0, "integrator id")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	orgs, err := c.db.RestoreIntegrator(*id)
+	if err != nil {
+		return fmt.Errorf("could not restore integrator %d: %w", *id, err)
+	}
+	// the public tokens of the restored organizations are registered when vaasapi restarts
+	return c.printFields(types.APIResponse{ID: *id}, map[string]string{
+		"id":            strconv.Itoa(*id),
+		"organizations": strconv.Itoa(len(orgs)),
+	})
+}
+
+func purgeDeleted(c *ctl, args []string) error {
+	flags := flagSet("integrators purge")
+	olderThan := flags.Duration("olderThan", 30*24*time.Hour,
+		"purge the integrators and organizations deleted longer ago than this")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	integrators, orgs, err := c.db.PurgeDeleted(time.Now().Add(-*olderThan))
+	if err != nil {
+		return fmt.Errorf("could not purge deleted accounts: %w", err)
+	}
+	return c.printFields(map[string]int{"integrators": integrators, "organizations": orgs},
+		map[string]string{
+			"integrators":   strconv.Itoa(integrators),
+			"organizations": strconv.Itoa(orgs),
+		})
+}
+
+func resetIntegratorKey(c *ctl, args []string) error {
+	flags := flagSet("integrators reset-key")
+	id := flags.Int("id", 0, "integrator id")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	resp := types.APIResponse{ID: *id, APIKey: util.GenerateBearerToken()}
+	apiKey, err := hex.DecodeString(resp.APIKey)
+	if err != nil {
+		return err
+	}
+	if _, err := c.db.UpdateIntegratorApiKey(*id, apiKey); err != nil {
+		return fmt.Errorf("could not reset key of integrator %d: %w", *id, err)
+	}
+	return c.printFields(resp, map[string]string{
+		"id":     strconv.Itoa(resp.ID),
+		"apiKey": resp.APIKey,
+	})
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.vocdoni.io/api/types"
+	dvoteUtil "go.vocdoni.io/dvote/util"
+)
+
+// integratorKey returns the api key of the integrator with the given id
+func integratorKey(c *ctl, id int) ([]byte, error) {
+	integrator, err := c.db.GetIntegrator(id)
+	if err != nil {
+		return nil, fmt.Errorf("could not get integrator %d: %w", id, err)
+	}
+	return integrator.SecretApiKey, nil
+}
+
+func decodeHexFlag(name, value string) ([]byte, error) {
+	if value == "" {
+		return nil, fmt.Errorf("--%s is required", name)
+	}
+	b, err := hex.DecodeString(dvoteUtil.TrimHex(value))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode --%s %s: %w", name, value, err)
+	}
+	return b, nil
+}
+
+func listOrganizations(c *ctl, args []string) error {
+	flags := flagSet("orgs list")
+	integratorID := flags.Int("integrator", 0, "integrator id")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	key, err := integratorKey(c, *integratorID)
+	if err != nil {
+		return err
+	}
+	orgs, _, err := c.db.ListOrganizations(key, nil)
+	if err != nil {
+		return fmt.Errorf("could not list organizations: %w", err)
+	}
+	if orgs == nil {
+		orgs = []types.Organization{}
+	}
+	rows := make([][]string, 0, len(orgs))
+	for _, org := range orgs {
+		rows = append(rows, []string{
+			strconv.Itoa(org.ID),
+			hex.EncodeToString(org.EthAddress),
+			org.HeaderURI,
+			org.PublicAPIToken,
+		})
+	}
+	return c.print(orgs, []string{"ID", "ADDRESS", "HEADER URI", "PUBLIC API TOKEN"}, rows)
+}
+
+func getOrganization(c *ctl, args []string) error {
+	flags := flagSet("orgs get")
+	integratorID := flags.Int("integrator", 0, "integrator id")
+	address := flags.String("org", "", "organization eth address")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	orgAddress, err := decodeHexFlag("org", *address)
+	if err != nil {
+		return err
+	}
+	key, err := integratorKey(c, *integratorID)
+	if err != nil {
+		return err
+	}
+	org, err := c.db.GetOrganization(key, orgAddress)
+	if err != nil {
+		return fmt.Errorf("could not get organization %x: %w", orgAddress, err)
+	}
+	// never print the (encrypted) organization private key
+	org.EthPrivKeyCipher = nil
+	plan := ""
+	if org.QuotaPlanID.Valid {
+		plan = org.QuotaPlanID.UUID.String()
+	}
+	return c.printFields(org, map[string]string{
+		"id":             strconv.Itoa(org.ID),
+		"ethAddress":     hex.EncodeToString(org.EthAddress),
+		"headerUri":      org.HeaderURI,
+		"avatarUri":      org.AvatarURI,
+		"publicApiToken": org.PublicAPIToken,
+		"publicApiQuota": strconv.Itoa(org.PublicAPIQuota),
+		"quotaPlanId":    plan,
+		"metadataUri":    org.MetadataURI,
+		"balance":        strconv.FormatInt(org.Balance, 10),
+		"missingSince":   formatMissingSince(org.MissingSince),
+		"createdAt":      org.CreatedAt.Format(time.RFC3339),
+		"updatedAt":      org.UpdatedAt.Format(time.RFC3339),
+	})
+}
+
+func restoreOrganization(c *ctl, args []string) error {
+	flags := flagSet("orgs restore")
+	integratorID := flags.Int("integrator", 0, "integrator id")
+	address := flags.String("org", "", "organization eth address")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	orgAddress, err := decodeHexFlag("org", *address)
+	if err != nil {
+		return err
+	}
+	key, err := integratorKey(c, *integratorID)
+	if err != nil {
+		return err
+	}
+	// the public token of the organization is registered when vaasapi restarts
+	if _, err := c.db.RestoreOrganization(key, orgAddress); err != nil {
+		return fmt.Errorf("could not restore organization %x: %w", orgAddress, err)
+	}
+	return getOrganization(c, args)
+}
+
+func listElections(c *ctl, args []string) error {
+	flags := flagSet("elections list")
+	integratorID := flags.Int("integrator", 0, "integrator id")
+	address := flags.String("org", "", "organization eth address")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	orgAddress, err := decodeHexFlag("org", *address)
+	if err != nil {
+		return err
+	}
+	key, err := integratorKey(c, *integratorID)
+	if err != nil {
+		return err
+	}
+	elections, _, err := c.db.ListElections(key, orgAddress, nil)
+	if err != nil {
+		return fmt.Errorf("could not list elections: %w", err)
+	}
+	if elections == nil {
+		elections = []types.Election{}
+	}
+	rows := make([][]string, 0, len(elections))
+	for i, election := range elections {
+		// never print the metadata private keys
+		elections[i].MetadataPrivKey = nil
+		rows = append(rows, []string{
+			hex.EncodeToString(election.ProcessID),
+			election.Title,
+			election.ProofType,
+			election.Status,
+			election.StartDate.Format(time.RFC3339),
+			election.EndDate.Format(time.RFC3339),
+			strconv.FormatBool(election.Confidential),
+		})
+	}
+	return c.print(elections,
+		[]string{"PROCESS ID", "TITLE", "PROOF", "STATUS", "START", "END", "CONFIDENTIAL"}, rows)
+}
+
+func getElection(c *ctl, args []string) error {
+	flags := flagSet("elections get")
+	integratorID := flags.Int("integrator", 0, "integrator id")
+	address := flags.String("org", "", "organization eth address")
+	process := flags.String("process", "", "election process id")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	orgAddress, err := decodeHexFlag("org", *address)
+	if err != nil {
+		return err
+	}
+	processID, err := decodeHexFlag("process", *process)
+	if err != nil {
+		return err
+	}
+	key, err := integratorKey(c, *integratorID)
+	if err != nil {
+		return err
+	}
+	election, err := c.db.GetElection(key, orgAddress, processID)
+	if err != nil {
+		return fmt.Errorf("could not get election %x: %w", processID, err)
+	}
+	election.ProcessID = processID
+	election.OrgEthAddress = orgAddress
+	// never print the metadata private key
+	election.MetadataPrivKey = nil
+	census := ""
+	if election.CensusID.Valid {
+		census = election.CensusID.UUID.String()
+	}
+	return c.printFields(election, map[string]string{
+		"processId":     hex.EncodeToString(processID),
+		"orgEthAddress": hex.EncodeToString(orgAddress),
+		"title":         election.Title,
+		"proofType":     election.ProofType,
+		"status":        election.Status,
+		"censusId":      census,
+		"startDate":     election.StartDate.Format(time.RFC3339),
+		"endDate":       election.EndDate.Format(time.RFC3339),
+		"startBlock":    strconv.Itoa(election.StartBlock),
+		"endBlock":      strconv.Itoa(election.EndBlock),
+		"confidential":  strconv.FormatBool(election.Confidential),
+		"hiddenResults": strconv.FormatBool(election.HiddenResults),
+		"voteCount":     strconv.Itoa(election.VoteCount),
+		"hasResults":    strconv.FormatBool(election.HasResults),
+		"missingSince":  formatMissingSince(election.MissingSince),
+		"createdAt":     election.CreatedAt.Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	"go.vocdoni.io/api/types"
+)
+
+func listPlans(c *ctl, args []string) error {
+	if err := flagSet("plans list").Parse(args); err != nil {
+		return err
+	}
+	plans, err := c.db.GetPlansList()
+	if err != nil {
+		return fmt.Errorf("could not list plans: %w", err)
+	}
+	if plans == nil {
+		plans = []types.QuotaPlan{}
+	}
+	rows := make([][]string, 0, len(plans))
+	for _, plan := range plans {
+		rows = append(rows, []string{
+			plan.ID.String(),
+			plan.Name,
+			strconv.Itoa(plan.MaxCensusSize),
+			strconv.Itoa(plan.MaxProcessCount),
+			strconv.Itoa(plan.RateLimit),
+			strconv.Itoa(plan.RateBurst),
+		})
+	}
+	return c.print(plans, []string{"ID", "NAME", "MAX CENSUS SIZE", "MAX PROCESS COUNT",
+		"RATE LIMIT", "RATE BURST"}, rows)
+}
+
+func createPlan(c *ctl, args []string) error {
+	flags := flagSet("plans create")
+	name := flags.String("name", "", "plan name")
+	maxCensusSize := flags.Int("maxCensusSize", 0, "maximum census size of an election")
+	maxProcessCount := flags.Int("maxProcessCount", 0, "maximum number of elections")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return fmt.Errorf("plans create: --name is required")
+	}
+	id, err := c.db.CreatePlan(*name, *maxCensusSize, *maxProcessCount)
+	if err != nil {
+		return fmt.Errorf("could not create plan: %w", err)
+	}
+	return printPlan(c, id)
+}
+
+func updatePlan(c *ctl, args []string) error {
+	flags := flagSet("plans update")
+	id := flags.String("id", "", "plan id")
+	name := flags.String("name", "", "new plan name")
+	maxCensusSize := flags.Int("maxCensusSize", 0, "new maximum census size of an election")
+	maxProcessCount := flags.Int("maxProcessCount", 0, "new maximum number of elections")
+	rateLimit := flags.Int("rateLimit", 0,
+		"new requests per minute of the organization tokens (0 for the API default)")
+	rateBurst := flags.Int("rateBurst", 0,
+		"new requests at once of the organization tokens (0 for the rate limit)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	planID, err := uuid.Parse(*id)
+	if err != nil {
+		return fmt.Errorf("invalid plan id %q: %w", *id, err)
+	}
+	// empty values are kept unchanged by the database
+	if _, err := c.db.UpdatePlan(planID, *maxCensusSize, *maxProcessCount, *name); err != nil {
+		return fmt.Errorf("could not update plan %s: %w", planID, err)
+	}
+	// 0 is a valid rate limit, so only the flags given are changed
+	if flags.Changed("rateLimit") || flags.Changed("rateBurst") {
+		plan, err := c.db.GetPlan(planID)
+		if err != nil {
+			return fmt.Errorf("could not get plan %s: %w", planID, err)
+		}
+		if !flags.Changed("rateLimit") {
+			*rateLimit = plan.RateLimit
+		}
+		if !flags.Changed("rateBurst") {
+			*rateBurst = plan.RateBurst
+		}
+		if _, err := c.db.UpdatePlanRateLimit(planID, *rateLimit, *rateBurst); err != nil {
+			return fmt.Errorf("could not update plan %s: %w", planID, err)
+		}
+	}
+	return printPlan(c, planID)
+}
+
+func deletePlan(c *ctl, args []string) error {
+	flags := flagSet("plans delete")
+	id := flags.String("id", "", "plan id")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	planID, err := uuid.Parse(*id)
+	if err != nil {
+		return fmt.Errorf("invalid plan id %q: %w", *id, err)
+	}
+	if err := c.db.DeletePlan(planID); err != nil {
+		return fmt.Errorf("could not delete plan %s: %w", planID, err)
+	}
+	return c.printFields(types.QuotaPlan{ID: planID}, map[string]string{"id": planID.String()})
+}
+
+func printPlan(c *ctl, id uuid.UUID) error {
+	plan, err := c.db.GetPlan(id)
+	if err != nil {
+		return fmt.Errorf("could not get plan %s: %w", id, err)
+	}
+	return c.printFields(plan, map[string]string{
+		"id":              plan.ID.String(),
+		"name":            plan.Name,
+		"maxCensusSize":   strconv.Itoa(plan.MaxCensusSize),
+		"maxProcessCount": strconv.Itoa(plan.MaxProcessCount),
+		"rateLimit":       strconv.Itoa(plan.RateLimit),
+		"rateBurst":       strconv.Itoa(plan.RateBurst),
+	})
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"go.vocdoni.io/api/client"
+	"go.vocdoni.io/api/types"
+)
+
+func verifyResultsBundle(c *ctl, args []string) error {
+	flags := flagSet("results verify <bundle file>")
+	signer := flags.String("signer", "", "eth address the bundle must be signed by")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected the bundle file")
+	}
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	var bundle types.ResultsBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return fmt.Errorf("could not decode results bundle: %w", err)
+	}
+	payload, err := client.VerifyResultsBundle(&bundle)
+	if err != nil {
+		return err
+	}
+	if *signer != "" {
+		address, err := decodeHexFlag("signer", *signer)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(address, bundle.Signer) {
+			return fmt.Errorf("results bundle signed by %x, not by %x", bundle.Signer, address)
+		}
+	}
+	return c.printFields(payload, map[string]string{
+		"signer":       fmt.Sprintf("%x", bundle.Signer),
+		"chainId":      payload.ChainID,
+		"organization": fmt.Sprintf("%x", payload.OrganizationID),
+		"election":     fmt.Sprintf("%x", payload.ElectionID),
+		"height":       strconv.Itoa(int(payload.Height)),
+		"final":        strconv.FormatBool(payload.Final),
+		"hash":         fmt.Sprintf("%x", payload.Hash),
+		"createdAt":    payload.CreatedAt.Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"go.vocdoni.io/api/database/pgsql"
+	"go.vocdoni.io/api/database/transactions"
+	dvotedb "go.vocdoni.io/dvote/db"
+	"go.vocdoni.io/dvote/db/metadb"
+)
+
+// listTxs lists the transactions cached by vaasapi until they are mined.
+// Pebble locks its directory, so vaasapi must be stopped to read the cache.
+func listTxs(c *ctl, args []string) error {
+	if err := flagSet("txs list").Parse(args); err != nil {
+		return err
+	}
+	db, err := metadb.New(dvotedb.TypePebble, filepath.Join(c.cfg.DataDir, "tx-cache-kv"))
+	if err != nil {
+		return fmt.Errorf("could not open the tx cache (is vaasapi running?): %w", err)
+	}
+	defer db.Close()
+	txs, err := transactions.NewTxKv(db).ListTxs()
+	if err != nil {
+		return err
+	}
+	if txs == nil {
+		txs = []transactions.CachedTx{}
+	}
+	type jsonTx struct {
+		Hash string `json:"hash"`
+		transactions.SerializableTx
+	}
+	list := make([]jsonTx, 0, len(txs))
+	rows := make([][]string, 0, len(txs))
+	for _, tx := range txs {
+		hash := hex.EncodeToString(tx.Hash)
+		list = append(list, jsonTx{Hash: hash, SerializableTx: tx.Tx})
+		rows = append(rows, []string{
+			hash,
+			string(tx.Tx.Type),
+			tx.Tx.CreationTime.Format(time.RFC3339),
+			time.Since(tx.Tx.CreationTime).Round(time.Second).String(),
+		})
+	}
+	return c.print(list, []string{"HASH", "TYPE", "CREATED", "AGE"}, rows)
+}
+
+// migrate returns a command running the given pgsql.Migrator action
+func migrate(action string) command {
+	return func(c *ctl, args []string) error {
+		if err := flagSet("migrate " + action).Parse(args); err != nil {
+			return err
+		}
+		return pgsql.Migrator(action, c.db)
+	}
+}
@@ -0,0 +1,214 @@
+// vaasctl is the command line tool for VaaS operators. It works directly on the
+//  database and the tx cache of a vaasapi deployment, reading the same vaasapi.yml
+//  config file from the data directory.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	flag "github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"go.vocdoni.io/api/config"
+	"go.vocdoni.io/api/database"
+	"go.vocdoni.io/api/database/pgsql"
+	log "go.vocdoni.io/dvote/log"
+)
+
+const usage = `Usage: vaasctl [global flags] <command> <subcommand> [flags]
+
+Commands:
+  integrators list|get|create|update|delete|restore|purge|reset-key
+  plans       list|create|update|delete
+  orgs        list|get|restore
+  elections   list|get
+  audit       list
+  chain       events
+  results     verify
+  txs         list
+  migrate     up|down|status|upSync
+
+Run vaasctl <command> <subcommand> --help for the flags of each subcommand.
+
+Global flags:
+`
+
+// ctl holds the state shared by every command
+type ctl struct {
+	cfg    *config.Vaas
+	output string
+	db     database.Database
+}
+
+// command runs a subcommand with its remaining arguments
+type command func(c *ctl, args []string) error
+
+var commands = map[string]map[string]command{
+	"integrators": {
+		"list":      listIntegrators,
+		"get":       getIntegrator,
+		"create":    createIntegrator,
+		"update":    updateIntegrator,
+		"delete":    deleteIntegrator,
+		"restore":   restoreIntegrator,
+		"purge":     purgeDeleted,
+		"reset-key": resetIntegratorKey,
+	},
+	"plans": {
+		"list":   listPlans,
+		"create": createPlan,
+		"update": updatePlan,
+		"delete": deletePlan,
+	},
+	"orgs": {
+		"list":    listOrganizations,
+		"get":     getOrganization,
+		"restore": restoreOrganization,
+	},
+	"elections": {
+		"list": listElections,
+		"get":  getElection,
+	},
+	"audit": {
+		"list": listAuditEvents,
+	},
+	"chain": {
+		"events": listChainEvents,
+	},
+	"results": {
+		"verify": verifyResultsBundle,
+	},
+	"txs": {
+		"list": listTxs,
+	},
+	"migrate": {
+		"up":     migrate("up"),
+		"down":   migrate("down"),
+		"status": migrate("status"),
+		"upSync": migrate("upSync"),
+	},
+}
+
+func newConfig() (*config.Vaas, string, error) {
+	cfg := config.NewVaasConfig()
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot get user home directory: %w", err)
+	}
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	// stop at the command name, the rest belongs to the subcommand
+	flag.CommandLine.SetInterspersed(false)
+	flag.StringVar(&cfg.DataDir, "dataDir", home+"/.vaasapi",
+		"directory of the vaasapi config file and tx cache")
+	logLevel := flag.String("logLevel", "warn", "Log level (debug, info, warn, error, fatal)")
+	output := flag.StringP("output", "o", "table", "output format (table, json)")
+	flag.String("dbHost", "127.0.0.1", "DB server address")
+	flag.Int("dbPort", 5432, "DB server port")
+	flag.String("dbUser", "user", "DB Username")
+	flag.String("dbPassword", "password", "DB password")
+	flag.String("dbName", "database", "DB database name")
+	flag.String("dbSslmode", "prefer", "DB postgres sslmode")
+	flag.Parse()
+	cfg.LogLevel = *logLevel
+
+	if *output != "table" && *output != "json" {
+		return nil, "", fmt.Errorf("unknown output format %q", *output)
+	}
+
+	// flags take precedence over the vaasapi config file and the environment
+	viper := viper.New()
+	viper.AddConfigPath(cfg.DataDir)
+	viper.SetConfigName("vaasapi")
+	viper.SetConfigType("yml")
+	viper.SetEnvPrefix("VAASAPI")
+	viper.AutomaticEnv()
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.BindPFlag("db.host", flag.Lookup("dbHost"))
+	viper.BindPFlag("db.port", flag.Lookup("dbPort"))
+	viper.BindPFlag("db.user", flag.Lookup("dbUser"))
+	viper.BindPFlag("db.password", flag.Lookup("dbPassword"))
+	viper.BindPFlag("db.dbName", flag.Lookup("dbName"))
+	viper.BindPFlag("db.sslMode", flag.Lookup("dbSslmode"))
+	if _, err := os.Stat(cfg.DataDir + "/vaasapi.yml"); err == nil {
+		if err := viper.ReadInConfig(); err != nil {
+			return nil, "", fmt.Errorf("cannot read config file in %s: %w", cfg.DataDir, err)
+		}
+	}
+	if err := viper.Unmarshal(cfg); err != nil {
+		return nil, "", fmt.Errorf("cannot unmarshal loaded config file: %w", err)
+	}
+	return cfg, *output, nil
+}
+
+func main() {
+	cfg, output, err := newConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	log.Init(cfg.LogLevel, "stderr")
+
+	args := flag.Args()
+	if len(args) < 2 || commands[args[0]] == nil || commands[args[0]][args[1]] == nil {
+		flag.Usage()
+		os.Exit(2)
+	}
+	c := &ctl{cfg: cfg, output: output}
+	// the tx cache and results bundles do not need the database
+	if args[0] != "txs" && args[0] != "results" {
+		if c.db, err = pgsql.New(cfg.DB); err != nil {
+			fmt.Fprintf(os.Stderr, "cannot connect to the database: %v\n", err)
+			os.Exit(1)
+		}
+		defer c.db.Close()
+	}
+	if err := commands[args[0]][args[1]](c, args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		// deferred calls do not run on os.Exit
+		if c.db != nil {
+			c.db.Close()
+		}
+		os.Exit(1)
+	}
+}
+
+// flagSet returns the flag set of a subcommand
+func flagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet("vaasctl "+name, flag.ExitOnError)
+}
+
+// print writes v as indented JSON, or header and rows as a table
+func (c *ctl) print(v interface{}, header []string, rows [][]string) error {
+	if c.output == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// printFields writes v as indented JSON, or a two column table of the given fields
+func (c *ctl) printFields(v interface{}, fields map[string]string) error {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	rows := make([][]string, 0, len(keys))
+	for _, k := range keys {
+		rows = append(rows, []string{k, fields[k]})
+	}
+	return c.print(v, []string{"FIELD", "VALUE"}, rows)
+}
@@ -39,6 +39,51 @@ type API struct {
 	GatewayUrl string
 	// MaxCensusSize is the maximum size for a voter census
 	MaxCensusSize uint64
+	// DeletedRetentionDays is the number of days deleted integrators and organizations
+	//  are kept (and can be restored) before being purged. 0 disables purging.
+	DeletedRetentionDays int
+	// ResultsCacheSeconds is the number of seconds the results of live elections
+	//  are cached. 0 disables the cache.
+	ResultsCacheSeconds int
+	// MetadataCacheSize is the number of IPFS files the metadata cache keeps in
+	//  memory. Every cached file is also persisted to disk. 0 disables the cache.
+	MetadataCacheSize int
+	// RateLimitStore keeps the rate limit buckets: "memory", limiting each API
+	//  process on its own, or "postgres", sharing the limits across replicas
+	RateLimitStore string
+	// IntegratorRateLimit and IntegratorRateBurst are the requests per minute
+	//  and at once allowed to each integrator key. 0 disables the limit.
+	IntegratorRateLimit int
+	IntegratorRateBurst int
+	// OrganizationRateLimit and OrganizationRateBurst are the requests per
+	//  minute and at once allowed to each organization API token, unless set by
+	//  its plan. 0 disables the limit.
+	OrganizationRateLimit int
+	OrganizationRateBurst int
+	// IPRateLimit and IPRateBurst are the requests per minute and at once
+	//  allowed to each client IP on public routes. 0 disables the limit.
+	IPRateLimit int
+	IPRateBurst int
+	// RateLimitTrustProxy takes the client IP from the X-Forwarded-For header,
+	//  set by the reverse proxy in front of the API
+	RateLimitTrustProxy bool
+	// AdjustEndBlocks ends the elections created while it is set at their end
+	//  date: their end block is the upper bound of its estimate, and the scheduler
+	//  ends them on time. Elections created before keep their estimated end block.
+	AdjustEndBlocks bool
+	// PublicURL is the scheme and host the API is reached at by voters, such as
+	//  https://vaas.example.com. The hosted CSP is served under it, and is
+	//  disabled if it is empty.
+	PublicURL string
+	// SMTP is the server sending the one-time codes of the email authentication
+	//  of the hosted CSP. If Host is empty, the codes are logged.
+	SMTP struct {
+		Host     string
+		Port     int
+		User     string
+		Password string
+		From     string
+	}
 }
 
 type Plan struct {
@@ -0,0 +1,317 @@
+package csp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+	dvoteUtil "go.vocdoni.io/dvote/util"
+)
+
+const (
+	// AuthCensus authenticates the voters holding a key of the election census
+	AuthCensus = "census"
+	// AuthIDList authenticates the voters of an uploaded list by their secret code
+	AuthIDList = "idlist"
+	// AuthEmail authenticates the voters of an uploaded list by a one-time code
+	//  sent to their email
+	AuthEmail = "email"
+	// AuthJWT authenticates the voters holding a token signed by the integrator
+	AuthJWT = "jwt"
+
+	// OTPValidity is the time a one-time code can be used
+	OTPValidity = 10 * time.Minute
+	// OTPResendInterval is the time before a new one-time code can be sent
+	OTPResendInterval = time.Minute
+	// OTPMaxAttempts is the number of wrong codes that invalidate a one-time code
+	OTPMaxAttempts = 5
+	otpDigits      = 6
+)
+
+// AuthHandler decides which voters of an election may get a signature from the
+//  hosted CSP
+type AuthHandler interface {
+	// Name identifies the handler
+	Name() string
+	// Auth authenticates a voter of election with the authData of its request,
+	//  and returns an id of the voter, unique within the election, so each voter
+	//  gets a single signature. Handlers authenticating in several steps return
+	//  an empty id, and the response to send to the voter, until the last one.
+	Auth(election *types.Election, authData []string) (voterID, response string, err error)
+}
+
+// CensusStore tells whether a public key belongs to a census
+type CensusStore interface {
+	IsCensusMember(censusID uuid.UUID, publicKey []byte) (bool, error)
+}
+
+// VoterStore keeps the voter lists uploaded for the elections, and the
+//  one-time codes sent to their voters
+type VoterStore interface {
+	GetCspVoter(electionID []byte, voterID string) (*types.CspVoter, error)
+	SetCspVoterOTP(electionID []byte, voterID string, otpHash []byte, expires time.Time) error
+	UseCspVoterOTP(electionID []byte, voterID string, otpHash []byte, now time.Time,
+		maxAttempts int) (bool, error)
+}
+
+// HashSecret returns the hash a voter secret of an election is stored as
+func HashSecret(electionID []byte, secret string) []byte {
+	hash := sha256.Sum256(append(append([]byte{}, electionID...), secret...))
+	return hash[:]
+}
+
+// CensusHandler authenticates the voters holding a key of the election census.
+//  The auth data is the signature of the election id with that key, and the
+//  voter id its compressed public key.
+type CensusHandler struct {
+	Census CensusStore
+}
+
+// Name returns "census"
+func (h *CensusHandler) Name() string {
+	return AuthCensus
+}
+
+// Auth authenticates a voter of election by the signature of its id
+func (h *CensusHandler) Auth(election *types.Election, authData []string) (string, string, error) {
+	if !election.CensusID.Valid {
+		return "", "", apierror.ErrForbidden.Withf("election %x has no census", election.ProcessID)
+	}
+	if len(authData) != 1 {
+		return "", "", apierror.ErrInvalidField.With(
+			"authData must hold the election id signed by the voter")
+	}
+	signature, err := hex.DecodeString(dvoteUtil.TrimHex(authData[0]))
+	if err != nil {
+		return "", "", apierror.ErrInvalidSignature.Withf("signature is not hex: %v", err)
+	}
+	pubKey, err := ethereum.PubKeyFromSignature(election.ProcessID, signature)
+	if err != nil {
+		return "", "", apierror.ErrInvalidSignature.WithErr(err)
+	}
+	member, err := h.Census.IsCensusMember(election.CensusID.UUID, pubKey)
+	if err != nil {
+		return "", "", err
+	}
+	if !member {
+		return "", "", apierror.ErrForbidden.Withf("%x is not in the election census", pubKey)
+	}
+	return hex.EncodeToString(pubKey), "", nil
+}
+
+// IDListHandler authenticates the voters of the list uploaded for the election
+//  by their id and secret code. The auth data is the id and the code.
+type IDListHandler struct {
+	Voters VoterStore
+}
+
+// Name returns "idlist"
+func (h *IDListHandler) Name() string {
+	return AuthIDList
+}
+
+// Auth authenticates a voter of election by its id and secret code
+func (h *IDListHandler) Auth(election *types.Election, authData []string) (string, string, error) {
+	if len(authData) != 2 {
+		return "", "", apierror.ErrInvalidField.With("authData must hold the voter id and code")
+	}
+	voter, err := getVoter(h.Voters, election, authData[0])
+	if err != nil {
+		return "", "", err
+	}
+	if len(voter.SecretHash) == 0 || subtle.ConstantTimeCompare(voter.SecretHash,
+		HashSecret(election.ProcessID, authData[1])) != 1 {
+		return "", "", apierror.ErrForbidden.With("wrong voter id or code")
+	}
+	return voter.VoterID, "", nil
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// EmailHandler authenticates the voters of the list uploaded for the election
+//  with a one-time code sent to their email. The voter first sends its email as
+//  auth data, then its email and the code it received.
+type EmailHandler struct {
+	Voters VoterStore
+	Mailer Mailer
+}
+
+// Name returns "email"
+func (h *EmailHandler) Name() string {
+	return AuthEmail
+}
+
+// Auth sends a one-time code to a voter of election, or authenticates it by
+//  the code it was sent
+func (h *EmailHandler) Auth(election *types.Election, authData []string) (string, string, error) {
+	if len(authData) != 1 && len(authData) != 2 {
+		return "", "", apierror.ErrInvalidField.With(
+			"authData must hold the voter email, and the code it was sent")
+	}
+	voter, err := getVoter(h.Voters, election, NormalizeEmail(authData[0]))
+	if err != nil {
+		return "", "", err
+	}
+	now := time.Now()
+	if len(authData) == 2 {
+		ok, err := h.Voters.UseCspVoterOTP(election.ProcessID, voter.VoterID,
+			HashSecret(election.ProcessID, authData[1]), now, OTPMaxAttempts)
+		if err != nil {
+			return "", "", err
+		}
+		if !ok {
+			return "", "", apierror.ErrForbidden.With("wrong or expired code")
+		}
+		return voter.VoterID, "", nil
+	}
+	if voter.OTPExpires != nil && now.Before(voter.OTPExpires.Add(OTPResendInterval-OTPValidity)) {
+		return "", "", apierror.ErrRateLimited.With("a code was sent less than a minute ago")
+	}
+	code, err := newOTP()
+	if err != nil {
+		return "", "", apierror.ErrCrypto.WithErr(err)
+	}
+	if err := h.Voters.SetCspVoterOTP(election.ProcessID, voter.VoterID,
+		HashSecret(election.ProcessID, code), now.Add(OTPValidity)); err != nil {
+		return "", "", err
+	}
+	body := fmt.Sprintf("Your code to vote in %q is %s. It expires in %d minutes.",
+		election.Title, code, int(OTPValidity.Minutes()))
+	if err := h.Mailer.Send(voter.Email, "Your voting code", body); err != nil {
+		return "", "", apierror.ErrGateway.Withf("could not send the code: %v", err)
+	}
+	return "", "a code was sent to the voter email", nil
+}
+
+// NormalizeEmail returns the voter id of an email
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// JWTHandler authenticates the voters holding a JSON Web Token issued by the
+//  integrator backend, signed with HS256 and the secret of the election. The
+//  token must hold the voter id as "sub" and an expiration as "exp", and may
+//  hold the hex election id as "election".
+type JWTHandler struct {
+	// Secret returns the secret of election
+	Secret func(election *types.Election) ([]byte, error)
+}
+
+// Name returns "jwt"
+func (h *JWTHandler) Name() string {
+	return AuthJWT
+}
+
+type jwtClaims struct {
+	Subject    string `json:"sub"`
+	Expiration int64  `json:"exp"`
+	Election   string `json:"election"`
+}
+
+// Auth authenticates a voter of election by its token
+func (h *JWTHandler) Auth(election *types.Election, authData []string) (string, string, error) {
+	if len(authData) != 1 {
+		return "", "", apierror.ErrInvalidField.With("authData must hold the voter token")
+	}
+	secret, err := h.Secret(election)
+	if err != nil {
+		return "", "", err
+	}
+	claims, err := verifyJWT(authData[0], secret)
+	if err != nil {
+		return "", "", apierror.ErrForbidden.Withf("invalid token: %v", err)
+	}
+	if claims.Subject == "" {
+		return "", "", apierror.ErrForbidden.With("invalid token: sub is required")
+	}
+	if claims.Expiration == 0 || time.Now().Unix() >= claims.Expiration {
+		return "", "", apierror.ErrForbidden.With("invalid token: expired")
+	}
+	if claims.Election != "" && !strings.EqualFold(dvoteUtil.TrimHex(claims.Election),
+		hex.EncodeToString(election.ProcessID)) {
+		return "", "", apierror.ErrForbidden.With("invalid token: issued for another election")
+	}
+	return claims.Subject, "", nil
+}
+
+// verifyJWT returns the claims of an HS256 token signed with secret
+func verifyJWT(token string, secret []byte) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	var header struct {
+		Algorithm string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Algorithm != "HS256" {
+		return nil, fmt.Errorf("unsupported algorithm %q", header.Algorithm)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed signature: %w", err)
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, errors.New("wrong signature")
+	}
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return fmt.Errorf("malformed token: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("malformed token: %w", err)
+	}
+	return nil
+}
+
+// getVoter returns a voter of the list of election, failing with ErrForbidden
+//  if it is not in the list
+func getVoter(voters VoterStore, election *types.Election,
+	voterID string) (*types.CspVoter, error) {
+	voter, err := voters.GetCspVoter(election.ProcessID, voterID)
+	if err != nil {
+		if errors.Is(err, apierror.ErrNotFound) {
+			return nil, apierror.ErrForbidden.Withf("%s is not in the election voter list",
+				voterID)
+		}
+		return nil, err
+	}
+	return voter, nil
+}
+
+// newOTP returns a random one-time code of otpDigits digits
+func newOTP() (string, error) {
+	n, err := rand.Int(rand.Reader, new(big.Int).Exp(big.NewInt(10), big.NewInt(otpDigits), nil))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", otpDigits, n), nil
+}
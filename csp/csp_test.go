@@ -0,0 +1,269 @@
+package csp
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"regexp"
+	"testing"
+	"time"
+
+	blind "github.com/arnaucube/go-blindsecp256k1"
+	qt "github.com/frankban/quicktest"
+	"github.com/google/uuid"
+	"github.com/vocdoni/blind-csp/saltedkey"
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/util"
+)
+
+func TestSigner(t *testing.T) {
+	privKey, pubKey, err := GenerateKey()
+	qt.Assert(t, err, qt.IsNil)
+	signer, err := NewSigner(privKey)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, signer.PubKey(), qt.DeepEquals, pubKey)
+	electionID := util.RandomBytes(ElectionIDSize)
+
+	// ECDSA signatures are made with the key salted with the election id
+	rootKey, err := signer.key.ECDSAPubKey()
+	qt.Assert(t, err, qt.IsNil)
+	saltedKey, err := saltedkey.SaltECDSAPubKey(rootKey, salt(electionID))
+	qt.Assert(t, err, qt.IsNil)
+	token, err := NewECDSARequest()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, token, qt.HasLen, tokenSize)
+	payload := util.RandomBytes(64)
+	signature, err := signer.SignECDSA(electionID, payload)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, recoverKey(t, payload, signature), qt.DeepEquals, saltedKey)
+
+	// and so is the shared key
+	sharedKey, err := signer.SharedKey(electionID)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, recoverKey(t, electionID, sharedKey), qt.DeepEquals, saltedKey)
+
+	// blind signatures verify with the salted blind key once unblinded
+	token, secretK, err := NewBlindRequest()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, secretK, qt.HasLen, blindSecretSize)
+	signerR, err := blind.NewPointFromBytesUncompressed(token)
+	qt.Assert(t, err, qt.IsNil)
+	m := new(big.Int).SetBytes(ethereum.HashRaw(payload))
+	blinded, secret, err := blind.Blind(m, signerR)
+	qt.Assert(t, err, qt.IsNil)
+	saltedBlindKey, err := saltedkey.SaltBlindPubKey(signer.key.BlindPubKey(), salt(electionID))
+	qt.Assert(t, err, qt.IsNil)
+	blindSignature, err := signer.SignBlind(electionID, blinded.Bytes(), secretK)
+	qt.Assert(t, err, qt.IsNil)
+	unblinded := blind.Unblind(new(big.Int).SetBytes(blindSignature), secret)
+	qt.Assert(t, blind.Verify(m, unblinded, saltedBlindKey), qt.IsTrue)
+	qt.Assert(t, blind.Verify(m, unblinded, signer.key.BlindPubKey()), qt.IsFalse)
+
+	// including blinded payloads with a leading zero byte, as sent or trimmed
+	for len(blinded.Bytes()) == 32 {
+		blinded, secret, err = blind.Blind(m, signerR)
+		qt.Assert(t, err, qt.IsNil)
+	}
+	for _, blindedPayload := range [][]byte{blinded.FillBytes(make([]byte, 32)),
+		blinded.Bytes()} {
+		blindSignature, err = signer.SignBlind(electionID, blindedPayload, secretK)
+		qt.Assert(t, err, qt.IsNil)
+		unblinded = blind.Unblind(new(big.Int).SetBytes(blindSignature), secret)
+		qt.Assert(t, blind.Verify(m, unblinded, saltedBlindKey), qt.IsTrue)
+	}
+	_, err = signer.SignBlind(electionID, make([]byte, 32), secretK)
+	qt.Assert(t, err, qt.Not(qt.IsNil))
+}
+
+type testCensus map[string]bool
+
+func (c testCensus) IsCensusMember(censusID uuid.UUID, publicKey []byte) (bool, error) {
+	return c[hex.EncodeToString(publicKey)], nil
+}
+
+func TestCensusHandler(t *testing.T) {
+	voter := ethereum.NewSignKeys()
+	qt.Assert(t, voter.Generate(), qt.IsNil)
+	handler := &CensusHandler{Census: testCensus{hex.EncodeToString(voter.PublicKey()): true}}
+	election := &types.Election{ProcessID: util.RandomBytes(ElectionIDSize),
+		CensusID: uuid.NullUUID{UUID: uuid.New(), Valid: true}}
+	signature, err := voter.SignEthereum(election.ProcessID)
+	qt.Assert(t, err, qt.IsNil)
+
+	voterID, _, err := handler.Auth(election, []string{hex.EncodeToString(signature)})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, voterID, qt.Equals, hex.EncodeToString(voter.PublicKey()))
+
+	// keys out of the census are forbidden
+	other := ethereum.NewSignKeys()
+	qt.Assert(t, other.Generate(), qt.IsNil)
+	signature, err = other.SignEthereum(election.ProcessID)
+	qt.Assert(t, err, qt.IsNil)
+	_, _, err = handler.Auth(election, []string{hex.EncodeToString(signature)})
+	qt.Assert(t, errors.Is(err, apierror.ErrForbidden), qt.IsTrue)
+
+	_, _, err = handler.Auth(election, nil)
+	qt.Assert(t, errors.Is(err, apierror.ErrInvalidField), qt.IsTrue)
+	_, _, err = handler.Auth(&types.Election{ProcessID: election.ProcessID},
+		[]string{hex.EncodeToString(signature)})
+	qt.Assert(t, errors.Is(err, apierror.ErrForbidden), qt.IsTrue)
+}
+
+// testVoters keeps the voters of a single election
+type testVoters map[string]*types.CspVoter
+
+func (v testVoters) GetCspVoter(electionID []byte, voterID string) (*types.CspVoter, error) {
+	voter, ok := v[voterID]
+	if !ok {
+		return nil, apierror.ErrNotFound
+	}
+	found := *voter
+	return &found, nil
+}
+
+func (v testVoters) SetCspVoterOTP(electionID []byte, voterID string, otpHash []byte,
+	expires time.Time) error {
+	v[voterID].OTPHash, v[voterID].OTPExpires, v[voterID].OTPAttempts = otpHash, &expires, 0
+	return nil
+}
+
+func (v testVoters) UseCspVoterOTP(electionID []byte, voterID string, otpHash []byte,
+	now time.Time, maxAttempts int) (bool, error) {
+	voter := v[voterID]
+	if len(voter.OTPHash) == 0 || !now.Before(*voter.OTPExpires) ||
+		voter.OTPAttempts >= maxAttempts {
+		return false, nil
+	}
+	voter.OTPAttempts++
+	if !bytes.Equal(voter.OTPHash, otpHash) {
+		return false, nil
+	}
+	voter.OTPHash = nil
+	return true, nil
+}
+
+// testMailer keeps the last email sent
+type testMailer struct {
+	to, body string
+}
+
+func (m *testMailer) Send(to, subject, body string) error {
+	m.to, m.body = to, body
+	return nil
+}
+
+func TestIDListHandler(t *testing.T) {
+	electionID := util.RandomBytes(ElectionIDSize)
+	handler := &IDListHandler{Voters: testVoters{
+		"voter1": {VoterID: "voter1", SecretHash: HashSecret(electionID, "1234")},
+		"voter2": {VoterID: "voter2"},
+	}}
+	election := &types.Election{ProcessID: electionID}
+
+	voterID, _, err := handler.Auth(election, []string{"voter1", "1234"})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, voterID, qt.Equals, "voter1")
+
+	// wrong codes, voters without code and voters out of the list are forbidden
+	for _, authData := range [][]string{{"voter1", "4321"}, {"voter2", ""}, {"voter3", "1234"}} {
+		_, _, err = handler.Auth(election, authData)
+		qt.Assert(t, errors.Is(err, apierror.ErrForbidden), qt.IsTrue, qt.Commentf("%v", authData))
+	}
+	_, _, err = handler.Auth(election, []string{"voter1"})
+	qt.Assert(t, errors.Is(err, apierror.ErrInvalidField), qt.IsTrue)
+}
+
+func TestEmailHandler(t *testing.T) {
+	voters := testVoters{"voter@example.com": {VoterID: "voter@example.com",
+		Email: "voter@example.com"}}
+	mailer := &testMailer{}
+	handler := &EmailHandler{Voters: voters, Mailer: mailer}
+	election := &types.Election{ProcessID: util.RandomBytes(ElectionIDSize), Title: "Board"}
+
+	// the first step sends the code
+	voterID, response, err := handler.Auth(election, []string{" Voter@Example.com"})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, voterID, qt.Equals, "")
+	qt.Assert(t, response, qt.Not(qt.Equals), "")
+	qt.Assert(t, mailer.to, qt.Equals, "voter@example.com")
+	code := regexp.MustCompile(`\d{6}`).FindString(mailer.body)
+	qt.Assert(t, code, qt.HasLen, otpDigits)
+
+	// which is not sent again so soon
+	_, _, err = handler.Auth(election, []string{"voter@example.com"})
+	qt.Assert(t, errors.Is(err, apierror.ErrRateLimited), qt.IsTrue)
+
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	_, _, err = handler.Auth(election, []string{"voter@example.com", wrong})
+	qt.Assert(t, errors.Is(err, apierror.ErrForbidden), qt.IsTrue)
+	voterID, _, err = handler.Auth(election, []string{"voter@example.com", code})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, voterID, qt.Equals, "voter@example.com")
+
+	// codes are used once
+	_, _, err = handler.Auth(election, []string{"voter@example.com", code})
+	qt.Assert(t, errors.Is(err, apierror.ErrForbidden), qt.IsTrue)
+	_, _, err = handler.Auth(election, []string{"other@example.com"})
+	qt.Assert(t, errors.Is(err, apierror.ErrForbidden), qt.IsTrue)
+}
+
+func TestJWTHandler(t *testing.T) {
+	secret := util.RandomBytes(32)
+	handler := &JWTHandler{Secret: func(*types.Election) ([]byte, error) { return secret, nil }}
+	election := &types.Election{ProcessID: util.RandomBytes(ElectionIDSize)}
+	expiration := time.Now().Add(time.Hour).Unix()
+
+	token := testJWT(t, "HS256", secret, jwtClaims{Subject: "voter1", Expiration: expiration,
+		Election: hex.EncodeToString(election.ProcessID)})
+	voterID, _, err := handler.Auth(election, []string{token})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, voterID, qt.Equals, "voter1")
+
+	for name, token := range map[string]string{
+		"wrong secret": testJWT(t, "HS256", util.RandomBytes(32),
+			jwtClaims{Subject: "voter1", Expiration: expiration}),
+		"wrong algorithm": testJWT(t, "none", secret,
+			jwtClaims{Subject: "voter1", Expiration: expiration}),
+		"expired": testJWT(t, "HS256", secret,
+			jwtClaims{Subject: "voter1", Expiration: time.Now().Unix() - 1}),
+		"no subject": testJWT(t, "HS256", secret, jwtClaims{Expiration: expiration}),
+		"other election": testJWT(t, "HS256", secret, jwtClaims{Subject: "voter1",
+			Expiration: expiration, Election: hex.EncodeToString(util.RandomBytes(32))}),
+		"malformed": "token",
+	} {
+		_, _, err := handler.Auth(election, []string{token})
+		qt.Assert(t, errors.Is(err, apierror.ErrForbidden), qt.IsTrue, qt.Commentf(name))
+	}
+}
+
+// testJWT returns a token with claims signed with secret
+func testJWT(t *testing.T, algorithm string, secret []byte, claims jwtClaims) string {
+	header, err := json.Marshal(map[string]string{"alg": algorithm, "typ": "JWT"})
+	qt.Assert(t, err, qt.IsNil)
+	payload, err := json.Marshal(claims)
+	qt.Assert(t, err, qt.IsNil)
+	token := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(token))
+	return token + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// recoverKey returns the uncompressed public key that signed message
+func recoverKey(t *testing.T, message, signature []byte) []byte {
+	pubKey, err := ethereum.PubKeyFromSignature(message, signature)
+	qt.Assert(t, err, qt.IsNil)
+	pubKey, err = ethereum.DecompressPubKey(pubKey)
+	qt.Assert(t, err, qt.IsNil)
+	return pubKey
+}
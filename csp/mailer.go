@@ -0,0 +1,43 @@
+package csp
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+
+	"go.vocdoni.io/dvote/log"
+)
+
+// SMTPMailer sends emails through an SMTP server, with plain auth if User is set
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	From     string
+}
+
+// Send sends a plain text email
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return fmt.Errorf("invalid email header")
+	}
+	var auth smtp.Auth
+	if m.User != "" {
+		auth = smtp.PlainAuth("", m.User, m.Password, m.Host)
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n"+
+		"Content-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n", m.From, to, subject, body)
+	return smtp.SendMail(fmt.Sprintf("%s:%d", m.Host, m.Port), auth, m.From,
+		[]string{to}, []byte(msg))
+}
+
+// LogMailer logs the emails instead of sending them. It is meant for local
+//  development, as the logs hold the one-time codes of the voters.
+type LogMailer struct{}
+
+// Send logs the email
+func (LogMailer) Send(to, subject, body string) error {
+	log.Infof("email to %s: %s: %s", to, subject, body)
+	return nil
+}
@@ -0,0 +1,139 @@
+// Package csp implements the hosted Credential Service Provider: the signer of
+//  the blind and ECDSA signatures voters use as proof of eligibility, with the
+//  salted keys of github.com/vocdoni/blind-csp, and the handlers authenticating
+//  the voters.
+package csp
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	blind "github.com/arnaucube/go-blindsecp256k1"
+	"github.com/vocdoni/blind-csp/saltedkey"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+)
+
+const (
+	// SignatureTypeBlind is a secp256k1 blind signature
+	SignatureTypeBlind = "blind"
+	// SignatureTypeECDSA is the standard secp256k1 signature used in Ethereum
+	SignatureTypeECDSA = "ecdsa"
+	// SignatureTypeSharedKey is the signature of the election id shared by its voters
+	SignatureTypeSharedKey = "sharedkey"
+
+	// ElectionIDSize is the size of the election ids the salts are taken from
+	ElectionIDSize = 32
+
+	// tokenSize is the size of the tokens of ECDSA signature requests
+	tokenSize = 32
+	// blindSecretSize is the size of the secret k of blind signature requests
+	blindSecretSize = 32
+)
+
+// Signer signs with a root key salted with the election id, so every election
+//  has its own key, derived from the root public key used as census root
+type Signer struct {
+	key     *saltedkey.SaltedKey
+	rootKey *big.Int
+	pubKey  []byte
+}
+
+// GenerateKey returns a new root private key and its compressed public key
+func GenerateKey() (privKey, pubKey []byte, err error) {
+	for {
+		signKeys := ethereum.NewSignKeys()
+		if err := signKeys.Generate(); err != nil {
+			return nil, nil, fmt.Errorf("could not generate csp key: %w", err)
+		}
+		_, priv := signKeys.HexString()
+		if privKey, err = hex.DecodeString(priv); err != nil {
+			return nil, nil, fmt.Errorf("could not decode csp key: %w", err)
+		}
+		// saltedkey drops the leading zeros of the key it signs with
+		if len(new(big.Int).SetBytes(privKey).Bytes()) == len(privKey) {
+			return privKey, signKeys.PublicKey(), nil
+		}
+	}
+}
+
+// NewSigner returns a signer with the root private key
+func NewSigner(privKey []byte) (*Signer, error) {
+	key, err := saltedkey.NewSaltedKey(hex.EncodeToString(privKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid csp key: %w", err)
+	}
+	signKeys := ethereum.NewSignKeys()
+	if err := signKeys.AddHexKey(hex.EncodeToString(privKey)); err != nil {
+		return nil, fmt.Errorf("invalid csp key: %w", err)
+	}
+	return &Signer{key: key, rootKey: new(big.Int).SetBytes(privKey),
+		pubKey: signKeys.PublicKey()}, nil
+}
+
+// PubKey returns the compressed root public key
+func (s *Signer) PubKey() []byte {
+	return s.pubKey
+}
+
+// NewBlindRequest returns the R point a voter blinds its payload with, as the
+//  token of the request, and the secret k needed to sign the blinded payload
+func NewBlindRequest() (token, secretK []byte, err error) {
+	k, signerR, err := blind.NewRequestParameters()
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create blind request: %w", err)
+	}
+	secretK = make([]byte, blindSecretSize)
+	k.FillBytes(secretK)
+	// uncompressed for blindsecp256k1-js compatibility
+	return signerR.BytesUncompressed(), secretK, nil
+}
+
+// NewECDSARequest returns a random token for an ECDSA signature request
+func NewECDSARequest() ([]byte, error) {
+	token := make([]byte, tokenSize)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("could not create request token: %w", err)
+	}
+	return token, nil
+}
+
+// SignBlind signs a blinded payload for an election with the secret k of its
+//  request. It computes the blindsecp256k1 signature s' = d·m' + k itself, as
+//  the library rejects the payloads and secrets with leading zero bytes.
+func (s *Signer) SignBlind(electionID, payload, secretK []byte) ([]byte, error) {
+	m := new(big.Int).SetBytes(payload)
+	if m.Sign() == 0 || m.Cmp(blind.N) >= 0 {
+		return nil, fmt.Errorf("blinded payload not inside the finite field")
+	}
+	k := new(big.Int).SetBytes(secretK)
+	if k.Sign() == 0 || k.Cmp(blind.N) >= 0 {
+		return nil, fmt.Errorf("invalid secret k")
+	}
+	electionSalt := salt(electionID)
+	// the key salted with the election id, as saltedkey derives it
+	signature := new(big.Int).SetBytes(electionSalt[:])
+	signature.Add(signature, s.rootKey)
+	signature.Mul(signature, m)
+	signature.Add(signature, k)
+	return signature.Mod(signature, blind.N).Bytes(), nil
+}
+
+// SignECDSA signs the hash of payload for an election
+func (s *Signer) SignECDSA(electionID, payload []byte) ([]byte, error) {
+	return s.key.SignECDSA(salt(electionID), payload)
+}
+
+// SharedKey signs the election id, so every voter of the election gets the same
+//  signature, used as the key to its confidential data
+func (s *Signer) SharedKey(electionID []byte) ([]byte, error) {
+	return s.key.SignECDSA(salt(electionID), electionID)
+}
+
+// salt returns the salt of the keys of an election
+func salt(electionID []byte) [saltedkey.SaltSize]byte {
+	var salt [saltedkey.SaltSize]byte
+	copy(salt[:], electionID)
+	return salt
+}
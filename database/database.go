@@ -5,6 +5,7 @@ import (
 
 	"github.com/google/uuid"
 	migrate "github.com/rubenv/sql-migrate"
+	"go.vocdoni.io/api/ratelimit"
 	"go.vocdoni.io/api/types"
 )
 
@@ -16,6 +17,7 @@ type Database interface {
 	GetIntegrator(id int) (*types.Integrator, error)
 	GetIntegratorByKey(secretApiKey []byte) (*types.Integrator, error)
 	DeleteIntegrator(id int) error
+	RestoreIntegrator(id int) ([]types.Organization, error)
 	CountIntegrators() (int, error)
 	GetIntegratorApiKeysList() ([][]byte, error)
 	// Plans
@@ -24,6 +26,7 @@ type Database interface {
 	GetPlanByName(name string) (*types.QuotaPlan, error)
 	DeletePlan(id uuid.UUID) error
 	UpdatePlan(id uuid.UUID, newMaxCensusSize, neWMaxProcessCount int, newName string) (int, error)
+	UpdatePlanRateLimit(id uuid.UUID, rateLimit, rateBurst int) (int, error)
 	GetPlansList() ([]types.QuotaPlan, error)
 	// Organization
 	CreateOrganization(integratorAPIKey, ethAddress, ethPrivKeyCipher []byte, planID uuid.NullUUID, publiApiQuota int, publicApiToken, headerUri, avatarUri string) (int, error)
@@ -32,15 +35,75 @@ type Database interface {
 	UpdateOrganizationEthPrivKeyCipher(integratorAPIKey, ethAddress, newEthPrivKeyCipher []byte) (int, error)
 	UpdateOrganizationPublicAPIToken(integratorAPIKey, ethAddress []byte, newPublicApiToken string) (int, error)
 	GetOrganization(integratorAPIKey, ethAddress []byte) (*types.Organization, error)
+	GetOrganizationByAPIToken(token string) (*types.Organization, error)
 	DeleteOrganization(integratorAPIKey, ethAddress []byte) error
-	ListOrganizations(integratorAPIKey []byte, filter *types.ListOptions) ([]types.Organization, error)
+	RestoreOrganization(integratorAPIKey, ethAddress []byte) (*types.Organization, error)
+	// List methods return a page and the cursor of the next one, empty on the
+	//  last page. A nil filter lists every row.
+	ListOrganizations(integratorAPIKey []byte, filter *types.ListOptions) ([]types.Organization, string, error)
 	CountOrganizations(integratorAPIKey []byte) (int, error)
 	// Election
 	CreateElection(integratorAPIKey, orgEthAddress, processID, encryptedMetadataKey []byte, title, proofType string, startDate, endDate time.Time, censusID uuid.NullUUID, startBlock, endBlock int, confidential, hiddenResults bool) (int, error)
+	StoreElection(election *types.Election, actions []types.ScheduledAction) (int, error)
 	GetElection(integratorAPIKey, orgEthAddress, processID []byte) (*types.Election, error)
 	GetElectionPublic(organizationEthAddress, processID []byte) (*types.Election, error)
 	GetElectionPrivate(organizationEthAddress, processID []byte) (*types.Election, error)
-	ListElections(integratorAPIKey, orgEthAddress []byte) ([]types.Election, error)
+	ListElections(integratorAPIKey, orgEthAddress []byte, filter *types.ElectionFilter) ([]types.Election, string, error)
+	ListElectionsPublic(organizationEthAddress []byte, filter *types.ElectionFilter) ([]types.Election, string, error)
+	ListElectionsToSync(syncedBefore time.Time, count int) ([]types.Election, error)
+	UpdateElectionStatus(processID []byte, status string) error
+	SyncElection(election *types.Election, events []types.ChainEvent) error
+	CreateResultsSnapshot(snapshot *types.ResultsSnapshot) error
+	GetResultsSnapshot(processID []byte) (*types.ResultsSnapshot, error)
+	// Chain state
+	ListOrganizationsToSync(syncedBefore time.Time, count int) ([]types.Organization, error)
+	SyncOrganization(organization *types.Organization, events []types.ChainEvent) error
+	ListChainEvents(filter *types.ChainEventFilter) ([]types.ChainEvent, string, error)
+	// Audit
+	CreateAuditEvent(event *types.AuditEvent) (int64, error)
+	ListAuditEvents(filter *types.AuditFilter) ([]types.AuditEvent, string, error)
+	// Usage
+	AddUsage(counters []types.UsageCounter) error
+	ListUsage(filter *types.UsageFilter) ([]types.UsageCounter, string, error)
+	// Hosted CSP
+	CreateCspKey(key *types.CspKey) (int, error)
+	GetCspKey(integratorID int, orgEthAddress []byte) (*types.CspKey, error)
+	GetCspKeyByPubKey(pubKey []byte) (*types.CspKey, error)
+	CreateCspRequest(request *types.CspRequest) error
+	UseCspRequest(electionID, token []byte, signType string,
+		sign func(request *types.CspRequest) error) error
+	IsCensusMember(censusID uuid.UUID, publicKey []byte) (bool, error)
+	SetElectionCspAuth(processID []byte, handler string, secretCipher []byte) error
+	AddCspVoters(electionID []byte, voters []types.CspVoter) error
+	GetCspVoter(electionID []byte, voterID string) (*types.CspVoter, error)
+	SetCspVoterOTP(electionID []byte, voterID string, otpHash []byte, expires time.Time) error
+	UseCspVoterOTP(electionID []byte, voterID string, otpHash []byte, now time.Time,
+		maxAttempts int) (bool, error)
+	// Turnout statistics
+	AddElectionSample(sample *types.ElectionSample) error
+	ListElectionSamples(processID []byte) ([]types.ElectionSample, error)
+	GetElectionCensusSize(processID []byte) (int, error)
+	// Block time samples
+	AddBlockSample(sample *types.BlockSample) error
+	ListBlockSamples(since time.Time) ([]types.BlockSample, error)
+	DeleteBlockSamples(before time.Time) (int, error)
+	// Election templates
+	CreateElectionTemplate(template *types.ElectionTemplate) (int, error)
+	ListElectionTemplates(integratorAPIKey, orgEthAddress []byte) ([]types.ElectionTemplate, error)
+	GetElectionTemplate(integratorAPIKey, orgEthAddress []byte, id int) (*types.ElectionTemplate, error)
+	DeleteElectionTemplate(integratorAPIKey, orgEthAddress []byte, id int) error
+	// Scheduled actions
+	CreateScheduledAction(action *types.ScheduledAction) (int, error)
+	ListScheduledActions(integratorAPIKey, processID []byte) ([]types.ScheduledAction, error)
+	CancelScheduledAction(integratorAPIKey, processID []byte, id int) error
+	ListDueScheduledActions(before, claimedBefore time.Time) ([]types.ScheduledAction, error)
+	SetScheduledActionTarget(id int, targetBlock uint32) error
+	ClaimScheduledAction(id int, claimedBefore time.Time) (bool, error)
+	FinishScheduledAction(id int, status, errorMessage string, txHash []byte) error
+	// Rate limits, shared by every API replica
+	ratelimit.Store
+	// Soft deletion
+	PurgeDeleted(deletedBefore time.Time) (integrators int, organizations int, err error)
 	// Manage DB
 	Ping() error
 	Close() error
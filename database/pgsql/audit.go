@@ -0,0 +1,84 @@
+package pgsql
+
+import (
+	"fmt"
+	"time"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+)
+
+func (d *Database) CreateAuditEvent(event *types.AuditEvent) (int64, error) {
+	// created_at has no time zone, so events are stored and filtered in UTC
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	event.CreatedAt = event.CreatedAt.UTC()
+	insert := `INSERT INTO audit_events
+			( created_at, actor_type, actor_id, integrator_id, action, target, request_id,
+			  outcome, http_status, error)
+			VALUES ( :created_at, :actor_type, :actor_id, :integrator_id, :action, :target,
+			  :request_id, :outcome, :http_status, :error)
+			RETURNING id`
+	result, err := d.db.NamedQuery(insert, event)
+	if err != nil {
+		return 0, dbError(fmt.Errorf("error creating audit event: %w", err),
+			apierror.ErrNotFound)
+	}
+	defer result.Close()
+	if !result.Next() {
+		return 0, fmt.Errorf("error creating audit event: there is no next result row")
+	}
+	if err := result.Scan(&event.ID); err != nil {
+		return 0, fmt.Errorf("error creating audit event: %w", err)
+	}
+	return event.ID, nil
+}
+
+// auditSortColumns are the columns audit event lists can be sorted by
+var auditSortColumns = map[string]sortColumn{
+	"id": {"id", sortInt},
+}
+
+// ListAuditEvents returns a page of the events matching filter, newest first,
+//  and the cursor of the next page
+func (d *Database) ListAuditEvents(filter *types.AuditFilter) ([]types.AuditEvent, string, error) {
+	if filter == nil {
+		filter = &types.AuditFilter{}
+	}
+	q := &listQuery{}
+	if filter.IntegratorID != 0 {
+		q.where("integrator_id = $%d", filter.IntegratorID)
+	}
+	if filter.ActorType != "" {
+		q.where("actor_type = $%d", filter.ActorType)
+	}
+	if filter.Action != "" {
+		q.where("action = $%d", filter.Action)
+	}
+	if filter.Outcome != "" {
+		q.where("outcome = $%d", filter.Outcome)
+	}
+	if !filter.From.IsZero() {
+		q.where("created_at >= $%d", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		q.where("created_at < $%d", filter.To.UTC())
+	}
+	if err := q.paginate(&types.ListOptions{Count: filter.Count, Skip: filter.Skip,
+		Cursor: filter.Cursor}, auditSortColumns, "id", true); err != nil {
+		return nil, "", err
+	}
+	selectQuery := `SELECT id, created_at, actor_type, actor_id, integrator_id, action, target,
+						request_id, outcome, http_status, error
+					FROM audit_events` + q.sql()
+	events := []types.AuditEvent{}
+	if err := d.db.Select(&events, selectQuery, q.args...); err != nil {
+		return nil, "", dbError(fmt.Errorf("error listing audit events: %w", err),
+			apierror.ErrNotFound)
+	}
+	n, next := q.page(len(events), func(i int) (interface{}, int64) {
+		return events[i].ID, events[i].ID
+	})
+	return events[:n], next, nil
+}
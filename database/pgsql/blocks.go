@@ -0,0 +1,45 @@
+package pgsql
+
+import (
+	"fmt"
+	"time"
+
+	"go.vocdoni.io/api/types"
+)
+
+// AddBlockSample stores the timestamp of a block. Blocks already sampled keep
+//  their first sample.
+func (d *Database) AddBlockSample(sample *types.BlockSample) error {
+	insert := `INSERT INTO block_samples (height, timestamp) VALUES ($1, $2)
+				ON CONFLICT (height) DO NOTHING`
+	if _, err := d.db.Exec(insert, int64(sample.Height), sample.Timestamp.UTC()); err != nil {
+		return fmt.Errorf("error adding block sample: %w", err)
+	}
+	return nil
+}
+
+// ListBlockSamples returns the samples of the blocks produced since the given
+//  time, lowest height first
+func (d *Database) ListBlockSamples(since time.Time) ([]types.BlockSample, error) {
+	samples := []types.BlockSample{}
+	selectQuery := `SELECT height, timestamp FROM block_samples
+					WHERE timestamp >= $1 ORDER BY height`
+	if err := d.db.Select(&samples, selectQuery, since.UTC()); err != nil {
+		return nil, fmt.Errorf("error listing block samples: %w", err)
+	}
+	return samples, nil
+}
+
+// DeleteBlockSamples deletes the samples of the blocks produced before the given
+//  time, and returns how many were deleted
+func (d *Database) DeleteBlockSamples(before time.Time) (int, error) {
+	result, err := d.db.Exec(`DELETE FROM block_samples WHERE timestamp < $1`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("error deleting block samples: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error deleting block samples: %w", err)
+	}
+	return int(rows), nil
+}
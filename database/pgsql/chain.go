@@ -0,0 +1,82 @@
+package pgsql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+)
+
+// insertChainEvents records the events of a sync within its transaction
+func insertChainEvents(tx *sqlx.Tx, events []types.ChainEvent, now time.Time) error {
+	insert := `INSERT INTO chain_events
+			( created_at, organization_eth_address, process_id, kind, old_value, new_value)
+			VALUES ( :created_at, :organization_eth_address, :process_id, :kind, :old_value,
+			  :new_value)`
+	for i := range events {
+		// created_at has no time zone, so events are stored and filtered in UTC
+		events[i].CreatedAt = now
+		if _, err := tx.NamedExec(insert, &events[i]); err != nil {
+			return fmt.Errorf("error creating chain event: %w", err)
+		}
+	}
+	return nil
+}
+
+// utcTime returns t in UTC, for the columns without time zone, or nil if t is nil
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// chainEventSortColumns are the columns chain event lists can be sorted by
+var chainEventSortColumns = map[string]sortColumn{
+	"id": {"id", sortInt},
+}
+
+// ListChainEvents returns a page of the chain events matching filter, newest
+//  first, and the cursor of the next page
+func (d *Database) ListChainEvents(
+	filter *types.ChainEventFilter) ([]types.ChainEvent, string, error) {
+	if filter == nil {
+		filter = &types.ChainEventFilter{}
+	}
+	q := &listQuery{}
+	if len(filter.OrgEthAddress) > 0 {
+		q.where("organization_eth_address = $%d", filter.OrgEthAddress)
+	}
+	if len(filter.ProcessID) > 0 {
+		q.where("process_id = $%d", filter.ProcessID)
+	}
+	if filter.Kind != "" {
+		q.where("kind = $%d", filter.Kind)
+	}
+	if !filter.From.IsZero() {
+		q.where("created_at >= $%d", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		q.where("created_at < $%d", filter.To.UTC())
+	}
+	if err := q.paginate(&types.ListOptions{Count: filter.Count, Skip: filter.Skip,
+		Cursor: filter.Cursor}, chainEventSortColumns, "id", true); err != nil {
+		return nil, "", err
+	}
+	selectQuery := `SELECT id, created_at, organization_eth_address, process_id, kind,
+						old_value, new_value
+					FROM chain_events` + q.sql()
+	events := []types.ChainEvent{}
+	if err := d.db.Select(&events, selectQuery, q.args...); err != nil {
+		return nil, "", dbError(fmt.Errorf("error listing chain events: %w", err),
+			apierror.ErrNotFound)
+	}
+	n, next := q.page(len(events), func(i int) (interface{}, int64) {
+		return events[i].ID, events[i].ID
+	})
+	return events[:n], next, nil
+}
@@ -0,0 +1,241 @@
+package pgsql
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+)
+
+// CreateCspKey stores a root key of the hosted CSP. Keys are never rotated: a
+//  second key of the same owner fails with ErrAlreadyExists.
+func (d *Database) CreateCspKey(key *types.CspKey) (int, error) {
+	address := []byte(key.OrgEthAddress)
+	if address == nil {
+		// the integrator key is stored with an empty address
+		address = []byte{}
+	}
+	insert := `INSERT INTO csp_keys
+			( integrator_id, organization_eth_address, pub_key, priv_key_cipher)
+			VALUES ( $1, $2, $3, $4)
+			RETURNING id`
+	var id int
+	if err := d.db.QueryRowx(insert, key.IntegratorID, address, []byte(key.PubKey),
+		key.PrivKeyCipher).Scan(&id); err != nil {
+		return 0, dbError(fmt.Errorf("error creating csp key: %w", err),
+			apierror.ErrIntegratorNotFound)
+	}
+	return id, nil
+}
+
+// GetCspKey returns the key of an integrator or, if orgEthAddress is not
+//  empty, of one of its organizations
+func (d *Database) GetCspKey(integratorID int, orgEthAddress []byte) (*types.CspKey, error) {
+	if orgEthAddress == nil {
+		orgEthAddress = []byte{}
+	}
+	var key types.CspKey
+	selectKey := `SELECT id, created_at, integrator_id, organization_eth_address, pub_key,
+				priv_key_cipher
+				FROM csp_keys WHERE integrator_id = $1 AND organization_eth_address = $2`
+	if err := d.db.QueryRowx(selectKey, integratorID, orgEthAddress).StructScan(&key); err != nil {
+		return nil, dbError(err, apierror.ErrCspKeyNotFound)
+	}
+	return &key, nil
+}
+
+// GetCspKeyByPubKey returns the key with the public key used as census root of
+//  the elections signed with it
+func (d *Database) GetCspKeyByPubKey(pubKey []byte) (*types.CspKey, error) {
+	var key types.CspKey
+	selectKey := `SELECT id, created_at, integrator_id, organization_eth_address, pub_key,
+				priv_key_cipher
+				FROM csp_keys WHERE pub_key = $1`
+	if err := d.db.QueryRowx(selectKey, pubKey).StructScan(&key); err != nil {
+		return nil, dbError(err, apierror.ErrCspKeyNotFound)
+	}
+	return &key, nil
+}
+
+// CreateCspRequest stores the signature request of an authenticated voter. The
+//  request replaces the unused one of the voter, if any, and fails with
+//  ErrAlreadyExists if the voter already got a signature for the election.
+func (d *Database) CreateCspRequest(request *types.CspRequest) error {
+	secretK := request.SecretKCipher
+	if secretK == nil {
+		secretK = []byte{}
+	}
+	upsert := `INSERT INTO csp_requests
+			( token, election_id, voter, sign_type, secret_k_cipher)
+			VALUES ( $1, $2, $3, $4, $5)
+			ON CONFLICT (election_id, voter) DO UPDATE SET
+				token = EXCLUDED.token,
+				sign_type = EXCLUDED.sign_type,
+				secret_k_cipher = EXCLUDED.secret_k_cipher,
+				created_at = now() at time zone 'utc'
+			WHERE csp_requests.signed_at IS NULL`
+	result, err := d.db.Exec(upsert, request.Token, request.ElectionID, request.Voter,
+		request.SignType, secretK)
+	if err != nil {
+		return dbError(fmt.Errorf("error creating csp request: %w", err),
+			apierror.ErrElectionNotFound)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("cannot get affected rows: %v", err)
+	}
+	if rows == 0 {
+		return apierror.ErrAlreadyExists.Withf("voter %s already got a signature", request.Voter)
+	}
+	return nil
+}
+
+// UseCspRequest signs with sign the unused request of token, of signType, for an
+//  election, and marks it as used if sign succeeds. The request is locked while
+//  signing, so a token is signed once.
+func (d *Database) UseCspRequest(electionID, token []byte, signType string,
+	sign func(request *types.CspRequest) error) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return apierror.ErrDatabase.WithErr(err)
+	}
+	defer tx.Rollback()
+	var request types.CspRequest
+	selectRequest := `SELECT token, election_id, voter, sign_type, secret_k_cipher, signed_at
+				FROM csp_requests
+				WHERE token = $1 AND election_id = $2 AND sign_type = $3 AND signed_at IS NULL
+				FOR UPDATE`
+	if err := tx.QueryRowx(selectRequest, token, electionID, signType).
+		StructScan(&request); err != nil {
+		return dbError(err, apierror.ErrCspRequestNotFound)
+	}
+	if err := sign(&request); err != nil {
+		return err
+	}
+	update := `UPDATE csp_requests SET signed_at = now() at time zone 'utc',
+				secret_k_cipher = ''
+				WHERE token = $1`
+	if _, err := tx.Exec(update, token); err != nil {
+		return apierror.ErrDatabase.WithErr(fmt.Errorf("error using csp request: %w", err))
+	}
+	if err := tx.Commit(); err != nil {
+		return apierror.ErrDatabase.WithErr(err)
+	}
+	return nil
+}
+
+// IsCensusMember tells whether publicKey is a member of a census
+func (d *Database) IsCensusMember(censusID uuid.UUID, publicKey []byte) (bool, error) {
+	var member bool
+	selectMember := `SELECT EXISTS (SELECT 1 FROM census_members
+				WHERE census_id = $1 AND public_key = $2)`
+	if err := d.db.QueryRowx(selectMember, censusID, publicKey).Scan(&member); err != nil {
+		return false, apierror.ErrDatabase.WithErr(err)
+	}
+	return member, nil
+}
+
+// SetElectionCspAuth sets the handler authenticating the voters of an election
+//  on the hosted CSP, and its encrypted secret
+func (d *Database) SetElectionCspAuth(processID []byte, handler string, secretCipher []byte) error {
+	if secretCipher == nil {
+		secretCipher = []byte{}
+	}
+	result, err := d.db.Exec(`UPDATE elections SET csp_auth = $2, csp_auth_secret = $3
+				WHERE process_id = $1`, processID, handler, secretCipher)
+	if err != nil {
+		return dbError(fmt.Errorf("error setting election csp auth: %w", err),
+			apierror.ErrElectionNotFound)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return apierror.ErrElectionNotFound.Withf("%x", processID)
+	}
+	return nil
+}
+
+// AddCspVoters adds voters to the list of an election, replacing the secret
+//  code and email of the voters already in it, within a single transaction
+func (d *Database) AddCspVoters(electionID []byte, voters []types.CspVoter) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return apierror.ErrDatabase.WithErr(err)
+	}
+	defer tx.Rollback()
+	upsert := `INSERT INTO csp_voters
+			( election_id, voter_id, secret_hash, email)
+			VALUES ( $1, $2, $3, $4)
+			ON CONFLICT (election_id, voter_id) DO UPDATE SET
+				secret_hash = EXCLUDED.secret_hash,
+				email = EXCLUDED.email`
+	for _, voter := range voters {
+		secretHash := voter.SecretHash
+		if secretHash == nil {
+			secretHash = []byte{}
+		}
+		if _, err := tx.Exec(upsert, electionID, voter.VoterID, secretHash,
+			voter.Email); err != nil {
+			return dbError(fmt.Errorf("error adding csp voters: %w", err),
+				apierror.ErrElectionNotFound)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return apierror.ErrDatabase.WithErr(err)
+	}
+	return nil
+}
+
+// GetCspVoter returns a voter of the list of an election
+func (d *Database) GetCspVoter(electionID []byte, voterID string) (*types.CspVoter, error) {
+	var voter types.CspVoter
+	selectVoter := `SELECT election_id, voter_id, secret_hash, email, otp_hash, otp_expires,
+				otp_attempts
+				FROM csp_voters WHERE election_id = $1 AND voter_id = $2`
+	if err := d.db.QueryRowx(selectVoter, electionID, voterID).StructScan(&voter); err != nil {
+		return nil, dbError(err, apierror.ErrNotFound.Withf("voter %s", voterID))
+	}
+	return &voter, nil
+}
+
+// SetCspVoterOTP stores the hash of the one-time code sent to a voter, valid
+//  until expires, replacing the previous one
+func (d *Database) SetCspVoterOTP(electionID []byte, voterID string, otpHash []byte,
+	expires time.Time) error {
+	result, err := d.db.Exec(`UPDATE csp_voters SET otp_hash = $3, otp_expires = $4,
+				otp_attempts = 0
+				WHERE election_id = $1 AND voter_id = $2`,
+		electionID, voterID, otpHash, expires.UTC())
+	if err != nil {
+		return apierror.ErrDatabase.WithErr(fmt.Errorf("error setting voter otp: %w", err))
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return apierror.ErrNotFound.Withf("voter %s", voterID)
+	}
+	return nil
+}
+
+// UseCspVoterOTP tells whether otpHash is the hash of the one-time code of a
+//  voter, valid at now, and clears it if so. Wrong codes count as attempts,
+//  and the code cannot be used after maxAttempts of them.
+func (d *Database) UseCspVoterOTP(electionID []byte, voterID string, otpHash []byte,
+	now time.Time, maxAttempts int) (bool, error) {
+	// the updated otp_hash is only empty if the code matched
+	update := `UPDATE csp_voters SET
+				otp_attempts = CASE WHEN otp_hash = $3 THEN 0 ELSE otp_attempts + 1 END,
+				otp_hash = CASE WHEN otp_hash = $3 THEN ''::bytea ELSE otp_hash END
+				WHERE election_id = $1 AND voter_id = $2 AND otp_hash <> ''::bytea
+					AND otp_expires > $4 AND otp_attempts < $5
+				RETURNING otp_hash = ''::bytea`
+	var used bool
+	err := d.db.QueryRowx(update, electionID, voterID, otpHash, now.UTC(),
+		maxAttempts).Scan(&used)
+	if err != nil {
+		if err := dbError(err, apierror.ErrNotFound); errors.Is(err, apierror.ErrNotFound) {
+			return false, nil
+		}
+		return false, apierror.ErrDatabase.WithErr(err)
+	}
+	return used, nil
+}
@@ -2,14 +2,23 @@ package pgsql
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	_ "github.com/jackc/pgx/stdlib"
 
+	"go.vocdoni.io/api/apierror"
 	"go.vocdoni.io/api/types"
 )
 
+// likeEscaper escapes the LIKE wildcards of a search string
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// liveOrganizations restricts election queries to organizations not soft deleted
+const liveOrganizations = `organization_eth_address IN
+							(SELECT eth_address FROM organizations WHERE deleted_at IS NULL)`
+
 func (d *Database) CreateElection(integratorAPIKey, orgEthAddress, processID, encryptedMetadataKey []byte, title, proofType string, startDate, endDate time.Time, censusID uuid.NullUUID, startBlock, endBlock int, confidential, hiddenResults bool) (int, error) {
 
 	election := &types.Election{
@@ -31,63 +40,263 @@ func (d *Database) CreateElection(integratorAPIKey, orgEthAddress, processID, en
 			UpdatedAt: time.Now(),
 		},
 	}
+	return d.StoreElection(election, nil)
+}
+
+// StoreElection stores a new election along with the actions scheduled on it,
+//  within a single transaction, and returns its id. The handler authenticating
+//  its voters on the hosted CSP is stored with it, so an election never shows
+//  as a census one while it is being created.
+func (d *Database) StoreElection(election *types.Election,
+	actions []types.ScheduledAction) (int, error) {
+	if election.CspAuthSecret == nil {
+		election.CspAuthSecret = []byte{}
+	}
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("error creating election: %w", err)
+	}
+	defer tx.Rollback()
 	// TODO: Calculate EntityID (consult go-dvote)
 	insert := `INSERT INTO elections
 			( organization_eth_address, integrator_api_key, process_id, metadata_priv_key, title, proof_type, census_id,
-				start_date, end_date, start_block, end_block, confidential, hidden_results, created_at, updated_at)
+				start_date, end_date, start_block, end_block, confidential, hidden_results, csp_auth, csp_auth_secret,
+				created_at, updated_at)
 			VALUES ( :organization_eth_address, :integrator_api_key, :process_id, :metadata_priv_key, :title, :proof_type, :census_id,
-				:start_date, :end_date, :start_block, :end_block, :confidential, :hidden_results, :created_at, :updated_at)
+				:start_date, :end_date, :start_block, :end_block, :confidential, :hidden_results, :csp_auth, :csp_auth_secret,
+				:created_at, :updated_at)
 			RETURNING id`
-	result, err := d.db.NamedQuery(insert, election)
+	result, err := tx.NamedQuery(insert, election)
 	if err != nil {
-		return 0, fmt.Errorf("error creating election: %v", err)
+		return 0, dbError(fmt.Errorf("error creating election: %w", err),
+			apierror.ErrElectionNotFound)
 	}
 	if !result.Next() {
+		result.Close()
 		return 0, fmt.Errorf("error creating election: there is no next result row")
 	}
 	var id int
 	err = result.Scan(&id)
+	result.Close()
 	if err != nil {
 		return 0, fmt.Errorf("error creating election: %v", err)
 	}
+	for i := range actions {
+		if actions[i].ID, err = insertScheduledAction(tx, &actions[i]); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("error creating election: %w", err)
+	}
 	return id, nil
 }
 
 func (d *Database) GetElectionPublic(organizationEthAddress, processID []byte) (*types.Election, error) {
 	var election types.Election
-	selectIntegrator := `SELECT title, proof_type, start_date, end_date, start_block, end_block, confidential, hidden_results
-						FROM elections WHERE organization_eth_address=$1 AND process_id=$2`
+	selectIntegrator := `SELECT title, proof_type, start_date, end_date, start_block, end_block, status, confidential, hidden_results
+						FROM elections WHERE organization_eth_address=$1 AND process_id=$2
+							AND ` + liveOrganizations
 	row := d.db.QueryRowx(selectIntegrator, organizationEthAddress, processID)
-	return &election, row.StructScan(&election)
+	return &election, dbError(row.StructScan(&election), apierror.ErrElectionNotFound)
 }
 
 func (d *Database) GetElectionPrivate(organizationEthAddress, processID []byte) (*types.Election, error) {
 	var election types.Election
-	selectIntegrator := `SELECT metadata_priv_key, title, proof_type, census_id, start_date, end_date, start_block, end_block, confidential, hidden_results, integrator_api_key
-						FROM elections WHERE organization_eth_address=$1 AND process_id=$2`
+	selectIntegrator := `SELECT metadata_priv_key, title, proof_type, census_id, start_date, end_date, start_block, end_block, status, confidential, hidden_results, integrator_api_key,
+							csp_auth, csp_auth_secret
+						FROM elections WHERE organization_eth_address=$1 AND process_id=$2
+							AND ` + liveOrganizations
 	row := d.db.QueryRowx(selectIntegrator, organizationEthAddress, processID)
-	return &election, row.StructScan(&election)
+	return &election, dbError(row.StructScan(&election), apierror.ErrElectionNotFound)
 }
 
 func (d *Database) GetElection(integratorAPIKey, orgEthAddress, processID []byte) (*types.Election, error) {
 	var election types.Election
-	selectIntegrator := `SELECT metadata_priv_key, title, proof_type, census_id, start_date, end_date, start_block, end_block, confidential, hidden_results, 
-							created_at, updated_at
+	selectIntegrator := `SELECT metadata_priv_key, title, proof_type, census_id, start_date, end_date, start_block, end_block, status, confidential, hidden_results, 
+							vote_count, has_results, missing_since, csp_auth, created_at, updated_at
 						FROM elections WHERE organization_eth_address =$1 AND integrator_api_key=$2
-									AND process_id=$3`
+									AND process_id=$3 AND ` + liveOrganizations
 	row := d.db.QueryRowx(selectIntegrator, orgEthAddress, integratorAPIKey, processID)
 	err := row.StructScan(&election)
 	if err != nil {
-		return nil, err
+		return nil, dbError(err, apierror.ErrElectionNotFound)
 	}
 
 	return &election, nil
 }
 
-func (d *Database) ListElections(integratorAPIKey, orgEthAddress []byte) ([]types.Election, error) {
-	var election []types.Election
-	selectIntegrator := `SELECT title, start_date, end_date, start_block, end_block, confidential, hidden_results, 
-							created_at, updated_at
-						FROM elections WHERE organization_eth_address =$1 AND integrator_api_key=$2`
-	return election, d.db.Select(&election, selectIntegrator, orgEthAddress, integratorAPIKey)
+// electionSortColumns are the columns election lists can be sorted by. The dates
+//  are estimated from the blocks, which are always set.
+var electionSortColumns = map[string]sortColumn{
+	"id":        {"id", sortInt},
+	"createdAt": {"created_at", sortTime},
+	"title":     {"title", sortText},
+	"startDate": {"start_block", sortInt},
+	"endDate":   {"end_block", sortInt},
+}
+
+// ListElections returns a page of an organization's elections, including the
+//  confidential ones, and the cursor of the next page
+func (d *Database) ListElections(integratorAPIKey, orgEthAddress []byte,
+	filter *types.ElectionFilter) ([]types.Election, string, error) {
+	q := &listQuery{}
+	q.where("integrator_api_key = $%d", integratorAPIKey)
+	return d.listElections(q, `id, process_id, metadata_priv_key, title, proof_type, census_id,
+		start_date, end_date, start_block, end_block, status, confidential, hidden_results,
+		vote_count, has_results, missing_since, created_at, updated_at`, orgEthAddress, filter)
+}
+
+// ListElectionsPublic returns a page of an organization's non-confidential
+//  elections and the cursor of the next page
+func (d *Database) ListElectionsPublic(organizationEthAddress []byte,
+	filter *types.ElectionFilter) ([]types.Election, string, error) {
+	q := &listQuery{}
+	q.where("confidential = false")
+	return d.listElections(q, `id, process_id, title, proof_type, start_date, end_date,
+		start_block, end_block, status, confidential, hidden_results, created_at`,
+		organizationEthAddress, filter)
+}
+
+func (d *Database) listElections(q *listQuery, columns string, orgEthAddress []byte,
+	filter *types.ElectionFilter) ([]types.Election, string, error) {
+	q.where("organization_eth_address = $%d", orgEthAddress)
+	q.where(liveOrganizations)
+	var opts *types.ListOptions
+	if filter != nil {
+		opts = &filter.ListOptions
+		if filter.ProofType != "" {
+			q.where("proof_type = $%d", filter.ProofType)
+		}
+		if filter.Title != "" {
+			q.where("title ILIKE $%d", "%"+likeEscaper.Replace(filter.Title)+"%")
+		}
+		if !filter.StartAfter.IsZero() {
+			q.where("start_date >= $%d", filter.StartAfter.UTC())
+		}
+		if !filter.StartBefore.IsZero() {
+			q.where("start_date < $%d", filter.StartBefore.UTC())
+		}
+		if !filter.EndAfter.IsZero() {
+			q.where("end_date >= $%d", filter.EndAfter.UTC())
+		}
+		if !filter.EndBefore.IsZero() {
+			q.where("end_date < $%d", filter.EndBefore.UTC())
+		}
+		if err := whereElectionStatus(q, filter.Status, filter.Height); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := q.paginate(opts, electionSortColumns, "id", false); err != nil {
+		return nil, "", err
+	}
+	var elections []types.Election
+	if err := d.db.Select(&elections,
+		"SELECT "+columns+" FROM elections"+q.sql(), q.args...); err != nil {
+		return nil, "", dbError(fmt.Errorf("error listing elections: %w", err),
+			apierror.ErrElectionNotFound)
+	}
+	n, next := q.page(len(elections), func(i int) (interface{}, int64) {
+		election := elections[i]
+		switch q.sortBy {
+		case "createdAt":
+			return election.CreatedAt, int64(election.ID)
+		case "title":
+			return election.Title, int64(election.ID)
+		case "startDate":
+			return election.StartBlock, int64(election.ID)
+		case "endDate":
+			return election.EndBlock, int64(election.ID)
+		}
+		return election.ID, int64(election.ID)
+	})
+	return elections[:n], next, nil
+}
+
+// whereElectionStatus adds the condition selecting the elections with the API
+//  status at the block height. READY elections are upcoming, active or ended
+//  depending on their blocks.
+func whereElectionStatus(q *listQuery, status string, height int) error {
+	switch status {
+	case "":
+	case types.ElectionStatusUpcoming:
+		q.where("status = 'READY' AND start_block >= $%d", height)
+	case types.ElectionStatusActive:
+		q.where("status = 'READY' AND start_block < $%d AND end_block > $%d", height, height)
+	case types.ElectionStatusEnded:
+		q.where("(status IN ('ENDED', 'RESULTS') OR (status = 'READY' AND end_block <= $%d))",
+			height)
+	case types.ElectionStatusPaused, types.ElectionStatusCanceled:
+		q.where("status = $%d", status)
+	default:
+		return apierror.ErrInvalidFilter.Withf("%s", status)
+	}
+	return nil
+}
+
+// ListElectionsToSync returns up to count elections of live organizations whose
+//  state may still change on the Vochain and was not synced since syncedBefore,
+//  the least recently synced first
+func (d *Database) ListElectionsToSync(syncedBefore time.Time,
+	count int) ([]types.Election, error) {
+	selectQuery := `SELECT process_id, organization_eth_address, status, start_block,
+						end_block, vote_count, has_results, missing_since, confidential,
+						metadata_priv_key
+					FROM elections
+					WHERE status NOT IN ('CANCELED', 'RESULTS')
+						AND (synced_at IS NULL OR synced_at < $1)
+						AND ` + liveOrganizations + `
+					ORDER BY synced_at NULLS FIRST LIMIT $2`
+	var elections []types.Election
+	if err := d.db.Select(&elections, selectQuery, syncedBefore.UTC(), count); err != nil {
+		return nil, dbError(fmt.Errorf("error listing elections to sync: %w", err),
+			apierror.ErrElectionNotFound)
+	}
+	return elections, nil
+}
+
+// UpdateElectionStatus stores the Vochain status of an election and when it was synced
+func (d *Database) UpdateElectionStatus(processID []byte, status string) error {
+	now := time.Now().UTC()
+	update := `UPDATE elections SET status = $2, synced_at = $3,
+					updated_at = CASE WHEN status <> $2 THEN $3 ELSE updated_at END
+				WHERE process_id = $1`
+	result, err := d.db.Exec(update, processID, status, now)
+	if err != nil {
+		return dbError(fmt.Errorf("error updating election status: %w", err),
+			apierror.ErrElectionNotFound)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return apierror.ErrElectionNotFound.Withf("%x", processID)
+	}
+	return nil
+}
+
+// SyncElection stores the Vochain state of an election synced by the reconciler,
+//  along with the events of the changes found
+func (d *Database) SyncElection(election *types.Election, events []types.ChainEvent) error {
+	now := time.Now().UTC()
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("error syncing election: %w", err)
+	}
+	defer tx.Rollback()
+	update := `UPDATE elections SET status = $2, start_block = $3, end_block = $4,
+					vote_count = $5, has_results = $6, missing_since = $7, synced_at = $8,
+					updated_at = CASE WHEN $9 THEN $8 ELSE updated_at END
+				WHERE process_id = $1`
+	result, err := tx.Exec(update, election.ProcessID, election.Status, election.StartBlock,
+		election.EndBlock, election.VoteCount, election.HasResults, utcTime(election.MissingSince),
+		now, len(events) > 0)
+	if err != nil {
+		return dbError(fmt.Errorf("error syncing election: %w", err),
+			apierror.ErrElectionNotFound)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return apierror.ErrElectionNotFound.Withf("%x", election.ProcessID)
+	}
+	if err := insertChainEvents(tx, events, now); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
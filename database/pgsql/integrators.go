@@ -6,6 +6,7 @@ import (
 
 	_ "github.com/jackc/pgx/stdlib"
 
+	"go.vocdoni.io/api/apierror"
 	"go.vocdoni.io/api/types"
 	"go.vocdoni.io/dvote/log"
 )
@@ -30,7 +31,7 @@ func (d *Database) CreateIntegrator(secretApiKey, cspPubKey []byte, cspUrlPrefix
 	result, err := d.db.NamedQuery(insert, integrator)
 	if err != nil {
 		log.Errorf("error creating integrator: %v", err)
-		return 0, fmt.Errorf("error creating integrator")
+		return 0, dbError(err, apierror.ErrIntegratorNotFound)
 	}
 	if !result.Next() {
 		log.Errorf("error creating integrator: there is no next result row")
@@ -48,11 +49,11 @@ func (d *Database) CreateIntegrator(secretApiKey, cspPubKey []byte, cspUrlPrefix
 func (d *Database) GetIntegrator(id int) (*types.Integrator, error) {
 	var integrator types.Integrator
 	selectIntegrator := `SELECT id,secret_api_key, name, csp_url_prefix, csp_pub_key, created_at, updated_at
-						FROM integrators WHERE id=$1`
+						FROM integrators WHERE id=$1 AND deleted_at IS NULL`
 	row := d.db.QueryRowx(selectIntegrator, id)
 	err := row.StructScan(&integrator)
 	if err != nil {
-		return nil, err
+		return nil, dbError(err, apierror.ErrIntegratorNotFound)
 	}
 
 	return &integrator, nil
@@ -61,19 +62,27 @@ func (d *Database) GetIntegrator(id int) (*types.Integrator, error) {
 func (d *Database) GetIntegratorByKey(secretApiKey []byte) (*types.Integrator, error) {
 	var integrator types.Integrator
 	selectIntegrator := `SELECT id, secret_api_key, name, csp_url_prefix, csp_pub_key, created_at, updated_at 
-						FROM integrators WHERE secret_api_key=$1`
+						FROM integrators WHERE secret_api_key=$1 AND deleted_at IS NULL`
 	row := d.db.QueryRowx(selectIntegrator, secretApiKey)
 	err := row.StructScan(&integrator)
 	if err != nil {
-		return nil, err
+		return nil, dbError(err, apierror.ErrIntegratorNotFound)
 	}
 
 	return &integrator, nil
 }
 
+// DeleteIntegrator soft deletes an integrator and its organizations, which are
+//  kept until PurgeDeleted removes them
 func (d *Database) DeleteIntegrator(id int) error {
-	deleteQuery := `DELETE FROM integrators WHERE id = $1`
-	result, err := d.db.Exec(deleteQuery, id)
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("error deleting integrator: %w", err)
+	}
+	defer tx.Rollback()
+	deleteQuery := `UPDATE integrators SET deleted_at = (now() at time zone 'utc')
+					WHERE id = $1 AND deleted_at IS NULL`
+	result, err := tx.Exec(deleteQuery, id)
 	if err != nil {
 		return fmt.Errorf("error deleting integrator: %v", err)
 	}
@@ -83,9 +92,49 @@ func (d *Database) DeleteIntegrator(id int) error {
 		return fmt.Errorf("error veryfying deleted integrator: %v", err)
 	}
 	if rows != 1 {
-		return fmt.Errorf("nothing to delete")
+		return apierror.ErrIntegratorNotFound.Withf("integrator %d: nothing to delete", id)
+	}
+	// organizations share the deletion time, so RestoreIntegrator can tell them apart
+	//  from the ones deleted before
+	deleteOrganizations := `UPDATE organizations
+					SET deleted_at = (SELECT deleted_at FROM integrators WHERE id = $1)
+					WHERE integrator_id = $1 AND deleted_at IS NULL`
+	if _, err := tx.Exec(deleteOrganizations, id); err != nil {
+		return fmt.Errorf("error deleting integrator organizations: %v", err)
 	}
-	return nil
+	return tx.Commit()
+}
+
+// RestoreIntegrator restores a soft deleted integrator, along with the organizations
+//  deleted with it, and returns the restored organizations
+func (d *Database) RestoreIntegrator(id int) ([]types.Organization, error) {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("error restoring integrator: %w", err)
+	}
+	defer tx.Rollback()
+	var deletedAt time.Time
+	if err := tx.Get(&deletedAt, `SELECT deleted_at FROM integrators
+					WHERE id = $1 AND deleted_at IS NOT NULL`, id); err != nil {
+		return nil, dbError(fmt.Errorf("integrator %d: nothing to restore: %w", id, err),
+			apierror.ErrIntegratorNotFound)
+	}
+	organizations := []types.Organization{}
+	restoreOrganizations := `UPDATE organizations SET deleted_at = NULL, updated_at = now()
+					WHERE integrator_id = $1 AND deleted_at = $2
+					RETURNING id, eth_address, public_api_token, public_api_quota`
+	if err := tx.Select(&organizations, restoreOrganizations, id, deletedAt); err != nil {
+		return nil, fmt.Errorf("error restoring integrator organizations: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE integrators SET deleted_at = NULL, updated_at = now()
+					WHERE id = $1`, id); err != nil {
+		return nil, dbError(fmt.Errorf("error restoring integrator: %w", err),
+			apierror.ErrIntegratorNotFound)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error restoring integrator: %w", err)
+	}
+	return organizations, nil
 }
 
 func (d *Database) UpdateIntegrator(id int, newCspPubKey []byte, newCspUrlPrefix, newName string) (int, error) {
@@ -96,14 +145,15 @@ func (d *Database) UpdateIntegrator(id int, newCspPubKey []byte, newCspUrlPrefix
 				csp_pub_key = COALESCE(NULLIF(:csp_pub_key, '' ::::bytea ),  csp_pub_key),
 				secret_api_key = COALESCE(NULLIF(:secret_api_key, '' ::::bytea ),  secret_api_key),
 				updated_at = now()
-				WHERE (id = :id )
+				WHERE (id = :id AND deleted_at IS NULL)
 				AND  (:name IS DISTINCT FROM name 
 					OR :csp_url_prefix IS DISTINCT FROM csp_url_prefix 					
 					OR encode(:csp_pub_key,'hex') IS DISTINCT FROM encode(csp_pub_key,'hex')
 					)`
 	result, err := d.db.NamedExec(update, integrator)
 	if err != nil {
-		return 0, fmt.Errorf("error updating integrator: %v", err)
+		return 0, dbError(fmt.Errorf("error updating integrator: %w", err),
+			apierror.ErrIntegratorNotFound)
 	}
 	var rows int64
 	if rows, err = result.RowsAffected(); err != nil {
@@ -117,13 +167,13 @@ func (d *Database) UpdateIntegrator(id int, newCspPubKey []byte, newCspUrlPrefix
 func (d *Database) UpdateIntegratorApiKey(id int, newSecretApiKey []byte) (int, error) {
 	integrator, err := d.GetIntegrator(id)
 	if err != nil {
-		return 0, fmt.Errorf("error updating integrator: %v", err)
+		return 0, fmt.Errorf("error updating integrator: %w", err)
 	}
 	integrator.SecretApiKey = newSecretApiKey
 	update := `UPDATE integrators SET
 				secret_api_key = COALESCE(NULLIF(:secret_api_key, '' ::::bytea ),  secret_api_key),
 				updated_at = now()
-				WHERE (id = :id )
+				WHERE (id = :id AND deleted_at IS NULL)
 				AND  (encode(:secret_api_key,'hex') IS DISTINCT FROM encode(secret_api_key,'hex'))`
 	result, err := d.db.NamedExec(update, integrator)
 	if err != nil {
@@ -139,7 +189,7 @@ func (d *Database) UpdateIntegratorApiKey(id int, newSecretApiKey []byte) (int,
 }
 
 func (d *Database) CountIntegrators() (int, error) {
-	selectQuery := `SELECT COUNT(*) FROM integrators`
+	selectQuery := `SELECT COUNT(*) FROM integrators WHERE deleted_at IS NULL`
 	var count int
 	if err := d.db.Get(&count, selectQuery); err != nil {
 		return 0, err
@@ -148,7 +198,7 @@ func (d *Database) CountIntegrators() (int, error) {
 }
 
 func (d *Database) GetIntegratorApiKeysList() ([][]byte, error) {
-	selectQuery := `SELECT secret_api_key FROM integrators`
+	selectQuery := `SELECT secret_api_key FROM integrators WHERE deleted_at IS NULL`
 	var integratorApiKeys [][]byte
 	if err := d.db.Select(&integratorApiKeys, selectQuery); err != nil {
 		return nil, err
@@ -21,6 +21,93 @@ var Migrations = migrate.MemoryMigrationSource{
 			Up:   []string{migration2up},
 			Down: []string{migration2down},
 		},
+		{
+			Id:   "3",
+			Up:   []string{migration3up},
+			Down: []string{migration3down},
+		},
+		{
+			Id:   "4",
+			Up:   []string{migration4up},
+			Down: []string{migration4down},
+		},
+		{
+			Id:   "5",
+			Up:   []string{migration5up},
+			Down: []string{migration5down},
+		},
+		{
+			Id:   "6",
+			Up:   []string{migration6up},
+			Down: []string{migration6down},
+		},
+		{
+			Id:   "7",
+			Up:   []string{migration7up},
+			Down: []string{migration7down},
+		},
+		{
+			Id:   "8",
+			Up:   []string{migration8up},
+			Down: []string{migration8down},
+		},
+		{
+			Id:   "9",
+			Up:   []string{migration9up},
+			Down: []string{migration9down},
+		},
+		{
+			Id:   "10",
+			Up:   []string{migration10up},
+			Down: []string{migration10down},
+		},
+		{
+			Id:   "11",
+			Up:   []string{migration11up},
+			Down: []string{migration11down},
+		},
+		{
+			Id:   "12",
+			Up:   []string{migration12up},
+			Down: []string{migration12down},
+		},
+		{
+			Id:   "13",
+			Up:   []string{migration13up},
+			Down: []string{migration13down},
+		},
+		{
+			Id:   "14",
+			Up:   []string{migration14up},
+			Down: []string{migration14down},
+		},
+		{
+			Id:   "15",
+			Up:   []string{migration15up},
+			Down: []string{migration15down},
+		},
+		{
+			Id:   "16",
+			Up:   []string{migration16up},
+			Down: []string{migration16down},
+		},
+		{
+			Id:   "17",
+			Up:   []string{migration17up},
+			Down: []string{migration17down},
+		},
+		{
+			Id:   "18",
+			Up:   []string{migration18up},
+			Down: []string{migration18down},
+		},
+		{
+			// publishResults actions only ended the election, so they cannot be
+			//  told apart once renamed
+			Id:   "19",
+			Up:   []string{migration19up},
+			Down: []string{},
+		},
 	},
 }
 
@@ -243,6 +330,509 @@ ALTER TABLE ONLY organizations
     ADD CONSTRAINT organizations_integrator_api_key_fkey FOREIGN KEY (integrator_api_key) REFERENCES integrators(secret_api_key) ON UPDATE CASCADE;
 `
 
+const migration3up = `
+--------------------------- Audit events
+-- Privileged operations performed through the API. The table is append-only:
+-- integrator_id has no foreign key so events outlive the integrators they refer to
+CREATE TABLE audit_events (
+    id BIGSERIAL NOT NULL,
+    created_at timestamp without time zone DEFAULT (now() at time zone 'utc') NOT NULL,
+    actor_type TEXT NOT NULL,
+    actor_id TEXT NOT NULL,
+    integrator_id INTEGER DEFAULT 0 NOT NULL,
+    action TEXT NOT NULL,
+    target TEXT NOT NULL,
+    request_id TEXT NOT NULL,
+    outcome TEXT NOT NULL,
+    http_status INTEGER NOT NULL,
+    error TEXT DEFAULT '' NOT NULL
+);
+
+ALTER TABLE ONLY audit_events
+    ADD CONSTRAINT audit_events_pkey PRIMARY KEY (id);
+
+CREATE INDEX audit_events_integrator_id_idx ON audit_events (integrator_id, id);
+CREATE INDEX audit_events_created_at_idx ON audit_events (created_at);
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trigger_audit_events_append_only
+  BEFORE UPDATE OR DELETE
+  ON audit_events
+  FOR EACH ROW
+  EXECUTE PROCEDURE audit_events_append_only();
+`
+
+const migration3down = `
+DROP TABLE audit_events;
+DROP FUNCTION IF EXISTS audit_events_append_only();
+`
+
+const migration4up = `
+--------------------------- Soft deletion
+-- Deleted integrators and organizations keep their rows (and the encrypted
+-- organization keys) until they are purged after the retention period
+ALTER TABLE ONLY integrators
+    ADD COLUMN deleted_at timestamp without time zone;
+
+ALTER TABLE ONLY organizations
+    ADD COLUMN deleted_at timestamp without time zone;
+
+CREATE INDEX integrators_deleted_at_idx ON integrators (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX organizations_deleted_at_idx ON organizations (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Soft deletions and key resets are updates, so the previous key is revoked
+-- explicitly, and only keys of live integrators are registered
+CREATE OR REPLACE FUNCTION notify_integrator_tokens_update() RETURNS TRIGGER AS $$
+BEGIN
+    IF (TG_OP = 'DELETE') THEN
+      IF (OLD.deleted_at IS NULL) THEN
+        PERFORM pg_notify('integrator_tokens_update',
+          'OPERATION = DELETE and KEY = ' || encode(OLD.secret_api_key,'hex'));
+      END IF;
+      RETURN NULL;
+    END IF;
+    IF (TG_OP = 'UPDATE') THEN
+      IF (OLD.deleted_at IS NULL AND (NEW.deleted_at IS NOT NULL
+          OR OLD.secret_api_key <> NEW.secret_api_key)) THEN
+        PERFORM pg_notify('integrator_tokens_update',
+          'OPERATION = DELETE and KEY = ' || encode(OLD.secret_api_key,'hex'));
+      END IF;
+    END IF;
+    IF (NEW.deleted_at IS NULL) THEN
+      PERFORM pg_notify('integrator_tokens_update',
+        'OPERATION = ' || TG_OP || ' and KEY = ' || encode(NEW.secret_api_key,'hex'));
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+`
+
+const migration4down = `
+CREATE OR REPLACE FUNCTION notify_integrator_tokens_update() RETURNS TRIGGER AS $$
+DECLARE
+    row RECORD;
+    output TEXT;
+BEGIN
+    IF (TG_OP = 'DELETE') THEN
+      row = OLD;
+    ELSE
+      row = NEW;
+    END IF;
+    output = 'OPERATION = ' || TG_OP || ' and KEY = ' || encode(row.secret_api_key,'hex');
+    PERFORM pg_notify('integrator_tokens_update',output);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE ONLY organizations
+    DROP COLUMN deleted_at;
+
+ALTER TABLE ONLY integrators
+    DROP COLUMN deleted_at;
+`
+const migration5up = `
+--------------------------- List indexes
+-- Lists are paginated with cursors over the sort column and the id
+CREATE INDEX organizations_integrator_created_at_idx
+    ON organizations (integrator_api_key, created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX organizations_integrator_id_idx
+    ON organizations (integrator_api_key, id) WHERE deleted_at IS NULL;
+
+CREATE INDEX elections_organization_id_idx ON elections (organization_eth_address, id);
+CREATE INDEX elections_organization_created_at_idx
+    ON elections (organization_eth_address, created_at, id);
+CREATE INDEX elections_organization_title_idx ON elections (organization_eth_address, title, id);
+CREATE INDEX elections_organization_start_block_idx
+    ON elections (organization_eth_address, start_block, id);
+CREATE INDEX elections_organization_end_block_idx
+    ON elections (organization_eth_address, end_block, id);
+`
+
+const migration5down = `
+DROP INDEX organizations_integrator_created_at_idx;
+DROP INDEX organizations_integrator_id_idx;
+DROP INDEX elections_organization_id_idx;
+DROP INDEX elections_organization_created_at_idx;
+DROP INDEX elections_organization_title_idx;
+DROP INDEX elections_organization_start_block_idx;
+DROP INDEX elections_organization_end_block_idx;
+`
+
+const migration6up = `
+--------------------------- Election status
+-- The Vochain status of each election (READY, PAUSED, ENDED, CANCELED or RESULTS),
+-- kept in sync by the election indexer
+ALTER TABLE ONLY elections
+    ADD COLUMN status TEXT DEFAULT 'READY' NOT NULL,
+    ADD COLUMN status_synced_at timestamp without time zone;
+
+CREATE INDEX elections_organization_status_idx ON elections (organization_eth_address, status, id);
+CREATE INDEX elections_status_synced_at_idx ON elections (status_synced_at NULLS FIRST)
+    WHERE status NOT IN ('CANCELED', 'RESULTS');
+`
+
+const migration6down = `
+DROP INDEX elections_organization_status_idx;
+DROP INDEX elections_status_synced_at_idx;
+ALTER TABLE ONLY elections
+    DROP COLUMN status,
+    DROP COLUMN status_synced_at;
+`
+
+const migration7up = `
+--------------------------- Chain state
+-- The Vochain state of elections and organizations, kept in sync by the reconciler.
+-- missing_since is set while a row is not found on the Vochain.
+ALTER TABLE ONLY elections RENAME COLUMN status_synced_at TO synced_at;
+ALTER TABLE ONLY elections
+    ADD COLUMN vote_count BIGINT DEFAULT 0 NOT NULL,
+    ADD COLUMN has_results BOOLEAN DEFAULT false NOT NULL,
+    ADD COLUMN missing_since timestamp without time zone;
+
+ALTER TABLE ONLY organizations
+    ADD COLUMN metadata_uri TEXT DEFAULT '' NOT NULL,
+    ADD COLUMN balance BIGINT DEFAULT 0 NOT NULL,
+    ADD COLUMN nonce BIGINT DEFAULT 0 NOT NULL,
+    ADD COLUMN synced_at timestamp without time zone,
+    ADD COLUMN missing_since timestamp without time zone;
+
+CREATE INDEX organizations_synced_at_idx ON organizations (synced_at NULLS FIRST)
+    WHERE deleted_at IS NULL;
+
+--------------------------- Chain events
+-- The changes found by the reconciler
+CREATE TABLE chain_events (
+    id BIGSERIAL NOT NULL,
+    created_at timestamp without time zone DEFAULT (now() at time zone 'utc') NOT NULL,
+    organization_eth_address BYTEA NOT NULL,
+    process_id BYTEA,
+    kind TEXT NOT NULL,
+    old_value TEXT NOT NULL,
+    new_value TEXT NOT NULL
+);
+
+ALTER TABLE ONLY chain_events
+    ADD CONSTRAINT chain_events_pkey PRIMARY KEY (id);
+
+CREATE INDEX chain_events_organization_idx ON chain_events (organization_eth_address, id);
+CREATE INDEX chain_events_process_idx ON chain_events (process_id, id)
+    WHERE process_id IS NOT NULL;
+CREATE INDEX chain_events_kind_idx ON chain_events (kind, id);
+`
+
+const migration7down = `
+DROP TABLE chain_events;
+DROP INDEX organizations_synced_at_idx;
+ALTER TABLE ONLY organizations
+    DROP COLUMN metadata_uri,
+    DROP COLUMN balance,
+    DROP COLUMN nonce,
+    DROP COLUMN synced_at,
+    DROP COLUMN missing_since;
+ALTER TABLE ONLY elections
+    DROP COLUMN vote_count,
+    DROP COLUMN has_results,
+    DROP COLUMN missing_since;
+ALTER TABLE ONLY elections RENAME COLUMN synced_at TO status_synced_at;
+`
+
+const migration8up = `
+--------------------------- Results snapshots
+-- The final results of elections, kept once the Vochain publishes them
+CREATE TABLE results_snapshots (
+    created_at timestamp without time zone DEFAULT (now() at time zone 'utc') NOT NULL,
+    process_id BYTEA NOT NULL,
+    height BIGINT NOT NULL,
+    state TEXT NOT NULL,
+    type TEXT NOT NULL,
+    results JSONB NOT NULL,
+    aggregated JSONB,
+    hash BYTEA NOT NULL
+);
+
+ALTER TABLE ONLY results_snapshots
+    ADD CONSTRAINT results_snapshots_pkey PRIMARY KEY (process_id);
+
+ALTER TABLE ONLY results_snapshots
+    ADD CONSTRAINT results_snapshots_process_id_fkey FOREIGN KEY (process_id)
+    REFERENCES elections(process_id) ON DELETE CASCADE;
+`
+
+const migration8down = `
+DROP TABLE results_snapshots;
+`
+
+const migration9up = `
+--------------------------- Usage counters
+-- The daily usage of each metric by integrators and their organizations, for
+-- billing. The organization is empty for the usage not tied to one. Counters
+-- are kept when integrators and organizations are purged.
+CREATE TABLE usage_counters (
+    id BIGSERIAL NOT NULL,
+    day DATE NOT NULL,
+    integrator_id INTEGER NOT NULL,
+    organization_eth_address BYTEA DEFAULT '' NOT NULL,
+    metric TEXT NOT NULL,
+    count BIGINT DEFAULT 0 NOT NULL
+);
+
+ALTER TABLE ONLY usage_counters
+    ADD CONSTRAINT usage_counters_pkey PRIMARY KEY (id);
+
+CREATE UNIQUE INDEX usage_counters_key_idx
+    ON usage_counters (integrator_id, organization_eth_address, metric, day);
+CREATE INDEX usage_counters_day_idx ON usage_counters (day, id);
+`
+
+const migration9down = `
+DROP TABLE usage_counters;
+`
+
+const migration10up = `
+-- The rate limits of the organizations on a plan, in requests per minute. 0
+-- uses the limits configured in the API.
+ALTER TABLE quota_plans
+    ADD COLUMN rate_limit INTEGER DEFAULT 0 NOT NULL,
+    ADD COLUMN rate_burst INTEGER DEFAULT 0 NOT NULL;
+
+-- The token buckets of the rate limiter, shared by every API replica. Keys are
+-- hashed tokens and client IPs.
+CREATE TABLE rate_limit_buckets (
+    key TEXT NOT NULL,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at timestamp without time zone NOT NULL
+);
+
+ALTER TABLE ONLY rate_limit_buckets
+    ADD CONSTRAINT rate_limit_buckets_pkey PRIMARY KEY (key);
+
+CREATE INDEX rate_limit_buckets_updated_at_idx ON rate_limit_buckets (updated_at);
+`
+
+const migration10down = `
+DROP TABLE rate_limit_buckets;
+ALTER TABLE quota_plans
+    DROP COLUMN rate_limit,
+    DROP COLUMN rate_burst;
+`
+
+const migration11up = `
+-- The root keys of the hosted CSP, one per integrator and, optionally, one per
+-- organization. An empty organization_eth_address is the integrator key.
+CREATE TABLE csp_keys (
+    created_at timestamp without time zone DEFAULT (now() at time zone 'utc') NOT NULL,
+    id SERIAL NOT NULL,
+    integrator_id INTEGER NOT NULL,
+    organization_eth_address BYTEA DEFAULT '' NOT NULL,
+    pub_key BYTEA NOT NULL,
+    priv_key_cipher BYTEA NOT NULL
+);
+
+ALTER TABLE ONLY csp_keys
+    ADD CONSTRAINT csp_keys_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY csp_keys
+    ADD CONSTRAINT csp_keys_owner_unique UNIQUE (integrator_id, organization_eth_address);
+
+ALTER TABLE ONLY csp_keys
+    ADD CONSTRAINT csp_keys_pub_key_unique UNIQUE (pub_key);
+
+ALTER TABLE ONLY csp_keys
+    ADD CONSTRAINT csp_keys_integrator_id_fkey FOREIGN KEY (integrator_id) REFERENCES integrators(id) ON DELETE CASCADE;
+
+-- The signature requests of the voters authenticated by the hosted CSP. A voter
+-- gets a single signature per election: signed_at is set, and the secret k of
+-- blind requests cleared, once the payload is signed.
+CREATE TABLE csp_requests (
+    created_at timestamp without time zone DEFAULT (now() at time zone 'utc') NOT NULL,
+    token BYTEA NOT NULL,
+    election_id BYTEA NOT NULL,
+    voter TEXT NOT NULL,
+    sign_type TEXT NOT NULL,
+    secret_k_cipher BYTEA DEFAULT '' NOT NULL,
+    signed_at timestamp without time zone
+);
+
+ALTER TABLE ONLY csp_requests
+    ADD CONSTRAINT csp_requests_pkey PRIMARY KEY (token);
+
+ALTER TABLE ONLY csp_requests
+    ADD CONSTRAINT csp_requests_voter_unique UNIQUE (election_id, voter);
+
+ALTER TABLE ONLY csp_requests
+    ADD CONSTRAINT csp_requests_election_id_fkey FOREIGN KEY (election_id) REFERENCES elections(process_id) ON DELETE CASCADE;
+`
+
+const migration11down = `
+DROP TABLE csp_requests;
+DROP TABLE csp_keys;
+`
+
+const migration12up = `
+-- The handler authenticating the voters of an election on the hosted CSP, empty
+-- for the census, and its encrypted secret, if any
+ALTER TABLE elections
+    ADD COLUMN csp_auth TEXT DEFAULT '' NOT NULL,
+    ADD COLUMN csp_auth_secret BYTEA DEFAULT '' NOT NULL;
+
+-- The voter lists uploaded for the elections authenticated by a voter id and a
+-- secret code, or by a one-time code sent to the voter email
+CREATE TABLE csp_voters (
+    created_at timestamp without time zone DEFAULT (now() at time zone 'utc') NOT NULL,
+    election_id BYTEA NOT NULL,
+    voter_id TEXT NOT NULL,
+    secret_hash BYTEA DEFAULT '' NOT NULL,
+    email TEXT DEFAULT '' NOT NULL,
+    otp_hash BYTEA DEFAULT '' NOT NULL,
+    otp_expires timestamp without time zone,
+    otp_attempts INTEGER DEFAULT 0 NOT NULL
+);
+
+ALTER TABLE ONLY csp_voters
+    ADD CONSTRAINT csp_voters_pkey PRIMARY KEY (election_id, voter_id);
+
+ALTER TABLE ONLY csp_voters
+    ADD CONSTRAINT csp_voters_election_id_fkey FOREIGN KEY (election_id) REFERENCES elections(process_id) ON DELETE CASCADE;
+`
+
+const migration12down = `
+DROP TABLE csp_voters;
+ALTER TABLE elections
+    DROP COLUMN csp_auth,
+    DROP COLUMN csp_auth_secret;
+`
+
+const migration13up = `
+-- The vote counts of the elections, sampled by the reconciler when they change,
+-- with the Vochain height, for the turnout statistics
+CREATE TABLE election_samples (
+    process_id BYTEA NOT NULL,
+    sampled_at timestamp without time zone NOT NULL,
+    height BIGINT NOT NULL,
+    vote_count BIGINT NOT NULL
+);
+
+ALTER TABLE ONLY election_samples
+    ADD CONSTRAINT election_samples_pkey PRIMARY KEY (process_id, sampled_at);
+
+ALTER TABLE ONLY election_samples
+    ADD CONSTRAINT election_samples_process_id_fkey FOREIGN KEY (process_id) REFERENCES elections(process_id) ON DELETE CASCADE;
+`
+
+const migration13down = `
+DROP TABLE election_samples;
+`
+
+const migration14up = `
+-- The status changes of elections scheduled by their integrators, run by the
+-- scheduler once the Vochain reaches the block estimated for execute_at
+CREATE TABLE scheduled_actions (
+    created_at timestamp without time zone DEFAULT (now() at time zone 'utc') NOT NULL,
+    id SERIAL NOT NULL,
+    integrator_api_key BYTEA NOT NULL,
+    organization_eth_address BYTEA NOT NULL,
+    process_id BYTEA NOT NULL,
+    action TEXT NOT NULL,
+    execute_at timestamp without time zone NOT NULL,
+    target_block BIGINT DEFAULT 0 NOT NULL,
+    status TEXT DEFAULT 'pending' NOT NULL,
+    error TEXT DEFAULT '' NOT NULL,
+    tx_hash BYTEA DEFAULT '' NOT NULL,
+    executed_at timestamp without time zone
+);
+
+ALTER TABLE ONLY scheduled_actions
+    ADD CONSTRAINT scheduled_actions_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY scheduled_actions
+    ADD CONSTRAINT scheduled_actions_process_id_fkey FOREIGN KEY (process_id) REFERENCES elections(process_id) ON DELETE CASCADE;
+
+CREATE INDEX scheduled_actions_pending_idx ON scheduled_actions (execute_at) WHERE status = 'pending';
+`
+
+const migration14down = `
+DROP TABLE scheduled_actions;
+`
+
+const migration15up = `
+-- The timestamps of Vochain blocks, sampled to estimate the dates of future blocks
+CREATE TABLE block_samples (
+    height BIGINT NOT NULL,
+    timestamp timestamp without time zone NOT NULL
+);
+
+ALTER TABLE ONLY block_samples
+    ADD CONSTRAINT block_samples_pkey PRIMARY KEY (height);
+
+CREATE INDEX block_samples_timestamp_idx ON block_samples (timestamp);
+`
+
+const migration15down = `
+DROP TABLE block_samples;
+`
+
+const migration16up = `
+-- The election definitions stored by organizations, to create elections from
+CREATE TABLE election_templates (
+    created_at timestamp without time zone DEFAULT (now() at time zone 'utc') NOT NULL,
+    id SERIAL NOT NULL,
+    integrator_api_key BYTEA NOT NULL,
+    organization_eth_address BYTEA NOT NULL,
+    name TEXT NOT NULL,
+    definition JSONB NOT NULL
+);
+
+ALTER TABLE ONLY election_templates
+    ADD CONSTRAINT election_templates_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY election_templates
+    ADD CONSTRAINT election_templates_organization_eth_address_fkey FOREIGN KEY (organization_eth_address) REFERENCES organizations(eth_address) ON DELETE CASCADE;
+
+CREATE INDEX election_templates_organization_idx ON election_templates (organization_eth_address);
+`
+
+const migration16down = `
+DROP TABLE election_templates;
+`
+
+const migration17up = `
+-- When scheduled actions were claimed, so the actions of replicas that stopped
+-- while running them are run again
+ALTER TABLE scheduled_actions ADD COLUMN claimed_at timestamp without time zone;
+
+CREATE INDEX scheduled_actions_running_idx ON scheduled_actions (claimed_at) WHERE status = 'running';
+`
+
+const migration17down = `
+DROP INDEX scheduled_actions_running_idx;
+ALTER TABLE scheduled_actions DROP COLUMN claimed_at;
+`
+
+const migration18up = `
+-- The vote count of results snapshots, whose height is now the block height of the
+-- snapshot. Earlier snapshots stored the vote count as their height, which their
+-- hash commits to, so it is kept.
+ALTER TABLE results_snapshots ADD COLUMN vote_count BIGINT DEFAULT 0 NOT NULL;
+
+UPDATE results_snapshots SET vote_count = height;
+`
+
+const migration18down = `
+ALTER TABLE results_snapshots DROP COLUMN vote_count;
+`
+
+const migration19up = `
+-- The Vochain publishes the results of an election once it ends, so publishResults
+-- actions are end actions
+UPDATE scheduled_actions SET action = 'end' WHERE action = 'publishResults';
+`
+
 func Migrator(action string, db database.Database) error {
 	switch action {
 	case "upSync":
@@ -2,14 +2,14 @@ package pgsql
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
-	"reflect"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	_ "github.com/jackc/pgx/stdlib"
 
+	"go.vocdoni.io/api/apierror"
 	"go.vocdoni.io/api/types"
 	"go.vocdoni.io/dvote/log"
 )
@@ -17,12 +17,11 @@ import (
 func (d *Database) CreateOrganization(integratorAPIKey, ethAddress, ethPrivKeyCipher []byte, planID uuid.NullUUID, publiApiQuota int, publicApiToken, headerUri, avatarUri string) (int, error) {
 	integrator, err := d.GetIntegratorByKey(integratorAPIKey)
 	if err != nil {
-		if err != sql.ErrNoRows {
+		if errors.Is(err, sql.ErrNoRows) {
 			log.Errorf("tried to createOrganization by uknown API Key %x", integratorAPIKey)
-			return 0, fmt.Errorf("unkown API key: %x", integratorAPIKey)
-		} else {
-			return 0, fmt.Errorf("createOrganization DB error: %v", err)
+			return 0, apierror.ErrIntegratorNotFound.Withf("unkown API key: %x", integratorAPIKey)
 		}
+		return 0, fmt.Errorf("createOrganization DB error: %w", err)
 	}
 
 	organization := &types.Organization{
@@ -51,7 +50,8 @@ func (d *Database) CreateOrganization(integratorAPIKey, ethAddress, ethPrivKeyCi
 			RETURNING id`
 	result, err := d.db.NamedQuery(insert, organization)
 	if err != nil {
-		return 0, fmt.Errorf("error creating organization: %v", err)
+		return 0, dbError(fmt.Errorf("error creating organization: %w", err),
+			apierror.ErrOrganizationNotFound)
 	}
 	if !result.Next() {
 		return 0, fmt.Errorf("error creating organization: there is no next result row")
@@ -68,22 +68,42 @@ func (d *Database) GetOrganization(integratorAPIKey, ethAddress []byte) (*types.
 	var organization types.Organization
 	selectOrganization := `SELECT id , integrator_id, integrator_api_key, eth_address, eth_priv_key_cipher, 
 								header_uri, avatar_uri, public_api_token, quota_plan_id,
-								public_api_quota, created_at, updated_at  
-							FROM organizations WHERE integrator_api_key=$1 AND eth_address=$2`
+								public_api_quota, metadata_uri, balance, nonce, missing_since,
+								created_at, updated_at
+							FROM organizations WHERE integrator_api_key=$1 AND eth_address=$2
+								AND deleted_at IS NULL`
 	row := d.db.QueryRowx(selectOrganization, integratorAPIKey, ethAddress)
 	err := row.StructScan(&organization)
 	if err != nil {
-		return nil, err
+		return nil, dbError(err, apierror.ErrOrganizationNotFound)
 	}
 
 	return &organization, nil
 }
 
+// GetOrganizationByAPIToken returns the live organization with the given public
+//  API token
+func (d *Database) GetOrganizationByAPIToken(token string) (*types.Organization, error) {
+	var organization types.Organization
+	selectOrganization := `SELECT id , integrator_id, integrator_api_key, eth_address,
+								eth_priv_key_cipher, header_uri, avatar_uri, public_api_token,
+								quota_plan_id, public_api_quota, metadata_uri, balance, nonce,
+								missing_since, created_at, updated_at
+							FROM organizations WHERE public_api_token=$1 AND deleted_at IS NULL`
+	if err := d.db.QueryRowx(selectOrganization, token).StructScan(&organization); err != nil {
+		return nil, dbError(err, apierror.ErrOrganizationNotFound)
+	}
+	return &organization, nil
+}
+
+// DeleteOrganization soft deletes an organization, which is kept with its
+//  encrypted private key until PurgeDeleted removes it
 func (d *Database) DeleteOrganization(integratorAPIKey, ethAddress []byte) error {
 	if len(integratorAPIKey) == 0 || len(ethAddress) == 0 {
-		return fmt.Errorf("invalid arguments")
+		return apierror.ErrInvalidField.With("invalid arguments")
 	}
-	deleteQuery := `DELETE FROM organizations WHERE integrator_api_key=$1 AND eth_address=$2`
+	deleteQuery := `UPDATE organizations SET deleted_at = (now() at time zone 'utc')
+					WHERE integrator_api_key=$1 AND eth_address=$2 AND deleted_at IS NULL`
 	result, err := d.db.Exec(deleteQuery, integratorAPIKey, ethAddress)
 	if err != nil {
 		return fmt.Errorf("error deleting organization: %v", err)
@@ -94,11 +114,30 @@ func (d *Database) DeleteOrganization(integratorAPIKey, ethAddress []byte) error
 		return fmt.Errorf("error veryfying deleted organization: %v", err)
 	}
 	if rows != 1 {
-		return fmt.Errorf("nothing to delete")
+		return apierror.ErrOrganizationNotFound.Withf("organization %x: nothing to delete", ethAddress)
 	}
 	return nil
 }
 
+// RestoreOrganization restores a soft deleted organization of a live integrator.
+//  The organizations of a deleted integrator are restored with it.
+func (d *Database) RestoreOrganization(integratorAPIKey,
+	ethAddress []byte) (*types.Organization, error) {
+	var organization types.Organization
+	restoreQuery := `UPDATE organizations SET deleted_at = NULL, updated_at = now()
+					WHERE integrator_api_key=$1 AND eth_address=$2 AND deleted_at IS NOT NULL
+						AND integrator_api_key IN
+							(SELECT secret_api_key FROM integrators WHERE deleted_at IS NULL)
+					RETURNING id, integrator_id, eth_address, header_uri, avatar_uri,
+						public_api_token, quota_plan_id, public_api_quota, created_at, updated_at`
+	row := d.db.QueryRowx(restoreQuery, integratorAPIKey, ethAddress)
+	if err := row.StructScan(&organization); err != nil {
+		return nil, dbError(fmt.Errorf("organization %x: nothing to restore: %w", ethAddress, err),
+			apierror.ErrOrganizationNotFound)
+	}
+	return &organization, nil
+}
+
 func (d *Database) UpdateOrganization(integratorAPIKey, ethAddress []byte, headerUri, avatarUri string) (int, error) {
 	if len(integratorAPIKey) == 0 || len(ethAddress) == 0 {
 		return 0, fmt.Errorf("invalid arguments")
@@ -108,7 +147,8 @@ func (d *Database) UpdateOrganization(integratorAPIKey, ethAddress []byte, heade
 				header_uri = COALESCE(NULLIF(:header_uri, ''), header_uri),
 				avatar_uri = COALESCE(NULLIF(:avatar_uri, ''), avatar_uri),
 				updated_at = now()
-				WHERE (integrator_api_key=:integrator_api_key AND eth_address=:eth_address)
+				WHERE (integrator_api_key=:integrator_api_key AND eth_address=:eth_address
+					AND deleted_at IS NULL)
 				AND  (:quota_plan_id IS DISTINCT FROM quota_plan_id OR
 					:public_api_quota IS DISTINCT FROM public_api_quota OR
 					:header_uri IS DISTINCT FROM header_uri OR
@@ -147,7 +187,8 @@ func (d *Database) UpdateOrganizationPlan(integratorAPIKey, ethAddress []byte, p
 				quota_plan_id = COALESCE(NULLIF(:quota_plan_id, NULL), quota_plan_id),
 				public_api_quota = COALESCE(NULLIF(:public_api_quota, 0), public_api_quota),
 				updated_at = now()
-				WHERE (integrator_api_key=:integrator_api_key AND eth_address=:eth_address)
+				WHERE (integrator_api_key=:integrator_api_key AND eth_address=:eth_address
+					AND deleted_at IS NULL)
 				AND  (:quota_plan_id IS DISTINCT FROM quota_plan_id OR
 					:public_api_quota IS DISTINCT FROM public_api_quota
 				)`
@@ -172,7 +213,8 @@ func (d *Database) UpdateOrganizationEthPrivKeyCipher(integratorAPIKey, ethAddre
 	update := `UPDATE organizations SET
 				eth_priv_key_cipher = COALESCE(NULLIF(:eth_priv_key_cipher, '' ::::bytea ),  eth_priv_key_cipher),
 				updated_at = now()
-				WHERE (integrator_api_key=:integrator_api_key AND eth_address=:eth_address)
+				WHERE (integrator_api_key=:integrator_api_key AND eth_address=:eth_address
+					AND deleted_at IS NULL)
 				AND  (encode(:eth_priv_key_cipher,'hex') IS DISTINCT FROM encode(eth_priv_key_cipher,'hex'))`
 	result, err := d.db.NamedExec(update, organization)
 	if err != nil {
@@ -195,7 +237,8 @@ func (d *Database) UpdateOrganizationPublicAPIToken(integratorAPIKey, ethAddress
 	update := `UPDATE organizations SET
 				public_api_token = COALESCE(NULLIF(:public_api_token, ''),  public_api_token),
 				updated_at = now()
-				WHERE (integrator_api_key=:integrator_api_key AND eth_address=:eth_address)
+				WHERE (integrator_api_key=:integrator_api_key AND eth_address=:eth_address
+					AND deleted_at IS NULL)
 				AND  (:public_api_token IS DISTINCT FROM public_api_token)`
 	result, err := d.db.NamedExec(update, organization)
 	if err != nil {
@@ -211,7 +254,8 @@ func (d *Database) UpdateOrganizationPublicAPIToken(integratorAPIKey, ethAddress
 }
 
 func (d *Database) CountOrganizations(integratorAPIKey []byte) (int, error) {
-	selectQuery := `SELECT COUNT(*) FROM organizations WHERE integrator_api_key=$1`
+	selectQuery := `SELECT COUNT(*) FROM organizations
+					WHERE integrator_api_key=$1 AND deleted_at IS NULL`
 	var entitiesCount int
 	if err := d.db.Get(&entitiesCount, selectQuery, integratorAPIKey); err != nil {
 		return 0, err
@@ -219,61 +263,84 @@ func (d *Database) CountOrganizations(integratorAPIKey []byte) (int, error) {
 	return entitiesCount, nil
 }
 
-func (d *Database) ListOrganizations(integratorAPIKey []byte, filter *types.ListOptions) ([]types.Organization, error) {
-	// TODO: Replace limit offset with better strategy, can slow down DB
-	// would nee to now last value from previous query
-	selectQuery := `SELECT
-	 				id, eth_address, header_uri, avatar_uri, public_api_token
-					FROM organizations WHERE integrator_api_key =$1
-					ORDER BY %s %s LIMIT $2 OFFSET $3`
-	// Define default values for arguments
-	t := reflect.TypeOf(types.Organization{})
-	field, found := t.FieldByName("ID")
-	if !found {
-		return nil, fmt.Errorf("organization id field not found in DB. Something is very wrong")
-	}
-	orderField := field.Tag.Get("db")
-	order := "ASC"
-	var limit, offset sql.NullInt32
-	// default limit should be nil (Postgres BIGINT NULL)
-	err := limit.Scan(nil)
-	if err != nil {
-		return nil, err
+// organizationSortColumns are the columns organization lists can be sorted by
+var organizationSortColumns = map[string]sortColumn{
+	"id":        {"id", sortInt},
+	"createdAt": {"created_at", sortTime},
+	"updatedAt": {"updated_at", sortTime},
+}
+
+// ListOrganizations returns a page of the integrator's organizations and the
+//  cursor of the next page. A nil filter lists all of them.
+func (d *Database) ListOrganizations(integratorAPIKey []byte,
+	filter *types.ListOptions) ([]types.Organization, string, error) {
+	q := &listQuery{}
+	q.where("integrator_api_key = $%d", integratorAPIKey)
+	q.where("deleted_at IS NULL")
+	if err := q.paginate(filter, organizationSortColumns, "id", false); err != nil {
+		return nil, "", err
 	}
-	err = offset.Scan(0)
-	if err != nil {
-		return nil, err
-	}
-	// offset := 0
-	if filter != nil {
-		if len(filter.SortBy) > 0 {
-			field, found := t.FieldByName(strings.Title(filter.SortBy))
-			if found {
-				if filter.Order == "descend" {
-					order = "DESC"
-				}
-				orderField = field.Tag.Get("db")
-			}
-		}
-		if filter.Skip > 0 {
-			err = offset.Scan(filter.Skip)
-			if err != nil {
-				return nil, err
-			}
-		}
-		if filter.Count > 0 {
-			err = limit.Scan(filter.Count)
-			if err != nil {
-				return nil, err
-			}
+	selectQuery := `SELECT id, eth_address, header_uri, avatar_uri, public_api_token,
+						created_at, updated_at
+					FROM organizations` + q.sql()
+	var organizations []types.Organization
+	if err := d.db.Select(&organizations, selectQuery, q.args...); err != nil {
+		return nil, "", dbError(fmt.Errorf("error listing organizations: %w", err),
+			apierror.ErrOrganizationNotFound)
+	}
+	n, next := q.page(len(organizations), func(i int) (interface{}, int64) {
+		org := organizations[i]
+		switch q.sortBy {
+		case "createdAt":
+			return org.CreatedAt, int64(org.ID)
+		case "updatedAt":
+			return org.UpdatedAt, int64(org.ID)
 		}
+		return org.ID, int64(org.ID)
+	})
+	return organizations[:n], next, nil
+}
+
+// ListOrganizationsToSync returns up to count live organizations whose account
+//  was not synced since syncedBefore, the least recently synced first
+func (d *Database) ListOrganizationsToSync(syncedBefore time.Time,
+	count int) ([]types.Organization, error) {
+	selectQuery := `SELECT eth_address, metadata_uri, balance, nonce, missing_since
+					FROM organizations
+					WHERE deleted_at IS NULL AND (synced_at IS NULL OR synced_at < $1)
+					ORDER BY synced_at NULLS FIRST LIMIT $2`
+	var organizations []types.Organization
+	if err := d.db.Select(&organizations, selectQuery, syncedBefore.UTC(), count); err != nil {
+		return nil, dbError(fmt.Errorf("error listing organizations to sync: %w", err),
+			apierror.ErrOrganizationNotFound)
 	}
+	return organizations, nil
+}
 
-	query := fmt.Sprintf(selectQuery, orderField, order)
-	var entitites []types.Organization
-	err = d.db.Select(&entitites, query, integratorAPIKey, limit, offset)
+// SyncOrganization stores the Vochain account state of an organization synced by
+//  the reconciler, along with the events of the changes found
+func (d *Database) SyncOrganization(organization *types.Organization,
+	events []types.ChainEvent) error {
+	now := time.Now().UTC()
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("error syncing organization: %w", err)
+	}
+	defer tx.Rollback()
+	update := `UPDATE organizations SET metadata_uri = $2, balance = $3, nonce = $4,
+					missing_since = $5, synced_at = $6
+				WHERE eth_address = $1`
+	result, err := tx.Exec(update, organization.EthAddress, organization.MetadataURI,
+		organization.Balance, organization.Nonce, utcTime(organization.MissingSince), now)
 	if err != nil {
-		return nil, err
+		return dbError(fmt.Errorf("error syncing organization: %w", err),
+			apierror.ErrOrganizationNotFound)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return apierror.ErrOrganizationNotFound.Withf("%x", organization.EthAddress)
+	}
+	if err := insertChainEvents(tx, events, now); err != nil {
+		return err
 	}
-	return entitites, nil
+	return tx.Commit()
 }
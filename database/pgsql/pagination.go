@@ -0,0 +1,193 @@
+package pgsql
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+)
+
+const (
+	// defaultListCount is the page size of a list when no count is given
+	defaultListCount = 100
+	// maxListCount is the maximum page size of a list
+	maxListCount = 1000
+)
+
+// Kinds of sort column values, which decide how cursors encode them
+const (
+	sortInt = iota
+	sortTime
+	sortText
+)
+
+// sortColumn is a column a list can be sorted by
+type sortColumn struct {
+	name string
+	kind int
+}
+
+// cursor is the position of the last row of a page, from which the next page
+//  starts. Clients receive it base64 encoded and should treat it as opaque.
+type cursor struct {
+	SortBy string `json:"s"`
+	Desc   bool   `json:"d,omitempty"`
+	Value  string `json:"v,omitempty"`
+	ID     int64  `json:"i"`
+}
+
+func encodeCursor(c *cursor) string {
+	data, err := json.Marshal(c)
+	if err != nil {
+		// a struct of strings and numbers always marshals
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, apierror.ErrInvalidFilter.Withf("invalid cursor %s", s)
+	}
+	c := &cursor{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, apierror.ErrInvalidFilter.Withf("invalid cursor %s", s)
+	}
+	return c, nil
+}
+
+// listQuery builds the conditions, order and page of a list query. Rows are
+//  ordered by the sort column and then by id, so cursors point to a single row.
+type listQuery struct {
+	conditions []string
+	args       []interface{}
+	sortBy     string
+	sort       sortColumn
+	desc       bool
+	limit      int
+	skip       int
+}
+
+// where adds a condition, with a %d verb for the placeholder of each argument
+func (q *listQuery) where(condition string, args ...interface{}) {
+	placeholders := make([]interface{}, len(args))
+	for i, arg := range args {
+		q.args = append(q.args, arg)
+		placeholders[i] = len(q.args)
+	}
+	q.conditions = append(q.conditions, fmt.Sprintf(condition, placeholders...))
+}
+
+// paginate sets the order and the page of the query from opts. Lists are sorted
+//  by defaultSort unless opts names another of columns, and nil opts list every row.
+func (q *listQuery) paginate(opts *types.ListOptions, columns map[string]sortColumn,
+	defaultSort string, defaultDesc bool) error {
+	q.sortBy, q.desc = defaultSort, defaultDesc
+	q.sort = columns[defaultSort]
+	if opts == nil {
+		return nil
+	}
+	if opts.SortBy != "" {
+		column, ok := columns[opts.SortBy]
+		if !ok {
+			return apierror.ErrInvalidFilter.Withf("cannot sort by %s", opts.SortBy)
+		}
+		q.sortBy, q.sort = opts.SortBy, column
+	}
+	switch opts.Order {
+	case "":
+	case "ascend":
+		q.desc = false
+	case "descend":
+		q.desc = true
+	default:
+		return apierror.ErrInvalidFilter.Withf("order must be ascend or descend: %s", opts.Order)
+	}
+	q.limit, q.skip = opts.Count, opts.Skip
+	if q.limit <= 0 {
+		q.limit = defaultListCount
+	}
+	if q.limit > maxListCount {
+		q.limit = maxListCount
+	}
+	if opts.Cursor == "" {
+		return nil
+	}
+	// the cursor keeps the order of the first page
+	c, err := decodeCursor(opts.Cursor)
+	if err != nil {
+		return err
+	}
+	column, ok := columns[c.SortBy]
+	if !ok {
+		return apierror.ErrInvalidFilter.Withf("invalid cursor %s", opts.Cursor)
+	}
+	q.sortBy, q.sort, q.desc = c.SortBy, column, c.Desc
+	var value interface{} = c.Value
+	switch column.kind {
+	case sortInt:
+		value, err = strconv.ParseInt(c.Value, 10, 64)
+	case sortTime:
+		value, err = time.Parse(time.RFC3339Nano, c.Value)
+	}
+	if err != nil {
+		return apierror.ErrInvalidFilter.Withf("invalid cursor %s", opts.Cursor)
+	}
+	op := ">"
+	if q.desc {
+		op = "<"
+	}
+	q.where(fmt.Sprintf("(%s, id) %s ($%%d, $%%d)", column.name, op), value, c.ID)
+	return nil
+}
+
+// sql returns the WHERE, ORDER BY, LIMIT and OFFSET clauses of the query. It asks
+//  for one row more than the page size, to know whether there is a next page.
+func (q *listQuery) sql() string {
+	clauses := ""
+	if len(q.conditions) > 0 {
+		clauses = " WHERE " + strings.Join(q.conditions, " AND ")
+	}
+	order := "ASC"
+	if q.desc {
+		order = "DESC"
+	}
+	clauses += fmt.Sprintf(" ORDER BY %s %s, id %s", q.sort.name, order, order)
+	if q.limit > 0 {
+		q.args = append(q.args, q.limit+1)
+		clauses += fmt.Sprintf(" LIMIT $%d", len(q.args))
+	}
+	if q.skip > 0 {
+		q.args = append(q.args, q.skip)
+		clauses += fmt.Sprintf(" OFFSET $%d", len(q.args))
+	}
+	return clauses
+}
+
+// page returns the number of the n rows fetched that belong to the page, and
+//  the cursor of the next page, empty if this is the last one. row returns the
+//  sort value and the id of the i-th row.
+func (q *listQuery) page(n int, row func(i int) (interface{}, int64)) (int, string) {
+	if q.limit <= 0 || n <= q.limit {
+		return n, ""
+	}
+	value, id := row(q.limit - 1)
+	c := &cursor{SortBy: q.sortBy, Desc: q.desc, ID: id}
+	switch v := value.(type) {
+	case time.Time:
+		c.Value = v.UTC().Format(time.RFC3339Nano)
+	case int:
+		c.Value = strconv.Itoa(v)
+	case int64:
+		c.Value = strconv.FormatInt(v, 10)
+	default:
+		c.Value = fmt.Sprint(v)
+	}
+	return q.limit, encodeCursor(c)
+}
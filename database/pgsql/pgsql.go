@@ -2,16 +2,20 @@ package pgsql
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx"
 	"github.com/jmoiron/sqlx"
 	migrate "github.com/rubenv/sql-migrate"
 
 	_ "github.com/jackc/pgx/stdlib"
 	"go.vocdoni.io/dvote/log"
 
+	"go.vocdoni.io/api/apierror"
 	"go.vocdoni.io/api/config"
 )
 
@@ -111,3 +115,23 @@ func (d *Database) MigrationUpSync() (int, error) {
 	}
 	return n, nil
 }
+
+// uniqueViolation is the postgres error code for a unique constraint violation
+const uniqueViolation = "23505"
+
+// dbError translates a database error into an API error: sql.ErrNoRows becomes
+//  notFound, unique constraint violations become ErrAlreadyExists, and any
+//  other error becomes ErrDatabase. A nil error is returned as nil.
+func dbError(err error, notFound apierror.Error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound.WithErr(err)
+	}
+	var pgErr pgx.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return apierror.ErrAlreadyExists.WithErr(err)
+	}
+	return apierror.ErrDatabase.WithErr(err)
+}
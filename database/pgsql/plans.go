@@ -7,6 +7,7 @@ import (
 	"github.com/google/uuid"
 	_ "github.com/jackc/pgx/stdlib"
 
+	"go.vocdoni.io/api/apierror"
 	"go.vocdoni.io/api/types"
 )
 
@@ -27,7 +28,8 @@ func (d *Database) CreatePlan(name string, maxCensusSize, maxProcessCount int) (
 			RETURNING id`
 	result, err := d.db.NamedQuery(insert, plan)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("error creating plan: %w", err)
+		return uuid.Nil, dbError(fmt.Errorf("error creating plan: %w", err),
+			apierror.ErrPlanNotFound)
 	}
 	if !result.Next() {
 		return uuid.Nil, fmt.Errorf("error creating plan: there is no next result row")
@@ -42,12 +44,13 @@ func (d *Database) CreatePlan(name string, maxCensusSize, maxProcessCount int) (
 
 func (d *Database) GetPlan(id uuid.UUID) (*types.QuotaPlan, error) {
 	var plan types.QuotaPlan
-	selectplan := `SELECT id, name, max_census_size, max_process_count, created_at, updated_at
+	selectplan := `SELECT id, name, max_census_size, max_process_count, rate_limit, rate_burst,
+						created_at, updated_at
 						FROM quota_plans WHERE id=$1`
 	row := d.db.QueryRowx(selectplan, id)
 	err := row.StructScan(&plan)
 	if err != nil {
-		return nil, err
+		return nil, dbError(err, apierror.ErrPlanNotFound)
 	}
 
 	return &plan, nil
@@ -55,12 +58,13 @@ func (d *Database) GetPlan(id uuid.UUID) (*types.QuotaPlan, error) {
 
 func (d *Database) GetPlanByName(name string) (*types.QuotaPlan, error) {
 	var plan types.QuotaPlan
-	selectplan := `SELECT id, name, max_census_size, max_process_count, created_at, updated_at
+	selectplan := `SELECT id, name, max_census_size, max_process_count, rate_limit, rate_burst,
+						created_at, updated_at
 						FROM quota_plans WHERE name=$1`
 	row := d.db.QueryRowx(selectplan, name)
 	err := row.StructScan(&plan)
 	if err != nil {
-		return nil, err
+		return nil, dbError(err, apierror.ErrPlanNotFound)
 	}
 
 	return &plan, nil
@@ -78,7 +82,7 @@ func (d *Database) DeletePlan(id uuid.UUID) error {
 		return fmt.Errorf("error veryfying deleted plan: %w", err)
 	}
 	if rows != 1 {
-		return fmt.Errorf("nothing to delete")
+		return apierror.ErrPlanNotFound.Withf("plan %s: nothing to delete", id)
 	}
 	return nil
 }
@@ -108,6 +112,25 @@ func (d *Database) UpdatePlan(id uuid.UUID, newMaxCensusSize, neWMaxProcessCount
 	return int(rows), nil
 }
 
+// UpdatePlanRateLimit sets the rate limit of the organizations on a plan. Unlike
+//  UpdatePlan, zero values are stored, so the API default applies again.
+func (d *Database) UpdatePlanRateLimit(id uuid.UUID, rateLimit, rateBurst int) (int, error) {
+	update := `UPDATE quota_plans SET rate_limit = $2, rate_burst = $3, updated_at = now()
+				WHERE id = $1`
+	result, err := d.db.Exec(update, id, rateLimit, rateBurst)
+	if err != nil {
+		return 0, fmt.Errorf("error updating plan rate limit: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("cannot get affected rows: %w", err)
+	}
+	if rows != 1 {
+		return 0, apierror.ErrPlanNotFound.Withf("plan %s", id)
+	}
+	return int(rows), nil
+}
+
 func (d *Database) GetPlansList() ([]types.QuotaPlan, error) {
 	selectQuery := `SELECT * FROM quota_plans`
 	var plans []types.QuotaPlan
@@ -0,0 +1,41 @@
+package pgsql
+
+import (
+	"fmt"
+	"time"
+)
+
+// PurgeDeleted permanently removes the integrators and organizations soft deleted
+//  before deletedBefore. Their censuses and elections are removed by cascade.
+func (d *Database) PurgeDeleted(deletedBefore time.Time) (int, int, error) {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return 0, 0, fmt.Errorf("error purging deleted rows: %w", err)
+	}
+	defer tx.Rollback()
+	// deleted_at has no time zone and is set in UTC
+	deletedBefore = deletedBefore.UTC()
+	// organizations first, so the ones removed with their integrator are not counted
+	result, err := tx.Exec(`DELETE FROM organizations
+					WHERE deleted_at < $1 AND integrator_id NOT IN
+						(SELECT id FROM integrators WHERE deleted_at < $1)`, deletedBefore)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error purging deleted organizations: %w", err)
+	}
+	organizations, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, fmt.Errorf("error purging deleted organizations: %w", err)
+	}
+	result, err = tx.Exec(`DELETE FROM integrators WHERE deleted_at < $1`, deletedBefore)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error purging deleted integrators: %w", err)
+	}
+	integrators, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, fmt.Errorf("error purging deleted integrators: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("error purging deleted rows: %w", err)
+	}
+	return int(integrators), int(organizations), nil
+}
@@ -0,0 +1,44 @@
+package pgsql
+
+import (
+	"fmt"
+	"time"
+
+	"go.vocdoni.io/api/ratelimit"
+)
+
+// refillTokens is the SQL expression of the tokens of a stored bucket refilled
+//  until $4, at $2 tokens per second up to $3
+const refillTokens = `LEAST($3::float8, b.tokens +
+	GREATEST(0, EXTRACT(EPOCH FROM ($4::timestamp - b.updated_at))) * $2::float8)`
+
+// TakeRateLimit takes a token from the bucket of key, created full, at now. The
+//  bucket is refilled and taken in a single statement, so concurrent requests of
+//  several replicas cannot take the same token.
+func (d *Database) TakeRateLimit(key string, limit ratelimit.Limit,
+	now time.Time) (*ratelimit.Result, error) {
+	upsert := fmt.Sprintf(`INSERT INTO rate_limit_buckets AS b
+			(key, tokens, allowed, updated_at)
+			VALUES ($1, $3::float8 - 1, true, $4::timestamp)
+			ON CONFLICT (key) DO UPDATE SET
+				tokens = CASE WHEN %[1]s >= 1 THEN %[1]s - 1 ELSE %[1]s END,
+				allowed = %[1]s >= 1,
+				updated_at = $4::timestamp
+			RETURNING tokens, allowed`, refillTokens)
+	var tokens float64
+	var allowed bool
+	if err := d.db.QueryRowx(upsert, key, limit.PerSecond(), float64(limit.Size()),
+		now.UTC()).Scan(&tokens, &allowed); err != nil {
+		return nil, fmt.Errorf("error taking rate limit token: %w", err)
+	}
+	return ratelimit.NewResult(allowed, tokens, limit), nil
+}
+
+// PurgeRateLimits drops the buckets not used since idleSince
+func (d *Database) PurgeRateLimits(idleSince time.Time) error {
+	if _, err := d.db.Exec(`DELETE FROM rate_limit_buckets WHERE updated_at < $1`,
+		idleSince.UTC()); err != nil {
+		return fmt.Errorf("error purging rate limits: %w", err)
+	}
+	return nil
+}
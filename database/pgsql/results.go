@@ -0,0 +1,96 @@
+package pgsql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+)
+
+// resultsSnapshotRow is a results snapshot as stored, with its results JSON encoded
+type resultsSnapshotRow struct {
+	CreatedAt     time.Time      `db:"created_at"`
+	OrgEthAddress []byte         `db:"organization_eth_address"`
+	ProcessID     []byte         `db:"process_id"`
+	Height        int64          `db:"height"`
+	VoteCount     int64          `db:"vote_count"`
+	State         string         `db:"state"`
+	Type          string         `db:"type"`
+	Results       string         `db:"results"`
+	Aggregated    sql.NullString `db:"aggregated"`
+	Hash          []byte         `db:"hash"`
+}
+
+// CreateResultsSnapshot stores the final results of an election. Snapshots never
+//  change, so storing one again keeps the first.
+func (d *Database) CreateResultsSnapshot(snapshot *types.ResultsSnapshot) error {
+	if snapshot.CreatedAt.IsZero() {
+		snapshot.CreatedAt = time.Now()
+	}
+	row := resultsSnapshotRow{
+		CreatedAt: snapshot.CreatedAt.UTC(),
+		ProcessID: snapshot.ElectionID,
+		Height:    int64(snapshot.Height),
+		VoteCount: int64(snapshot.VoteCount),
+		State:     snapshot.State,
+		Type:      snapshot.Type,
+		Hash:      snapshot.Hash,
+	}
+	results, err := json.Marshal(snapshot.Results)
+	if err != nil {
+		return fmt.Errorf("error encoding results: %w", err)
+	}
+	row.Results = string(results)
+	if len(snapshot.Aggregated) > 0 {
+		aggregated, err := json.Marshal(snapshot.Aggregated)
+		if err != nil {
+			return fmt.Errorf("error encoding aggregated results: %w", err)
+		}
+		row.Aggregated = sql.NullString{String: string(aggregated), Valid: true}
+	}
+	insert := `INSERT INTO results_snapshots
+			( created_at, process_id, height, vote_count, state, type, results, aggregated, hash)
+			VALUES ( :created_at, :process_id, :height, :vote_count, :state, :type, :results,
+				:aggregated, :hash)
+			ON CONFLICT (process_id) DO NOTHING`
+	if _, err := d.db.NamedExec(insert, row); err != nil {
+		return dbError(fmt.Errorf("error creating results snapshot: %w", err),
+			apierror.ErrElectionNotFound)
+	}
+	return nil
+}
+
+// GetResultsSnapshot returns the final results stored for an election of a live
+//  organization
+func (d *Database) GetResultsSnapshot(processID []byte) (*types.ResultsSnapshot, error) {
+	var row resultsSnapshotRow
+	selectQuery := `SELECT s.created_at, e.organization_eth_address, s.process_id, s.height,
+						s.vote_count, s.state, s.type, s.results, s.aggregated, s.hash
+					FROM results_snapshots s JOIN elections e ON e.process_id = s.process_id
+					WHERE s.process_id = $1 AND e.` + liveOrganizations
+	if err := d.db.Get(&row, selectQuery, processID); err != nil {
+		return nil, dbError(err, apierror.ErrResultsNotFound)
+	}
+	snapshot := &types.ResultsSnapshot{
+		CreatedAt:      row.CreatedAt,
+		OrganizationID: row.OrgEthAddress,
+		ElectionID:     row.ProcessID,
+		Height:         uint32(row.Height),
+		VoteCount:      uint32(row.VoteCount),
+		State:          row.State,
+		Type:           row.Type,
+		Hash:           row.Hash,
+	}
+	if err := json.Unmarshal([]byte(row.Results), &snapshot.Results); err != nil {
+		return nil, fmt.Errorf("error decoding results snapshot: %w", err)
+	}
+	if row.Aggregated.Valid {
+		if err := json.Unmarshal([]byte(row.Aggregated.String), &snapshot.Aggregated); err != nil {
+			return nil, fmt.Errorf("error decoding results snapshot: %w", err)
+		}
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,128 @@
+package pgsql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+)
+
+const scheduledActionColumns = `created_at, id, integrator_api_key, organization_eth_address,
+	process_id, action, execute_at, target_block, status, error, tx_hash, claimed_at,
+	executed_at`
+
+// CreateScheduledAction stores a pending action and returns its id
+func (d *Database) CreateScheduledAction(action *types.ScheduledAction) (int, error) {
+	return insertScheduledAction(d.db, action)
+}
+
+// insertScheduledAction stores a pending action with q, the database or a
+//  transaction, and returns its id
+func insertScheduledAction(q sqlx.Queryer, action *types.ScheduledAction) (int, error) {
+	insert := `INSERT INTO scheduled_actions
+				(integrator_api_key, organization_eth_address, process_id, action, execute_at,
+					target_block)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				RETURNING id`
+	var id int
+	if err := q.QueryRowx(insert, action.IntegratorApiKey, []byte(action.OrgEthAddress),
+		[]byte(action.ProcessID), action.Action, action.ExecuteAt.UTC(),
+		int64(action.TargetBlock)).Scan(&id); err != nil {
+		return 0, dbError(fmt.Errorf("error creating scheduled action: %w", err),
+			apierror.ErrElectionNotFound)
+	}
+	return id, nil
+}
+
+// ListScheduledActions returns the actions scheduled by an integrator on an
+//  election, in order of execution
+func (d *Database) ListScheduledActions(integratorAPIKey,
+	processID []byte) ([]types.ScheduledAction, error) {
+	actions := []types.ScheduledAction{}
+	selectQuery := `SELECT ` + scheduledActionColumns + ` FROM scheduled_actions
+					WHERE integrator_api_key = $1 AND process_id = $2
+					ORDER BY execute_at, id`
+	if err := d.db.Select(&actions, selectQuery, integratorAPIKey, processID); err != nil {
+		return nil, fmt.Errorf("error listing scheduled actions: %w", err)
+	}
+	return actions, nil
+}
+
+// CancelScheduledAction cancels a pending action of an integrator on an election
+func (d *Database) CancelScheduledAction(integratorAPIKey, processID []byte, id int) error {
+	update := `UPDATE scheduled_actions SET status = $4
+				WHERE id = $1 AND integrator_api_key = $2 AND process_id = $3 AND status = $5`
+	result, err := d.db.Exec(update, id, integratorAPIKey, processID,
+		types.ScheduledActionCanceled, types.ScheduledActionPending)
+	if err != nil {
+		return fmt.Errorf("error canceling scheduled action: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return apierror.ErrNotFound.Withf("no pending scheduled action %d", id)
+	}
+	return nil
+}
+
+// ListDueScheduledActions returns the pending actions to execute before the given
+//  time, on elections of live organizations, and the running ones claimed before
+//  claimedBefore, whose replica did not finish them
+func (d *Database) ListDueScheduledActions(before,
+	claimedBefore time.Time) ([]types.ScheduledAction, error) {
+	actions := []types.ScheduledAction{}
+	selectQuery := `SELECT ` + scheduledActionColumns + ` FROM scheduled_actions
+					WHERE (status = $1 OR (status = $3 AND claimed_at < $4))
+						AND execute_at <= $2 AND ` + liveOrganizations + `
+					ORDER BY execute_at, id`
+	if err := d.db.Select(&actions, selectQuery, types.ScheduledActionPending, before.UTC(),
+		types.ScheduledActionRunning, claimedBefore.UTC()); err != nil {
+		return nil, fmt.Errorf("error listing due scheduled actions: %w", err)
+	}
+	return actions, nil
+}
+
+// SetScheduledActionTarget updates the block estimated for an action
+func (d *Database) SetScheduledActionTarget(id int, targetBlock uint32) error {
+	if _, err := d.db.Exec(`UPDATE scheduled_actions SET target_block = $2 WHERE id = $1`,
+		id, int64(targetBlock)); err != nil {
+		return fmt.Errorf("error updating scheduled action: %w", err)
+	}
+	return nil
+}
+
+// ClaimScheduledAction marks a pending action as running, and tells whether it
+//  was still pending, so a single API replica executes it. Running actions
+//  claimed before claimedBefore are claimed again.
+func (d *Database) ClaimScheduledAction(id int, claimedBefore time.Time) (bool, error) {
+	result, err := d.db.Exec(`UPDATE scheduled_actions SET status = $2, claimed_at = $5
+								WHERE id = $1
+									AND (status = $3 OR (status = $2 AND claimed_at < $4))`,
+		id, types.ScheduledActionRunning, types.ScheduledActionPending, claimedBefore.UTC(),
+		time.Now().UTC())
+	if err != nil {
+		return false, fmt.Errorf("error claiming scheduled action: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error claiming scheduled action: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// FinishScheduledAction records the outcome of an action: its final status, its
+//  error if it failed, and the hash of its transaction, if any
+func (d *Database) FinishScheduledAction(id int, status, errorMessage string,
+	txHash []byte) error {
+	if txHash == nil {
+		txHash = []byte{}
+	}
+	update := `UPDATE scheduled_actions SET status = $2, error = $3, tx_hash = $4,
+					executed_at = $5
+				WHERE id = $1`
+	if _, err := d.db.Exec(update, id, status, errorMessage, txHash,
+		time.Now().UTC()); err != nil {
+		return fmt.Errorf("error finishing scheduled action: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package pgsql
+
+import (
+	"fmt"
+	"time"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+)
+
+// AddElectionSample stores the vote count of an election at a height. Samples
+//  taken at the same time keep the first.
+func (d *Database) AddElectionSample(sample *types.ElectionSample) error {
+	if sample.SampledAt.IsZero() {
+		sample.SampledAt = time.Now()
+	}
+	insert := `INSERT INTO election_samples (process_id, sampled_at, height, vote_count)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (process_id, sampled_at) DO NOTHING`
+	if _, err := d.db.Exec(insert, sample.ProcessID, sample.SampledAt.UTC(),
+		int64(sample.Height), sample.VoteCount); err != nil {
+		return dbError(fmt.Errorf("error adding election sample: %w", err),
+			apierror.ErrElectionNotFound)
+	}
+	return nil
+}
+
+// ListElectionSamples returns the vote count samples of an election, oldest first
+func (d *Database) ListElectionSamples(processID []byte) ([]types.ElectionSample, error) {
+	samples := []types.ElectionSample{}
+	selectQuery := `SELECT process_id, sampled_at, height, vote_count FROM election_samples
+					WHERE process_id = $1 ORDER BY sampled_at`
+	if err := d.db.Select(&samples, selectQuery, processID); err != nil {
+		return nil, fmt.Errorf("error listing election samples: %w", err)
+	}
+	return samples, nil
+}
+
+// GetElectionCensusSize returns the number of voters of an election: those uploaded
+//  to the hosted CSP for elections authenticated by a voter list, or else the
+//  members of its census. It is 0 when unknown.
+func (d *Database) GetElectionCensusSize(processID []byte) (int, error) {
+	var size int
+	selectQuery := `SELECT CASE
+						WHEN e.csp_auth IN ('idlist', 'email') THEN
+							(SELECT COUNT(*) FROM csp_voters v WHERE v.election_id = e.process_id)
+						WHEN e.census_id IS NOT NULL THEN
+							(SELECT COUNT(*) FROM census_members m WHERE m.census_id = e.census_id)
+						ELSE 0 END
+					FROM elections e WHERE e.process_id = $1`
+	if err := d.db.Get(&size, selectQuery, processID); err != nil {
+		return 0, dbError(err, apierror.ErrElectionNotFound)
+	}
+	return size, nil
+}
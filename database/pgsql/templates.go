@@ -0,0 +1,107 @@
+package pgsql
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+)
+
+// electionTemplateRow is an election template as stored, with its definition
+//  JSON encoded
+type electionTemplateRow struct {
+	CreatedAt        time.Time `db:"created_at"`
+	ID               int       `db:"id"`
+	IntegratorApiKey []byte    `db:"integrator_api_key"`
+	OrgEthAddress    []byte    `db:"organization_eth_address"`
+	Name             string    `db:"name"`
+	Definition       string    `db:"definition"`
+}
+
+func (row *electionTemplateRow) template() (*types.ElectionTemplate, error) {
+	template := &types.ElectionTemplate{
+		ID:               row.ID,
+		CreatedAt:        row.CreatedAt,
+		IntegratorApiKey: row.IntegratorApiKey,
+		OrgEthAddress:    row.OrgEthAddress,
+		Name:             row.Name,
+	}
+	if err := json.Unmarshal([]byte(row.Definition), &template.Definition); err != nil {
+		return nil, fmt.Errorf("error decoding election template %d: %w", row.ID, err)
+	}
+	return template, nil
+}
+
+const electionTemplateColumns = `created_at, id, integrator_api_key, organization_eth_address,
+	name, definition`
+
+// CreateElectionTemplate stores the template of an organization and returns its id
+func (d *Database) CreateElectionTemplate(template *types.ElectionTemplate) (int, error) {
+	definition, err := json.Marshal(template.Definition)
+	if err != nil {
+		return 0, fmt.Errorf("error encoding election template: %w", err)
+	}
+	insert := `INSERT INTO election_templates
+					(integrator_api_key, organization_eth_address, name, definition)
+					VALUES ($1, $2, $3, $4)
+					RETURNING id`
+	var id int
+	if err := d.db.QueryRowx(insert, template.IntegratorApiKey, []byte(template.OrgEthAddress),
+		template.Name, string(definition)).Scan(&id); err != nil {
+		return 0, dbError(fmt.Errorf("error creating election template: %w", err),
+			apierror.ErrOrganizationNotFound)
+	}
+	return id, nil
+}
+
+// ListElectionTemplates returns the templates of an organization, oldest first
+func (d *Database) ListElectionTemplates(integratorAPIKey,
+	orgEthAddress []byte) ([]types.ElectionTemplate, error) {
+	var rows []electionTemplateRow
+	selectQuery := `SELECT ` + electionTemplateColumns + ` FROM election_templates
+					WHERE integrator_api_key = $1 AND organization_eth_address = $2
+					ORDER BY id`
+	if err := d.db.Select(&rows, selectQuery, integratorAPIKey, orgEthAddress); err != nil {
+		return nil, fmt.Errorf("error listing election templates: %w", err)
+	}
+	templates := []types.ElectionTemplate{}
+	for i := range rows {
+		template, err := rows[i].template()
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *template)
+	}
+	return templates, nil
+}
+
+// GetElectionTemplate returns a template of an organization
+func (d *Database) GetElectionTemplate(integratorAPIKey, orgEthAddress []byte,
+	id int) (*types.ElectionTemplate, error) {
+	var row electionTemplateRow
+	selectQuery := `SELECT ` + electionTemplateColumns + ` FROM election_templates
+					WHERE id = $1 AND integrator_api_key = $2 AND organization_eth_address = $3`
+	if err := d.db.Get(&row, selectQuery, id, integratorAPIKey, orgEthAddress); err != nil {
+		return nil, dbError(fmt.Errorf("error getting election template %d: %w", id, err),
+			apierror.ErrNotFound)
+	}
+	return row.template()
+}
+
+// DeleteElectionTemplate deletes a template of an organization. The elections
+//  created from it are kept.
+func (d *Database) DeleteElectionTemplate(integratorAPIKey, orgEthAddress []byte, id int) error {
+	result, err := d.db.Exec(`DELETE FROM election_templates
+								WHERE id = $1 AND integrator_api_key = $2
+									AND organization_eth_address = $3`,
+		id, integratorAPIKey, orgEthAddress)
+	if err != nil {
+		return fmt.Errorf("error deleting election template: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return apierror.ErrNotFound.Withf("election template %d", id)
+	}
+	return nil
+}
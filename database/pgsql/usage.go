@@ -0,0 +1,83 @@
+package pgsql
+
+import (
+	"fmt"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+)
+
+// AddUsage adds the counts of counters to the stored ones, creating the counters
+//  not stored yet, within a single transaction
+func (d *Database) AddUsage(counters []types.UsageCounter) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("error adding usage: %w", err)
+	}
+	defer tx.Rollback()
+	upsert := `INSERT INTO usage_counters
+			( day, integrator_id, organization_eth_address, metric, count)
+			VALUES ( $1, $2, $3, $4, $5)
+			ON CONFLICT (integrator_id, organization_eth_address, metric, day)
+			DO UPDATE SET count = usage_counters.count + EXCLUDED.count`
+	for _, counter := range counters {
+		// the usage not tied to an organization is stored with an empty address
+		address := counter.OrgEthAddress
+		if address == nil {
+			address = []byte{}
+		}
+		if _, err := tx.Exec(upsert, counter.Day.UTC(), counter.IntegratorID, address,
+			counter.Metric, counter.Count); err != nil {
+			return fmt.Errorf("error adding usage: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// usageSortColumns are the columns usage lists can be sorted by
+var usageSortColumns = map[string]sortColumn{
+	"day": {"day", sortTime},
+	"id":  {"id", sortInt},
+}
+
+// ListUsage returns a page of the usage counters matching filter, oldest day
+//  first, and the cursor of the next page
+func (d *Database) ListUsage(filter *types.UsageFilter) ([]types.UsageCounter, string, error) {
+	if filter == nil {
+		filter = &types.UsageFilter{}
+	}
+	q := &listQuery{}
+	if filter.IntegratorID != 0 {
+		q.where("integrator_id = $%d", filter.IntegratorID)
+	}
+	if len(filter.OrgEthAddress) > 0 {
+		q.where("organization_eth_address = $%d", filter.OrgEthAddress)
+	}
+	if filter.Metric != "" {
+		q.where("metric = $%d", filter.Metric)
+	}
+	if !filter.From.IsZero() {
+		q.where("day >= $%d", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		q.where("day < $%d", filter.To.UTC())
+	}
+	if err := q.paginate(&types.ListOptions{Count: filter.Count, Skip: filter.Skip,
+		Cursor: filter.Cursor}, usageSortColumns, "day", false); err != nil {
+		return nil, "", err
+	}
+	selectQuery := `SELECT id, day, integrator_id, organization_eth_address, metric, count,
+						(SELECT quota_plan_id FROM organizations
+							WHERE eth_address = usage_counters.organization_eth_address)
+							AS quota_plan_id
+					FROM usage_counters` + q.sql()
+	counters := []types.UsageCounter{}
+	if err := d.db.Select(&counters, selectQuery, q.args...); err != nil {
+		return nil, "", dbError(fmt.Errorf("error listing usage: %w", err),
+			apierror.ErrNotFound)
+	}
+	n, next := q.page(len(counters), func(i int) (interface{}, int64) {
+		return counters[i].Day, counters[i].ID
+	})
+	return counters[:n], next, nil
+}
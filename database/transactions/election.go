@@ -26,15 +26,62 @@ type CreateElectionTx struct {
 	EndBlock          uint32
 	Confidential      bool
 	HiddenResults     bool
+	// CspAuth and CspAuthSecret set how the hosted CSP authenticates the voters
+	CspAuth       string
+	CspAuthSecret []byte
+	// ScheduleEnd schedules the end of the election at EndDate, as the end block
+	//  is only an estimate
+	ScheduleEnd bool
 }
 
 func (tx CreateElectionTx) commit(db database.Database) error {
-	_, err := db.CreateElection(tx.IntegratorPrivKey,
-		tx.EthAddress, tx.ElectionID, tx.EncryptedMetaKey,
-		tx.Title, string(tx.ProofType), tx.StartDate, tx.EndDate, tx.CensusID, int(tx.StartBlock),
-		int(tx.EndBlock), tx.Confidential, tx.HiddenResults)
+	var actions []types.ScheduledAction
+	if tx.ScheduleEnd {
+		actions = append(actions, types.ScheduledAction{
+			IntegratorApiKey: tx.IntegratorPrivKey,
+			OrgEthAddress:    tx.EthAddress,
+			ProcessID:        tx.ElectionID,
+			Action:           types.ScheduledActionEnd,
+			ExecuteAt:        tx.EndDate,
+			TargetBlock:      tx.EndBlock,
+		})
+	}
+	now := time.Now()
+	_, err := db.StoreElection(&types.Election{
+		OrgEthAddress:    tx.EthAddress,
+		IntegratorApiKey: tx.IntegratorPrivKey,
+		ProcessID:        tx.ElectionID,
+		Title:            tx.Title,
+		CensusID:         tx.CensusID,
+		StartDate:        tx.StartDate,
+		EndDate:          tx.EndDate,
+		StartBlock:       int(tx.StartBlock),
+		EndBlock:         int(tx.EndBlock),
+		ProofType:        string(tx.ProofType),
+		Confidential:     tx.Confidential,
+		HiddenResults:    tx.HiddenResults,
+		MetadataPrivKey:  tx.EncryptedMetaKey,
+		CspAuth:          tx.CspAuth,
+		CspAuthSecret:    tx.CspAuthSecret,
+		CreatedUpdated:   types.CreatedUpdated{CreatedAt: now, UpdatedAt: now},
+	}, actions)
 	if err != nil {
 		return fmt.Errorf("could not create election: %w", err)
 	}
 	return nil
 }
+
+// SetElectionStatusTx is the serializable transaction for setting the status of
+//  an election, before the election indexer syncs it from the Vochain
+type SetElectionStatusTx struct {
+	TxBody
+	ElectionID []byte
+	Status     string
+}
+
+func (tx SetElectionStatusTx) commit(db database.Database) error {
+	if err := db.UpdateElectionStatus(tx.ElectionID, tx.Status); err != nil {
+		return fmt.Errorf("could not set election status: %w", err)
+	}
+	return nil
+}
@@ -107,3 +107,28 @@ func (kv *TxCacheDB) GetTxTime(hash []byte) (*time.Time, error) {
 	}
 	return &timestamp, nil
 }
+
+// CachedTx is a cached transaction waiting to be committed to the database
+type CachedTx struct {
+	Hash []byte
+	Tx   SerializableTx
+}
+
+// ListTxs returns every cached transaction not yet committed to the database.
+// Entries that cannot be decoded are returned as an error after the iteration.
+func (kv *TxCacheDB) ListTxs() ([]CachedTx, error) {
+	var txs []CachedTx
+	var decodeErr error
+	if err := kv.DB.Iterate([]byte(TxPrefix), func(key, value []byte) bool {
+		var tx SerializableTx
+		if err := json.Unmarshal(value, &tx); err != nil {
+			decodeErr = fmt.Errorf("could not decode cached tx %x: %w", key, err)
+			return true
+		}
+		txs = append(txs, CachedTx{Hash: append([]byte{}, key...), Tx: tx})
+		return true
+	}); err != nil {
+		return nil, fmt.Errorf("could not iterate tx cache: %w", err)
+	}
+	return txs, decodeErr
+}
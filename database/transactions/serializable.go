@@ -19,6 +19,8 @@ const (
 	CreateOrganization SerializableTxType = "createOrganization"
 	// Transaction type to update an organization in the database
 	UpdateOrganization SerializableTxType = "updateOrganization"
+	// Transaction type to set the status of an election in the database
+	SetElectionStatus SerializableTxType = "setElectionStatus"
 )
 
 // SerializableTx is a database transaction that can be serialized and stored for use later.
@@ -70,6 +72,13 @@ func (tx *SerializableTx) UnmarshalJSON(b []byte) error {
 			return err
 		}
 		tx.Body = body
+	case SetElectionStatus:
+		var body SetElectionStatusTx
+		err = json.Unmarshal(*objMap["body"], &body)
+		if err != nil {
+			return err
+		}
+		tx.Body = body
 	default:
 		return errors.New("unknown transaction type")
 	}
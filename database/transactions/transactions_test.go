@@ -45,14 +45,14 @@ func TestStoreTx(t *testing.T) {
 			Body:         nil,
 			CreationTime: time.Now(),
 		}
-		if i%3 == 0 {
+		if i%4 == 0 {
 			query.Type = CreateElection
 			query.Body = CreateElectionTx{
 				IntegratorPrivKey: integratorPrivKey,
 				Title:             "new election",
 				Confidential:      true,
 			}
-		} else if i%3 == 1 {
+		} else if i%4 == 1 {
 			query.Type = CreateOrganization
 			query.Body = CreateOrganizationTx{
 				IntegratorPrivKey: integratorPrivKey,
@@ -60,13 +60,19 @@ func TestStoreTx(t *testing.T) {
 				HeaderURI:         "header",
 				AvatarURI:         "avatar",
 			}
-		} else {
+		} else if i%4 == 2 {
 			query.Type = UpdateOrganization
 			query.Body = UpdateOrganizationTx{
 				IntegratorPrivKey: integratorPrivKey,
 				HeaderUri:         "updateheader",
 				AvatarUri:         "updateavatar",
 			}
+		} else {
+			query.Type = SetElectionStatus
+			query.Body = SetElectionStatusTx{
+				ElectionID: integratorPrivKey,
+				Status:     "PAUSED",
+			}
 		}
 		hash := util.RandomBytes(32)
 		hashes = append(hashes, hash)
@@ -76,12 +82,15 @@ func TestStoreTx(t *testing.T) {
 	for i, hash := range hashes {
 		tx, err := kv.GetTx(hash)
 		qt.Assert(t, err, qt.IsNil)
-		if i%3 == 0 {
+		switch i % 4 {
+		case 0:
 			testGetElection(t, tx.Type, CreateElection, tx.Body)
-		} else if i%3 == 1 {
+		case 1:
 			testGetElection(t, tx.Type, CreateOrganization, tx.Body)
-		} else {
+		case 2:
 			testGetElection(t, tx.Type, UpdateOrganization, tx.Body)
+		default:
+			testGetElection(t, tx.Type, SetElectionStatus, tx.Body)
 		}
 	}
 
@@ -135,7 +144,41 @@ func testGetElection(t *testing.T, Type SerializableTxType,
 		qt.Assert(t, bytes.Compare(query.IntegratorPrivKey, integratorPrivKey), qt.Equals, 0)
 		qt.Assert(t, query.HeaderUri, qt.Equals, "updateheader")
 		qt.Assert(t, query.AvatarUri, qt.Equals, "updateavatar")
+	case SetElectionStatus:
+		query, ok := tx.(SetElectionStatusTx)
+		qt.Assert(t, ok, qt.IsTrue)
+		qt.Assert(t, bytes.Compare(query.ElectionID, integratorPrivKey), qt.Equals, 0)
+		qt.Assert(t, query.Status, qt.Equals, "PAUSED")
 	default:
 		t.Fail()
 	}
 }
+
+func TestListTxs(t *testing.T) {
+	t.Parallel()
+	// use a separate kv, as the other tests store txs concurrently
+	db, err := metadb.New(dvotedb.TypePebble, t.TempDir())
+	qt.Assert(t, err, qt.IsNil)
+	defer db.Close()
+	txKv := NewTxKv(db)
+
+	txs, err := txKv.ListTxs()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, txs, qt.HasLen, 0)
+
+	hash := util.RandomBytes(32)
+	qt.Assert(t, txKv.StoreTx(hash, SerializableTx{
+		Type:         UpdateOrganization,
+		Body:         UpdateOrganizationTx{HeaderUri: "header"},
+		CreationTime: time.Now(),
+	}), qt.IsNil)
+	// timestamps are stored under a different prefix and are not listed
+	qt.Assert(t, txKv.StoreTxTime(hash, time.Now()), qt.IsNil)
+
+	txs, err = txKv.ListTxs()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, txs, qt.HasLen, 1)
+	qt.Assert(t, bytes.Equal(txs[0].Hash, hash), qt.IsTrue)
+	qt.Assert(t, txs[0].Tx.Type, qt.Equals, UpdateOrganization)
+	qt.Assert(t, txs[0].Tx.Body.(UpdateOrganizationTx).HeaderUri, qt.Equals, "header")
+}
@@ -0,0 +1,128 @@
+// Package ratelimit implements token bucket rate limiting. Every key, such as a
+//  token or a client IP, has a bucket holding up to Burst requests, refilled at
+//  Rate requests per minute. A request is allowed when it can take a token.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is the rate of a bucket. A zero Rate does not limit.
+type Limit struct {
+	// Rate is the number of requests per minute
+	Rate int
+	// Burst is the number of requests that can be made at once. 0 means Rate.
+	Burst int
+}
+
+// Enabled tells whether the limit limits anything
+func (l Limit) Enabled() bool {
+	return l.Rate > 0
+}
+
+// Size returns the number of tokens a full bucket holds
+func (l Limit) Size() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Rate
+}
+
+// PerSecond returns the number of tokens added to the bucket every second
+func (l Limit) PerSecond() float64 {
+	return float64(l.Rate) / 60
+}
+
+// Refill returns the tokens of a bucket holding tokens after elapsed
+func (l Limit) Refill(tokens float64, elapsed time.Duration) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(l.Size()), tokens+elapsed.Seconds()*l.PerSecond())
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed bool
+	// Limit is the size of the bucket
+	Limit int
+	// Remaining is the number of whole tokens left
+	Remaining int
+	// RetryAfter is the time until a token is available, if not Allowed
+	RetryAfter time.Duration
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+}
+
+// NewResult returns the result of a take from a bucket left with tokens
+func NewResult(allowed bool, tokens float64, limit Limit) *Result {
+	result := &Result{
+		Allowed:   allowed,
+		Limit:     limit.Size(),
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset: time.Duration((float64(limit.Size()) - tokens) / limit.PerSecond() *
+			float64(time.Second)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / limit.PerSecond() *
+			float64(time.Second))
+	}
+	return result
+}
+
+// Store keeps the buckets. Stores shared by several API replicas, such as the
+//  database, enforce the limits across all of them.
+type Store interface {
+	// TakeRateLimit takes a token from the bucket of key, created full, at now
+	TakeRateLimit(key string, limit Limit, now time.Time) (*Result, error)
+	// PurgeRateLimits drops the buckets not used since idleSince
+	PurgeRateLimits(idleSince time.Time) error
+}
+
+// MemoryStore keeps the buckets in memory, so its limits apply to a single process
+type MemoryStore struct {
+	lock    sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewMemoryStore returns an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+// TakeRateLimit takes a token from the bucket of key, created full, at now
+func (s *MemoryStore) TakeRateLimit(key string, limit Limit, now time.Time) (*Result, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Size()), updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = limit.Refill(b.tokens, now.Sub(b.updated))
+	b.updated = now
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return NewResult(allowed, b.tokens, limit), nil
+}
+
+// PurgeRateLimits drops the buckets not used since idleSince
+func (s *MemoryStore) PurgeRateLimits(idleSince time.Time) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for key, b := range s.buckets {
+		if b.updated.Before(idleSince) {
+			delete(s.buckets, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	// 2 requests at once, and one more every 2 seconds
+	limit := Limit{Rate: 30, Burst: 2}
+	now := time.Now()
+
+	result, err := store.TakeRateLimit("a", limit, now)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, result.Allowed, qt.IsTrue)
+	qt.Assert(t, result.Limit, qt.Equals, 2)
+	qt.Assert(t, result.Remaining, qt.Equals, 1)
+	qt.Assert(t, result.Reset, qt.Equals, 2*time.Second)
+	result, _ = store.TakeRateLimit("a", limit, now)
+	qt.Assert(t, result.Allowed, qt.IsTrue)
+	qt.Assert(t, result.Remaining, qt.Equals, 0)
+	result, _ = store.TakeRateLimit("a", limit, now.Add(time.Second))
+	qt.Assert(t, result.Allowed, qt.IsFalse)
+	qt.Assert(t, result.RetryAfter, qt.Equals, time.Second)
+
+	// other keys have their own bucket
+	result, _ = store.TakeRateLimit("b", limit, now)
+	qt.Assert(t, result.Allowed, qt.IsTrue)
+
+	// the bucket refills over time, up to its size
+	result, _ = store.TakeRateLimit("a", limit, now.Add(2*time.Second))
+	qt.Assert(t, result.Allowed, qt.IsTrue)
+	result, _ = store.TakeRateLimit("a", limit, now.Add(time.Hour))
+	qt.Assert(t, result.Allowed, qt.IsTrue)
+	qt.Assert(t, result.Remaining, qt.Equals, 1)
+
+	qt.Assert(t, store.PurgeRateLimits(now.Add(time.Minute)), qt.IsNil)
+	qt.Assert(t, store.buckets, qt.HasLen, 1)
+}
+
+func TestLimit(t *testing.T) {
+	qt.Assert(t, Limit{}.Enabled(), qt.IsFalse)
+	qt.Assert(t, Limit{Rate: 60}.Size(), qt.Equals, 60)
+	qt.Assert(t, Limit{Rate: 60, Burst: 5}.Size(), qt.Equals, 5)
+	qt.Assert(t, Limit{Rate: 60}.Refill(0, -time.Second), qt.Equals, float64(0))
+	qt.Assert(t, Limit{Rate: 60}.Refill(0, 2*time.Second), qt.Equals, float64(2))
+}
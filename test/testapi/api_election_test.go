@@ -9,11 +9,11 @@ import (
 	qt "github.com/frankban/quicktest"
 	"go.vocdoni.io/api/test/testcommon"
 	"go.vocdoni.io/api/types"
-	"go.vocdoni.io/api/urlapi"
 )
 
 func TestElection(t *testing.T) {
 	t.Parallel()
+	c := integratorClient(testIntegrators[0])
 
 	// test create different kinds of elections
 	elections := testcommon.CreateElections(1, false, false, types.PROOF_TYPE_BLIND)
@@ -22,60 +22,31 @@ func TestElection(t *testing.T) {
 	elections = append(elections, testcommon.CreateElections(1, true, true, types.PROOF_TYPE_ECDSA)...)
 
 	for _, election := range elections {
-		var resp *types.APIResponse
-		req := types.APIRequest{
-			Title:         election.Title,
-			Description:   election.Description,
-			Header:        election.Header,
-			StreamURI:     election.StreamURI,
-			EndDate:       election.EndDate.Format("2006-01-02T15:04:05.000Z"),
-			Confidential:  election.Confidential,
-			HiddenResults: election.HiddenResults,
-			Questions:     election.Questions,
-		}
-		statusCode := DoRequest(t,
-			fmt.Sprintf("%s/v1/priv/organizations/%x/elections/%s",
-				API.URL, testOrganizations[0].EthAddress, election.ProofType),
-			hex.EncodeToString(testIntegrators[0].SecretApiKey), "POST", req, &resp)
-		qt.Assert(t, statusCode, qt.Equals, 200)
+		resp, err := c.CreateElection(testOrganizations[0].EthAddress,
+			election.ProofType, electionRequest(election))
+		qt.Assert(t, err, qt.IsNil)
 		election.ElectionID = resp.ElectionID
 		election.OrganizationID = testOrganizations[0].EthAddress
 		election.CreationTxHash = resp.TxHash
 	}
 
 	// create election: check txHash has been mined
-	var respMined urlapi.APIMined
 	for _, election := range elections {
-		for numTries := 10; numTries > 0; numTries-- {
-			if numTries != 10 {
-				time.Sleep(time.Second * 4)
-			}
-			req := types.APIRequest{}
-			statusCode := DoRequest(t,
-				fmt.Sprintf("%s/v1/priv/transactions/%x", API.URL, election.CreationTxHash),
-				hex.EncodeToString(testIntegrators[0].SecretApiKey), "GET", req, &respMined)
-			qt.Assert(t, statusCode, qt.Equals, 200)
-			// if mined, break loop
-			if respMined.Mined != nil && *respMined.Mined {
-				break
-			}
-		}
-		qt.Assert(t, *respMined.Mined, qt.IsTrue)
+		qt.Assert(t, waitForTx(c, election.CreationTxHash, 40*time.Second), qt.IsNil)
 	}
 
 	// test get elections
 	for _, election := range elections {
 		var status string
 		numTries := 10
-		var electionResp types.APIElectionInfo
+		var electionResp *types.APIElectionInfo
 		for status != "ACTIVE" && numTries > 0 {
 			if status != "" {
 				time.Sleep(2 * time.Second)
 			}
-			statusCode := DoRequest(t,
-				fmt.Sprintf("%s/v1/priv/elections/%x", API.URL, election.ElectionID),
-				hex.EncodeToString(testIntegrators[0].SecretApiKey), "GET", types.APIRequest{}, &electionResp)
-			qt.Assert(t, statusCode, qt.Equals, 200)
+			var err error
+			electionResp, err = c.GetElection(election.ElectionID)
+			qt.Assert(t, err, qt.IsNil)
 			qt.Assert(t, electionResp.Description, qt.Equals, election.Description)
 			qt.Assert(t, electionResp.Title, qt.Equals, election.Title)
 			qt.Assert(t, electionResp.Header, qt.Equals, election.Header)
@@ -103,123 +74,59 @@ func TestElection(t *testing.T) {
 
 func TestElectionStatus(t *testing.T) {
 	t.Parallel()
+	c := integratorClient(testIntegrators[0])
 	// test set election status
 	for _, election := range testElections {
-		var resp *types.APIResponse
-		statusCode := DoRequest(t,
-			fmt.Sprintf("%s/v1/priv/elections/%x/CANCELED", API.URL, election.ElectionID),
-			hex.EncodeToString(testIntegrators[0].SecretApiKey), "PUT", types.APIRequest{}, &resp)
-		qt.Assert(t, statusCode, qt.Equals, 200)
-		election.CreationTxHash = resp.TxHash
+		txHash, err := c.SetElectionStatus(election.ElectionID, "CANCELED")
+		qt.Assert(t, err, qt.IsNil)
+		election.CreationTxHash = txHash
 	}
 
 	// set election status: check txHash has been mined
-	var respMined urlapi.APIMined
 	for _, election := range testElections {
-		for numTries := 10; numTries > 0; numTries-- {
-			if numTries != 10 {
-				time.Sleep(time.Second * 4)
-			}
-			req := types.APIRequest{}
-			statusCode := DoRequest(t,
-				fmt.Sprintf("%s/v1/priv/transactions/%x", API.URL, election.CreationTxHash),
-				hex.EncodeToString(testIntegrators[0].SecretApiKey), "GET", req, &respMined)
-			qt.Assert(t, statusCode, qt.Equals, 200)
-			// if mined, break loop
-			if respMined.Mined != nil && *respMined.Mined {
-				break
-			}
-		}
-		qt.Assert(t, respMined.Mined, qt.Not(qt.IsNil))
-		qt.Assert(t, *respMined.Mined, qt.IsTrue)
+		qt.Assert(t, waitForTx(c, election.CreationTxHash, 40*time.Second), qt.IsNil)
 	}
 
 	// get canceled election list
-	var canceledElectionList []types.APIElectionSummary
-	statusCode := DoRequest(t,
-		fmt.Sprintf("%s/v1/priv/organizations/%x/elections/canceled",
-			API.URL, testOrganizations[0].EthAddress),
-		hex.EncodeToString(testIntegrators[0].SecretApiKey),
-		"GET", types.APIRequest{}, &canceledElectionList)
-	qt.Assert(t, statusCode, qt.Equals, 200)
+	canceledElectionList, err := c.ListElections(testOrganizations[0].EthAddress, "canceled")
+	qt.Assert(t, err, qt.IsNil)
 	qt.Assert(t, canceledElectionList, qt.HasLen, len(testElections))
 
 	// test get election statuses
 	for _, election := range testElections {
-		var electionResp types.APIElectionInfo
-		statusCode := DoRequest(t,
-			fmt.Sprintf("%s/v1/priv/elections/%x", API.URL, election.ElectionID),
-			hex.EncodeToString(testIntegrators[0].SecretApiKey), "GET", types.APIRequest{}, &electionResp)
-		qt.Assert(t, statusCode, qt.Equals, 200)
+		electionResp, err := c.GetElection(election.ElectionID)
+		qt.Assert(t, err, qt.IsNil)
 		qt.Assert(t, electionResp.Status, qt.Equals, "CANCELED")
 	}
 }
 
 func TestElectionList(t *testing.T) {
 	t.Parallel()
+	c := integratorClient(testIntegrators[0])
+	organizationID := testOrganizations[1].EthAddress
 	// get election list
-	var electionList []types.APIElectionSummary
-	statusCode := DoRequest(t,
-		fmt.Sprintf("%s/v1/priv/organizations/%x/elections", API.URL, testOrganizations[1].EthAddress),
-		hex.EncodeToString(testIntegrators[0].SecretApiKey), "GET", types.APIRequest{}, &electionList)
-	qt.Assert(t, statusCode, qt.Equals, 200)
+	electionList, err := c.ListElections(organizationID, "")
+	qt.Assert(t, err, qt.IsNil)
 
 	// get active election list
-	var activeElectionList []types.APIElectionSummary
-	statusCode = DoRequest(t,
-		fmt.Sprintf("%s/v1/priv/organizations/%x/elections/active",
-			API.URL, testOrganizations[1].EthAddress),
-		hex.EncodeToString(testIntegrators[0].SecretApiKey), "GET", types.APIRequest{}, &activeElectionList)
-	qt.Assert(t, statusCode, qt.Equals, 200)
+	activeElectionList, err := c.ListElections(organizationID, "active")
+	qt.Assert(t, err, qt.IsNil)
 
 	// get election lists with empty filters
-	var emptyElectionList []types.APIElectionSummary
-	statusCode = DoRequest(t,
-		fmt.Sprintf("%s/v1/priv/organizations/%x/elections/upcoming",
-			API.URL, testOrganizations[1].EthAddress),
-		hex.EncodeToString(testIntegrators[0].SecretApiKey), "GET", types.APIRequest{}, &emptyElectionList)
-	qt.Assert(t, statusCode, qt.Equals, 200)
-	qt.Assert(t, emptyElectionList, qt.HasLen, 0)
-
-	statusCode = DoRequest(t,
-		fmt.Sprintf("%s/v1/priv/organizations/%x/elections/ended",
-			API.URL, testOrganizations[1].EthAddress),
-		hex.EncodeToString(testIntegrators[0].SecretApiKey), "GET", types.APIRequest{}, &emptyElectionList)
-	qt.Assert(t, statusCode, qt.Equals, 200)
-	qt.Assert(t, emptyElectionList, qt.HasLen, 0)
-
-	statusCode = DoRequest(t,
-		fmt.Sprintf("%s/v1/priv/organizations/%x/elections/canceled",
-			API.URL, testOrganizations[1].EthAddress),
-		hex.EncodeToString(testIntegrators[0].SecretApiKey), "GET", types.APIRequest{}, &emptyElectionList)
-	qt.Assert(t, statusCode, qt.Equals, 200)
-	qt.Assert(t, emptyElectionList, qt.HasLen, 0)
-
-	statusCode = DoRequest(t,
-		fmt.Sprintf("%s/v1/priv/organizations/%x/elections/paused",
-			API.URL, testOrganizations[1].EthAddress),
-		hex.EncodeToString(testIntegrators[0].SecretApiKey), "GET", types.APIRequest{}, &emptyElectionList)
-	qt.Assert(t, statusCode, qt.Equals, 200)
-	qt.Assert(t, emptyElectionList, qt.HasLen, 0)
+	for _, filter := range []string{"upcoming", "ended", "canceled", "paused"} {
+		emptyElectionList, err := c.ListElections(organizationID, filter)
+		qt.Assert(t, err, qt.IsNil)
+		qt.Assert(t, emptyElectionList, qt.HasLen, 0)
+	}
 
 	// get blind election list
-	var blindElectionList []types.APIElectionSummary
-	statusCode = DoRequest(t,
-		fmt.Sprintf("%s/v1/priv/organizations/%x/elections/blind",
-			API.URL, testOrganizations[1].EthAddress),
-		hex.EncodeToString(testIntegrators[0].SecretApiKey),
-		"GET", types.APIRequest{}, &blindElectionList)
-	qt.Assert(t, statusCode, qt.Equals, 200)
+	blindElectionList, err := c.ListElections(organizationID, "blind")
+	qt.Assert(t, err, qt.IsNil)
 	qt.Assert(t, blindElectionList, qt.HasLen, 1)
 
 	// get signed election list
-	var signedElectionList []types.APIElectionSummary
-	statusCode = DoRequest(t,
-		fmt.Sprintf("%s/v1/priv/organizations/%x/elections/signed",
-			API.URL, testOrganizations[1].EthAddress),
-		hex.EncodeToString(testIntegrators[0].SecretApiKey),
-		"GET", types.APIRequest{}, &signedElectionList)
-	qt.Assert(t, statusCode, qt.Equals, 200)
+	signedElectionList, err := c.ListElections(organizationID, "signed")
+	qt.Assert(t, err, qt.IsNil)
 	qt.Assert(t, signedElectionList, qt.HasLen, 2)
 
 	qt.Assert(t, electionList, qt.HasLen, len(testActiveElections))
@@ -3,10 +3,12 @@ package testapi
 import (
 	"bytes"
 	"encoding/hex"
-	"fmt"
+	"errors"
 	"testing"
 
 	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/client"
 	"go.vocdoni.io/api/test/testcommon"
 	"go.vocdoni.io/api/types"
 )
@@ -15,16 +17,13 @@ func TestIntegrator(t *testing.T) {
 	t.Parallel()
 	integrators := testcommon.CreateIntegrators(1)
 	// test integrator creation
-	req := types.APIRequest{
+	resp, err := API.Client.CreateIntegrator(types.CreateIntegratorRequest{
 		CspUrlPrefix: integrators[0].CspUrlPrefix,
 		CspPubKey:    hex.EncodeToString(integrators[0].CspPubKey),
 		Name:         integrators[0].Name,
 		Email:        integrators[0].Email,
-	}
-	var resp types.APIResponse
-	statusCode := DoRequest(t,
-		fmt.Sprintf("%s/v1/admin/accounts", API.URL), API.AuthToken, "POST", req, &resp)
-	qt.Assert(t, statusCode, qt.Equals, 200)
+	})
+	qt.Assert(t, err, qt.IsNil)
 	qt.Check(t, resp.ID, qt.Not(qt.Equals), 0)
 	qt.Check(t, resp.APIKey, qt.Not(qt.HasLen), 0)
 	integrators[0].ID = resp.ID
@@ -32,12 +31,8 @@ func TestIntegrator(t *testing.T) {
 
 	// test fetching integrators
 	for _, integrator := range integrators {
-		req := types.APIRequest{}
-		var resp types.APIResponse
-		statusCode := DoRequest(t,
-			fmt.Sprintf("%s/v1/admin/accounts/%d", API.URL, integrator.ID),
-			API.AuthToken, "GET", req, &resp)
-		qt.Assert(t, statusCode, qt.Equals, 200)
+		resp, err := API.Client.GetIntegrator(integrator.ID)
+		qt.Assert(t, err, qt.IsNil)
 		qt.Assert(t, resp.Name, qt.Equals, integrator.Name)
 		qt.Assert(t, bytes.Compare(resp.CspPubKey, integrator.CspPubKey), qt.Equals, 0)
 		qt.Assert(t, resp.CspUrlPrefix, qt.Equals, integrator.CspUrlPrefix)
@@ -45,12 +40,8 @@ func TestIntegrator(t *testing.T) {
 
 	// test resetting integrator api keys
 	for _, integrator := range integrators {
-		req := types.APIRequest{}
-		var resp types.APIResponse
-		statusCode := DoRequest(t,
-			fmt.Sprintf("%s/v1/admin/accounts/%d/key", API.URL, integrator.ID),
-			API.AuthToken, "PATCH", req, &resp)
-		qt.Assert(t, statusCode, qt.Equals, 200)
+		resp, err := API.Client.ResetIntegratorKey(integrator.ID)
+		qt.Assert(t, err, qt.IsNil)
 		qt.Assert(t, resp.ID, qt.Equals, integrator.ID)
 		qt.Assert(t, resp.APIKey, qt.Not(qt.Equals), string(integrator.SecretApiKey))
 		qt.Assert(t, resp.APIKey, qt.Not(qt.Equals), "")
@@ -59,62 +50,72 @@ func TestIntegrator(t *testing.T) {
 
 	// cleaning up
 	for _, integrator := range integrators {
-		var resp interface{}
-		statusCode := DoRequest(t,
-			fmt.Sprintf("%s/v1/admin/accounts/%d", API.URL, integrator.ID),
-			API.AuthToken, "DELETE", types.APIRequest{}, &resp)
-		qt.Assert(t, statusCode, qt.Equals, 200)
+		qt.Assert(t, API.Client.DeleteIntegrator(integrator.ID), qt.IsNil)
 	}
 
 	// test fetching integrators
 	for _, integrator := range integrators {
-		var resp interface{}
-		statusCode := DoRequest(t,
-			fmt.Sprintf("%s/v1/admin/accounts/%d", API.URL, integrator.ID),
-			API.AuthToken, "GET", types.APIRequest{}, &resp)
-		qt.Assert(t, statusCode, qt.Equals, 400)
+		_, err := API.Client.GetIntegrator(integrator.ID)
+		qt.Assert(t, client.StatusCode(err), qt.Equals, 404)
+		qt.Assert(t, errors.Is(err, apierror.ErrIntegratorNotFound), qt.IsTrue)
 	}
+
+	// deleted integrators can be restored, once
+	qt.Assert(t, API.Client.RestoreIntegrator(integrators[0].ID), qt.IsNil)
+	resp, err = API.Client.GetIntegrator(integrators[0].ID)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, resp.Name, qt.Equals, integrators[0].Name)
+	err = API.Client.RestoreIntegrator(integrators[0].ID)
+	qt.Assert(t, errors.Is(err, apierror.ErrIntegratorNotFound), qt.IsTrue)
+	qt.Assert(t, API.Client.DeleteIntegrator(integrators[0].ID), qt.IsNil)
+
+	// the admin operations on the account are audited, newest first
+	events, _, err := API.Client.ListAuditEvents(types.AuditFilter{IntegratorID: integrators[0].ID})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, events, qt.HasLen, 5)
+	qt.Assert(t, events[0].Action, qt.Equals, "integrator.delete")
+	qt.Assert(t, events[1].Action, qt.Equals, "integrator.restore")
+	qt.Assert(t, events[1].Outcome, qt.Equals, types.AuditOutcomeFailure)
+	qt.Assert(t, events[2].Action, qt.Equals, "integrator.restore")
+	qt.Assert(t, events[3].Action, qt.Equals, "integrator.delete")
+	qt.Assert(t, events[4].Action, qt.Equals, "integrator.resetKey")
+	events = append(events[:1], events[2:]...)
+	for _, event := range events {
+		qt.Assert(t, event.ActorType, qt.Equals, types.AuditActorAdmin)
+		qt.Assert(t, event.Outcome, qt.Equals, types.AuditOutcomeSuccess, qt.Commentf("%s", event.Action))
+		qt.Assert(t, event.RequestID, qt.Not(qt.Equals), "")
+	}
+	// filters are validated
+	err = API.Client.Request("GET", "/admin/audit?count=x", nil, nil)
+	qt.Assert(t, errors.Is(err, apierror.ErrInvalidFilter), qt.IsTrue)
 }
 
 func TestCreateIntegratorFail(t *testing.T) {
 	t.Parallel()
 	failIntegrators := testcommon.CreateIntegrators(1)
 	// test failure: invalid api auth token
-	req := types.APIRequest{
+	req := types.CreateIntegratorRequest{
 		CspUrlPrefix: API.CSP.UrlPrefix,
 		CspPubKey:    "zzz",
 		Name:         failIntegrators[0].Name,
 		Email:        failIntegrators[0].Email,
 	}
-	var resp interface{}
-	statusCode := DoRequest(t,
-		fmt.Sprintf("%s/v1/admin/accounts", API.URL), "1234", "POST", req, &resp)
-	qt.Assert(t, statusCode, qt.Equals, 401)
+	_, err := API.Client.WithToken("1234").CreateIntegrator(req)
+	qt.Assert(t, client.StatusCode(err), qt.Equals, 401)
 
 	// test failure: invalid pubKey
-	req = types.APIRequest{
-		CspUrlPrefix: API.CSP.UrlPrefix,
-		CspPubKey:    "zzz",
-		Name:         failIntegrators[0].Name,
-		Email:        failIntegrators[0].Email,
-	}
-	statusCode = DoRequest(t, fmt.Sprintf("%s/v1/admin/accounts", API.URL),
-		API.AuthToken, "POST", req, &resp)
-	qt.Assert(t, statusCode, qt.Equals, 400)
+	_, err = API.Client.CreateIntegrator(req)
+	qt.Assert(t, client.StatusCode(err), qt.Equals, 400)
 
 	// test failure: missing name, email
-	statusCode = DoRequest(t,
-		fmt.Sprintf("%s/v1/admin/accounts", API.URL),
-		API.AuthToken, "POST", types.APIRequest{}, &resp)
-	qt.Assert(t, statusCode, qt.Equals, 400)
+	_, err = API.Client.CreateIntegrator(types.CreateIntegratorRequest{})
+	qt.Assert(t, client.StatusCode(err), qt.Equals, 400)
+	qt.Assert(t, err.(*client.Error).Fields, qt.HasLen, 2)
 }
 
 func TestFetchIntegratorFail(t *testing.T) {
 	t.Parallel()
 	// test fetching nonexistent integrator
-	var resp interface{}
-	statusCode := DoRequest(t,
-		fmt.Sprintf("%s/v1/admin/accounts/222222222222", API.URL),
-		API.AuthToken, "GET", types.APIRequest{}, &resp)
-	qt.Assert(t, statusCode, qt.Equals, 400)
+	_, err := API.Client.GetIntegrator(222222222222)
+	qt.Assert(t, client.StatusCode(err), qt.Equals, 404)
 }
@@ -1,33 +1,28 @@
 package testapi
 
 import (
-	"encoding/hex"
-	"fmt"
 	"strings"
 	"testing"
 	"time"
 
 	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/api/client"
 	"go.vocdoni.io/api/test/testcommon"
 	"go.vocdoni.io/api/types"
-	"go.vocdoni.io/api/urlapi"
 )
 
 func TestOrganization(t *testing.T) {
 	t.Parallel()
+	c := integratorClient(testIntegrators[0])
 	// test create organization
 	organization := testcommon.CreateOrganizations(1)[0]
-	req := types.APIRequest{
+	resp, err := c.CreateOrganization(types.CreateOrganizationRequest{
 		Name:        organization.Name,
 		Description: organization.Description,
 		Header:      organization.HeaderURI,
 		Avatar:      organization.AvatarURI,
-	}
-	var resp types.APIResponse
-	statusCode := DoRequest(t,
-		fmt.Sprintf("%s/v1/priv/account/organizations", API.URL),
-		hex.EncodeToString(testIntegrators[0].SecretApiKey), "POST", req, &resp)
-	qt.Assert(t, statusCode, qt.Equals, 200)
+	})
+	qt.Assert(t, err, qt.IsNil)
 	qt.Check(t, resp.APIToken, qt.Not(qt.HasLen), 0)
 	qt.Check(t, resp.TxHash, qt.Not(qt.HasLen), 0)
 	organization.ID = resp.ID
@@ -37,28 +32,11 @@ func TestOrganization(t *testing.T) {
 	organization.CreationTxHash = resp.TxHash
 
 	// create organization: check txHash has been mined
-	var respMined urlapi.APIMined
-	for numTries := 5; numTries > 0; numTries-- {
-		if numTries != 5 {
-			time.Sleep(time.Second * 4)
-		}
-		req = types.APIRequest{}
-		statusCode = DoRequest(t,
-			fmt.Sprintf("%s/v1/priv/transactions/%x", API.URL, organization.CreationTxHash),
-			hex.EncodeToString(testIntegrators[0].SecretApiKey), "GET", req, &respMined)
-		qt.Assert(t, statusCode, qt.Equals, 200)
-		// if mined, break loop
-		if respMined.Mined != nil && *respMined.Mined {
-			break
-		}
-	}
-	qt.Assert(t, *respMined.Mined, qt.IsTrue)
+	qt.Assert(t, waitForTx(c, organization.CreationTxHash, 20*time.Second), qt.IsNil)
 
 	// now fetch the organization we created
-	statusCode = DoRequest(t,
-		fmt.Sprintf("%s/v1/priv/account/organizations/%x", API.URL, organization.EthAddress),
-		hex.EncodeToString(testIntegrators[0].SecretApiKey), "GET", types.APIRequest{}, &resp)
-	qt.Assert(t, statusCode, qt.Equals, 200)
+	resp, err = c.GetOrganization(organization.EthAddress)
+	qt.Assert(t, err, qt.IsNil)
 	qt.Assert(t, resp.APIToken, qt.Not(qt.HasLen), 0)
 	organization.APIToken = resp.APIToken
 	qt.Assert(t, resp.Name, qt.Equals, organization.Name)
@@ -67,57 +45,38 @@ func TestOrganization(t *testing.T) {
 	qt.Assert(t, resp.Header, qt.Equals, organization.HeaderURI)
 
 	// cleaning up
-	var emptyResp interface{}
-	statusCode = DoRequest(t,
-		fmt.Sprintf("%s/v1/priv/account/organizations/%x", API.URL, organization.EthAddress),
-		hex.EncodeToString(testIntegrators[0].SecretApiKey), "DELETE", types.APIRequest{}, &emptyResp)
-	qt.Assert(t, statusCode, qt.Equals, 200)
+	qt.Assert(t, c.DeleteOrganization(organization.EthAddress), qt.IsNil)
 
 	// fail get organization: should be deleted
-	statusCode = DoRequest(t,
-		fmt.Sprintf("%s/v1/priv/account/organizations/%x", API.URL, organization.EthAddress),
-		hex.EncodeToString(testIntegrators[0].SecretApiKey), "GET", types.APIRequest{}, &emptyResp)
-	qt.Assert(t, statusCode, qt.Equals, 400)
+	_, err = c.GetOrganization(organization.EthAddress)
+	qt.Assert(t, client.StatusCode(err), qt.Equals, 404)
 }
 
 func TestCreateOrganizationFailure(t *testing.T) {
 	t.Parallel()
 	organization := testcommon.CreateOrganizations(1)[0]
 	// create organization failure: missing integrator token
-	req := types.APIRequest{
+	req := types.CreateOrganizationRequest{
 		Name:        organization.Name,
 		Description: organization.Description,
 		Header:      organization.HeaderURI,
 		Avatar:      organization.AvatarURI,
 	}
-	var resp interface{}
-	statusCode := DoRequest(t,
-		fmt.Sprintf("%s/v1/priv/account/organizations", API.URL),
-		"1234", "POST", req, &resp)
-	qt.Assert(t, statusCode, qt.Equals, 401)
+	_, err := API.Client.WithToken("1234").CreateOrganization(req)
+	qt.Assert(t, client.StatusCode(err), qt.Equals, 401)
 
 	// create organization failure: empty name
-	req = types.APIRequest{
-		Description: organization.Description,
-		Header:      organization.HeaderURI,
-		Avatar:      organization.AvatarURI,
-	}
-	statusCode = DoRequest(t,
-		fmt.Sprintf("%s/v1/priv/account/organizations", API.URL),
-		hex.EncodeToString(testIntegrators[0].SecretApiKey), "POST", req, &resp)
-	qt.Assert(t, statusCode, qt.Equals, 400)
+	req.Name = ""
+	_, err = integratorClient(testIntegrators[0]).CreateOrganization(req)
+	qt.Assert(t, client.StatusCode(err), qt.Equals, 400)
 }
 
 func TestGetOrganizationList(t *testing.T) {
 	t.Parallel()
-	// fail get organization: bad id
-	var resp types.APIResponse
-	statusCode := DoRequest(t,
-		fmt.Sprintf("%s/v1/priv/account/organizations", API.URL),
-		hex.EncodeToString(testIntegrators[0].SecretApiKey), "GET", types.APIRequest{}, &resp)
-	qt.Assert(t, statusCode, qt.Equals, 200)
-	qt.Assert(t, resp.Organizations, qt.HasLen, len(testOrganizations))
-	for _, organization := range resp.Organizations {
+	organizations, err := integratorClient(testIntegrators[0]).ListOrganizations()
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, organizations, qt.HasLen, len(testOrganizations))
+	for _, organization := range organizations {
 		qt.Assert(t, strings.HasPrefix(organization.Name, "Test"), qt.IsTrue)
 	}
 }
@@ -125,27 +84,21 @@ func TestGetOrganizationList(t *testing.T) {
 func TestGetOrganizationFailure(t *testing.T) {
 	t.Parallel()
 	// fail get organization: bad id
-	var resp interface{}
-	statusCode := DoRequest(t,
-		fmt.Sprintf("%s/v1/priv/account/organizations/%s", API.URL, "1234"),
-		hex.EncodeToString(testIntegrators[0].SecretApiKey), "GET", types.APIRequest{}, &resp)
-	qt.Assert(t, statusCode, qt.Equals, 400)
+	_, err := integratorClient(testIntegrators[0]).GetOrganization([]byte{0x12, 0x34})
+	qt.Assert(t, client.StatusCode(err), qt.Equals, 404)
 
 	// fail get organization: bad api key
-	statusCode = DoRequest(t,
-		fmt.Sprintf("%s/v1/priv/account/organizations/%x", API.URL, testOrganizations[0].EthAddress),
-		hex.EncodeToString(testIntegrators[1].SecretApiKey), "GET", types.APIRequest{}, &resp)
-	qt.Assert(t, statusCode, qt.Equals, 400)
+	_, err = integratorClient(testIntegrators[1]).GetOrganization(
+		testOrganizations[0].EthAddress)
+	qt.Assert(t, client.StatusCode(err), qt.Equals, 404)
 }
 
 func TestResetAPIToken(t *testing.T) {
 	t.Parallel()
 	// reset the organization api token
-	var resp types.APIResponse
-	statusCode := DoRequest(t,
-		fmt.Sprintf("%s/v1/priv/account/organizations/%x/key", API.URL, testOrganizations[0].EthAddress),
-		hex.EncodeToString(testIntegrators[0].SecretApiKey), "PATCH", types.APIRequest{}, &resp)
-	qt.Assert(t, statusCode, qt.Equals, 200)
-	qt.Assert(t, resp.APIToken, qt.Not(qt.HasLen), 0)
-	qt.Assert(t, resp.APIToken, qt.Not(qt.Equals), testOrganizations[0].APIToken)
+	token, err := integratorClient(testIntegrators[0]).ResetOrganizationKey(
+		testOrganizations[0].EthAddress)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, token, qt.Not(qt.HasLen), 0)
+	qt.Assert(t, token, qt.Not(qt.Equals), testOrganizations[0].APIToken)
 }
@@ -1,9 +1,9 @@
 package testapi
 
 import (
-	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"testing"
@@ -11,6 +11,8 @@ import (
 
 	blind "github.com/arnaucube/go-blindsecp256k1"
 	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/client"
 	"go.vocdoni.io/api/test/testcommon"
 	"go.vocdoni.io/api/types"
 	"go.vocdoni.io/dvote/crypto/ethereum"
@@ -21,28 +23,26 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+// authReq is the body of the CSP requests
 type authReq struct {
 	AuthData  []string            `json:"authData,omitempty"`
 	TokenR    string              `json:"tokenR,omitempty"`
 	Signature dvotetypes.HexBytes `json:"signature,omitempty"`
 	Payload   dvotetypes.HexBytes `json:"payload,omitempty"`
-	Vote      string              `json:"vote,omitempty"`
-	Nullifier dvotetypes.HexBytes `json:"nullifier,omitempty"`
 }
 
 func TestGetElectionsPub(t *testing.T) {
 	t.Parallel()
+	c := API.Client.WithToken(testOrganizations[0].APIToken)
 	// test get elections (pub)
 	for _, election := range testElections {
-		var electionResp types.APIElectionInfo
-		statusCode := DoRequest(t,
-			fmt.Sprintf("%s/v1/pub/elections/%x", API.URL, election.ElectionID),
-			testOrganizations[0].APIToken, "GET", types.APIRequest{}, &electionResp)
+		electionResp, err := c.GetElectionPublic(election.ElectionID)
 		if election.Confidential {
-			qt.Assert(t, statusCode, qt.Equals, 400)
+			qt.Assert(t, client.StatusCode(err), qt.Equals, 403)
+			qt.Assert(t, errors.Is(err, apierror.ErrElectionConfidential), qt.IsTrue)
 			break
 		}
-		qt.Assert(t, statusCode, qt.Equals, 200)
+		qt.Assert(t, err, qt.IsNil)
 		qt.Assert(t, electionResp.Description, qt.Equals, election.Description)
 		qt.Assert(t, electionResp.Title, qt.Equals, election.Title)
 		qt.Assert(t, electionResp.Header, qt.Equals, election.Header)
@@ -66,15 +66,12 @@ func TestGetElectionsPub(t *testing.T) {
 
 func TestGetElectionsPriv(t *testing.T) {
 	t.Parallel()
+	c := API.Client.WithToken(testOrganizations[0].APIToken)
 	// test get elections (priv)
 	for _, election := range testElections {
-		cspSignature := fmt.Sprintf("%x",
-			testcommon.GetCSPSignature(t, election.ElectionID, API.CSP.CspSignKeys))
-		var electionResp types.APIElectionInfo
-		statusCode := DoRequest(t,
-			fmt.Sprintf("%s/v1/pub/elections/%x/auth/%s", API.URL, election.ElectionID, cspSignature),
-			testOrganizations[0].APIToken, "GET", types.APIRequest{}, &electionResp)
-		qt.Assert(t, statusCode, qt.Equals, 200)
+		cspSignature := testcommon.GetCSPSignature(t, election.ElectionID, API.CSP.CspSignKeys)
+		electionResp, err := c.GetElectionConfidential(election.ElectionID, cspSignature)
+		qt.Assert(t, err, qt.IsNil)
 		qt.Assert(t, electionResp.Description, qt.Equals, election.Description)
 		qt.Assert(t, electionResp.Title, qt.Equals, election.Title)
 		qt.Assert(t, electionResp.Header, qt.Equals, election.Header)
@@ -114,18 +111,17 @@ func TestVote(t *testing.T) {
 		testOrganizations[1].APIToken)
 }
 
-func verifyNullifier(t *testing.T, nullifier, processID dvotetypes.HexBytes, orgAPIToken string) {
-	var statusCode int
-	var resp types.APIResponse
+func verifyNullifier(t *testing.T, nullifier string, processID dvotetypes.HexBytes,
+	orgAPIToken string) {
+	var resp *types.APIResponse
+	var err error
 	for i := 0; i < 10; i++ {
 		if i > 0 {
 			// sleep total of 30 seconds for vote to be confirmed
 			time.Sleep(time.Second * 3)
 		}
-		statusCode = DoRequest(t,
-			fmt.Sprintf("%s/v1/pub/nullifiers/%x", API.URL, nullifier),
-			orgAPIToken, "GET", types.APIRequest{}, &resp)
-		qt.Assert(t, statusCode, qt.Equals, 200)
+		resp, err = API.Client.WithToken(orgAPIToken).GetVote(nullifier)
+		qt.Assert(t, err, qt.IsNil)
 		// if vote is confirmed, break loop
 		if resp.Registered != nil && *resp.Registered {
 			break
@@ -136,7 +132,7 @@ func verifyNullifier(t *testing.T, nullifier, processID dvotetypes.HexBytes, org
 }
 
 func submitVoteSigned(t *testing.T, processID []byte,
-	cspSignKeys *ethereum.SignKeys, orgAPIToken string) dvotetypes.HexBytes {
+	cspSignKeys *ethereum.SignKeys, orgAPIToken string) string {
 
 	voterWallet := ethereum.NewSignKeys()
 	err := voterWallet.Generate()
@@ -212,18 +208,15 @@ func submitVoteSigned(t *testing.T, processID []byte,
 	if err != nil {
 		t.Fatal(err)
 	}
-	req = authReq{Vote: base64.StdEncoding.EncodeToString(signedVoteTxBytes)}
-	statusCode = DoRequest(t,
-		fmt.Sprintf("%s/v1/pub/elections/%x/vote", API.URL, processID),
-		orgAPIToken, "POST", req, &aResp)
-	qt.Assert(t, statusCode, qt.Equals, 200)
-	t.Logf("submitted vote with nullifier %x", aResp.Nullifier)
-	qt.Assert(t, aResp.Nullifier, qt.Not(qt.HasLen), 0)
-	return aResp.Nullifier
+	nullifier, err := API.Client.WithToken(orgAPIToken).SubmitVote(processID, signedVoteTxBytes)
+	qt.Assert(t, err, qt.IsNil)
+	t.Logf("submitted vote with nullifier %s", nullifier)
+	qt.Assert(t, nullifier, qt.Not(qt.HasLen), 0)
+	return nullifier
 }
 
 func submitVoteBlind(t *testing.T, processID []byte,
-	cspSignKeys *ethereum.SignKeys, orgAPIToken string) dvotetypes.HexBytes {
+	cspSignKeys *ethereum.SignKeys, orgAPIToken string) string {
 	voterWallet := ethereum.NewSignKeys()
 	err := voterWallet.Generate()
 	if err != nil {
@@ -317,12 +310,9 @@ func submitVoteBlind(t *testing.T, processID []byte,
 	if err != nil {
 		t.Fatal(err)
 	}
-	req = authReq{Vote: base64.StdEncoding.EncodeToString(signedVoteTxBytes)}
-	statusCode = DoRequest(t,
-		fmt.Sprintf("%s/v1/pub/elections/%x/vote", API.URL, processID),
-		orgAPIToken, "POST", req, &aResp)
-	qt.Assert(t, statusCode, qt.Equals, 200)
-	t.Logf("submitted vote with nullifier %x", aResp.Nullifier)
-	qt.Assert(t, aResp.Nullifier, qt.Not(qt.HasLen), 0)
-	return aResp.Nullifier
+	nullifier, err := API.Client.WithToken(orgAPIToken).SubmitVote(processID, signedVoteTxBytes)
+	qt.Assert(t, err, qt.IsNil)
+	t.Logf("submitted vote with nullifier %s", nullifier)
+	qt.Assert(t, nullifier, qt.Not(qt.HasLen), 0)
+	return nullifier
 }
@@ -2,9 +2,9 @@ package testapi
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/json"
-	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -14,10 +14,10 @@ import (
 	"time"
 
 	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/api/client"
 	"go.vocdoni.io/api/config"
 	"go.vocdoni.io/api/test/testcommon"
 	"go.vocdoni.io/api/types"
-	"go.vocdoni.io/api/urlapi"
 	"go.vocdoni.io/dvote/log"
 )
 
@@ -69,6 +69,8 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
+// DoRequest sends a raw JSON request. It is used for the CSP endpoints, the VaaS API
+//  is reached through API.Client
 func DoRequest(t *testing.T, url, authToken,
 	method string, request interface{}, response interface{}) int {
 	data, err := json.Marshal(request)
@@ -138,27 +140,34 @@ func setupFaucetAccount() {
 	}
 }
 
+// integratorClient returns an API client authenticated with the integrator's key
+func integratorClient(integrator *types.Integrator) *client.Client {
+	return API.Client.WithToken(hex.EncodeToString(integrator.SecretApiKey))
+}
+
+// waitForTx waits up to timeout for the transaction to be mined
+func waitForTx(c *client.Client, txHash []byte, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return c.WaitForTx(ctx, txHash)
+}
+
 func setupTestIntegrators() {
 	log.Infof("setting up test integrators")
 	testIntegrators = testcommon.CreateIntegrators(2)
 	cspPubKey := hex.EncodeToString(API.CSP.CspPubKey)
 	// create two integrators to test with
 	for _, integrator := range testIntegrators {
-		req := types.APIRequest{
+		resp, err := API.Client.CreateIntegrator(types.CreateIntegratorRequest{
 			CspUrlPrefix: integrator.CspUrlPrefix,
 			CspPubKey:    cspPubKey,
 			Name:         integrator.Name,
 			Email:        integrator.Email,
-		}
-		var resp types.APIResponse
-		statusCode := DoRequest(nil,
-			fmt.Sprintf("%s/v1/admin/accounts", API.URL),
-			API.AuthToken, "POST", req, &resp)
-		if statusCode != 200 {
-			log.Fatalf("could not create testing integrator")
+		})
+		if err != nil {
+			log.Fatalf("could not create testing integrator: %v", err)
 		}
 		integrator.ID = resp.ID
-		var err error
 		if integrator.SecretApiKey, err = hex.DecodeString(resp.APIKey); err != nil {
 			log.Fatal(err)
 		}
@@ -168,20 +177,17 @@ func setupTestIntegrators() {
 func setupTestOrganizations() {
 	log.Infof("setting up test organizations")
 	testOrganizations = testcommon.CreateOrganizations(2)
+	c := integratorClient(testIntegrators[0])
 	// create two integrators to test with
 	for _, organization := range testOrganizations {
-		req := types.APIRequest{
+		resp, err := c.CreateOrganization(types.CreateOrganizationRequest{
 			Name:        organization.Name,
 			Description: organization.Description,
 			Header:      organization.HeaderURI,
 			Avatar:      organization.AvatarURI,
-		}
-		var resp types.APIResponse
-		statusCode := DoRequest(nil,
-			fmt.Sprintf("%s/v1/priv/account/organizations", API.URL),
-			hex.EncodeToString(testIntegrators[0].SecretApiKey), "POST", req, &resp)
-		if statusCode != 200 {
-			log.Fatalf("could not create testing organization")
+		})
+		if err != nil {
+			log.Fatalf("could not create testing organization: %v", err)
 		}
 		organization.ID = resp.ID
 		organization.EthAddress = resp.OrganizationID
@@ -189,25 +195,8 @@ func setupTestOrganizations() {
 		organization.CreationTxHash = resp.TxHash
 
 		// create organization: check txHash has been mined
-		var respMined urlapi.APIMined
-		for numTries := 5; numTries > 0; numTries-- {
-			if numTries != 5 {
-				time.Sleep(time.Second * 4)
-			}
-			req = types.APIRequest{}
-			statusCode = DoRequest(nil,
-				fmt.Sprintf("%s/v1/priv/transactions/%x", API.URL, organization.CreationTxHash),
-				hex.EncodeToString(testIntegrators[0].SecretApiKey), "GET", req, &respMined)
-			if statusCode != 200 {
-				log.Fatalf("could not create testing organization")
-			}
-			// if mined, break loop
-			if respMined.Mined != nil && *respMined.Mined {
-				break
-			}
-		}
-		if respMined.Mined == nil || !*respMined.Mined {
-			log.Fatalf("could not create testing organization: tx never mined")
+		if err := waitForTx(c, organization.CreationTxHash, 20*time.Second); err != nil {
+			log.Fatalf("could not create testing organization: %v", err)
 		}
 	}
 }
@@ -224,27 +213,29 @@ func setupTestElections() {
 	checkElectionsMined(testActiveElections)
 }
 
+// electionRequest returns the request creating the given test election
+func electionRequest(election *testcommon.TestElection) types.CreateElectionRequest {
+	return types.CreateElectionRequest{
+		Title:         election.Title,
+		Description:   election.Description,
+		Header:        election.Header,
+		StreamURI:     election.StreamURI,
+		EndDate:       election.EndDate.Format(types.DateLayout),
+		Confidential:  election.Confidential,
+		HiddenResults: election.HiddenResults,
+		Questions:     election.Questions,
+	}
+}
+
 func createElections(organization *testcommon.TestOrganization) []*testcommon.TestElection {
 	elections := testcommon.CreateElections(1, false, false, types.PROOF_TYPE_BLIND)
 	elections = append(elections, testcommon.CreateElections(1, true, false, types.PROOF_TYPE_ECDSA)...)
 	elections = append(elections, testcommon.CreateElections(1, true, true, types.PROOF_TYPE_ECDSA)...)
 	for _, election := range elections {
-		var resp types.APIResponse
-		req := types.APIRequest{
-			Title:         election.Title,
-			Description:   election.Description,
-			Header:        election.Header,
-			StreamURI:     election.StreamURI,
-			EndDate:       election.EndDate.Format("2006-01-02T15:04:05.000Z"),
-			Confidential:  election.Confidential,
-			HiddenResults: election.HiddenResults,
-			Questions:     election.Questions,
-		}
-		statusCode := DoRequest(nil,
-			fmt.Sprintf("%s/v1/priv/organizations/%x/elections/%s", API.URL, organization.EthAddress, election.ProofType),
-			hex.EncodeToString(testIntegrators[0].SecretApiKey), "POST", req, &resp)
-		if statusCode != 200 {
-			log.Fatalf("could not create testing organization")
+		resp, err := integratorClient(testIntegrators[0]).CreateElection(
+			organization.EthAddress, election.ProofType, electionRequest(election))
+		if err != nil {
+			log.Fatalf("could not create testing election: %v", err)
 		}
 		election.ElectionID = resp.ElectionID
 		election.OrganizationID = organization.EthAddress
@@ -254,26 +245,10 @@ func createElections(organization *testcommon.TestOrganization) []*testcommon.Te
 }
 
 func checkElectionsMined(elections []*testcommon.TestElection) {
-	var respMined urlapi.APIMined
 	for _, election := range elections {
-		for numTries := 10; numTries > 0; numTries-- {
-			if numTries != 10 {
-				time.Sleep(time.Second * 4)
-			}
-			req := types.APIRequest{}
-			statusCode := DoRequest(nil,
-				fmt.Sprintf("%s/v1/priv/transactions/%x", API.URL, election.CreationTxHash),
-				hex.EncodeToString(testIntegrators[0].SecretApiKey), "GET", req, &respMined)
-			if statusCode != 200 {
-				log.Fatalf("could not create testing organization")
-			}
-			// if mined, break loop
-			if respMined.Mined != nil && *respMined.Mined {
-				break
-			}
-		}
-		if respMined.Mined == nil || !*respMined.Mined {
-			log.Fatalf("could not create testing organization: tx never mined")
+		if err := waitForTx(integratorClient(testIntegrators[0]),
+			election.CreationTxHash, 40*time.Second); err != nil {
+			log.Fatalf("could not create testing election: %v", err)
 		}
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"go.vocdoni.io/api/client"
 	"go.vocdoni.io/api/config"
 	"go.vocdoni.io/api/database"
 	"go.vocdoni.io/api/database/pgsql"
@@ -27,6 +28,7 @@ type TestAPI struct {
 	FaucetAccount *ethereum.SignKeys
 	URL           string
 	AuthToken     string
+	Client        *client.Client
 	CSP           TestCSP
 	Gateway       string
 	Vocclient     *vocclient.Client
@@ -116,6 +118,7 @@ func (t *TestAPI) Start(dbc *config.DB, route, authToken, storageDir string, por
 		go integratorTokenNotifier.FetchNewTokens(urlApi)
 		t.URL = fmt.Sprintf("http://%s:%d%s", TestHost, port, route)
 		t.AuthToken = authToken
+		t.Client = client.New(t.URL+"/v1", authToken)
 		time.Sleep(time.Second)
 	}
 	return nil
@@ -0,0 +1,75 @@
+package testpgsql
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+)
+
+func TestAuditEvents(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+	// a random integrator id keeps the test independent from other audit events
+	integratorID := 100000 + rand.Intn(100000)
+	start := time.Now().Add(-time.Second)
+	for _, outcome := range []string{types.AuditOutcomeSuccess, types.AuditOutcomeFailure} {
+		id, err := API.DB.CreateAuditEvent(&types.AuditEvent{
+			ActorType:    types.AuditActorIntegrator,
+			ActorID:      "1",
+			IntegratorID: integratorID,
+			Action:       "organization.create",
+			Target:       "/priv/account/organizations",
+			RequestID:    "request",
+			Outcome:      outcome,
+			HTTPStatus:   200,
+		})
+		c.Assert(err, qt.IsNil)
+		c.Assert(id, qt.Not(qt.Equals), int64(0))
+	}
+
+	events, next, err := API.DB.ListAuditEvents(&types.AuditFilter{IntegratorID: integratorID})
+	c.Assert(err, qt.IsNil)
+	c.Assert(events, qt.HasLen, 2)
+	c.Assert(next, qt.Equals, "")
+	// newest first
+	c.Assert(events[0].Outcome, qt.Equals, types.AuditOutcomeFailure)
+	c.Assert(events[0].Action, qt.Equals, "organization.create")
+	c.Assert(events[0].CreatedAt.After(start), qt.IsTrue)
+
+	events, _, err = API.DB.ListAuditEvents(&types.AuditFilter{IntegratorID: integratorID,
+		Outcome: types.AuditOutcomeSuccess})
+	c.Assert(err, qt.IsNil)
+	c.Assert(events, qt.HasLen, 1)
+
+	events, _, err = API.DB.ListAuditEvents(&types.AuditFilter{IntegratorID: integratorID,
+		From: time.Now().Add(time.Hour)})
+	c.Assert(err, qt.IsNil)
+	c.Assert(events, qt.HasLen, 0)
+
+	events, _, err = API.DB.ListAuditEvents(&types.AuditFilter{IntegratorID: integratorID,
+		Count: 1, Skip: 1})
+	c.Assert(err, qt.IsNil)
+	c.Assert(events, qt.HasLen, 1)
+	c.Assert(events[0].Outcome, qt.Equals, types.AuditOutcomeSuccess)
+
+	// pages follow the cursor of the previous one
+	events, next, err = API.DB.ListAuditEvents(&types.AuditFilter{IntegratorID: integratorID,
+		Count: 1})
+	c.Assert(err, qt.IsNil)
+	c.Assert(events, qt.HasLen, 1)
+	c.Assert(events[0].Outcome, qt.Equals, types.AuditOutcomeFailure)
+	c.Assert(next, qt.Not(qt.Equals), "")
+	events, next, err = API.DB.ListAuditEvents(&types.AuditFilter{IntegratorID: integratorID,
+		Count: 1, Cursor: next})
+	c.Assert(err, qt.IsNil)
+	c.Assert(events, qt.HasLen, 1)
+	c.Assert(events[0].Outcome, qt.Equals, types.AuditOutcomeSuccess)
+	c.Assert(next, qt.Equals, "")
+	_, _, err = API.DB.ListAuditEvents(&types.AuditFilter{Cursor: "invalid"})
+	c.Assert(errors.Is(err, apierror.ErrInvalidFilter), qt.IsTrue)
+}
@@ -0,0 +1,43 @@
+package testpgsql
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/api/types"
+)
+
+func TestBlockSamples(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+	// samples of blocks produced long ago, so the samples of other runs are not listed
+	start := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC).
+		Add(time.Duration(rand.Intn(100000)) * time.Hour)
+	height := uint32(rand.Intn(1000000)) * 1000
+	for i := 0; i < 3; i++ {
+		c.Assert(API.DB.AddBlockSample(&types.BlockSample{Height: height + uint32(i)*360,
+			Timestamp: start.Add(time.Duration(i) * time.Hour)}), qt.IsNil)
+	}
+	// blocks already sampled keep their first sample
+	c.Assert(API.DB.AddBlockSample(&types.BlockSample{Height: height,
+		Timestamp: start.Add(time.Minute)}), qt.IsNil)
+
+	samples, err := API.DB.ListBlockSamples(start)
+	c.Assert(err, qt.IsNil)
+	c.Assert(len(samples) >= 3, qt.IsTrue)
+	c.Assert(samples[0].Height, qt.Equals, height)
+	c.Assert(samples[0].Timestamp.Equal(start), qt.IsTrue)
+	c.Assert(samples[1].Height, qt.Equals, height+360)
+	samples, err = API.DB.ListBlockSamples(start.Add(time.Hour))
+	c.Assert(err, qt.IsNil)
+	c.Assert(samples[0].Height, qt.Equals, height+360)
+
+	deleted, err := API.DB.DeleteBlockSamples(start.Add(time.Hour))
+	c.Assert(err, qt.IsNil)
+	c.Assert(deleted >= 1, qt.IsTrue)
+	samples, err = API.DB.ListBlockSamples(start)
+	c.Assert(err, qt.IsNil)
+	c.Assert(samples[0].Height, qt.Equals, height+360)
+}
@@ -0,0 +1,126 @@
+package testpgsql
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/google/uuid"
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/test/testcommon"
+	"go.vocdoni.io/api/types"
+)
+
+func TestChainSync(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+	integrators := testcommon.CreateIntegrators(1)
+	var err error
+	integrators[0].SecretApiKey = []byte(fmt.Sprintf("key%d", rand.Intn(10000)))
+	integrators[0].ID, err = API.DB.CreateIntegrator(integrators[0].SecretApiKey, integrators[0].CspPubKey,
+		integrators[0].CspUrlPrefix, integrators[0].Name, integrators[0].Email)
+	c.Assert(err, qt.IsNil)
+	organizations := testcommon.CreateDbOrganizations(1)
+	_, err = API.DB.CreateOrganization(integrators[0].SecretApiKey, organizations[0].EthAddress,
+		organizations[0].EthPrivKeyCipher, organizations[0].QuotaPlanID, organizations[0].PublicAPIQuota,
+		organizations[0].PublicAPIToken, organizations[0].HeaderURI, organizations[0].AvatarURI)
+	c.Assert(err, qt.IsNil)
+	elections := testcommon.CreateDbElections(t, 1)
+	_, err = API.DB.CreateElection(integrators[0].SecretApiKey, organizations[0].EthAddress, elections[0].ProcessID,
+		elections[0].MetadataPrivKey, elections[0].Title, string(types.PROOF_TYPE_ECDSA), elections[0].StartDate,
+		elections[0].EndDate, uuid.NullUUID{}, 10, 20, false, false)
+	c.Assert(err, qt.IsNil)
+
+	// new elections and organizations are synced first
+	syncStart := time.Now()
+	toSync, err := API.DB.ListElectionsToSync(syncStart, 1000)
+	c.Assert(err, qt.IsNil)
+	c.Assert(containsElection(toSync, elections[0].ProcessID), qt.IsTrue)
+	orgsToSync, err := API.DB.ListOrganizationsToSync(syncStart, 1000)
+	c.Assert(err, qt.IsNil)
+	c.Assert(containsOrganization(orgsToSync, organizations[0].EthAddress), qt.IsTrue)
+
+	election := &types.Election{ProcessID: elections[0].ProcessID,
+		Status: types.ElectionStatusEnded, StartBlock: 10, EndBlock: 15, VoteCount: 3,
+		HasResults: true}
+	events := []types.ChainEvent{
+		{OrgEthAddress: organizations[0].EthAddress, ProcessID: elections[0].ProcessID,
+			Kind: types.ChainEventStatus, OldValue: "READY", NewValue: "ENDED"},
+		{OrgEthAddress: organizations[0].EthAddress, ProcessID: elections[0].ProcessID,
+			Kind: types.ChainEventEndBlock, OldValue: "20", NewValue: "15"},
+	}
+	c.Assert(API.DB.SyncElection(election, events), qt.IsNil)
+	stored, err := API.DB.GetElection(integrators[0].SecretApiKey, organizations[0].EthAddress,
+		elections[0].ProcessID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(stored.Status, qt.Equals, types.ElectionStatusEnded)
+	c.Assert(stored.EndBlock, qt.Equals, 15)
+	c.Assert(stored.VoteCount, qt.Equals, 3)
+	c.Assert(stored.HasResults, qt.IsTrue)
+	c.Assert(stored.MissingSince, qt.IsNil)
+	toSync, err = API.DB.ListElectionsToSync(syncStart, 1000)
+	c.Assert(err, qt.IsNil)
+	c.Assert(containsElection(toSync, elections[0].ProcessID), qt.IsFalse)
+	err = API.DB.SyncElection(&types.Election{ProcessID: []byte("missing")}, nil)
+	c.Assert(errors.Is(err, apierror.ErrElectionNotFound), qt.IsTrue)
+
+	// organizations missing on the vochain are recorded as drift
+	missingSince := time.Now()
+	organization := &types.Organization{EthAddress: organizations[0].EthAddress,
+		MissingSince: &missingSince}
+	c.Assert(API.DB.SyncOrganization(organization, []types.ChainEvent{
+		{OrgEthAddress: organizations[0].EthAddress, Kind: types.ChainEventMissing,
+			NewValue: missingSince.UTC().Format(time.RFC3339)},
+	}), qt.IsNil)
+	org, err := API.DB.GetOrganization(integrators[0].SecretApiKey, organizations[0].EthAddress)
+	c.Assert(err, qt.IsNil)
+	c.Assert(org.MissingSince, qt.Not(qt.IsNil))
+	orgsToSync, err = API.DB.ListOrganizationsToSync(syncStart, 1000)
+	c.Assert(err, qt.IsNil)
+	c.Assert(containsOrganization(orgsToSync, organizations[0].EthAddress), qt.IsFalse)
+
+	// events are listed newest first, by organization, election and kind
+	list, next, err := API.DB.ListChainEvents(&types.ChainEventFilter{
+		OrgEthAddress: organizations[0].EthAddress})
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.HasLen, 3)
+	c.Assert(next, qt.Equals, "")
+	c.Assert(list[0].Kind, qt.Equals, types.ChainEventMissing)
+	c.Assert(list[0].ProcessID, qt.HasLen, 0)
+	list, _, err = API.DB.ListChainEvents(&types.ChainEventFilter{
+		ProcessID: elections[0].ProcessID, Kind: types.ChainEventEndBlock})
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.HasLen, 1)
+	c.Assert(list[0].OldValue, qt.Equals, "20")
+	c.Assert(list[0].NewValue, qt.Equals, "15")
+	list, next, err = API.DB.ListChainEvents(&types.ChainEventFilter{
+		OrgEthAddress: organizations[0].EthAddress, Count: 2})
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.HasLen, 2)
+	list, next, err = API.DB.ListChainEvents(&types.ChainEventFilter{
+		OrgEthAddress: organizations[0].EthAddress, Count: 2, Cursor: next})
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.HasLen, 1)
+	c.Assert(next, qt.Equals, "")
+}
+
+func containsElection(elections []types.Election, processID []byte) bool {
+	for _, election := range elections {
+		if string(election.ProcessID) == string(processID) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsOrganization(organizations []types.Organization, ethAddress []byte) bool {
+	for _, organization := range organizations {
+		if string(organization.EthAddress) == string(ethAddress) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+package testpgsql
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/google/uuid"
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/test/testcommon"
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/dvote/util"
+)
+
+func TestCspKeys(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+	integrators := testcommon.CreateIntegrators(1)
+	var err error
+	integrators[0].SecretApiKey = []byte(fmt.Sprintf("key%d", rand.Intn(10000)))
+	integrators[0].ID, err = API.DB.CreateIntegrator(integrators[0].SecretApiKey, integrators[0].CspPubKey,
+		integrators[0].CspUrlPrefix, integrators[0].Name, integrators[0].Email)
+	c.Assert(err, qt.IsNil)
+
+	_, err = API.DB.GetCspKey(integrators[0].ID, nil)
+	c.Assert(errors.Is(err, apierror.ErrCspKeyNotFound), qt.IsTrue)
+	key := &types.CspKey{IntegratorID: integrators[0].ID, PubKey: util.RandomBytes(33),
+		PrivKeyCipher: util.RandomBytes(32)}
+	_, err = API.DB.CreateCspKey(key)
+	c.Assert(err, qt.IsNil)
+	orgKey := &types.CspKey{IntegratorID: integrators[0].ID, OrgEthAddress: util.RandomBytes(20),
+		PubKey: util.RandomBytes(33), PrivKeyCipher: util.RandomBytes(32)}
+	_, err = API.DB.CreateCspKey(orgKey)
+	c.Assert(err, qt.IsNil)
+
+	stored, err := API.DB.GetCspKey(integrators[0].ID, nil)
+	c.Assert(err, qt.IsNil)
+	c.Assert(stored.PubKey, qt.DeepEquals, key.PubKey)
+	c.Assert(stored.PrivKeyCipher, qt.DeepEquals, key.PrivKeyCipher)
+	c.Assert(stored.OrgEthAddress, qt.HasLen, 0)
+	stored, err = API.DB.GetCspKeyByPubKey(orgKey.PubKey)
+	c.Assert(err, qt.IsNil)
+	c.Assert(stored.OrgEthAddress, qt.DeepEquals, orgKey.OrgEthAddress)
+
+	// keys are never replaced
+	_, err = API.DB.CreateCspKey(&types.CspKey{IntegratorID: integrators[0].ID,
+		PubKey: util.RandomBytes(33), PrivKeyCipher: util.RandomBytes(32)})
+	c.Assert(errors.Is(err, apierror.ErrAlreadyExists), qt.IsTrue)
+}
+
+func TestCspRequests(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+	integrators := testcommon.CreateIntegrators(1)
+	var err error
+	integrators[0].SecretApiKey = []byte(fmt.Sprintf("key%d", rand.Intn(10000)))
+	integrators[0].ID, err = API.DB.CreateIntegrator(integrators[0].SecretApiKey, integrators[0].CspPubKey,
+		integrators[0].CspUrlPrefix, integrators[0].Name, integrators[0].Email)
+	c.Assert(err, qt.IsNil)
+	organizations := testcommon.CreateDbOrganizations(1)
+	_, err = API.DB.CreateOrganization(integrators[0].SecretApiKey, organizations[0].EthAddress,
+		organizations[0].EthPrivKeyCipher, organizations[0].QuotaPlanID, organizations[0].PublicAPIQuota,
+		organizations[0].PublicAPIToken, organizations[0].HeaderURI, organizations[0].AvatarURI)
+	c.Assert(err, qt.IsNil)
+	elections := testcommon.CreateDbElections(t, 1)
+	_, err = API.DB.CreateElection(integrators[0].SecretApiKey, organizations[0].EthAddress, elections[0].ProcessID,
+		elections[0].MetadataPrivKey, elections[0].Title, string(types.PROOF_TYPE_BLIND), elections[0].StartDate,
+		elections[0].EndDate, uuid.NullUUID{}, 10, 20, false, false)
+	c.Assert(err, qt.IsNil)
+	electionID := elections[0].ProcessID
+
+	request := &types.CspRequest{Token: util.RandomBytes(65), ElectionID: electionID,
+		Voter: "voter", SignType: "blind", SecretKCipher: []byte{1}}
+	c.Assert(API.DB.CreateCspRequest(request), qt.IsNil)
+	// a new request of the voter replaces the unused one
+	replaced := request.Token
+	request.Token = util.RandomBytes(65)
+	request.SecretKCipher = []byte{2}
+	c.Assert(API.DB.CreateCspRequest(request), qt.IsNil)
+	err = API.DB.UseCspRequest(electionID, replaced, "blind",
+		func(*types.CspRequest) error { return nil })
+	c.Assert(errors.Is(err, apierror.ErrCspRequestNotFound), qt.IsTrue)
+
+	// a failed signature keeps the request unused
+	err = API.DB.UseCspRequest(electionID, request.Token, "blind",
+		func(*types.CspRequest) error { return apierror.ErrCrypto })
+	c.Assert(errors.Is(err, apierror.ErrCrypto), qt.IsTrue)
+	err = API.DB.UseCspRequest(electionID, request.Token, "ecdsa",
+		func(*types.CspRequest) error { return nil })
+	c.Assert(errors.Is(err, apierror.ErrCspRequestNotFound), qt.IsTrue)
+	var secretK []byte
+	c.Assert(API.DB.UseCspRequest(electionID, request.Token, "blind",
+		func(r *types.CspRequest) error {
+			secretK = r.SecretKCipher
+			return nil
+		}), qt.IsNil)
+	c.Assert(secretK, qt.DeepEquals, []byte{2})
+
+	// tokens sign once, and voters get a single signature
+	err = API.DB.UseCspRequest(electionID, request.Token, "blind",
+		func(*types.CspRequest) error { return nil })
+	c.Assert(errors.Is(err, apierror.ErrCspRequestNotFound), qt.IsTrue)
+	request.Token = util.RandomBytes(65)
+	err = API.DB.CreateCspRequest(request)
+	c.Assert(errors.Is(err, apierror.ErrAlreadyExists), qt.IsTrue)
+
+	member, err := API.DB.IsCensusMember(uuid.New(), util.RandomBytes(33))
+	c.Assert(err, qt.IsNil)
+	c.Assert(member, qt.IsFalse)
+}
+
+func TestCspVoters(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+	integrators := testcommon.CreateIntegrators(1)
+	var err error
+	integrators[0].SecretApiKey = []byte(fmt.Sprintf("key%d", rand.Intn(10000)))
+	integrators[0].ID, err = API.DB.CreateIntegrator(integrators[0].SecretApiKey, integrators[0].CspPubKey,
+		integrators[0].CspUrlPrefix, integrators[0].Name, integrators[0].Email)
+	c.Assert(err, qt.IsNil)
+	organizations := testcommon.CreateDbOrganizations(1)
+	_, err = API.DB.CreateOrganization(integrators[0].SecretApiKey, organizations[0].EthAddress,
+		organizations[0].EthPrivKeyCipher, organizations[0].QuotaPlanID, organizations[0].PublicAPIQuota,
+		organizations[0].PublicAPIToken, organizations[0].HeaderURI, organizations[0].AvatarURI)
+	c.Assert(err, qt.IsNil)
+	elections := testcommon.CreateDbElections(t, 1)
+	// the csp auth is stored along with the election
+	_, err = API.DB.StoreElection(&types.Election{IntegratorApiKey: integrators[0].SecretApiKey,
+		OrgEthAddress: organizations[0].EthAddress, ProcessID: elections[0].ProcessID,
+		MetadataPrivKey: elections[0].MetadataPrivKey, Title: elections[0].Title,
+		ProofType: string(types.PROOF_TYPE_BLIND), StartDate: elections[0].StartDate,
+		EndDate: elections[0].EndDate, StartBlock: 10, EndBlock: 20, CspAuth: "idlist"}, nil)
+	c.Assert(err, qt.IsNil)
+	electionID := elections[0].ProcessID
+	election, err := API.DB.GetElectionPrivate(organizations[0].EthAddress, electionID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(election.CspAuth, qt.Equals, "idlist")
+	c.Assert(election.CspAuthSecret, qt.HasLen, 0)
+
+	c.Assert(API.DB.SetElectionCspAuth(electionID, "email", []byte{1}), qt.IsNil)
+	election, err = API.DB.GetElectionPrivate(organizations[0].EthAddress, electionID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(election.CspAuth, qt.Equals, "email")
+	c.Assert(election.CspAuthSecret, qt.DeepEquals, []byte{1})
+
+	_, err = API.DB.GetCspVoter(electionID, "voter@example.com")
+	c.Assert(errors.Is(err, apierror.ErrNotFound), qt.IsTrue)
+	c.Assert(API.DB.AddCspVoters(electionID, []types.CspVoter{
+		{VoterID: "voter@example.com", Email: "old@example.com"},
+		{VoterID: "voter1", SecretHash: []byte{1}},
+	}), qt.IsNil)
+	// voters already on the list are replaced
+	c.Assert(API.DB.AddCspVoters(electionID, []types.CspVoter{
+		{VoterID: "voter@example.com", Email: "voter@example.com"},
+	}), qt.IsNil)
+	voter, err := API.DB.GetCspVoter(electionID, "voter@example.com")
+	c.Assert(err, qt.IsNil)
+	c.Assert(voter.Email, qt.Equals, "voter@example.com")
+	voter, err = API.DB.GetCspVoter(electionID, "voter1")
+	c.Assert(err, qt.IsNil)
+	c.Assert(voter.SecretHash, qt.DeepEquals, []byte{1})
+
+	// one-time codes are used once, while valid and within the attempts
+	now := time.Now()
+	c.Assert(API.DB.SetCspVoterOTP(electionID, "voter@example.com", []byte{2},
+		now.Add(time.Minute)), qt.IsNil)
+	used, err := API.DB.UseCspVoterOTP(electionID, "voter@example.com", []byte{3}, now, 2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(used, qt.IsFalse)
+	used, err = API.DB.UseCspVoterOTP(electionID, "voter@example.com", []byte{2},
+		now.Add(time.Hour), 2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(used, qt.IsFalse)
+	used, err = API.DB.UseCspVoterOTP(electionID, "voter@example.com", []byte{2}, now, 2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(used, qt.IsTrue)
+	used, err = API.DB.UseCspVoterOTP(electionID, "voter@example.com", []byte{2}, now, 2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(used, qt.IsFalse)
+
+	c.Assert(API.DB.SetCspVoterOTP(electionID, "voter@example.com", []byte{4},
+		now.Add(time.Minute)), qt.IsNil)
+	for i := 0; i < 2; i++ {
+		used, err = API.DB.UseCspVoterOTP(electionID, "voter@example.com", []byte{5}, now, 2)
+		c.Assert(err, qt.IsNil)
+		c.Assert(used, qt.IsFalse)
+	}
+	used, err = API.DB.UseCspVoterOTP(electionID, "voter@example.com", []byte{4}, now, 2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(used, qt.IsFalse)
+
+	err = API.DB.SetCspVoterOTP(electionID, "nobody", []byte{2}, now)
+	c.Assert(errors.Is(err, apierror.ErrNotFound), qt.IsTrue)
+}
@@ -1,12 +1,15 @@
 package testpgsql
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
 	"testing"
+	"time"
 
 	qt "github.com/frankban/quicktest"
 	"github.com/google/uuid"
+	"go.vocdoni.io/api/apierror"
 	"go.vocdoni.io/api/test/testcommon"
 	"go.vocdoni.io/api/types"
 )
@@ -40,10 +43,84 @@ func TestElection(t *testing.T) {
 	c.Assert(election.ID, qt.Not(qt.Equals), elections[0].ID)
 	c.Assert(election.ProofType, qt.Equals, string(types.PROOF_TYPE_BLIND))
 
-	list, err := API.DB.ListElections(integrators[0].SecretApiKey, organizations[0].EthAddress)
+	list, _, err := API.DB.ListElections(integrators[0].SecretApiKey, organizations[0].EthAddress, nil)
 	c.Assert(err, qt.IsNil)
 	c.Assert(len(list), qt.Equals, 1)
 	c.Assert(list[0].Title, qt.DeepEquals, elections[0].Title)
+
+	elections[1].Title = "Board_election 100%"
+	_, err = API.DB.CreateElection(integrators[0].SecretApiKey, organizations[0].EthAddress, elections[1].ProcessID,
+		elections[1].MetadataPrivKey, elections[1].Title, string(types.PROOF_TYPE_ECDSA), elections[1].StartDate,
+		elections[1].EndDate, uuid.NullUUID{}, 10, 20, false, false)
+	c.Assert(err, qt.IsNil)
+
+	// filters
+	filter := &types.ElectionFilter{ProofType: string(types.PROOF_TYPE_ECDSA)}
+	list, _, err = API.DB.ListElections(integrators[0].SecretApiKey, organizations[0].EthAddress, filter)
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.HasLen, 1)
+	c.Assert(list[0].ProcessID, qt.DeepEquals, elections[1].ProcessID)
+	filter = &types.ElectionFilter{Title: "board_ELECTION 100%"}
+	list, _, err = API.DB.ListElections(integrators[0].SecretApiKey, organizations[0].EthAddress, filter)
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.HasLen, 1)
+	filter = &types.ElectionFilter{Title: "100_"}
+	list, _, err = API.DB.ListElections(integrators[0].SecretApiKey, organizations[0].EthAddress, filter)
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.HasLen, 0)
+	filter = &types.ElectionFilter{Status: types.ElectionStatusActive, Height: 15}
+	list, _, err = API.DB.ListElections(integrators[0].SecretApiKey, organizations[0].EthAddress, filter)
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.HasLen, 1)
+	c.Assert(list[0].ProcessID, qt.DeepEquals, elections[1].ProcessID)
+	filter = &types.ElectionFilter{Status: types.ElectionStatusEnded, Height: 15}
+	list, _, err = API.DB.ListElections(integrators[0].SecretApiKey, organizations[0].EthAddress, filter)
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.HasLen, 1)
+	c.Assert(list[0].ProcessID, qt.DeepEquals, elections[0].ProcessID)
+
+	// statuses synced from the vochain
+	syncStart := time.Now()
+	c.Assert(API.DB.UpdateElectionStatus(elections[0].ProcessID, types.ElectionStatusPaused), qt.IsNil)
+	filter = &types.ElectionFilter{Status: types.ElectionStatusPaused}
+	list, _, err = API.DB.ListElections(integrators[0].SecretApiKey, organizations[0].EthAddress, filter)
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.HasLen, 1)
+	c.Assert(list[0].Status, qt.Equals, types.ElectionStatusPaused)
+	filter = &types.ElectionFilter{Status: types.ElectionStatusEnded, Height: 15}
+	list, _, err = API.DB.ListElections(integrators[0].SecretApiKey, organizations[0].EthAddress, filter)
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.HasLen, 0)
+	toSync, err := API.DB.ListElectionsToSync(syncStart, 1000)
+	c.Assert(err, qt.IsNil)
+	for _, election := range toSync {
+		c.Assert(election.ProcessID, qt.Not(qt.DeepEquals), elections[0].ProcessID)
+	}
+	err = API.DB.UpdateElectionStatus([]byte("missing"), types.ElectionStatusReady)
+	c.Assert(errors.Is(err, apierror.ErrElectionNotFound), qt.IsTrue)
+	// the confidential election is not public
+	list, _, err = API.DB.ListElectionsPublic(organizations[0].EthAddress, nil)
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.HasLen, 1)
+	c.Assert(list[0].MetadataPrivKey, qt.IsNil)
+
+	// pages follow the cursor of the previous one, in its order
+	filter = &types.ElectionFilter{ListOptions: types.ListOptions{Count: 1, SortBy: "startDate",
+		Order: "descend"}}
+	list, next, err := API.DB.ListElections(integrators[0].SecretApiKey, organizations[0].EthAddress, filter)
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.HasLen, 1)
+	c.Assert(list[0].ProcessID, qt.DeepEquals, elections[1].ProcessID)
+	c.Assert(next, qt.Not(qt.Equals), "")
+	filter = &types.ElectionFilter{ListOptions: types.ListOptions{Count: 1, Cursor: next}}
+	list, next, err = API.DB.ListElections(integrators[0].SecretApiKey, organizations[0].EthAddress, filter)
+	c.Assert(err, qt.IsNil)
+	c.Assert(list, qt.HasLen, 1)
+	c.Assert(list[0].ProcessID, qt.DeepEquals, elections[0].ProcessID)
+	c.Assert(next, qt.Equals, "")
+	filter = &types.ElectionFilter{ListOptions: types.ListOptions{SortBy: "metadataPrivKey"}}
+	_, _, err = API.DB.ListElections(integrators[0].SecretApiKey, organizations[0].EthAddress, filter)
+	c.Assert(errors.Is(err, apierror.ErrInvalidFilter), qt.IsTrue)
 	// integrator, err := API.DB.GetIntegrator(elections[0].ID)
 	// t.Logf("%w", integrator)
 	// c.Assert(err, qt.IsNil)
@@ -2,11 +2,14 @@ package testpgsql
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/rand"
 	"testing"
+	"time"
 
 	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/api/apierror"
 	"go.vocdoni.io/api/test/testcommon"
 )
 
@@ -47,7 +50,7 @@ func TestOrganization(t *testing.T) {
 	c.Assert(err, qt.IsNil)
 	c.Assert(count, qt.Equals, 1)
 
-	dbOrganizations, err := API.DB.ListOrganizations(integrators[0].SecretApiKey, nil)
+	dbOrganizations, _, err := API.DB.ListOrganizations(integrators[0].SecretApiKey, nil)
 	c.Assert(err, qt.IsNil)
 	c.Assert(len(dbOrganizations), qt.Equals, 1)
 
@@ -128,3 +131,72 @@ func TestOrganizationUpdate(t *testing.T) {
 
 	}
 }
+
+func TestOrganizationSoftDelete(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+	integrators := testcommon.CreateIntegrators(1)
+	var err error
+	integrators[0].SecretApiKey = []byte(fmt.Sprintf("key%d", rand.Intn(10000000)))
+	integrators[0].ID, err = API.DB.CreateIntegrator(integrators[0].SecretApiKey, integrators[0].CspPubKey,
+		integrators[0].CspUrlPrefix, integrators[0].Name, integrators[0].Email)
+	c.Assert(err, qt.IsNil)
+	key := integrators[0].SecretApiKey
+
+	organizations := testcommon.CreateDbOrganizations(2)
+	for _, org := range organizations {
+		_, err := API.DB.CreateOrganization(key, org.EthAddress, org.EthPrivKeyCipher, org.QuotaPlanID,
+			org.PublicAPIQuota, org.PublicAPIToken, org.HeaderURI, org.AvatarURI)
+		c.Assert(err, qt.IsNil)
+	}
+
+	// a deleted organization is hidden but can be restored with its private key
+	c.Assert(API.DB.DeleteOrganization(key, organizations[0].EthAddress), qt.IsNil)
+	_, err = API.DB.GetOrganization(key, organizations[0].EthAddress)
+	c.Assert(errors.Is(err, apierror.ErrOrganizationNotFound), qt.IsTrue)
+	count, err := API.DB.CountOrganizations(key)
+	c.Assert(err, qt.IsNil)
+	c.Assert(count, qt.Equals, 1)
+	err = API.DB.DeleteOrganization(key, organizations[0].EthAddress)
+	c.Assert(errors.Is(err, apierror.ErrOrganizationNotFound), qt.IsTrue)
+
+	restored, err := API.DB.RestoreOrganization(key, organizations[0].EthAddress)
+	c.Assert(err, qt.IsNil)
+	c.Assert(restored.PublicAPIToken, qt.Equals, organizations[0].PublicAPIToken)
+	organization, err := API.DB.GetOrganization(key, organizations[0].EthAddress)
+	c.Assert(err, qt.IsNil)
+	c.Assert(organization.EthPrivKeyCipher, qt.DeepEquals, organizations[0].EthPrivKeyCipher)
+	_, err = API.DB.RestoreOrganization(key, organizations[0].EthAddress)
+	c.Assert(errors.Is(err, apierror.ErrOrganizationNotFound), qt.IsTrue)
+
+	// restoring an integrator only restores the organizations deleted with it
+	c.Assert(API.DB.DeleteOrganization(key, organizations[1].EthAddress), qt.IsNil)
+	c.Assert(API.DB.DeleteIntegrator(integrators[0].ID), qt.IsNil)
+	_, err = API.DB.GetIntegratorByKey(key)
+	c.Assert(errors.Is(err, apierror.ErrIntegratorNotFound), qt.IsTrue)
+	restoredOrgs, err := API.DB.RestoreIntegrator(integrators[0].ID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(restoredOrgs, qt.HasLen, 1)
+	c.Assert(restoredOrgs[0].EthAddress, qt.DeepEquals, organizations[0].EthAddress)
+	_, err = API.DB.GetOrganization(key, organizations[1].EthAddress)
+	c.Assert(errors.Is(err, apierror.ErrOrganizationNotFound), qt.IsTrue)
+
+	// the organizations of a deleted integrator are only restored with it
+	c.Assert(API.DB.DeleteIntegrator(integrators[0].ID), qt.IsNil)
+	_, err = API.DB.RestoreOrganization(key, organizations[1].EthAddress)
+	c.Assert(errors.Is(err, apierror.ErrOrganizationNotFound), qt.IsTrue)
+
+	// purging only removes the rows deleted before the given time
+	_, _, err = API.DB.PurgeDeleted(time.Now().Add(-time.Hour))
+	c.Assert(err, qt.IsNil)
+	_, err = API.DB.RestoreIntegrator(integrators[0].ID)
+	c.Assert(err, qt.IsNil)
+	_, err = API.DB.RestoreOrganization(key, organizations[1].EthAddress)
+	c.Assert(err, qt.IsNil)
+	c.Assert(API.DB.DeleteIntegrator(integrators[0].ID), qt.IsNil)
+	integratorsPurged, _, err := API.DB.PurgeDeleted(time.Now().Add(time.Hour))
+	c.Assert(err, qt.IsNil)
+	c.Assert(integratorsPurged >= 1, qt.IsTrue)
+	_, err = API.DB.RestoreIntegrator(integrators[0].ID)
+	c.Assert(errors.Is(err, apierror.ErrIntegratorNotFound), qt.IsTrue)
+}
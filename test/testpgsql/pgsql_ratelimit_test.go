@@ -0,0 +1,59 @@
+package testpgsql
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/api/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+	key := fmt.Sprintf("test:%d", rand.Int())
+	// 2 requests at once, and one more every 2 seconds
+	limit := ratelimit.Limit{Rate: 30, Burst: 2}
+	now := time.Now()
+
+	result, err := API.DB.TakeRateLimit(key, limit, now)
+	c.Assert(err, qt.IsNil)
+	c.Assert(result.Allowed, qt.IsTrue)
+	c.Assert(result.Remaining, qt.Equals, 1)
+	result, err = API.DB.TakeRateLimit(key, limit, now)
+	c.Assert(err, qt.IsNil)
+	c.Assert(result.Allowed, qt.IsTrue)
+	result, err = API.DB.TakeRateLimit(key, limit, now.Add(time.Second))
+	c.Assert(err, qt.IsNil)
+	c.Assert(result.Allowed, qt.IsFalse)
+	c.Assert(result.RetryAfter > 0, qt.IsTrue)
+	result, err = API.DB.TakeRateLimit(key, limit, now.Add(2*time.Second))
+	c.Assert(err, qt.IsNil)
+	c.Assert(result.Allowed, qt.IsTrue)
+
+	c.Assert(API.DB.PurgeRateLimits(now.Add(time.Minute)), qt.IsNil)
+	// purged buckets start full
+	result, err = API.DB.TakeRateLimit(key, limit, now.Add(2*time.Second))
+	c.Assert(err, qt.IsNil)
+	c.Assert(result.Remaining, qt.Equals, 1)
+}
+
+func TestPlanRateLimit(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+	id, err := API.DB.CreatePlan(fmt.Sprintf("rate-limit-%d", rand.Int()), 10, 10)
+	c.Assert(err, qt.IsNil)
+	plan, err := API.DB.GetPlan(id)
+	c.Assert(err, qt.IsNil)
+	c.Assert(plan.RateLimit, qt.Equals, 0)
+
+	_, err = API.DB.UpdatePlanRateLimit(id, 100, 10)
+	c.Assert(err, qt.IsNil)
+	plan, err = API.DB.GetPlan(id)
+	c.Assert(err, qt.IsNil)
+	c.Assert(plan.RateLimit, qt.Equals, 100)
+	c.Assert(plan.RateBurst, qt.Equals, 10)
+	c.Assert(API.DB.DeletePlan(id), qt.IsNil)
+}
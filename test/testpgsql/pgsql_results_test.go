@@ -0,0 +1,71 @@
+package testpgsql
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/google/uuid"
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/test/testcommon"
+	"go.vocdoni.io/api/types"
+)
+
+func TestResultsSnapshot(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+	integrators := testcommon.CreateIntegrators(1)
+	var err error
+	integrators[0].SecretApiKey = []byte(fmt.Sprintf("key%d", rand.Intn(10000)))
+	integrators[0].ID, err = API.DB.CreateIntegrator(integrators[0].SecretApiKey, integrators[0].CspPubKey,
+		integrators[0].CspUrlPrefix, integrators[0].Name, integrators[0].Email)
+	c.Assert(err, qt.IsNil)
+	organizations := testcommon.CreateDbOrganizations(1)
+	_, err = API.DB.CreateOrganization(integrators[0].SecretApiKey, organizations[0].EthAddress,
+		organizations[0].EthPrivKeyCipher, organizations[0].QuotaPlanID, organizations[0].PublicAPIQuota,
+		organizations[0].PublicAPIToken, organizations[0].HeaderURI, organizations[0].AvatarURI)
+	c.Assert(err, qt.IsNil)
+	elections := testcommon.CreateDbElections(t, 1)
+	_, err = API.DB.CreateElection(integrators[0].SecretApiKey, organizations[0].EthAddress, elections[0].ProcessID,
+		elections[0].MetadataPrivKey, elections[0].Title, string(types.PROOF_TYPE_ECDSA), elections[0].StartDate,
+		elections[0].EndDate, uuid.NullUUID{}, 10, 20, false, false)
+	c.Assert(err, qt.IsNil)
+
+	_, err = API.DB.GetResultsSnapshot(elections[0].ProcessID)
+	c.Assert(errors.Is(err, apierror.ErrResultsNotFound), qt.IsTrue)
+
+	snapshot := &types.ResultsSnapshot{
+		ElectionID: elections[0].ProcessID,
+		Height:     1200,
+		VoteCount:  12,
+		State:      "RESULTS",
+		Type:       "poll-vote",
+		Results:    [][]string{{"3", "9"}},
+		Aggregated: []types.Result{{Title: []string{"yes", "no"}, Value: []string{"3", "9"}}},
+		Hash:       []byte{1, 2, 3},
+	}
+	c.Assert(API.DB.CreateResultsSnapshot(snapshot), qt.IsNil)
+	stored, err := API.DB.GetResultsSnapshot(elections[0].ProcessID)
+	c.Assert(err, qt.IsNil)
+	c.Assert([]byte(stored.OrganizationID), qt.DeepEquals, organizations[0].EthAddress)
+	c.Assert(stored.Height, qt.Equals, uint32(1200))
+	c.Assert(stored.VoteCount, qt.Equals, uint32(12))
+	c.Assert(stored.Results, qt.DeepEquals, snapshot.Results)
+	c.Assert(stored.Aggregated, qt.DeepEquals, snapshot.Aggregated)
+	c.Assert([]byte(stored.Hash), qt.DeepEquals, []byte{1, 2, 3})
+
+	// snapshots never change
+	c.Assert(API.DB.CreateResultsSnapshot(&types.ResultsSnapshot{
+		ElectionID: elections[0].ProcessID, Height: 13, Results: [][]string{{"4", "9"}},
+		Hash: []byte{4}}), qt.IsNil)
+	stored, err = API.DB.GetResultsSnapshot(elections[0].ProcessID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(stored.Height, qt.Equals, uint32(1200))
+
+	// snapshots need a stored election
+	err = API.DB.CreateResultsSnapshot(&types.ResultsSnapshot{ElectionID: []byte("missing"),
+		Results: [][]string{}, Hash: []byte{4}})
+	c.Assert(err, qt.Not(qt.IsNil))
+}
@@ -0,0 +1,159 @@
+package testpgsql
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/google/uuid"
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/test/testcommon"
+	"go.vocdoni.io/api/types"
+)
+
+func TestScheduledActions(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+	integrators := testcommon.CreateIntegrators(1)
+	var err error
+	integrators[0].SecretApiKey = []byte(fmt.Sprintf("key%d", rand.Intn(10000)))
+	integrators[0].ID, err = API.DB.CreateIntegrator(integrators[0].SecretApiKey, integrators[0].CspPubKey,
+		integrators[0].CspUrlPrefix, integrators[0].Name, integrators[0].Email)
+	c.Assert(err, qt.IsNil)
+	organizations := testcommon.CreateDbOrganizations(1)
+	_, err = API.DB.CreateOrganization(integrators[0].SecretApiKey, organizations[0].EthAddress,
+		organizations[0].EthPrivKeyCipher, organizations[0].QuotaPlanID, organizations[0].PublicAPIQuota,
+		organizations[0].PublicAPIToken, organizations[0].HeaderURI, organizations[0].AvatarURI)
+	c.Assert(err, qt.IsNil)
+	elections := testcommon.CreateDbElections(t, 1)
+	_, err = API.DB.CreateElection(integrators[0].SecretApiKey, organizations[0].EthAddress, elections[0].ProcessID,
+		elections[0].MetadataPrivKey, elections[0].Title, string(types.PROOF_TYPE_BLIND), elections[0].StartDate,
+		elections[0].EndDate, uuid.NullUUID{}, 10, 20, false, false)
+	c.Assert(err, qt.IsNil)
+	electionID := elections[0].ProcessID
+	key := integrators[0].SecretApiKey
+
+	now := time.Now().Truncate(time.Second)
+	action := func(kind string, executeAt time.Time) *types.ScheduledAction {
+		return &types.ScheduledAction{IntegratorApiKey: key, OrgEthAddress: organizations[0].EthAddress,
+			ProcessID: electionID, Action: kind, ExecuteAt: executeAt, TargetBlock: 100}
+	}
+	endID, err := API.DB.CreateScheduledAction(action(types.ScheduledActionEnd, now.Add(time.Hour)))
+	c.Assert(err, qt.IsNil)
+	pauseID, err := API.DB.CreateScheduledAction(action(types.ScheduledActionPause, now.Add(time.Minute)))
+	c.Assert(err, qt.IsNil)
+	_, err = API.DB.CreateScheduledAction(&types.ScheduledAction{IntegratorApiKey: key,
+		OrgEthAddress: organizations[0].EthAddress, ProcessID: []byte("missing"),
+		Action: types.ScheduledActionEnd, ExecuteAt: now})
+	c.Assert(err, qt.Not(qt.IsNil))
+
+	actions, err := API.DB.ListScheduledActions(key, electionID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(actions, qt.HasLen, 2)
+	c.Assert(actions[0].ID, qt.Equals, pauseID)
+	c.Assert(actions[0].Status, qt.Equals, types.ScheduledActionPending)
+	c.Assert(actions[0].ExecuteAt.Equal(now.Add(time.Minute)), qt.IsTrue)
+	c.Assert(actions[1].ID, qt.Equals, endID)
+	actions, err = API.DB.ListScheduledActions([]byte("other"), electionID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(actions, qt.HasLen, 0)
+
+	// only the actions of live organizations due before the given time are listed
+	due, err := API.DB.ListDueScheduledActions(now.Add(10*time.Minute), now.Add(-time.Minute))
+	c.Assert(err, qt.IsNil)
+	ids := map[int]bool{}
+	for _, action := range due {
+		ids[action.ID] = true
+	}
+	c.Assert(ids[pauseID], qt.IsTrue)
+	c.Assert(ids[endID], qt.IsFalse)
+
+	c.Assert(API.DB.SetScheduledActionTarget(pauseID, 120), qt.IsNil)
+	claimed, err := API.DB.ClaimScheduledAction(pauseID, now.Add(-time.Minute))
+	c.Assert(err, qt.IsNil)
+	c.Assert(claimed, qt.IsTrue)
+	// actions are claimed once
+	claimed, err = API.DB.ClaimScheduledAction(pauseID, now.Add(-time.Minute))
+	c.Assert(err, qt.IsNil)
+	c.Assert(claimed, qt.IsFalse)
+	due, err = API.DB.ListDueScheduledActions(now.Add(10*time.Minute), now.Add(-time.Minute))
+	c.Assert(err, qt.IsNil)
+	for _, action := range due {
+		c.Assert(action.ID, qt.Not(qt.Equals), pauseID)
+	}
+	// unless they are still running after the claim timeout
+	due, err = API.DB.ListDueScheduledActions(now.Add(10*time.Minute), now.Add(time.Minute))
+	c.Assert(err, qt.IsNil)
+	ids = map[int]bool{}
+	for _, action := range due {
+		ids[action.ID] = true
+	}
+	c.Assert(ids[pauseID], qt.IsTrue)
+	claimed, err = API.DB.ClaimScheduledAction(pauseID, now.Add(time.Minute))
+	c.Assert(err, qt.IsNil)
+	c.Assert(claimed, qt.IsTrue)
+	c.Assert(API.DB.FinishScheduledAction(pauseID, types.ScheduledActionFailed, "failed",
+		nil), qt.IsNil)
+
+	// only pending actions are canceled
+	err = API.DB.CancelScheduledAction(key, electionID, pauseID)
+	c.Assert(errors.Is(err, apierror.ErrNotFound), qt.IsTrue)
+	err = API.DB.CancelScheduledAction([]byte("other"), electionID, endID)
+	c.Assert(errors.Is(err, apierror.ErrNotFound), qt.IsTrue)
+	c.Assert(API.DB.CancelScheduledAction(key, electionID, endID), qt.IsNil)
+
+	actions, err = API.DB.ListScheduledActions(key, electionID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(actions[0].Status, qt.Equals, types.ScheduledActionFailed)
+	c.Assert(actions[0].Error, qt.Equals, "failed")
+	c.Assert(actions[0].TargetBlock, qt.Equals, uint32(120))
+	c.Assert(actions[0].ClaimedAt, qt.Not(qt.IsNil))
+	c.Assert(actions[0].ExecutedAt, qt.Not(qt.IsNil))
+	c.Assert(actions[1].Status, qt.Equals, types.ScheduledActionCanceled)
+}
+
+func TestStoreElectionActions(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+	integrators := testcommon.CreateIntegrators(1)
+	var err error
+	integrators[0].SecretApiKey = []byte(fmt.Sprintf("key%d", rand.Intn(10000)))
+	integrators[0].ID, err = API.DB.CreateIntegrator(integrators[0].SecretApiKey, integrators[0].CspPubKey,
+		integrators[0].CspUrlPrefix, integrators[0].Name, integrators[0].Email)
+	c.Assert(err, qt.IsNil)
+	organizations := testcommon.CreateDbOrganizations(1)
+	_, err = API.DB.CreateOrganization(integrators[0].SecretApiKey, organizations[0].EthAddress,
+		organizations[0].EthPrivKeyCipher, organizations[0].QuotaPlanID, organizations[0].PublicAPIQuota,
+		organizations[0].PublicAPIToken, organizations[0].HeaderURI, organizations[0].AvatarURI)
+	c.Assert(err, qt.IsNil)
+	elections := testcommon.CreateDbElections(t, 2)
+	key := integrators[0].SecretApiKey
+	election := func(i int) *types.Election {
+		return &types.Election{IntegratorApiKey: key, OrgEthAddress: organizations[0].EthAddress,
+			ProcessID: elections[i].ProcessID, MetadataPrivKey: elections[i].MetadataPrivKey,
+			Title: elections[i].Title, ProofType: string(types.PROOF_TYPE_BLIND),
+			StartDate: elections[i].StartDate, EndDate: elections[i].EndDate, StartBlock: 10, EndBlock: 20}
+	}
+	end := func(processID []byte) types.ScheduledAction {
+		return types.ScheduledAction{IntegratorApiKey: key, OrgEthAddress: organizations[0].EthAddress,
+			ProcessID: processID, Action: types.ScheduledActionEnd, ExecuteAt: elections[0].EndDate,
+			TargetBlock: 20}
+	}
+
+	// the election and its actions are stored together
+	_, err = API.DB.StoreElection(election(0), []types.ScheduledAction{end(elections[0].ProcessID)})
+	c.Assert(err, qt.IsNil)
+	actions, err := API.DB.ListScheduledActions(key, elections[0].ProcessID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(actions, qt.HasLen, 1)
+	c.Assert(actions[0].Action, qt.Equals, types.ScheduledActionEnd)
+
+	// or not at all
+	_, err = API.DB.StoreElection(election(1), []types.ScheduledAction{end([]byte("missing"))})
+	c.Assert(err, qt.Not(qt.IsNil))
+	_, err = API.DB.GetElectionPrivate(organizations[0].EthAddress, elections[1].ProcessID)
+	c.Assert(errors.Is(err, apierror.ErrElectionNotFound), qt.IsTrue)
+}
@@ -0,0 +1,70 @@
+package testpgsql
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/google/uuid"
+	"go.vocdoni.io/api/test/testcommon"
+	"go.vocdoni.io/api/types"
+)
+
+func TestElectionSamples(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+	integrators := testcommon.CreateIntegrators(1)
+	var err error
+	integrators[0].SecretApiKey = []byte(fmt.Sprintf("key%d", rand.Intn(10000)))
+	integrators[0].ID, err = API.DB.CreateIntegrator(integrators[0].SecretApiKey, integrators[0].CspPubKey,
+		integrators[0].CspUrlPrefix, integrators[0].Name, integrators[0].Email)
+	c.Assert(err, qt.IsNil)
+	organizations := testcommon.CreateDbOrganizations(1)
+	_, err = API.DB.CreateOrganization(integrators[0].SecretApiKey, organizations[0].EthAddress,
+		organizations[0].EthPrivKeyCipher, organizations[0].QuotaPlanID, organizations[0].PublicAPIQuota,
+		organizations[0].PublicAPIToken, organizations[0].HeaderURI, organizations[0].AvatarURI)
+	c.Assert(err, qt.IsNil)
+	elections := testcommon.CreateDbElections(t, 1)
+	_, err = API.DB.CreateElection(integrators[0].SecretApiKey, organizations[0].EthAddress, elections[0].ProcessID,
+		elections[0].MetadataPrivKey, elections[0].Title, string(types.PROOF_TYPE_BLIND), elections[0].StartDate,
+		elections[0].EndDate, uuid.NullUUID{}, 10, 20, false, false)
+	c.Assert(err, qt.IsNil)
+	electionID := elections[0].ProcessID
+
+	samples, err := API.DB.ListElectionSamples(electionID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(samples, qt.HasLen, 0)
+	start := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	c.Assert(API.DB.AddElectionSample(&types.ElectionSample{ProcessID: electionID,
+		SampledAt: start.Add(time.Minute), Height: 12, VoteCount: 5}), qt.IsNil)
+	c.Assert(API.DB.AddElectionSample(&types.ElectionSample{ProcessID: electionID,
+		SampledAt: start, Height: 10, VoteCount: 2}), qt.IsNil)
+	// samples taken at the same time keep the first
+	c.Assert(API.DB.AddElectionSample(&types.ElectionSample{ProcessID: electionID,
+		SampledAt: start, Height: 10, VoteCount: 3}), qt.IsNil)
+	samples, err = API.DB.ListElectionSamples(electionID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(samples, qt.HasLen, 2)
+	c.Assert(samples[0].SampledAt.Equal(start), qt.IsTrue)
+	c.Assert(samples[0].VoteCount, qt.Equals, 2)
+	c.Assert(samples[1].Height, qt.Equals, uint32(12))
+	c.Assert(samples[1].VoteCount, qt.Equals, 5)
+	// samples need a stored election
+	c.Assert(API.DB.AddElectionSample(&types.ElectionSample{ProcessID: []byte("missing"),
+		Height: 1, VoteCount: 1}), qt.Not(qt.IsNil))
+
+	// the census size is unknown without a census or a voter list
+	size, err := API.DB.GetElectionCensusSize(electionID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(size, qt.Equals, 0)
+	c.Assert(API.DB.SetElectionCspAuth(electionID, "idlist", []byte{}), qt.IsNil)
+	c.Assert(API.DB.AddCspVoters(electionID, []types.CspVoter{
+		{VoterID: "voter1", SecretHash: []byte{1}},
+		{VoterID: "voter2", SecretHash: []byte{2}},
+	}), qt.IsNil)
+	size, err = API.DB.GetElectionCensusSize(electionID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(size, qt.Equals, 2)
+}
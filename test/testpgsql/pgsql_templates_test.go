@@ -0,0 +1,71 @@
+package testpgsql
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/test/testcommon"
+	"go.vocdoni.io/api/types"
+)
+
+func TestElectionTemplates(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+	integrators := testcommon.CreateIntegrators(1)
+	var err error
+	integrators[0].SecretApiKey = []byte(fmt.Sprintf("key%d", rand.Intn(10000)))
+	integrators[0].ID, err = API.DB.CreateIntegrator(integrators[0].SecretApiKey, integrators[0].CspPubKey,
+		integrators[0].CspUrlPrefix, integrators[0].Name, integrators[0].Email)
+	c.Assert(err, qt.IsNil)
+	organizations := testcommon.CreateDbOrganizations(1)
+	_, err = API.DB.CreateOrganization(integrators[0].SecretApiKey, organizations[0].EthAddress,
+		organizations[0].EthPrivKeyCipher, organizations[0].QuotaPlanID, organizations[0].PublicAPIQuota,
+		organizations[0].PublicAPIToken, organizations[0].HeaderURI, organizations[0].AvatarURI)
+	c.Assert(err, qt.IsNil)
+	key, org := integrators[0].SecretApiKey, organizations[0].EthAddress
+
+	templates, err := API.DB.ListElectionTemplates(key, org)
+	c.Assert(err, qt.IsNil)
+	c.Assert(templates, qt.HasLen, 0)
+
+	definition := types.ElectionDefinition{
+		Title: types.LanguageString{"default": "Assembly", "es": "Asamblea"},
+		Questions: []types.QuestionMeta{{
+			Title: types.LanguageString{"default": "Approve?"},
+			Choices: []types.ChoiceMetadata{{Title: types.LanguageString{"default": "Yes"}},
+				{Title: types.LanguageString{"default": "No"}, Value: 1}},
+		}},
+		ProofType:     types.PROOF_TYPE_BLIND,
+		HiddenResults: true,
+	}
+	id, err := API.DB.CreateElectionTemplate(&types.ElectionTemplate{IntegratorApiKey: key,
+		OrgEthAddress: org, Name: "assembly", Definition: definition})
+	c.Assert(err, qt.IsNil)
+	_, err = API.DB.CreateElectionTemplate(&types.ElectionTemplate{IntegratorApiKey: key,
+		OrgEthAddress: org, Name: "board", Definition: definition})
+	c.Assert(err, qt.IsNil)
+
+	template, err := API.DB.GetElectionTemplate(key, org, id)
+	c.Assert(err, qt.IsNil)
+	c.Assert(template.Name, qt.Equals, "assembly")
+	c.Assert(template.Definition, qt.DeepEquals, definition)
+	templates, err = API.DB.ListElectionTemplates(key, org)
+	c.Assert(err, qt.IsNil)
+	c.Assert(templates, qt.HasLen, 2)
+	c.Assert(templates[0].ID, qt.Equals, id)
+	c.Assert(templates[1].Name, qt.Equals, "board")
+
+	// templates are only found by their organization's integrator
+	_, err = API.DB.GetElectionTemplate([]byte("other"), org, id)
+	c.Assert(errors.Is(err, apierror.ErrNotFound), qt.IsTrue)
+
+	c.Assert(API.DB.DeleteElectionTemplate(key, org, id), qt.IsNil)
+	err = API.DB.DeleteElectionTemplate(key, org, id)
+	c.Assert(errors.Is(err, apierror.ErrNotFound), qt.IsTrue)
+	_, err = API.DB.GetElectionTemplate(key, org, id)
+	c.Assert(errors.Is(err, apierror.ErrNotFound), qt.IsTrue)
+}
@@ -0,0 +1,61 @@
+package testpgsql
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/api/types"
+)
+
+func TestUsage(t *testing.T) {
+	t.Parallel()
+	c := qt.New(t)
+	// a random integrator id keeps the test independent from other usage
+	integratorID := 100000 + rand.Intn(100000)
+	day := time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC)
+	counters := []types.UsageCounter{
+		{Day: day, IntegratorID: integratorID, OrgEthAddress: []byte{1},
+			Metric: types.UsageVotes, Count: 2},
+		{Day: day, IntegratorID: integratorID, Metric: types.UsageAPICalls, Count: 5},
+		{Day: day.AddDate(0, 0, 1), IntegratorID: integratorID, OrgEthAddress: []byte{1},
+			Metric: types.UsageVotes, Count: 1},
+	}
+	c.Assert(API.DB.AddUsage(counters), qt.IsNil)
+	// adding usage again adds up to the stored counters
+	c.Assert(API.DB.AddUsage(counters[:1]), qt.IsNil)
+
+	usage, next, err := API.DB.ListUsage(&types.UsageFilter{IntegratorID: integratorID})
+	c.Assert(err, qt.IsNil)
+	c.Assert(usage, qt.HasLen, 3)
+	c.Assert(next, qt.Equals, "")
+	// oldest day first
+	c.Assert(usage[2].Day.Equal(day.AddDate(0, 0, 1)), qt.IsTrue)
+
+	usage, _, err = API.DB.ListUsage(&types.UsageFilter{IntegratorID: integratorID,
+		OrgEthAddress: []byte{1}, To: day.AddDate(0, 0, 1)})
+	c.Assert(err, qt.IsNil)
+	c.Assert(usage, qt.HasLen, 1)
+	c.Assert(usage[0].Count, qt.Equals, int64(4))
+	c.Assert(usage[0].Metric, qt.Equals, types.UsageVotes)
+	c.Assert(usage[0].PlanID.Valid, qt.IsFalse)
+
+	usage, _, err = API.DB.ListUsage(&types.UsageFilter{IntegratorID: integratorID,
+		Metric: types.UsageAPICalls})
+	c.Assert(err, qt.IsNil)
+	c.Assert(usage, qt.HasLen, 1)
+	c.Assert(usage[0].OrgEthAddress, qt.HasLen, 0)
+
+	// pages follow the cursor of the previous one
+	usage, next, err = API.DB.ListUsage(&types.UsageFilter{IntegratorID: integratorID,
+		Count: 2})
+	c.Assert(err, qt.IsNil)
+	c.Assert(usage, qt.HasLen, 2)
+	c.Assert(next, qt.Not(qt.Equals), "")
+	usage, next, err = API.DB.ListUsage(&types.UsageFilter{IntegratorID: integratorID,
+		Count: 2, Cursor: next})
+	c.Assert(err, qt.IsNil)
+	c.Assert(usage, qt.HasLen, 1)
+	c.Assert(next, qt.Equals, "")
+}
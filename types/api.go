@@ -1,11 +1,14 @@
 package types
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
+	"go.vocdoni.io/api/apierror"
 	"go.vocdoni.io/dvote/api"
 	"go.vocdoni.io/dvote/types"
+	"go.vocdoni.io/dvote/vochain/scrutinizer/indexertypes"
 )
 
 const (
@@ -15,6 +18,9 @@ const (
 
 type ProofType string
 
+// DateLayout is the layout of the dates sent in API requests
+const DateLayout = "2006-01-02T15:04:05.000Z"
+
 // APIRequest contains all of the possible request fields.
 // Fields must be in alphabetical order
 // Those fields with valid zero-values (such as bool) must be pointers
@@ -44,13 +50,16 @@ type APIRequest struct {
 type APIResponse struct {
 	APIKey         string                `json:"apiKey,omitempty"`
 	APIToken       string                `json:"apiToken,omitempty"`
+	AuthSecret     types.HexBytes        `json:"authSecret,omitempty"`
 	Avatar         string                `json:"avatar,omitempty"`
 	CensusID       int                   `json:"censusId,omitempty"`
+	Code           int                   `json:"code,omitempty"`
 	ContentURI     string                `json:"contentUri,omitempty"`
 	CspPubKey      types.HexBytes        `json:"cspPubKey,omitempty"`
 	CspUrlPrefix   string                `json:"cspUrlPrefix,omitempty"`
 	Description    string                `json:"description,omitempty"`
 	ElectionID     types.HexBytes        `json:"electionId,omitempty"`
+	EndDateError   int64                 `json:"endDateError,omitempty"`
 	ExplorerUrl    string                `json:"explorerUrl,omitempty"`
 	Header         string                `json:"header,omitempty"`
 	ID             int                   `json:"id,omitempty"`
@@ -60,7 +69,9 @@ type APIResponse struct {
 	OrganizationID types.HexBytes        `json:"organizationId,omitempty"`
 	Organizations  []APIOrganizationInfo `json:"organizations,omitempty"`
 	Registered     *bool                 `json:"registered,omitempty"`
+	StartDateError int64                 `json:"startDateError,omitempty"`
 	TxHash         types.HexBytes        `json:"txHash,omitempty"`
+	Voters         int                   `json:"voters,omitempty"`
 }
 
 // APIOrganizationInfo is the organization summary for the getOrganizationList call
@@ -88,15 +99,153 @@ type APIElectionInfo struct {
 	Results            []Result       `json:"results,omitempty"`
 	ResultsAggregation string         `json:"aggregation,omitempty"`
 	ResultsDisplay     string         `json:"display,omitempty"`
-	// Estimated start/end dates
-	EndDate   time.Time `json:"endDate,omitempty"`
-	StartDate time.Time `json:"startDate,omitempty"`
-	Status    string    `json:"status,omitempty"`
-	StreamURI string    `json:"streamUri,omitempty"`
-	Title     string    `json:"title,omitempty"`
-	ProofType ProofType `json:"proofType,omitempty"`
-	Type      string    `json:"type,omitempty"`
-	VoteCount uint32    `json:"voteCount,omitempty"`
+	// Estimated start/end dates, and their error bounds in seconds (0 if unknown)
+	EndDate        time.Time `json:"endDate,omitempty"`
+	EndDateError   int64     `json:"endDateError,omitempty"`
+	StartDate      time.Time `json:"startDate,omitempty"`
+	StartDateError int64     `json:"startDateError,omitempty"`
+	Status         string    `json:"status,omitempty"`
+	StreamURI      string    `json:"streamUri,omitempty"`
+	Title          string    `json:"title,omitempty"`
+	ProofType      ProofType `json:"proofType,omitempty"`
+	Type           string    `json:"type,omitempty"`
+	VoteCount      uint32    `json:"voteCount,omitempty"`
+}
+
+// APIVotePackage is the vote of a voter built by the API. CspBundle is what the
+//  CSP signs for the voter address, and SignPayload what the voter signs for
+//  VoteTx, as an Ethereum personal message.
+type APIVotePackage struct {
+	CspBundle         types.HexBytes `json:"cspBundle"`
+	EncryptionPubKeys []api.Key      `json:"encryptionPubKeys,omitempty"`
+	SignPayload       string         `json:"signPayload,omitempty"`
+	VoteTx            types.HexBytes `json:"voteTx,omitempty"`
+}
+
+// APIElectionResults is the response struct for a getResults request. Final
+//  results come from a persisted snapshot, with its hash.
+type APIElectionResults struct {
+	ElectionID types.HexBytes `json:"electionId"`
+	Height     uint32         `json:"height"`
+	VoteCount  uint32         `json:"voteCount"`
+	Final      bool           `json:"final"`
+	Results    []Result       `json:"results,omitempty"`
+	RawResults [][]string     `json:"rawResults,omitempty"`
+	Hash       types.HexBytes `json:"hash,omitempty"`
+}
+
+// Election stream event types
+const (
+	ElectionEventStatus  = "status"
+	ElectionEventVotes   = "votes"
+	ElectionEventResults = "results"
+)
+
+// ElectionEvent is an update of an election sent on its stream, with the status
+//  and vote count of the election. Results are only set on results events,
+//  once the results are final.
+type ElectionEvent struct {
+	Type       string              `json:"type"`
+	ElectionID types.HexBytes      `json:"electionId"`
+	Status     string              `json:"status,omitempty"`
+	VoteCount  uint32              `json:"voteCount"`
+	Results    *APIElectionResults `json:"results,omitempty"`
+}
+
+// ElectionStats is the turnout of an election over time, from the samples of its
+//  vote count. Turnouts are percentages of the census size, only set when it
+//  is known.
+type ElectionStats struct {
+	ElectionID types.HexBytes `json:"electionId"`
+	VoteCount  int            `json:"voteCount"`
+	CensusSize int            `json:"censusSize,omitempty"`
+	Turnout    float64        `json:"turnout,omitempty"`
+	// PeakRate is the highest rate between two samples, in votes per hour,
+	//  reached at PeakAt
+	PeakRate float64               `json:"peakRate"`
+	PeakAt   *time.Time            `json:"peakAt,omitempty"`
+	Buckets  []ElectionStatsBucket `json:"buckets"`
+}
+
+// ElectionStatsBucket is the turnout of an election during a period of time, or
+//  a range of blocks. Votes were cast during the bucket, and VoteCount is the
+//  total at its end.
+type ElectionStatsBucket struct {
+	Start       *time.Time `json:"start,omitempty"`
+	StartHeight uint32     `json:"startHeight,omitempty"`
+	Votes       int        `json:"votes"`
+	VoteCount   int        `json:"voteCount"`
+	Turnout     float64    `json:"turnout,omitempty"`
+}
+
+// BlockEstimate is the estimated date of a Vochain block. DateError is the 95%
+//  error bound of the date in seconds, and BlockTime the fitted block time in
+//  ms, fitted on Samples block timestamps. They are 0 while too few blocks are
+//  sampled.
+type BlockEstimate struct {
+	Height    uint32    `json:"height"`
+	Date      time.Time `json:"date"`
+	DateError int64     `json:"dateError,omitempty"`
+	BlockTime int64     `json:"blockTime,omitempty"`
+	Samples   int       `json:"samples,omitempty"`
+}
+
+// Results export formats
+const (
+	ResultsFormatCSV    = "csv"
+	ResultsFormatJSON   = "json"
+	ResultsFormatBundle = "bundle"
+)
+
+// ResultsBundle is a verifiable report of the results of an election. Signature
+//  is the Ethereum signature of the exact Payload bytes by Signer, the address
+//  of the API signing key, so anyone can audit the bundle offline.
+type ResultsBundle struct {
+	Payload   json.RawMessage `json:"payload"`
+	Signer    types.HexBytes  `json:"signer"`
+	Signature types.HexBytes  `json:"signature"`
+}
+
+// ResultsBundlePayload is the signed content of a ResultsBundle. Height is the
+//  Vochain block height of the results, and for final results that of their snapshot.
+type ResultsBundlePayload struct {
+	ChainID        string                `json:"chainId"`
+	OrganizationID types.HexBytes        `json:"organizationId"`
+	ElectionID     types.HexBytes        `json:"electionId"`
+	Height         uint32                `json:"height"`
+	VoteCount      uint32                `json:"voteCount"`
+	Final          bool                  `json:"final"`
+	Hash           types.HexBytes        `json:"hash,omitempty"`
+	Process        *indexertypes.Process `json:"process"`
+	Metadata       *ProcessMetadata      `json:"metadata"`
+	Results        *VochainResults       `json:"results"`
+	Aggregated     []Result              `json:"aggregatedResults,omitempty"`
+	CreatedAt      time.Time             `json:"createdAt"`
+}
+
+// VoteReceipt is the receipt of a vote counted by the Vochain. As in a
+//  ResultsBundle, Signature is the Ethereum signature of the exact Payload bytes
+//  by Signer, the address of the API signing key.
+type VoteReceipt struct {
+	Payload   json.RawMessage `json:"payload"`
+	Signer    types.HexBytes  `json:"signer"`
+	Signature types.HexBytes  `json:"signature"`
+}
+
+// VoteReceiptPayload is the signed content of a VoteReceipt. Votes are only
+//  set for elections without encrypted votes.
+type VoteReceiptPayload struct {
+	ChainID              string         `json:"chainId"`
+	ElectionID           types.HexBytes `json:"electionId"`
+	Nullifier            types.HexBytes `json:"nullifier"`
+	Height               uint32         `json:"height"`
+	TxIndex              int32          `json:"txIndex"`
+	TxHash               types.HexBytes `json:"txHash"`
+	Timestamp            time.Time      `json:"timestamp"`
+	Weight               string         `json:"weight"`
+	EncryptionKeyIndexes []uint32       `json:"encryptionKeyIndexes,omitempty"`
+	Votes                []int          `json:"votes,omitempty"`
+	CreatedAt            time.Time      `json:"createdAt"`
 }
 
 // APIElectionSummary is the struct for returning election info from the database
@@ -131,16 +280,16 @@ type Result struct {
 	Value []string `json:"value"`
 }
 
-// Question is a single election question for the API response
+// Question is a single election question for the API request and response
 type Question struct {
-	Title       string   `json:"title"`
+	Title       string   `json:"title" validate:"required,max=256"`
 	Description string   `json:"description"`
-	Choices     []Choice `json:"choices"`
+	Choices     []Choice `json:"choices" validate:"required,max=64,unique=Value"`
 }
 
-// Choice is a sigle question choice for the API response
+// Choice is a sigle question choice for the API request and response
 type Choice struct {
-	Title string `json:"title"`
+	Title string `json:"title" validate:"required,max=256"`
 	Value uint32 `json:"value"`
 }
 
@@ -192,12 +341,13 @@ type EntityMedia struct {
 	Logo   string `json:"logo,omitempty"`
 }
 
-// VochainResults is the results of a single process, as returned by the vochain
+// VochainResults is the results of a single process, as returned by the vochain.
+//  VoteCount is the number of votes counted, which the gateway returns as height.
 type VochainResults struct {
-	Height  uint32     `json:"height,omitempty"`
-	Results [][]string `json:"results,omitempty"`
-	State   string     `json:"state,omitempty"`
-	Type    string     `json:"type,omitempty"`
+	VoteCount uint32     `json:"voteCount,omitempty"`
+	Results   [][]string `json:"results,omitempty"`
+	State     string     `json:"state,omitempty"`
+	Type      string     `json:"type,omitempty"`
 }
 
 // RawFile provides a json struct wrapper to a raw bytes payload, used for storing
@@ -209,6 +359,22 @@ type RawFile struct {
 
 // SetError sets the MetaResponse's Message to a string
 // representation of v. Usually, v's type will be error or string.
+// If v is an error, Code is set to its API error code.
 func (r *APIResponse) SetError(v interface{}) {
 	r.Message = fmt.Sprintf("%s", v)
+	if err, ok := v.(error); ok {
+		r.Code = apierror.From(err).Code
+	}
+}
+
+// CspMessage is the request and response of the hosted CSP, compatible with the
+//  messages of github.com/vocdoni/blind-csp
+type CspMessage struct {
+	Error     string         `json:"error,omitempty"`
+	Token     types.HexBytes `json:"tokenR,omitempty"`
+	Payload   types.HexBytes `json:"payload,omitempty"`
+	Signature types.HexBytes `json:"signature,omitempty"`
+	SharedKey types.HexBytes `json:"sharedkey,omitempty"`
+	AuthData  []string       `json:"authData,omitempty"`
+	Response  string         `json:"response,omitempty"`
 }
@@ -0,0 +1,106 @@
+package types
+
+// Request bodies of each endpoint. Field names match APIRequest, so clients sending
+//  an APIRequest keep working. The `validate` tags are checked by util.Validate.
+
+// CreateIntegratorRequest is the body of POST /admin/accounts
+type CreateIntegratorRequest struct {
+	CspPubKey    string `json:"cspPubKey" validate:"hex"`
+	CspUrlPrefix string `json:"cspUrlPrefix"`
+	Email        string `json:"email" validate:"required,email"`
+	Name         string `json:"name" validate:"required,max=256"`
+}
+
+// UpdateIntegratorRequest is the body of PUT /admin/accounts/{id}
+type UpdateIntegratorRequest struct {
+	CspPubKey    string `json:"cspPubKey" validate:"hex"`
+	CspUrlPrefix string `json:"cspUrlPrefix"`
+	Name         string `json:"name" validate:"max=256"`
+}
+
+// CreateOrganizationRequest is the body of POST /priv/account/organizations
+type CreateOrganizationRequest struct {
+	Avatar      string `json:"avatar"`
+	Description string `json:"description"`
+	Header      string `json:"header"`
+	Name        string `json:"name" validate:"required,max=256"`
+}
+
+// SetOrganizationMetadataRequest is the body of PUT /priv/organizations/{organizationId}/metadata
+type SetOrganizationMetadataRequest struct {
+	Avatar      string `json:"avatar"`
+	Description string `json:"description"`
+	Header      string `json:"header"`
+	Name        string `json:"name" validate:"max=256"`
+}
+
+// CreateElectionRequest is the body of POST /priv/organizations/{organizationId}/elections/{type}
+// StartDate may be empty, so the election starts as soon as possible
+// The election is defined by the template TemplateID, or like the election
+//  CloneFrom, if set. The other fields override those of the definition if they
+//  are not empty, and the flags can only be turned on. Title and Questions are
+//  required without a definition.
+type CreateElectionRequest struct {
+	// AuthHandler authenticates the voters on the hosted CSP: census (default),
+	//  idlist, email or jwt
+	AuthHandler   string     `json:"authHandler"`
+	CloneFrom     string     `json:"cloneFrom" validate:"hex"`
+	Confidential  bool       `json:"confidential"`
+	Description   string     `json:"description"`
+	EndDate       string     `json:"endDate" validate:"required,date"`
+	Header        string     `json:"header"`
+	HiddenResults bool       `json:"hiddenResults"`
+	Questions     []Question `json:"questions" validate:"required_without=TemplateID|CloneFrom,max=64"`
+	StartDate     string     `json:"startDate" validate:"date"`
+	StreamURI     string     `json:"streamUri"`
+	TemplateID    int        `json:"templateId"`
+	Title         string     `json:"title" validate:"required_without=TemplateID|CloneFrom,max=256"`
+}
+
+// CreateElectionTemplateRequest is the body of POST
+//  /priv/organizations/{organizationId}/templates. The template is either
+//  Definition, or the definition of the election ElectionID, from its metadata.
+type CreateElectionTemplateRequest struct {
+	Definition *ElectionDefinition `json:"definition"`
+	ElectionID string              `json:"electionId" validate:"hex"`
+	Name       string              `json:"name" validate:"required,max=256"`
+}
+
+// AddCspVotersRequest is the body of POST /priv/elections/{electionId}/voters.
+// The voters of idlist elections need an id and a code, those of email
+//  elections an email.
+type AddCspVotersRequest struct {
+	Voters []CspVoterEntry `json:"voters" validate:"required,max=100000"`
+}
+
+// BuildVoteRequest is the body of POST /pub/elections/{electionId}/vote/package
+// Without CspSignature, only the CSP bundle of VoterAddress is built.
+type BuildVoteRequest struct {
+	CspSignature string `json:"cspSignature" validate:"hex"`
+	Votes        []int  `json:"votes" validate:"required,max=256"`
+	VoterAddress string `json:"voterAddress" validate:"required,hex"`
+}
+
+// CspVoterEntry is a voter of AddCspVotersRequest
+type CspVoterEntry struct {
+	Code  string `json:"code" validate:"max=256"`
+	Email string `json:"email" validate:"email,max=256"`
+	ID    string `json:"id" validate:"max=256"`
+}
+
+// ScheduleActionRequest is the body of POST /priv/elections/{electionId}/schedule
+type ScheduleActionRequest struct {
+	Action    string `json:"action" validate:"required"`
+	ExecuteAt string `json:"executeAt" validate:"required,date"`
+}
+
+// SubmitVoteRequest is the body of POST /pub/elections/{electionId}/vote
+type SubmitVoteRequest struct {
+	Vote string `json:"vote" validate:"required,base64"`
+}
+
+// SubmitSignedVoteRequest is the body of POST /pub/elections/{electionId}/vote/signed
+type SubmitSignedVoteRequest struct {
+	Signature string `json:"signature" validate:"required,hex"`
+	VoteTx    string `json:"voteTx" validate:"required,hex"`
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"go.vocdoni.io/dvote/types"
 )
 
 type CreatedUpdated struct {
@@ -19,6 +20,8 @@ type Integrator struct {
 	Email        string `json:"email" db:"email"`
 	CspUrlPrefix string `json:"cspUrlPrefix" db:"csp_url_prefix"`
 	CspPubKey    []byte `json:"cspPubKey" db:"csp_pub_key"` // CSP compressed eth public key
+	// DeletedAt is set when the integrator is deleted, until it is purged
+	DeletedAt *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
 }
 
 type QuotaPlan struct {
@@ -27,6 +30,10 @@ type QuotaPlan struct {
 	Name            string    `json:"name" db:"name"`
 	MaxCensusSize   int       `json:"maxCensusSize" db:"max_census_size"`
 	MaxProcessCount int       `json:"maxProcessCount" db:"max_process_count"`
+	// RateLimit and RateBurst are the requests per minute and at once allowed
+	//  to the API tokens of the organizations on the plan. 0 uses the API default.
+	RateLimit int `json:"rateLimit" db:"rate_limit"`
+	RateBurst int `json:"rateBurst" db:"rate_burst"`
 }
 
 type Organization struct {
@@ -41,6 +48,14 @@ type Organization struct {
 	PublicAPIToken   string        `json:"publicApiToken" db:"public_api_token"`      // Public API token
 	QuotaPlanID      uuid.NullUUID `json:"quotaPlanId" db:"quota_plan_id"`            // Billing plan ID
 	PublicAPIQuota   int           `json:"publicApiQuota" db:"public_api_quota"`
+	// DeletedAt is set when the organization is deleted, until it is purged
+	DeletedAt *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
+	// Vochain account state, synced by the reconciler. MissingSince is set while
+	//  the account is not found on the Vochain.
+	MetadataURI  string     `json:"metadataUri,omitempty" db:"metadata_uri"`
+	Balance      int64      `json:"balance,omitempty" db:"balance"`
+	Nonce        int64      `json:"nonce,omitempty" db:"nonce"`
+	MissingSince *time.Time `json:"missingSince,omitempty" db:"missing_since"`
 }
 
 type Election struct {
@@ -56,14 +71,303 @@ type Election struct {
 	StartBlock       int           `json:"startBlock,omitempty" db:"start_block"`
 	EndBlock         int           `json:"endBlock,omitempty" db:"end_block"`
 	ProofType        string        `json:"proofType,omitempty" db:"proof_type"`
+	Status           string        `json:"status,omitempty" db:"status"`
 	Confidential     bool          `json:"confidential,omitempty" db:"confidential"`
 	HiddenResults    bool          `json:"hiddenResults,omitempty" db:"hidden_results"`
 	MetadataPrivKey  []byte        `json:"metadataPrivKey,omitempty" db:"metadata_priv_key"`
+	// Vochain process state, synced by the reconciler. MissingSince is set while
+	//  the process is not found on the Vochain.
+	VoteCount    int        `json:"voteCount,omitempty" db:"vote_count"`
+	HasResults   bool       `json:"hasResults,omitempty" db:"has_results"`
+	MissingSince *time.Time `json:"missingSince,omitempty" db:"missing_since"`
+	// CspAuth is the handler authenticating the voters on the hosted CSP, and
+	//  CspAuthSecret its encrypted secret, if any
+	CspAuth       string `json:"cspAuth,omitempty" db:"csp_auth"`
+	CspAuthSecret []byte `json:"-" db:"csp_auth_secret"`
 }
 
+// ListOptions selects a page of a list. Cursor is the opaque position returned
+//  with the previous page, and keeps its order.
 type ListOptions struct {
 	Count  int    `json:"count,omitempty"`
 	Order  string `json:"order,omitempty"`
 	Skip   int    `json:"skip,omitempty"`
 	SortBy string `json:"sortBy,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// ElectionFilter selects a page of an organization's elections. Zero values do not filter.
+type ElectionFilter struct {
+	ListOptions
+	ProofType string
+	// Title matches the elections whose title contains it, ignoring case
+	Title       string
+	StartAfter  time.Time
+	StartBefore time.Time
+	EndAfter    time.Time
+	EndBefore   time.Time
+	// Status selects the elections with the given API status (one of the
+	//  ElectionStatus values) at the block Height
+	Status string
+	Height int
+}
+
+// Election statuses shown by the API. Elections stored as READY, as on the
+//  Vochain, are upcoming, active or ended depending on the block height.
+const (
+	ElectionStatusReady    = "READY"
+	ElectionStatusUpcoming = "UPCOMING"
+	ElectionStatusActive   = "ACTIVE"
+	ElectionStatusEnded    = "ENDED"
+	ElectionStatusResults  = "RESULTS"
+	ElectionStatusPaused   = "PAUSED"
+	ElectionStatusCanceled = "CANCELED"
+	ElectionStatusUnknown  = "UNKNOWN"
+)
+
+// ResultsSnapshot is the final results of an election, persisted once the Vochain
+//  publishes them so they stay available if the gateway prunes its history.
+//  Height is the Vochain block height when the snapshot was taken, and Hash the
+//  SHA-256 of the JSON object {"electionId","height","results"}, with the
+//  election id in hex.
+type ResultsSnapshot struct {
+	CreatedAt      time.Time      `json:"createdAt"`
+	OrganizationID types.HexBytes `json:"organizationId"`
+	ElectionID     types.HexBytes `json:"electionId"`
+	Height         uint32         `json:"height"`
+	VoteCount      uint32         `json:"voteCount"`
+	State          string         `json:"state,omitempty"`
+	Type           string         `json:"type,omitempty"`
+	Results        [][]string     `json:"results"`
+	// Aggregated is empty if the results could not be aggregated with the metadata
+	Aggregated []Result       `json:"aggregated,omitempty"`
+	Hash       types.HexBytes `json:"hash"`
+}
+
+// Chain event kinds. Missing and found events record the drift between the
+//  database and the Vochain, the others a change of the named field.
+const (
+	ChainEventStatus      = "status"
+	ChainEventStartBlock  = "startBlock"
+	ChainEventEndBlock    = "endBlock"
+	ChainEventVoteCount   = "voteCount"
+	ChainEventResults     = "results"
+	ChainEventMetadataURI = "metadataUri"
+	ChainEventBalance     = "balance"
+	ChainEventMissing     = "missing"
+	ChainEventFound       = "found"
+)
+
+// ChainEvent records a change of the Vochain state of an election or an
+//  organization, found by the reconciler. ProcessID is empty for organizations.
+type ChainEvent struct {
+	ID            int64          `json:"id" db:"id"`
+	CreatedAt     time.Time      `json:"createdAt" db:"created_at"`
+	OrgEthAddress types.HexBytes `json:"organizationId" db:"organization_eth_address"`
+	ProcessID     types.HexBytes `json:"electionId,omitempty" db:"process_id"`
+	Kind          string         `json:"kind" db:"kind"`
+	OldValue      string         `json:"oldValue" db:"old_value"`
+	NewValue      string         `json:"newValue" db:"new_value"`
+}
+
+// ChainEventFilter selects chain events. Zero values do not filter.
+type ChainEventFilter struct {
+	OrgEthAddress []byte
+	ProcessID     []byte
+	Kind          string
+	From          time.Time
+	To            time.Time
+	Count         int
+	Skip          int
+	Cursor        string
+}
+
+// Audit event actor types and outcomes
+const (
+	AuditActorAdmin        = "admin"
+	AuditActorIntegrator   = "integrator"
+	AuditActorOrganization = "organization"
+
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
+
+// AuditEvent records a privileged operation performed through the API
+type AuditEvent struct {
+	ID        int64     `json:"id" db:"id"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	// ActorType is one of the AuditActor constants, and ActorID identifies the actor
+	//  within its type: the integrator id, the SHA-256 hash of the organization's
+	//  token, or empty for the admin
+	ActorType string `json:"actorType" db:"actor_type"`
+	ActorID   string `json:"actorId" db:"actor_id"`
+	// IntegratorID is the integrator acting or acted upon, 0 if none
+	IntegratorID int    `json:"integratorId" db:"integrator_id"`
+	Action       string `json:"action" db:"action"`
+	Target       string `json:"target" db:"target"`
+	RequestID    string `json:"requestId" db:"request_id"`
+	Outcome      string `json:"outcome" db:"outcome"`
+	HTTPStatus   int    `json:"httpStatus" db:"http_status"`
+	Error        string `json:"error,omitempty" db:"error"`
+}
+
+// AuditFilter selects audit events. Zero values do not filter.
+type AuditFilter struct {
+	IntegratorID int
+	ActorType    string
+	Action       string
+	Outcome      string
+	From         time.Time
+	To           time.Time
+	Count        int
+	Skip         int
+	Cursor       string
+}
+
+// Usage metrics, counted per day, integrator and organization
+const (
+	// UsageAPICalls counts the calls to the API, failed or not
+	UsageAPICalls = "apiCalls"
+	// UsageElections counts the elections created
+	UsageElections = "elections"
+	// UsageVotes counts the votes relayed to the Vochain
+	UsageVotes = "votes"
+	// UsageFaucetTokens counts the Vochain tokens sent from the faucet
+	UsageFaucetTokens = "faucetTokens"
+)
+
+// ElectionSample is the vote count of an election at a Vochain height, sampled
+//  by the reconciler
+type ElectionSample struct {
+	ProcessID []byte    `json:"-" db:"process_id"`
+	SampledAt time.Time `json:"sampledAt" db:"sampled_at"`
+	Height    uint32    `json:"height" db:"height"`
+	VoteCount int       `json:"voteCount" db:"vote_count"`
+}
+
+// ElectionDefinition is the definition of an election, reusable by a template or
+//  cloned from an election: its metadata, with its texts in every language, and
+//  its settings. AuthHandler is empty for the default, census.
+type ElectionDefinition struct {
+	Title         LanguageString `json:"title"`
+	Description   LanguageString `json:"description,omitempty"`
+	Header        string         `json:"header,omitempty"`
+	StreamURI     string         `json:"streamUri,omitempty"`
+	Questions     []QuestionMeta `json:"questions"`
+	ProofType     ProofType      `json:"proofType"`
+	Confidential  bool           `json:"confidential"`
+	HiddenResults bool           `json:"hiddenResults"`
+	AuthHandler   string         `json:"authHandler,omitempty"`
+}
+
+// ElectionTemplate is an election definition stored by an organization, to
+//  create elections from
+type ElectionTemplate struct {
+	ID               int                `json:"id"`
+	CreatedAt        time.Time          `json:"createdAt"`
+	IntegratorApiKey []byte             `json:"-"`
+	OrgEthAddress    types.HexBytes     `json:"organizationId"`
+	Name             string             `json:"name"`
+	Definition       ElectionDefinition `json:"definition"`
+}
+
+// BlockSample is the timestamp of a Vochain block, sampled to estimate the
+//  dates of future blocks
+type BlockSample struct {
+	Height    uint32    `json:"height" db:"height"`
+	Timestamp time.Time `json:"timestamp" db:"timestamp"`
+}
+
+// Scheduled actions, and their states. Ending an election is what makes the
+//  Vochain reveal the keys of hidden results and publish them.
+const (
+	ScheduledActionPause = "pause"
+	ScheduledActionEnd   = "end"
+
+	ScheduledActionPending  = "pending"
+	ScheduledActionRunning  = "running"
+	ScheduledActionDone     = "done"
+	ScheduledActionFailed   = "failed"
+	ScheduledActionCanceled = "canceled"
+)
+
+// ScheduledAction is a status change of an election to execute at ExecuteAt.
+//  TargetBlock is the Vochain height estimated for ExecuteAt, estimated again
+//  by the scheduler as it gets close, and the action runs once it is reached.
+type ScheduledAction struct {
+	ID               int            `json:"id" db:"id"`
+	CreatedAt        time.Time      `json:"createdAt" db:"created_at"`
+	IntegratorApiKey []byte         `json:"-" db:"integrator_api_key"`
+	OrgEthAddress    types.HexBytes `json:"organizationId" db:"organization_eth_address"`
+	ProcessID        types.HexBytes `json:"electionId" db:"process_id"`
+	Action           string         `json:"action" db:"action"`
+	ExecuteAt        time.Time      `json:"executeAt" db:"execute_at"`
+	TargetBlock      uint32         `json:"targetBlock" db:"target_block"`
+	Status           string         `json:"status" db:"status"`
+	Error            string         `json:"error,omitempty" db:"error"`
+	TxHash           types.HexBytes `json:"txHash,omitempty" db:"tx_hash"`
+	// ClaimedAt is when a replica last started running the action
+	ClaimedAt  *time.Time `json:"claimedAt,omitempty" db:"claimed_at"`
+	ExecutedAt *time.Time `json:"executedAt,omitempty" db:"executed_at"`
+}
+
+// UsageCounter is the usage of a metric by an integrator during a day (UTC).
+//  OrgEthAddress is empty for the usage not tied to an organization, and PlanID
+//  is the current plan of the organization.
+type UsageCounter struct {
+	ID            int64          `json:"-" db:"id"`
+	Day           time.Time      `json:"day" db:"day"`
+	IntegratorID  int            `json:"integratorId" db:"integrator_id"`
+	OrgEthAddress types.HexBytes `json:"organizationId,omitempty" db:"organization_eth_address"`
+	PlanID        uuid.NullUUID  `json:"planId" db:"quota_plan_id"`
+	Metric        string         `json:"metric" db:"metric"`
+	Count         int64          `json:"count" db:"count"`
+}
+
+// UsageFilter selects usage counters. Zero values do not filter. From and To
+//  select whole days (UTC), from the day of From to the day before To.
+type UsageFilter struct {
+	IntegratorID  int
+	OrgEthAddress []byte
+	Metric        string
+	From          time.Time
+	To            time.Time
+	Count         int
+	Skip          int
+	Cursor        string
+}
+
+// CspKey is a root key of the hosted CSP, owned by an integrator or, if
+//  OrgEthAddress is set, by one of its organizations
+type CspKey struct {
+	ID            int            `json:"-" db:"id"`
+	CreatedAt     time.Time      `json:"createdAt" db:"created_at"`
+	IntegratorID  int            `json:"integratorId" db:"integrator_id"`
+	OrgEthAddress types.HexBytes `json:"organizationId,omitempty" db:"organization_eth_address"`
+	PubKey        types.HexBytes `json:"cspPubKey" db:"pub_key"`
+	PrivKeyCipher []byte         `json:"-" db:"priv_key_cipher"`
+}
+
+// CspRequest is a signature request of a voter authenticated by the hosted CSP.
+//  SecretKCipher is the encrypted secret k of blind requests.
+type CspRequest struct {
+	Token         []byte     `db:"token"`
+	ElectionID    []byte     `db:"election_id"`
+	Voter         string     `db:"voter"`
+	SignType      string     `db:"sign_type"`
+	SecretKCipher []byte     `db:"secret_k_cipher"`
+	SignedAt      *time.Time `db:"signed_at"`
+}
+
+// CspVoter is a voter of the list uploaded for an election authenticated by
+//  the hosted CSP. SecretHash is the hash of its secret code, and OTPHash the
+//  hash of the one-time code last sent to its email, valid until OTPExpires.
+type CspVoter struct {
+	ElectionID  []byte     `json:"-" db:"election_id"`
+	VoterID     string     `json:"id" db:"voter_id"`
+	SecretHash  []byte     `json:"-" db:"secret_hash"`
+	Email       string     `json:"email,omitempty" db:"email"`
+	OTPHash     []byte     `json:"-" db:"otp_hash"`
+	OTPExpires  *time.Time `json:"-" db:"otp_expires"`
+	OTPAttempts int        `json:"-" db:"otp_attempts"`
 }
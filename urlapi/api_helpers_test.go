@@ -2,14 +2,40 @@ package urlapi
 
 import (
 	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	qt "github.com/frankban/quicktest"
 	sk "github.com/vocdoni/blind-csp/saltedkey"
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/config"
+	"go.vocdoni.io/api/csp"
+	"go.vocdoni.io/api/database"
+	"go.vocdoni.io/api/ratelimit"
 	"go.vocdoni.io/api/types"
 	"go.vocdoni.io/api/util"
+	"go.vocdoni.io/dvote/api"
+	"go.vocdoni.io/dvote/crypto"
 	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/crypto/nacl"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/bearerstdapi"
+	dvoteutil "go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain"
+	"go.vocdoni.io/dvote/vochain/scrutinizer/indexertypes"
+	"go.vocdoni.io/proto/build/go/models"
+	"google.golang.org/protobuf/proto"
 )
 
 // testing non-handler methods
@@ -143,6 +169,288 @@ func TestAppendProcess(t *testing.T) {
 	qt.Assert(t, len(electionList), qt.Equals, 0)
 }
 
+func TestElectionStatus(t *testing.T) {
+	for _, tc := range []struct {
+		status   string
+		height   uint32
+		expected string
+	}{
+		{types.ElectionStatusReady, 100, types.ElectionStatusUpcoming},
+		{types.ElectionStatusReady, 200, types.ElectionStatusUpcoming},
+		{types.ElectionStatusReady, 300, types.ElectionStatusActive},
+		{types.ElectionStatusReady, 400, types.ElectionStatusEnded},
+		{types.ElectionStatusReady, 0, types.ElectionStatusReady},
+		{types.ElectionStatusEnded, 300, types.ElectionStatusEnded},
+		{types.ElectionStatusResults, 500, types.ElectionStatusEnded},
+		{types.ElectionStatusPaused, 300, types.ElectionStatusPaused},
+		{types.ElectionStatusCanceled, 100, types.ElectionStatusCanceled},
+		{"PROCESS_UNKNOWN", 300, types.ElectionStatusUnknown},
+	} {
+		qt.Check(t, electionStatus(tc.status, 200, 400, tc.height), qt.Equals, tc.expected,
+			qt.Commentf("%s at %d", tc.status, tc.height))
+	}
+}
+
+func TestElectionChanges(t *testing.T) {
+	stored := &types.Election{OrgEthAddress: []byte{1}, ProcessID: []byte{2},
+		Status: types.ElectionStatusReady, StartBlock: 100, EndBlock: 200}
+	synced := *stored
+	qt.Assert(t, electionChanges(stored, &synced), qt.HasLen, 0)
+
+	synced.Status = types.ElectionStatusEnded
+	synced.EndBlock = 150
+	synced.HasResults = true
+	synced.VoteCount = 7
+	events := electionChanges(stored, &synced)
+	qt.Assert(t, events, qt.DeepEquals, []types.ChainEvent{
+		{OrgEthAddress: []byte{1}, ProcessID: []byte{2}, Kind: types.ChainEventStatus,
+			OldValue: types.ElectionStatusReady, NewValue: types.ElectionStatusEnded},
+		{OrgEthAddress: []byte{1}, ProcessID: []byte{2}, Kind: types.ChainEventEndBlock,
+			OldValue: "200", NewValue: "150"},
+		{OrgEthAddress: []byte{1}, ProcessID: []byte{2}, Kind: types.ChainEventResults,
+			OldValue: "false", NewValue: "true"},
+		{OrgEthAddress: []byte{1}, ProcessID: []byte{2}, Kind: types.ChainEventVoteCount,
+			OldValue: "0", NewValue: "7"},
+	})
+
+	// going missing and being found again are recorded once
+	missingSince := time.Date(2022, 4, 1, 10, 0, 0, 0, time.UTC)
+	missing := *stored
+	missing.MissingSince = &missingSince
+	events = electionChanges(stored, &missing)
+	qt.Assert(t, events, qt.HasLen, 1)
+	qt.Assert(t, events[0].Kind, qt.Equals, types.ChainEventMissing)
+	qt.Assert(t, events[0].NewValue, qt.Equals, "2022-04-01T10:00:00Z")
+	qt.Assert(t, electionChanges(&missing, &missing), qt.HasLen, 0)
+	events = electionChanges(&missing, stored)
+	qt.Assert(t, events, qt.HasLen, 1)
+	qt.Assert(t, events[0].Kind, qt.Equals, types.ChainEventFound)
+
+	// elections with encrypted votes count their envelopes before having results
+	process := &indexertypes.Process{Status: int32(models.ProcessStatus_READY),
+		StartBlock: 100, EndBlock: 200, Envelope: &models.EnvelopeType{EncryptedVotes: true}}
+	synced = *syncedElection(&missing, process, 3)
+	qt.Assert(t, synced.MissingSince, qt.IsNil)
+	qt.Assert(t, synced.HasResults, qt.IsFalse)
+	qt.Assert(t, synced.VoteCount, qt.Equals, 3)
+	qt.Assert(t, electionChanges(stored, &synced), qt.HasLen, 1)
+}
+
+func TestOrganizationChanges(t *testing.T) {
+	stored := &types.Organization{EthAddress: []byte{1}, MetadataURI: "ipfs://a", Balance: 10,
+		Nonce: 1}
+	synced := *stored
+	// nonces are not recorded
+	synced.Nonce = 2
+	qt.Assert(t, organizationChanges(stored, &synced), qt.HasLen, 0)
+
+	synced.MetadataURI = "ipfs://b"
+	synced.Balance = 8
+	qt.Assert(t, organizationChanges(stored, &synced), qt.DeepEquals, []types.ChainEvent{
+		{OrgEthAddress: []byte{1}, Kind: types.ChainEventMetadataURI,
+			OldValue: "ipfs://a", NewValue: "ipfs://b"},
+		{OrgEthAddress: []byte{1}, Kind: types.ChainEventBalance, OldValue: "10", NewValue: "8"},
+	})
+}
+
+func TestResultsHash(t *testing.T) {
+	results := [][]string{{"1", "2"}, {"0", "3"}}
+	expected := sha256.Sum256([]byte(
+		`{"electionId":"0a0b","height":5,"results":[["1","2"],["0","3"]]}`))
+	qt.Assert(t, resultsHash([]byte{10, 11}, 5, results), qt.DeepEquals, expected[:])
+	qt.Assert(t, resultsHash([]byte{10, 11}, 6, results), qt.Not(qt.DeepEquals), expected[:])
+}
+
+func TestResultsSnapshot(t *testing.T) {
+	results := &types.VochainResults{VoteCount: 5, State: "RESULTS",
+		Results: [][]string{{"2", "3"}}}
+
+	// snapshots are at a block height, and commit to it, not to the vote count
+	snapshot := newResultsSnapshot([]byte{10, 11}, 1200, results, nil)
+	qt.Assert(t, snapshot.Height, qt.Equals, uint32(1200))
+	qt.Assert(t, snapshot.VoteCount, qt.Equals, uint32(5))
+	qt.Assert(t, []byte(snapshot.Hash), qt.DeepEquals,
+		resultsHash([]byte{10, 11}, 1200, results.Results))
+
+	resp := snapshotResults(snapshot)
+	qt.Assert(t, resp.Height, qt.Not(qt.Equals), resp.VoteCount)
+	qt.Assert(t, resp.Height, qt.Equals, uint32(1200))
+	qt.Assert(t, resp.VoteCount, qt.Equals, uint32(5))
+	qt.Assert(t, resp.Final, qt.IsTrue)
+}
+
+func TestResultsCache(t *testing.T) {
+	results := &types.VochainResults{VoteCount: 3, Results: [][]string{{"1"}}}
+	cache := newResultsCache(time.Hour)
+	qt.Assert(t, cache.get([]byte{1}), qt.IsNil)
+	cache.set([]byte{1}, results)
+	qt.Assert(t, cache.get([]byte{1}), qt.Equals, results)
+	qt.Assert(t, cache.get([]byte{2}), qt.IsNil)
+
+	// expired entries are not returned, and are dropped on the next set
+	cache.entries[string([]byte{1})] = cachedResults{results: results,
+		expires: time.Now().Add(-time.Second)}
+	qt.Assert(t, cache.get([]byte{1}), qt.IsNil)
+	cache.set([]byte{2}, results)
+	qt.Assert(t, cache.entries, qt.HasLen, 1)
+
+	disabled := newResultsCache(0)
+	disabled.set([]byte{1}, results)
+	qt.Assert(t, disabled.get([]byte{1}), qt.IsNil)
+}
+
+func TestResultsCSV(t *testing.T) {
+	meta := &types.ProcessMetadata{Questions: []types.QuestionMeta{
+		{Title: map[string]string{"default": "Color, or not"}, Choices: []types.ChoiceMetadata{
+			{Title: map[string]string{"default": "red"}, Value: 0},
+			{Title: map[string]string{"default": "blue"}, Value: 1},
+		}},
+		{Title: map[string]string{"default": "Size"}, Choices: []types.ChoiceMetadata{
+			{Title: map[string]string{"default": "big"}, Value: 0},
+		}},
+	}}
+	data, err := resultsCSV(meta, &types.VochainResults{Results: [][]string{{"3", "5"}, {"7"}}})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, string(data), qt.Equals, "question,questionTitle,choice,choiceTitle,votes\n"+
+		"0,\"Color, or not\",0,red,3\n0,\"Color, or not\",1,blue,5\n1,Size,0,big,7\n")
+
+	_, err = resultsCSV(meta, &types.VochainResults{Results: [][]string{{"3", "5"}}})
+	qt.Assert(t, err, qt.ErrorMatches, ".*number of questions")
+	_, err = resultsCSV(meta, &types.VochainResults{Results: [][]string{{"3"}, {"7"}}})
+	qt.Assert(t, err, qt.ErrorMatches, ".*number of choices")
+}
+
+func TestUsageMeter(t *testing.T) {
+	meter := newUsageMeter()
+	meter.addIntegrator("aa", []byte{1}, types.UsageAPICalls, 1)
+	meter.addIntegrator("aa", []byte{1}, types.UsageAPICalls, 2)
+	meter.addIntegrator("aa", nil, types.UsageAPICalls, 1)
+	meter.addOrganization("token", types.UsageVotes, 1)
+	// anonymous usage is not counted
+	meter.addOrganization("", types.UsageVotes, 1)
+
+	entries := meter.take()
+	qt.Assert(t, entries, qt.HasLen, 3)
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+	qt.Assert(t, entries[usageKey{day: day, integrator: true, token: "aa",
+		organization: "\x01", metric: types.UsageAPICalls}], qt.Equals, int64(3))
+	qt.Assert(t, entries[usageKey{day: day, token: "token", metric: types.UsageVotes}],
+		qt.Equals, int64(1))
+	qt.Assert(t, meter.take(), qt.HasLen, 0)
+
+	meter.addOrganization("token", types.UsageVotes, 1)
+	meter.restore(entries)
+	qt.Assert(t, meter.take()[usageKey{day: day, token: "token", metric: types.UsageVotes}],
+		qt.Equals, int64(2))
+}
+
+// usageDB attributes usage to the integrator "aa" and the organization of "token",
+//  failing the lookups of "bb" until it is fixed
+type usageDB struct {
+	database.Database
+	broken bool
+	usage  []types.UsageCounter
+}
+
+func (d *usageDB) GetIntegratorByKey(secretKey []byte) (*types.Integrator, error) {
+	switch hex.EncodeToString(secretKey) {
+	case "aa":
+		return &types.Integrator{ID: 1}, nil
+	case "bb":
+		if d.broken {
+			return nil, apierror.ErrDatabase
+		}
+		return &types.Integrator{ID: 2}, nil
+	}
+	return nil, apierror.ErrIntegratorNotFound
+}
+
+func (d *usageDB) GetOrganizationByAPIToken(token string) (*types.Organization, error) {
+	if token == "token" {
+		return &types.Organization{IntegratorID: 1, EthAddress: []byte{1}}, nil
+	}
+	return nil, apierror.ErrOrganizationNotFound
+}
+
+func (d *usageDB) AddUsage(counters []types.UsageCounter) error {
+	d.usage = append(d.usage, counters...)
+	return nil
+}
+
+func TestFlushUsage(t *testing.T) {
+	db := &usageDB{broken: true}
+	u := &URLAPI{db: db, usage: newUsageMeter()}
+	u.usage.addIntegrator("aa", []byte{1}, types.UsageAPICalls, 1)
+	u.usage.addOrganization("token", types.UsageAPICalls, 2)
+	u.usage.addIntegrator("bb", nil, types.UsageAPICalls, 3)
+	u.usage.addIntegrator("cc", nil, types.UsageAPICalls, 4)
+	u.usage.addIntegrator("not hex", nil, types.UsageAPICalls, 5)
+
+	// unknown tokens are dropped, and usage that could not be attributed is kept
+	qt.Assert(t, errors.Is(u.flushUsage(), apierror.ErrDatabase), qt.IsTrue)
+	qt.Assert(t, db.usage, qt.HasLen, 1)
+	qt.Assert(t, db.usage[0].IntegratorID, qt.Equals, 1)
+	qt.Assert(t, db.usage[0].Count, qt.Equals, int64(3))
+
+	db.broken, db.usage = false, nil
+	qt.Assert(t, u.flushUsage(), qt.IsNil)
+	qt.Assert(t, db.usage, qt.HasLen, 1)
+	qt.Assert(t, db.usage[0].IntegratorID, qt.Equals, 2)
+	qt.Assert(t, db.usage[0].Count, qt.Equals, int64(3))
+	qt.Assert(t, u.usage.take(), qt.HasLen, 0)
+}
+
+func TestUsageCSV(t *testing.T) {
+	data, err := usageCSV([]types.UsageCounter{{
+		Day:           time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC),
+		IntegratorID:  12,
+		OrgEthAddress: []byte{0x12, 0x34},
+		Metric:        types.UsageElections,
+		Count:         2,
+	}})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, string(data), qt.Equals, "day,integratorId,organizationId,planId,metric,count\n"+
+		"2022-04-01,12,1234,,elections,2\n")
+}
+
+func TestForEachConcurrently(t *testing.T) {
+	var lock sync.Mutex
+	running, maxRunning := 0, 0
+	squares := make([]int, 3*maxConcurrentFetches)
+	err := forEachConcurrently(len(squares), func(i int) error {
+		lock.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		lock.Unlock()
+		time.Sleep(time.Millisecond)
+		squares[i] = i * i
+		lock.Lock()
+		running--
+		lock.Unlock()
+		return nil
+	})
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, maxRunning <= maxConcurrentFetches, qt.IsTrue)
+	for i, square := range squares {
+		qt.Assert(t, square, qt.Equals, i*i)
+	}
+
+	// every call runs, and the error of the lowest index is returned
+	calls := int32(0)
+	err = forEachConcurrently(5, func(i int) error {
+		atomic.AddInt32(&calls, 1)
+		if i >= 2 {
+			return fmt.Errorf("error %d", i)
+		}
+		return nil
+	})
+	qt.Assert(t, err, qt.ErrorMatches, "error 2")
+	qt.Assert(t, calls, qt.Equals, int32(5))
+	qt.Assert(t, forEachConcurrently(0, nil), qt.IsNil)
+}
+
 func TestReflectElection(t *testing.T) {
 	entityId := []byte{1, 2, 3}
 	privKey := []byte{4, 5, 6}
@@ -254,3 +562,564 @@ func TestVerifyCspSharedSignature(t *testing.T) {
 	qt.Assert(t, verifyCspSharedSignature(processId, signature,
 		append([]byte{1, 1, 1, 1, 1}, rootPub[5:]...)), qt.IsNotNil)
 }
+
+func TestOpenAPIDocument(t *testing.T) {
+	u := &URLAPI{BaseRoute: "/v1"}
+	u.routes = []route{
+		{pattern: "/priv/elections/{electionId}", method: "GET",
+			accessType: "private", doc: routeDoc{Response: types.APIElectionInfo{}}},
+		{pattern: "/priv/censuses/{censusId}/import/*", method: "POST",
+			accessType: "private", doc: routeDoc{Request: types.APIRequest{}}},
+		{pattern: "/pub/elections/{electionId}", method: "GET",
+			accessType: "public", doc: routeDoc{Response: types.APIElectionInfo{}}},
+	}
+	data, err := json.Marshal(u.openAPIDocument())
+	qt.Assert(t, err, qt.IsNil)
+	var doc struct {
+		Paths map[string]map[string]struct {
+			OperationID string                   `json:"operationId"`
+			Parameters  []map[string]interface{} `json:"parameters"`
+			Security    []interface{}            `json:"security"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	qt.Assert(t, json.Unmarshal(data, &doc), qt.IsNil)
+
+	op := doc.Paths["/priv/elections/{electionId}"]["get"]
+	qt.Assert(t, op.OperationID, qt.Equals, "getPrivElectionsByElectionId")
+	qt.Assert(t, op.Parameters, qt.HasLen, 1)
+	qt.Assert(t, op.Parameters[0]["name"], qt.Equals, "electionId")
+	qt.Assert(t, op.Security, qt.HasLen, 1)
+	qt.Assert(t, doc.Paths["/pub/elections/{electionId}"]["get"].Security, qt.HasLen, 0)
+	qt.Assert(t, doc.Paths["/priv/censuses/{censusId}/import/{path}"]["post"].Parameters,
+		qt.HasLen, 2)
+
+	// named types are stored as components, with custom encodings as strings
+	election := doc.Components.Schemas["APIElectionInfo"]
+	qt.Assert(t, election.Properties["electionId"]["type"], qt.Equals, "string")
+	qt.Assert(t, election.Properties["endDate"]["format"], qt.Equals, "date-time")
+	qt.Assert(t, election.Properties["questions"]["type"], qt.Equals, "array")
+	qt.Assert(t, doc.Components.Schemas["Question"].Properties["choices"]["type"],
+		qt.Equals, "array")
+	qt.Assert(t, doc.Components.Schemas["APIRequest"].Properties, qt.Not(qt.HasLen), 0)
+}
+
+func TestSetRequestID(t *testing.T) {
+	newCtx := func(requestID string) *httprouter.HTTPContext {
+		req := httptest.NewRequest("GET", "/v1/admin/audit", nil)
+		if requestID != "" {
+			req.Header.Set(RequestIDHeader, requestID)
+		}
+		return &httprouter.HTTPContext{Request: req, Writer: httptest.NewRecorder()}
+	}
+	// ids sent by clients are kept
+	ctx := newCtx("client-id.1")
+	qt.Assert(t, setRequestID(ctx), qt.Equals, "client-id.1")
+	qt.Assert(t, ctx.Writer.Header().Get(RequestIDHeader), qt.Equals, "client-id.1")
+
+	// missing or malformed ids are replaced
+	for _, requestID := range []string{"", "bad id", strings.Repeat("a", 65)} {
+		ctx := newCtx(requestID)
+		generated := setRequestID(ctx)
+		qt.Assert(t, generated, qt.HasLen, 32)
+		qt.Assert(t, ctx.Writer.Header().Get(RequestIDHeader), qt.Equals, generated)
+	}
+}
+
+// auditDB records the audit events of the usageDB integrators and organizations
+type auditDB struct {
+	usageDB
+	events []types.AuditEvent
+}
+
+func (d *auditDB) CreateAuditEvent(event *types.AuditEvent) (int64, error) {
+	d.events = append(d.events, *event)
+	return int64(len(d.events)), nil
+}
+
+func TestAudit(t *testing.T) {
+	db := &auditDB{}
+	u := &URLAPI{db: db, BaseRoute: "/v1"}
+	r := route{accessType: bearerstdapi.MethodAccessTypePublic,
+		doc: routeDoc{Action: "election.vote"}}
+	ctx := &httprouter.HTTPContext{Request: httptest.NewRequest("POST",
+		"/v1/pub/elections/0a/vote", nil), Writer: httptest.NewRecorder()}
+
+	// organization tokens are recorded by their hash, with their integrator
+	u.audit(r, &bearerstdapi.BearerStandardAPIdata{AuthToken: "token"}, ctx, "id", nil)
+	qt.Assert(t, db.events, qt.HasLen, 1)
+	qt.Assert(t, db.events[0].ActorType, qt.Equals, types.AuditActorOrganization)
+	qt.Assert(t, db.events[0].ActorID, qt.Equals, tokenHash("token"))
+	qt.Assert(t, db.events[0].IntegratorID, qt.Equals, 1)
+	qt.Assert(t, db.events[0].Target, qt.Equals, "/pub/elections/0a/vote")
+
+	u.audit(r, &bearerstdapi.BearerStandardAPIdata{AuthToken: "unknown"}, ctx, "id",
+		apierror.ErrOrganizationNotFound)
+	qt.Assert(t, db.events, qt.HasLen, 2)
+	qt.Assert(t, db.events[1].IntegratorID, qt.Equals, 0)
+	qt.Assert(t, db.events[1].Outcome, qt.Equals, types.AuditOutcomeFailure)
+}
+
+func TestRateLimit(t *testing.T) {
+	u := &URLAPI{limiter: newRateLimiter(&config.API{IntegratorRateLimit: 60,
+		IntegratorRateBurst: 1, IPRateLimit: 60}, ratelimit.NewMemoryStore())}
+	newCtx := func() *httprouter.HTTPContext {
+		req := httptest.NewRequest("GET", "/v1/pub/elections", nil)
+		return &httprouter.HTTPContext{Request: req, Writer: httptest.NewRecorder()}
+	}
+	msg := &bearerstdapi.BearerStandardAPIdata{AuthToken: "aa"}
+	ctx := newCtx()
+	qt.Assert(t, u.rateLimit(bearerstdapi.MethodAccessTypePrivate, msg, ctx), qt.IsNil)
+	qt.Assert(t, ctx.Writer.Header().Get("X-RateLimit-Limit"), qt.Equals, "1")
+	qt.Assert(t, ctx.Writer.Header().Get("X-RateLimit-Remaining"), qt.Equals, "0")
+
+	ctx = newCtx()
+	err := u.rateLimit(bearerstdapi.MethodAccessTypePrivate, msg, ctx)
+	qt.Assert(t, errors.Is(err, apierror.ErrRateLimited), qt.IsTrue)
+	qt.Assert(t, ctx.Writer.Header().Get("Retry-After"), qt.Equals, "1")
+
+	// public routes are limited by client IP, admin routes are not limited
+	ctx = newCtx()
+	qt.Assert(t, u.rateLimit(bearerstdapi.MethodAccessTypePublic,
+		&bearerstdapi.BearerStandardAPIdata{}, ctx), qt.IsNil)
+	qt.Assert(t, ctx.Writer.Header().Get("X-RateLimit-Limit"), qt.Equals, "60")
+	ctx = newCtx()
+	qt.Assert(t, u.rateLimit(bearerstdapi.MethodAccessTypeAdmin, msg, ctx), qt.IsNil)
+	qt.Assert(t, ctx.Writer.Header().Get("X-RateLimit-Limit"), qt.Equals, "")
+}
+
+func TestClientIP(t *testing.T) {
+	req := httptest.NewRequest("GET", "/v1/pub/elections", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "1.1.1.1, 2.2.2.2")
+	qt.Assert(t, clientIP(req, false), qt.Equals, "10.0.0.1")
+	qt.Assert(t, clientIP(req, true), qt.Equals, "2.2.2.2")
+}
+
+func TestBuildVoteTx(t *testing.T) {
+	processID := dvoteutil.RandomBytes(32)
+	voter := ethereum.NewSignKeys()
+	qt.Assert(t, voter.Generate(), qt.IsNil)
+	proof := &models.ProofCA{Type: cspProofType(types.PROOF_TYPE_BLIND),
+		Bundle:    &models.CAbundle{ProcessId: processID, Address: voter.Address().Bytes()},
+		Signature: dvoteutil.RandomBytes(65)}
+	qt.Assert(t, proof.Type, qt.Equals, models.ProofCA_ECDSA_BLIND_PIDSALTED)
+
+	// the vote package of encrypted elections is encrypted with every key
+	var keys []api.Key
+	var privKeys []crypto.Cipher
+	for i := 0; i < 2; i++ {
+		key, err := nacl.Generate(rand.Reader)
+		qt.Assert(t, err, qt.IsNil)
+		keys = append(keys, api.Key{Idx: i + 1,
+			Key: hex.EncodeToString(key.Public().Bytes())})
+		privKeys = append(privKeys, key)
+	}
+	voteTx, err := buildVoteTx(processID, []int{1, 0, 2}, true, keys, proof)
+	qt.Assert(t, err, qt.IsNil)
+	var tx models.Tx
+	qt.Assert(t, proto.Unmarshal(voteTx, &tx), qt.IsNil)
+	envelope := tx.GetVote()
+	qt.Assert(t, envelope.ProcessId, qt.DeepEquals, processID)
+	qt.Assert(t, envelope.EncryptionKeyIndexes, qt.DeepEquals, []uint32{1, 2})
+	qt.Assert(t, envelope.GetProof().GetCa().Signature, qt.DeepEquals, proof.Signature)
+	votePackage := envelope.VotePackage
+	for i := len(privKeys) - 1; i >= 0; i-- {
+		votePackage, err = privKeys[i].Decrypt(votePackage)
+		qt.Assert(t, err, qt.IsNil)
+	}
+	var vote vochain.VotePackage
+	qt.Assert(t, json.Unmarshal(votePackage, &vote), qt.IsNil)
+	qt.Assert(t, vote.Votes, qt.DeepEquals, []int{1, 0, 2})
+	qt.Assert(t, vote.Nonce, qt.Not(qt.Equals), "")
+	_, err = buildVoteTx(processID, []int{1}, true, nil, proof)
+	qt.Assert(t, errors.Is(err, apierror.ErrElectionNotReady), qt.IsTrue)
+
+	// only the voter of the proof can sign its vote
+	voteTx, err = buildVoteTx(processID, []int{1, 0, 2}, false, nil, proof)
+	qt.Assert(t, err, qt.IsNil)
+	signature, err := voter.SignVocdoniTx(voteTx, "chain")
+	qt.Assert(t, err, qt.IsNil)
+	stx := &models.SignedTx{Tx: voteTx, Signature: signature}
+	qt.Assert(t, verifyVoteTx(processID, stx, "chain"), qt.IsNil)
+	err = verifyVoteTx(dvoteutil.RandomBytes(32), stx, "chain")
+	qt.Assert(t, errors.Is(err, apierror.ErrInvalidVote), qt.IsTrue)
+	err = verifyVoteTx(processID, stx, "other chain")
+	qt.Assert(t, errors.Is(err, apierror.ErrInvalidSignature), qt.IsTrue)
+	other := ethereum.NewSignKeys()
+	qt.Assert(t, other.Generate(), qt.IsNil)
+	stx.Signature, err = other.SignVocdoniTx(voteTx, "chain")
+	qt.Assert(t, err, qt.IsNil)
+	err = verifyVoteTx(processID, stx, "chain")
+	qt.Assert(t, errors.Is(err, apierror.ErrInvalidSignature), qt.IsTrue)
+}
+
+func TestValidateVoteEnvelope(t *testing.T) {
+	process := &indexertypes.Process{
+		ID:           dvoteutil.RandomBytes(32),
+		StartBlock:   10,
+		EndBlock:     20,
+		Status:       int32(models.ProcessStatus_READY),
+		CensusOrigin: int32(models.CensusOrigin_OFF_CHAIN_CA),
+		Envelope:     &models.EnvelopeType{UniqueValues: true},
+		VoteOpts: &models.ProcessVoteOptions{MaxCount: 2, MaxValue: 3, MaxTotalCost: 4,
+			CostExponent: 1},
+	}
+	envelope := func(proofType models.ProofCA_Type, votes ...int) *models.VoteEnvelope {
+		votePackage, err := json.Marshal(vochain.VotePackage{Votes: votes})
+		qt.Assert(t, err, qt.IsNil)
+		return &models.VoteEnvelope{ProcessId: process.ID, VotePackage: votePackage,
+			Proof: &models.Proof{Payload: &models.Proof_Ca{Ca: &models.ProofCA{Type: proofType}}}}
+	}
+	blindVote := func(votes ...int) *models.VoteEnvelope {
+		return envelope(models.ProofCA_ECDSA_BLIND_PIDSALTED, votes...)
+	}
+	// from the start block to the end block, both included, with a lagging height
+	for _, height := range []uint32{15, 10, 20, 10 - voteHeightLag, 0} {
+		qt.Assert(t, validateVoteEnvelope(process, types.PROOF_TYPE_BLIND, blindVote(1, 0),
+			height), qt.IsNil, qt.Commentf("height %d", height))
+	}
+
+	for name, test := range map[string]struct {
+		envelope  *models.VoteEnvelope
+		proofType types.ProofType
+		height    uint32
+		err       apierror.Error
+	}{
+		"upcoming":       {blindVote(1, 0), types.PROOF_TYPE_BLIND, 3, apierror.ErrElectionNotReady},
+		"ended":          {blindVote(1, 0), types.PROOF_TYPE_BLIND, 21, apierror.ErrElectionNotReady},
+		"proof type":     {blindVote(1, 0), types.PROOF_TYPE_ECDSA, 15, apierror.ErrInvalidProofType},
+		"no proof":       {&models.VoteEnvelope{}, types.PROOF_TYPE_BLIND, 15, apierror.ErrInvalidVote},
+		"max count":      {blindVote(1, 0, 2), types.PROOF_TYPE_BLIND, 15, apierror.ErrInvalidVote},
+		"max value":      {blindVote(4), types.PROOF_TYPE_BLIND, 15, apierror.ErrInvalidVote},
+		"negative value": {blindVote(-1), types.PROOF_TYPE_BLIND, 15, apierror.ErrInvalidVote},
+		"unique values":  {blindVote(1, 1), types.PROOF_TYPE_BLIND, 15, apierror.ErrInvalidVote},
+		"max total cost": {blindVote(3, 2), types.PROOF_TYPE_BLIND, 15, apierror.ErrInvalidVote},
+	} {
+		err := validateVoteEnvelope(process, test.proofType, test.envelope, test.height)
+		qt.Assert(t, errors.Is(err, test.err), qt.IsTrue, qt.Commentf("%s: %v", name, err))
+	}
+	qt.Assert(t, validateVoteEnvelope(process, types.PROOF_TYPE_ECDSA,
+		envelope(models.ProofCA_ECDSA_PIDSALTED, 1, 0), 15), qt.IsNil)
+
+	// paused elections take no votes
+	process.Status = int32(models.ProcessStatus_PAUSED)
+	err := validateVoteEnvelope(process, types.PROOF_TYPE_BLIND, blindVote(1, 0), 15)
+	qt.Assert(t, errors.Is(err, apierror.ErrElectionNotReady), qt.IsTrue)
+
+	// the votes of encrypted elections cannot be checked, but must be encrypted
+	process.Status = int32(models.ProcessStatus_READY)
+	process.Envelope.EncryptedVotes = true
+	encrypted := blindVote(9, 9, 9)
+	err = validateVoteEnvelope(process, types.PROOF_TYPE_BLIND, encrypted, 15)
+	qt.Assert(t, errors.Is(err, apierror.ErrInvalidVote), qt.IsTrue)
+	encrypted.EncryptionKeyIndexes = []uint32{1}
+	qt.Assert(t, validateVoteEnvelope(process, types.PROOF_TYPE_BLIND, encrypted, 15), qt.IsNil)
+}
+
+func TestVoteReceiptPayload(t *testing.T) {
+	votePackage, err := json.Marshal(vochain.VotePackage{Votes: []int{1, 0, 2}})
+	qt.Assert(t, err, qt.IsNil)
+	envelope := &indexertypes.EnvelopePackage{
+		Meta: indexertypes.EnvelopeMetadata{ProcessId: []byte{1}, Nullifier: []byte{2},
+			TxIndex: 4, Height: 12, TxHash: []byte{3}},
+		VotePackage: votePackage,
+		Weight:      "5",
+	}
+	block := &indexertypes.BlockMetadata{Timestamp: time.Unix(1000, 0)}
+
+	payload := voteReceiptPayload("test", envelope, block)
+	qt.Assert(t, payload.ChainID, qt.Equals, "test")
+	qt.Assert(t, []byte(payload.Nullifier), qt.DeepEquals, []byte{2})
+	qt.Assert(t, payload.Height, qt.Equals, uint32(12))
+	qt.Assert(t, payload.TxIndex, qt.Equals, int32(4))
+	qt.Assert(t, payload.Weight, qt.Equals, "5")
+	qt.Assert(t, payload.Timestamp.Equal(time.Unix(1000, 0)), qt.IsTrue)
+	qt.Assert(t, payload.Votes, qt.DeepEquals, []int{1, 0, 2})
+
+	// encrypted votes are not decoded
+	envelope.EncryptionKeyIndexes = []uint32{0}
+	envelope.VotePackage = []byte{0xff, 0x01}
+	payload = voteReceiptPayload("test", envelope, block)
+	qt.Assert(t, payload.Votes, qt.IsNil)
+	qt.Assert(t, payload.EncryptionKeyIndexes, qt.DeepEquals, []uint32{0})
+}
+
+func TestElectionStreams(t *testing.T) {
+	var polls int32
+	voteCount := uint32(0)
+	poll := func(processID []byte, last *electionState) (*electionState, error) {
+		if string(processID) == "missing" {
+			return nil, apierror.ErrElectionNotFound
+		}
+		atomic.AddInt32(&polls, 1)
+		state := &electionState{status: "ACTIVE", voteCount: atomic.LoadUint32(&voteCount)}
+		if state.voteCount >= 2 {
+			state.status = "ENDED"
+			state.results = &types.APIElectionResults{Final: true, VoteCount: 2}
+		}
+		return state, nil
+	}
+	streams := newElectionStreams(10*time.Millisecond, 50*time.Millisecond, poll)
+	receive := func(ch chan streamUpdate) streamUpdate {
+		select {
+		case update := <-ch:
+			return update
+		case <-time.After(time.Second):
+			t.Fatal("no stream update received")
+		}
+		return streamUpdate{}
+	}
+	eventTypes := func(update streamUpdate) []string {
+		var kinds []string
+		for _, event := range update.events {
+			kinds = append(kinds, event.Type)
+		}
+		return kinds
+	}
+
+	// the first subscriber gets the state once polled, later ones right away
+	first := streams.subscribe([]byte("p1"))
+	qt.Assert(t, eventTypes(receive(first)), qt.DeepEquals, []string{types.ElectionEventStatus})
+	second := streams.subscribe([]byte("p1"))
+	qt.Assert(t, eventTypes(receive(second)), qt.DeepEquals, []string{types.ElectionEventStatus})
+	qt.Assert(t, len(streams.pollers), qt.Equals, 1)
+
+	// changes are sent to every subscriber
+	atomic.StoreUint32(&voteCount, 1)
+	qt.Assert(t, eventTypes(receive(first)), qt.DeepEquals, []string{types.ElectionEventVotes})
+	qt.Assert(t, eventTypes(receive(second)), qt.DeepEquals, []string{types.ElectionEventVotes})
+	atomic.StoreUint32(&voteCount, 2)
+	update := receive(first)
+	qt.Assert(t, eventTypes(update), qt.DeepEquals, []string{types.ElectionEventStatus,
+		types.ElectionEventVotes, types.ElectionEventResults})
+	qt.Assert(t, update.events[2].Results.Final, qt.IsTrue)
+
+	// idle pollers keep their state for reconnecting subscribers
+	streams.unsubscribe([]byte("p1"), first)
+	streams.unsubscribe([]byte("p1"), second)
+	third := streams.subscribe([]byte("p1"))
+	update = receive(third)
+	qt.Assert(t, update.state.voteCount, qt.Equals, uint32(2))
+	qt.Assert(t, eventTypes(update), qt.DeepEquals, []string{types.ElectionEventStatus,
+		types.ElectionEventVotes, types.ElectionEventResults})
+
+	// and stop once idle for too long
+	streams.unsubscribe([]byte("p1"), third)
+	time.Sleep(100 * time.Millisecond)
+	streams.lock.Lock()
+	qt.Assert(t, len(streams.pollers), qt.Equals, 0)
+	streams.lock.Unlock()
+	stopped := atomic.LoadInt32(&polls)
+	time.Sleep(30 * time.Millisecond)
+	qt.Assert(t, atomic.LoadInt32(&polls), qt.Equals, stopped)
+
+	// first poll errors are sent, and the next subscriber polls again
+	missing := streams.subscribe([]byte("missing"))
+	qt.Assert(t, errors.Is(receive(missing).err, apierror.ErrElectionNotFound), qt.IsTrue)
+	streams.lock.Lock()
+	qt.Assert(t, len(streams.pollers), qt.Equals, 0)
+	streams.lock.Unlock()
+}
+
+func TestElectionSample(t *testing.T) {
+	stored := &types.Election{ProcessID: []byte{1}, Status: types.ElectionStatusReady,
+		HiddenResults: true, StartBlock: 100, EndBlock: 200}
+	process := &indexertypes.Process{Status: int32(models.ProcessStatus_READY),
+		StartBlock: 100, EndBlock: 200, Envelope: &models.EnvelopeType{EncryptedVotes: true}}
+
+	// hidden results elections are sampled from their envelopes, with no results
+	synced := syncedElection(stored, process, 5)
+	sample := electionSample(stored, synced, 150)
+	qt.Assert(t, sample, qt.DeepEquals, &types.ElectionSample{ProcessID: []byte{1},
+		Height: 150, VoteCount: 5})
+	qt.Assert(t, electionSample(synced, syncedElection(synced, process, 5), 160), qt.IsNil)
+	sample = electionSample(synced, syncedElection(synced, process, 8), 170)
+	qt.Assert(t, sample.VoteCount, qt.Equals, 8)
+}
+
+func TestElectionStats(t *testing.T) {
+	start := time.Date(2022, 5, 1, 10, 0, 0, 0, time.UTC)
+	samples := []types.ElectionSample{
+		{SampledAt: start.Add(10 * time.Minute), Height: 100, VoteCount: 4},
+		{SampledAt: start.Add(40 * time.Minute), Height: 160, VoteCount: 10},
+		// no votes during the second hour
+		{SampledAt: start.Add(150 * time.Minute), Height: 380, VoteCount: 30},
+		{SampledAt: start.Add(160 * time.Minute), Height: 400, VoteCount: 40},
+	}
+
+	stats, err := electionStats(samples, time.Hour, 0, 80)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, stats.VoteCount, qt.Equals, 40)
+	qt.Assert(t, stats.Turnout, qt.Equals, 50.0)
+	qt.Assert(t, stats.Buckets, qt.HasLen, 3)
+	qt.Assert(t, stats.Buckets[0].Start.Equal(start), qt.IsTrue)
+	qt.Assert(t, stats.Buckets[0].Votes, qt.Equals, 10)
+	qt.Assert(t, stats.Buckets[1].Votes, qt.Equals, 0)
+	qt.Assert(t, stats.Buckets[1].VoteCount, qt.Equals, 10)
+	qt.Assert(t, stats.Buckets[2].Votes, qt.Equals, 30)
+	qt.Assert(t, stats.Buckets[2].Turnout, qt.Equals, 50.0)
+	// 10 votes in the last 10 minutes
+	qt.Assert(t, stats.PeakRate, qt.Equals, 60.0)
+	qt.Assert(t, stats.PeakAt.Equal(start.Add(160*time.Minute)), qt.IsTrue)
+
+	stats, err = electionStats(samples, time.Hour, 200, 0)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, stats.Turnout, qt.Equals, 0.0)
+	qt.Assert(t, stats.Buckets, qt.HasLen, 3)
+	qt.Assert(t, stats.Buckets[0].Start, qt.IsNil)
+	qt.Assert(t, stats.Buckets[1].StartHeight, qt.Equals, uint32(200))
+	qt.Assert(t, stats.Buckets[1].Votes, qt.Equals, 20)
+	qt.Assert(t, stats.Buckets[2].Votes, qt.Equals, 10)
+
+	data, err := statsCSV(stats)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, string(data), qt.Equals, "start,startHeight,votes,voteCount,turnout\n"+
+		",0,10,10,0.00\n,200,20,30,0.00\n,400,10,40,0.00\n")
+
+	_, err = electionStats(samples, time.Minute, 0, 0)
+	qt.Assert(t, err, qt.IsNil)
+	samples = append(samples, types.ElectionSample{SampledAt: start.Add(200 * time.Hour),
+		Height: 9000, VoteCount: 41})
+	_, err = electionStats(samples, time.Minute, 0, 0)
+	qt.Assert(t, errors.Is(err, apierror.ErrInvalidFilter), qt.IsTrue)
+	stats, err = electionStats(nil, time.Hour, 0, 10)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, stats.Buckets, qt.HasLen, 0)
+}
+
+func TestScheduledStatus(t *testing.T) {
+	status, done, err := scheduledStatus(types.ScheduledActionPause, models.ProcessStatus_READY)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, done, qt.IsFalse)
+	qt.Assert(t, status, qt.Equals, models.ProcessStatus_PAUSED)
+	_, done, err = scheduledStatus(types.ScheduledActionPause, models.ProcessStatus_PAUSED)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, done, qt.IsTrue)
+	status, done, err = scheduledStatus(types.ScheduledActionEnd, models.ProcessStatus_PAUSED)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, done, qt.IsFalse)
+	qt.Assert(t, status, qt.Equals, models.ProcessStatus_ENDED)
+	// the Vochain publishes the results once the election ends
+	_, _, err = scheduledStatus("publishResults", models.ProcessStatus_PAUSED)
+	qt.Assert(t, errors.Is(err, apierror.ErrInvalidField), qt.IsTrue)
+
+	// ended elections are not paused, but are already ended
+	_, done, err = scheduledStatus(types.ScheduledActionEnd, models.ProcessStatus_RESULTS)
+	qt.Assert(t, err, qt.IsNil)
+	qt.Assert(t, done, qt.IsTrue)
+	_, _, err = scheduledStatus(types.ScheduledActionPause, models.ProcessStatus_ENDED)
+	qt.Assert(t, errors.Is(err, apierror.ErrInvalidStatus), qt.IsTrue)
+	_, _, err = scheduledStatus(types.ScheduledActionEnd, models.ProcessStatus_CANCELED)
+	qt.Assert(t, errors.Is(err, apierror.ErrInvalidStatus), qt.IsTrue)
+	_, _, err = scheduledStatus("resume", models.ProcessStatus_READY)
+	qt.Assert(t, errors.Is(err, apierror.ErrInvalidField), qt.IsTrue)
+}
+
+func TestBlockTimeModel(t *testing.T) {
+	start := time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC)
+	samples := []types.BlockSample{}
+	for i := 0; i < minBlockSamples; i++ {
+		height := uint32(1000 + i*360)
+		samples = append(samples, types.BlockSample{Height: height,
+			Timestamp: start.Add(time.Duration(i*360*10) * time.Second)})
+	}
+	qt.Assert(t, fitBlockTimes(samples[1:]), qt.IsNil)
+
+	// blocks every 10s exactly
+	model := fitBlockTimes(samples)
+	qt.Assert(t, model, qt.Not(qt.IsNil))
+	qt.Assert(t, model.blockTime, qt.Equals, 10.0)
+	last := samples[len(samples)-1]
+	date, margin := model.time(last.Height + 360)
+	qt.Assert(t, date.Equal(last.Timestamp.Add(time.Hour)), qt.IsTrue)
+	qt.Assert(t, margin < time.Second, qt.IsTrue)
+	height, _ := model.height(last.Timestamp.Add(-time.Hour))
+	qt.Assert(t, height, qt.Equals, last.Height-360)
+	height, _ = model.height(start.Add(-24 * time.Hour))
+	qt.Assert(t, height, qt.Equals, uint32(0))
+
+	// blocks every 10s on average, drifting by up to a minute per hour: the error
+	//  bound grows with the distance to the last sample
+	for i := 1; i < len(samples); i++ {
+		samples[i].Timestamp = samples[i-1].Timestamp.Add(time.Hour +
+			time.Duration(i%3-1)*time.Minute)
+	}
+	last = samples[len(samples)-1]
+	model = fitBlockTimes(samples)
+	qt.Assert(t, math.Abs(model.blockTime-10) < 0.1, qt.IsTrue)
+	_, hour := model.time(last.Height + 360)
+	_, day := model.time(last.Height + 24*360)
+	qt.Assert(t, hour > 0, qt.IsTrue)
+	qt.Assert(t, day > hour, qt.IsTrue)
+	// the block of one day later is within the bound
+	date, margin = model.time(last.Height + 24*360)
+	actual := last.Timestamp.Add(24 * time.Hour)
+	qt.Assert(t, date.Sub(actual) < margin && actual.Sub(date) < margin, qt.IsTrue)
+	height, margin = model.height(date)
+	qt.Assert(t, height, qt.Equals, last.Height+24*360)
+	qt.Assert(t, margin, qt.Equals, day)
+}
+
+func TestElectionDefinition(t *testing.T) {
+	u := &URLAPI{cspAuth: map[string]csp.AuthHandler{csp.AuthIDList: &csp.IDListHandler{}}}
+	meta := &types.ProcessMetadata{
+		Title:       types.LanguageString{"default": "Assembly", "es": "Asamblea"},
+		Description: types.LanguageString{"default": "Annual assembly"},
+		Media:       types.ProcessMedia{Header: "https://header.jpeg"},
+		Questions: []types.QuestionMeta{{
+			Title: types.LanguageString{"default": "Approve?", "es": "¿Aprobar?"},
+			Choices: []types.ChoiceMetadata{
+				{Title: types.LanguageString{"default": "Yes", "es": "Sí"}, Value: 0},
+				{Title: types.LanguageString{"default": "No"}, Value: 1},
+			},
+		}},
+	}
+	election := &types.Election{ProofType: string(types.PROOF_TYPE_BLIND), HiddenResults: true,
+		CspAuth: csp.AuthIDList}
+	definition := definitionFromMetadata(meta, election)
+	qt.Assert(t, u.validateElectionDefinition(definition), qt.IsNil)
+	qt.Assert(t, definition.Title["es"], qt.Equals, "Asamblea")
+	qt.Assert(t, definition.Header, qt.Equals, "https://header.jpeg")
+	qt.Assert(t, definition.ProofType, qt.Equals, types.PROOF_TYPE_BLIND)
+	qt.Assert(t, definition.HiddenResults, qt.IsTrue)
+	qt.Assert(t, definition.AuthHandler, qt.Equals, csp.AuthIDList)
+
+	// empty overrides keep the definition, and flags are only turned on
+	overrideElectionDefinition(definition, &types.CreateElectionRequest{Confidential: true})
+	qt.Assert(t, definition.Title["es"], qt.Equals, "Asamblea")
+	qt.Assert(t, definition.Questions, qt.HasLen, 1)
+	qt.Assert(t, definition.Confidential, qt.IsTrue)
+	qt.Assert(t, definition.HiddenResults, qt.IsTrue)
+	overrideElectionDefinition(definition, &types.CreateElectionRequest{
+		Title: "Assembly 2023",
+		Questions: []types.Question{{Title: "Budget?",
+			Choices: []types.Choice{{Title: "Yes", Value: 0}, {Title: "No", Value: 1}}}},
+	})
+	qt.Assert(t, definition.Title, qt.DeepEquals, types.LanguageString{"default": "Assembly 2023"})
+	qt.Assert(t, definition.Description["default"], qt.Equals, "Annual assembly")
+	qt.Assert(t, definition.Questions[0].Title["default"], qt.Equals, "Budget?")
+	qt.Assert(t, definition.Questions[0].Choices[1].Title["default"], qt.Equals, "No")
+	qt.Assert(t, u.validateElectionDefinition(definition), qt.IsNil)
+
+	// every text needs its default language
+	definition.Title = types.LanguageString{"es": "Asamblea"}
+	definition.Questions[0].Choices[1].Value = 0
+	definition.AuthHandler = "sms"
+	definition.ProofType = "signed"
+	err := u.validateElectionDefinition(definition)
+	qt.Assert(t, errors.Is(err, apierror.ErrInvalidField), qt.IsTrue)
+	fields := apierror.From(err).Fields
+	qt.Assert(t, fields, qt.HasLen, 4)
+	qt.Assert(t, fields[0].Field, qt.Equals, "title")
+	qt.Assert(t, fields[1].Field, qt.Equals, "proofType")
+	qt.Assert(t, fields[2].Field, qt.Equals, "authHandler")
+	qt.Assert(t, fields[3].Field, qt.Equals, "questions[0].choices")
+	definition.Questions = nil
+	fields = apierror.From(u.validateElectionDefinition(definition)).Fields
+	qt.Assert(t, fields[len(fields)-1].Field, qt.Equals, "questions")
+}
@@ -0,0 +1,170 @@
+package urlapi
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/api/util"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/bearerstdapi"
+	"go.vocdoni.io/dvote/log"
+	dvoteUtil "go.vocdoni.io/dvote/util"
+)
+
+// RequestIDHeader carries the id of a request. Clients may set it to correlate
+//  their logs with the audit log, otherwise the API generates one.
+const RequestIDHeader = "X-Request-Id"
+
+var requestIDRgx = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,64}$`)
+
+func (u *URLAPI) enableAuditHandlers() error {
+	if err := u.registerMethod(
+		"/admin/audit",
+		"GET",
+		bearerstdapi.MethodAccessTypeAdmin,
+		u.listAuditEventsHandler,
+		routeDoc{Summary: "List audit events", Tag: "audit",
+			Response: []types.AuditEvent{}},
+	); err != nil {
+		return err
+	}
+	if err := u.registerMethod(
+		"/priv/audit",
+		"GET",
+		bearerstdapi.MethodAccessTypePrivate,
+		u.listIntegratorAuditEventsHandler,
+		routeDoc{Summary: "List the integrator's audit events", Tag: "audit",
+			Response: []types.AuditEvent{}},
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GET https://server/v1/admin/audit?integratorId=&actorType=&action=&outcome=&from=&to=&count=&cursor=
+// listAuditEventsHandler lists the audit events of every actor, newest first
+func (u *URLAPI) listAuditEventsHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	filter, err := auditFilter(ctx)
+	if err != nil {
+		return err
+	}
+	if filter.IntegratorID, err = util.GetQueryInt(ctx, "integratorId"); err != nil {
+		return err
+	}
+	events, next, err := u.db.ListAuditEvents(filter)
+	if err != nil {
+		return err
+	}
+	return sendPage(events, next, ctx)
+}
+
+// GET https://server/v1/priv/audit?actorType=&action=&outcome=&from=&to=&count=&cursor=
+// listIntegratorAuditEventsHandler lists the audit events of the calling integrator,
+//  including the admin operations on its account, newest first
+func (u *URLAPI) listIntegratorAuditEventsHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	integratorKey, err := util.GetAuthToken(msg)
+	if err != nil {
+		return err
+	}
+	integrator, err := u.db.GetIntegratorByKey(integratorKey)
+	if err != nil {
+		return err
+	}
+	filter, err := auditFilter(ctx)
+	if err != nil {
+		return err
+	}
+	filter.IntegratorID = integrator.ID
+	events, next, err := u.db.ListAuditEvents(filter)
+	if err != nil {
+		return err
+	}
+	return sendPage(events, next, ctx)
+}
+
+// auditFilter reads the audit filters shared by the admin and integrator views
+func auditFilter(ctx *httprouter.HTTPContext) (*types.AuditFilter, error) {
+	query := ctx.Request.URL.Query()
+	filter := &types.AuditFilter{
+		ActorType: query.Get("actorType"),
+		Action:    query.Get("action"),
+		Outcome:   query.Get("outcome"),
+		Cursor:    query.Get("cursor"),
+	}
+	var err error
+	if filter.From, err = util.GetQueryTime(ctx, "from"); err != nil {
+		return nil, err
+	}
+	if filter.To, err = util.GetQueryTime(ctx, "to"); err != nil {
+		return nil, err
+	}
+	if filter.Count, err = util.GetQueryInt(ctx, "count"); err != nil {
+		return nil, err
+	}
+	if filter.Skip, err = util.GetQueryInt(ctx, "skip"); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// setRequestID returns the request id sent by the client, or a new one if it
+//  is missing or malformed, and sets it on the response headers
+func setRequestID(ctx *httprouter.HTTPContext) string {
+	requestID := ctx.Request.Header.Get(RequestIDHeader)
+	if !requestIDRgx.MatchString(requestID) {
+		requestID = dvoteUtil.RandomHex(16)
+	}
+	ctx.Writer.Header().Set(RequestIDHeader, requestID)
+	return requestID
+}
+
+// audit records the outcome of a call to an audited route. Failing to record it
+//  does not change the response, which has already been sent.
+func (u *URLAPI) audit(r route, msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext, requestID string, err error) {
+	event := &types.AuditEvent{
+		Action:     r.doc.Action,
+		Target:     strings.TrimPrefix(ctx.Request.URL.Path, u.BaseRoute),
+		RequestID:  requestID,
+		Outcome:    types.AuditOutcomeSuccess,
+		HTTPStatus: http.StatusOK,
+	}
+	if err != nil {
+		event.Outcome = types.AuditOutcomeFailure
+		event.HTTPStatus = apierror.From(err).HTTPstatus
+		event.Error = err.Error()
+	}
+	switch r.accessType {
+	case bearerstdapi.MethodAccessTypeAdmin:
+		event.ActorType = types.AuditActorAdmin
+		// admin routes address integrator accounts by id
+		event.IntegratorID, _ = strconv.Atoi(ctx.URLParam("id"))
+	case bearerstdapi.MethodAccessTypePrivate:
+		event.ActorType = types.AuditActorIntegrator
+		if key, err := util.GetAuthToken(msg); err == nil {
+			if integrator, err := u.db.GetIntegratorByKey(key); err == nil {
+				event.IntegratorID = integrator.ID
+				event.ActorID = strconv.Itoa(integrator.ID)
+			}
+		}
+	default:
+		event.ActorType = types.AuditActorOrganization
+		// the audit log is append-only, so it never keeps the token itself
+		if msg.AuthToken != "" {
+			event.ActorID = tokenHash(msg.AuthToken)
+			if organization, err := u.db.GetOrganizationByAPIToken(msg.AuthToken); err == nil {
+				event.IntegratorID = organization.IntegratorID
+			}
+		}
+	}
+	if _, err := u.db.CreateAuditEvent(event); err != nil {
+		log.Warnf("could not record audit event %s %s (request %s): %v",
+			r.doc.Action, event.Target, requestID, err)
+	}
+}
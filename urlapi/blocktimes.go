@@ -0,0 +1,264 @@
+package urlapi
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/bearerstdapi"
+	"go.vocdoni.io/dvote/log"
+)
+
+const (
+	// blockSampleInterval is the interval between samples of the current block
+	blockSampleInterval = 10 * time.Minute
+	// blockSampleWindow is the period of the samples the block time model is
+	//  fitted on. Older samples are deleted.
+	blockSampleWindow = 7 * 24 * time.Hour
+	// blockBackfillStep is the time between the past blocks sampled on start,
+	//  when the window has too few samples
+	blockBackfillStep = time.Hour
+	// minBlockSamples is the number of samples the model needs. The rolling
+	//  averages of the gateway are used until then.
+	minBlockSamples = 12
+	// blockTimeConfidence is the z-score of the error bounds, for 95% confidence
+	blockTimeConfidence = 1.96
+)
+
+func (u *URLAPI) enableBlockTimeHandlers() error {
+	return u.registerMethod(
+		"/pub/blocks/estimate",
+		"GET",
+		bearerstdapi.MethodAccessTypePublic,
+		u.estimateBlockHandler,
+		routeDoc{Summary: "Estimate the date of a block, or the block at a date", Tag: "chain",
+			Response: types.BlockEstimate{}},
+	)
+}
+
+// GET https://server/v1/pub/blocks/estimate?height=<height>|date=<date>
+// estimateBlockHandler returns the estimated date of a block, or the block at a
+//  date, with the error bound of the date
+func (u *URLAPI) estimateBlockHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	query := ctx.Request.URL.Query()
+	height, date := query.Get("height"), query.Get("date")
+	if (height == "") == (date == "") {
+		return apierror.ErrInvalidFilter.With("either height or date must be set")
+	}
+	estimate := types.BlockEstimate{}
+	var margin time.Duration
+	if height != "" {
+		h, err := strconv.ParseUint(height, 10, 32)
+		if err != nil {
+			return apierror.ErrInvalidFilter.Withf("invalid height %q", height)
+		}
+		estimate.Height = uint32(h)
+		if estimate.Date, margin, err = u.estimateBlockTime(estimate.Height); err != nil {
+			return err
+		}
+	} else {
+		target, err := time.Parse(types.DateLayout, date)
+		if err != nil {
+			return apierror.ErrInvalidDate.Withf("could not parse date: %v", err)
+		}
+		if estimate.Height, margin, err = u.estimateBlockHeight(target); err != nil {
+			return err
+		}
+		estimate.Date = target
+	}
+	estimate.Date = estimate.Date.UTC()
+	estimate.DateError = int64(margin / time.Second)
+	if model := u.blockTimeModel(); model != nil {
+		estimate.BlockTime = int64(model.blockTime * 1000)
+		estimate.Samples = model.samples
+	}
+	return sendResponse(estimate, ctx)
+}
+
+// blockTimeModel is a least squares fit of the block timestamps on their
+//  heights. Estimates extrapolate the fitted block time from the last sample,
+//  and their error bound grows with the distance to it: it adds the error of
+//  the fitted block time to the drift of the residuals, modeled as a random
+//  walk over the span of the samples.
+type blockTimeModel struct {
+	// blockTime and blockTimeErr are the fitted seconds per block and their
+	//  standard error
+	blockTime    float64
+	blockTimeErr float64
+	// residual is the standard deviation of the residuals, in seconds, over span
+	//  blocks
+	residual float64
+	span     float64
+	last     types.BlockSample
+	samples  int
+}
+
+// fitBlockTimes fits the block time model on samples, lowest height first. It
+//  returns nil if there are too few samples to fit it.
+func fitBlockTimes(samples []types.BlockSample) *blockTimeModel {
+	n := float64(len(samples))
+	if len(samples) < minBlockSamples {
+		return nil
+	}
+	first := samples[0]
+	var meanHeight, meanTime float64
+	for _, s := range samples {
+		meanHeight += float64(s.Height-first.Height) / n
+		meanTime += s.Timestamp.Sub(first.Timestamp).Seconds() / n
+	}
+	var sxx, sxy float64
+	for _, s := range samples {
+		dx := float64(s.Height-first.Height) - meanHeight
+		sxx += dx * dx
+		sxy += dx * (s.Timestamp.Sub(first.Timestamp).Seconds() - meanTime)
+	}
+	if sxx == 0 || sxy <= 0 {
+		return nil
+	}
+	model := &blockTimeModel{
+		blockTime: sxy / sxx,
+		span:      float64(samples[len(samples)-1].Height - first.Height),
+		last:      samples[len(samples)-1],
+		samples:   len(samples),
+	}
+	var squares float64
+	for _, s := range samples {
+		fitted := meanTime + model.blockTime*(float64(s.Height-first.Height)-meanHeight)
+		r := s.Timestamp.Sub(first.Timestamp).Seconds() - fitted
+		squares += r * r
+	}
+	model.residual = math.Sqrt(squares / (n - 2))
+	model.blockTimeErr = model.residual / math.Sqrt(sxx)
+	return model
+}
+
+// margin returns the error bound of an estimate at blocks from the last sample
+func (m *blockTimeModel) margin(blocks float64) time.Duration {
+	blocks = math.Abs(blocks)
+	variance := blocks * blocks * m.blockTimeErr * m.blockTimeErr
+	if m.span > 0 {
+		variance += m.residual * m.residual * blocks / m.span
+	}
+	return time.Duration(blockTimeConfidence * math.Sqrt(variance) * float64(time.Second))
+}
+
+// time returns the estimated date of a block, and its error bound
+func (m *blockTimeModel) time(height uint32) (time.Time, time.Duration) {
+	blocks := float64(height) - float64(m.last.Height)
+	return m.last.Timestamp.Add(time.Duration(blocks * m.blockTime * float64(time.Second))),
+		m.margin(blocks)
+}
+
+// height returns the estimated block at a date, or 0 if it is before the first
+//  block, and the error bound of the date of that block
+func (m *blockTimeModel) height(date time.Time) (uint32, time.Duration) {
+	blocks := date.Sub(m.last.Timestamp).Seconds() / m.blockTime
+	height := math.Round(float64(m.last.Height) + blocks)
+	if height < 1 {
+		return 0, 0
+	}
+	return uint32(height), m.margin(height - float64(m.last.Height))
+}
+
+// currentHeight returns the current Vochain block height, as cached, or from the
+//  gateway if it is not known yet
+func (u *URLAPI) currentHeight() (uint32, error) {
+	if height, _, _ := u.vocClient.GetBlockTimes(); height > 0 {
+		return height, nil
+	}
+	return u.vocClient.GetCurrentBlock()
+}
+
+// blockTimeModel returns the current block time model, or nil if it has too few
+//  samples
+func (u *URLAPI) blockTimeModel() *blockTimeModel {
+	u.blockModelLock.RLock()
+	defer u.blockModelLock.RUnlock()
+	return u.blockModel
+}
+
+// sampleBlockTimes samples the timestamp of the current block every
+//  blockSampleInterval, and fits the block time model on the samples of the
+//  last blockSampleWindow. Replicas share the samples, and the past blocks of
+//  the window are sampled on start if it has too few.
+func (u *URLAPI) sampleBlockTimes() {
+	backfilled := false
+	for {
+		if height, times, _ := u.vocClient.GetBlockTimes(); height > 0 {
+			if err := u.sampleBlockTime(height); err != nil {
+				log.Warnf("could not sample block %d: %v", height, err)
+			}
+			if !backfilled {
+				if err := u.backfillBlockTimes(height, times[4]); err != nil {
+					log.Warnf("could not sample past blocks: %v", err)
+				}
+				backfilled = true
+			}
+			if err := u.fitBlockTimeModel(time.Now()); err != nil {
+				log.Warnf("could not fit block time model: %v", err)
+			}
+		}
+		time.Sleep(blockSampleInterval)
+	}
+}
+
+func (u *URLAPI) sampleBlockTime(height uint32) error {
+	block, err := u.vocClient.GetBlock(height)
+	if err != nil {
+		return err
+	}
+	if block == nil {
+		return fmt.Errorf("block %d not found", height)
+	}
+	return u.db.AddBlockSample(&types.BlockSample{Height: height, Timestamp: block.Timestamp})
+}
+
+// backfillBlockTimes samples a block every blockBackfillStep back to the start of
+//  the window, stepping by the average block time in ms, if the window has too
+//  few samples
+func (u *URLAPI) backfillBlockTimes(height uint32, averageTime int32) error {
+	samples, err := u.db.ListBlockSamples(time.Now().Add(-blockSampleWindow))
+	if err != nil {
+		return err
+	}
+	if len(samples) >= minBlockSamples {
+		return nil
+	}
+	if averageTime <= 0 {
+		averageTime = 10000
+	}
+	step := uint32(blockBackfillStep.Milliseconds() / int64(averageTime))
+	for i := 1; i <= int(blockSampleWindow/blockBackfillStep) &&
+		uint32(i)*step < height; i++ {
+		if err := u.sampleBlockTime(height - uint32(i)*step); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fitBlockTimeModel deletes the samples older than the window and fits the block
+//  time model on the others
+func (u *URLAPI) fitBlockTimeModel(now time.Time) error {
+	if _, err := u.db.DeleteBlockSamples(now.Add(-blockSampleWindow)); err != nil {
+		return err
+	}
+	samples, err := u.db.ListBlockSamples(now.Add(-blockSampleWindow))
+	if err != nil {
+		return err
+	}
+	model := fitBlockTimes(samples)
+	if model != nil {
+		log.Debugf("block time model: %.3fs per block (±%.3fs), %d samples",
+			model.blockTime, model.blockTimeErr, model.samples)
+	}
+	u.blockModelLock.Lock()
+	defer u.blockModelLock.Unlock()
+	u.blockModel = model
+	return nil
+}
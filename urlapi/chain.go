@@ -0,0 +1,105 @@
+package urlapi
+
+import (
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/api/util"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/bearerstdapi"
+)
+
+func (u *URLAPI) enableChainHandlers() error {
+	if err := u.registerMethod(
+		"/admin/chain/events",
+		"GET",
+		bearerstdapi.MethodAccessTypeAdmin,
+		u.listChainEventsHandler,
+		routeDoc{Summary: "List chain events", Tag: "chain",
+			Response: []types.ChainEvent{}},
+	); err != nil {
+		return err
+	}
+	if err := u.registerMethod(
+		"/priv/organizations/{organizationId}/chain/events",
+		"GET",
+		bearerstdapi.MethodAccessTypePrivate,
+		u.listOrganizationChainEventsHandler,
+		routeDoc{Summary: "List an organization's chain events", Tag: "chain",
+			Response: []types.ChainEvent{}},
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GET https://server/v1/admin/chain/events?organizationId=&electionId=&kind=&from=&to=&count=&cursor=
+// listChainEventsHandler lists the chain events of every organization, newest first
+func (u *URLAPI) listChainEventsHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	filter, err := chainEventFilter(ctx)
+	if err != nil {
+		return err
+	}
+	if filter.OrgEthAddress, err = util.GetQueryBytes(ctx, "organizationId"); err != nil {
+		return err
+	}
+	events, next, err := u.db.ListChainEvents(filter)
+	if err != nil {
+		return err
+	}
+	return sendPage(events, next, ctx)
+}
+
+// GET https://server/v1/priv/organizations/{organizationId}/chain/events?electionId=&kind=&from=&to=&count=&cursor=
+// listOrganizationChainEventsHandler lists the chain events of an organization of
+//  the calling integrator and its elections, newest first
+func (u *URLAPI) listOrganizationChainEventsHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	integratorKey, err := util.GetAuthToken(msg)
+	if err != nil {
+		return err
+	}
+	orgEthAddress, err := util.GetBytesID(ctx, "organizationId")
+	if err != nil {
+		return err
+	}
+	if _, err := u.db.GetOrganization(integratorKey, orgEthAddress); err != nil {
+		return err
+	}
+	filter, err := chainEventFilter(ctx)
+	if err != nil {
+		return err
+	}
+	filter.OrgEthAddress = orgEthAddress
+	events, next, err := u.db.ListChainEvents(filter)
+	if err != nil {
+		return err
+	}
+	return sendPage(events, next, ctx)
+}
+
+// chainEventFilter reads the chain event filters shared by the admin and
+//  integrator views
+func chainEventFilter(ctx *httprouter.HTTPContext) (*types.ChainEventFilter, error) {
+	query := ctx.Request.URL.Query()
+	filter := &types.ChainEventFilter{
+		Kind:   query.Get("kind"),
+		Cursor: query.Get("cursor"),
+	}
+	var err error
+	if filter.ProcessID, err = util.GetQueryBytes(ctx, "electionId"); err != nil {
+		return nil, err
+	}
+	if filter.From, err = util.GetQueryTime(ctx, "from"); err != nil {
+		return nil, err
+	}
+	if filter.To, err = util.GetQueryTime(ctx, "to"); err != nil {
+		return nil, err
+	}
+	if filter.Count, err = util.GetQueryInt(ctx, "count"); err != nil {
+		return nil, err
+	}
+	if filter.Skip, err = util.GetQueryInt(ctx, "skip"); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
@@ -0,0 +1,484 @@
+package urlapi
+
+import (
+	"bytes"
+	"errors"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/csp"
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/api/util"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/bearerstdapi"
+	"go.vocdoni.io/dvote/log"
+)
+
+// cspRoute is the route of the hosted CSP, below the base route
+const cspRoute = "/pub/csp/elections"
+
+func (u *URLAPI) enableCspHandlers() error {
+	if err := u.registerMethod(
+		"/admin/accounts/{id}/csp",
+		"POST",
+		bearerstdapi.MethodAccessTypeAdmin,
+		u.hostIntegratorCspHandler,
+		routeDoc{Summary: "Host the CSP of an integrator", Tag: "csp",
+			Action: "integrator.hostCsp", Response: types.APIResponse{}},
+	); err != nil {
+		return err
+	}
+	if err := u.registerMethod(
+		"/priv/organizations/{organizationId}/csp",
+		"POST",
+		bearerstdapi.MethodAccessTypePrivate,
+		u.hostOrganizationCspHandler,
+		routeDoc{Summary: "Host the CSP of an organization", Tag: "csp",
+			Action: "organization.hostCsp", Response: types.APIResponse{}},
+	); err != nil {
+		return err
+	}
+	if err := u.registerMethod(
+		"/priv/elections/{electionId}/voters",
+		"POST",
+		bearerstdapi.MethodAccessTypePrivate,
+		u.addCspVotersHandler,
+		routeDoc{Summary: "Add voters to the list of an election", Tag: "csp",
+			Action: "election.addVoters", Request: types.AddCspVotersRequest{},
+			Response: types.APIResponse{}},
+	); err != nil {
+		return err
+	}
+	if err := u.registerMethod(
+		cspRoute+"/{electionId}/{signType}/auth",
+		"POST",
+		bearerstdapi.MethodAccessTypePublic,
+		u.cspAuthHandler,
+		routeDoc{Summary: "Authenticate a voter to the hosted CSP", Tag: "csp",
+			Request: types.CspMessage{}, Response: types.CspMessage{}},
+	); err != nil {
+		return err
+	}
+	if err := u.registerMethod(
+		cspRoute+"/{electionId}/{signType}/sign",
+		"POST",
+		bearerstdapi.MethodAccessTypePublic,
+		u.cspSignHandler,
+		routeDoc{Summary: "Sign a voter payload with the hosted CSP", Tag: "csp",
+			Request: types.CspMessage{}, Response: types.CspMessage{}},
+	); err != nil {
+		return err
+	}
+	return u.registerMethod(
+		cspRoute+"/{electionId}/sharedkey",
+		"POST",
+		bearerstdapi.MethodAccessTypePublic,
+		u.cspSharedKeyHandler,
+		routeDoc{Summary: "Get the shared key of an election from the hosted CSP", Tag: "csp",
+			Request: types.CspMessage{}, Response: types.CspMessage{}},
+	)
+}
+
+// POST https://server/v1/admin/accounts/<id>/csp
+// hostIntegratorCspHandler makes the API the CSP of an integrator: it creates
+//  the integrator's CSP key, if it has none, and sets its CSP public key and url
+//  prefix to the hosted ones
+func (u *URLAPI) hostIntegratorCspHandler(
+	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
+	id, err := util.GetIntID(ctx, "id")
+	if err != nil {
+		return err
+	}
+	if _, err := u.db.GetIntegrator(id); err != nil {
+		return err
+	}
+	key, err := u.hostCsp(id, nil)
+	if err != nil {
+		return err
+	}
+	resp := types.APIResponse{CspPubKey: key.PubKey, CspUrlPrefix: u.cspURLPrefix()}
+	if _, err := u.db.UpdateIntegrator(id, key.PubKey, resp.CspUrlPrefix, ""); err != nil {
+		return err
+	}
+	return sendResponse(resp, ctx)
+}
+
+// POST https://server/v1/priv/organizations/<organizationId>/csp
+// hostOrganizationCspHandler creates the CSP key of an organization, if it has
+//  none. The elections it creates afterwards are signed by the hosted CSP with
+//  its key, whatever the CSP of its integrator.
+func (u *URLAPI) hostOrganizationCspHandler(
+	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
+	orgInfo, err := u.authEntityPermissions(msg, ctx)
+	if err != nil {
+		return err
+	}
+	key, err := u.hostCsp(orgInfo.organization.IntegratorID, orgInfo.entityID)
+	if err != nil {
+		return err
+	}
+	return sendResponse(types.APIResponse{
+		CspPubKey:    key.PubKey,
+		CspUrlPrefix: u.cspURLPrefix(),
+	}, ctx)
+}
+
+// hostCsp returns the CSP key of an integrator or one of its organizations,
+//  creating it if there is none
+func (u *URLAPI) hostCsp(integratorID int, orgEthAddress []byte) (*types.CspKey, error) {
+	if u.config.PublicURL == "" {
+		return nil, apierror.ErrUnimplemented.With("the hosted csp needs a public url")
+	}
+	key, err := u.db.GetCspKey(integratorID, orgEthAddress)
+	if err == nil || !errors.Is(err, apierror.ErrCspKeyNotFound) {
+		return key, err
+	}
+	privKey, pubKey, err := csp.GenerateKey()
+	if err != nil {
+		return nil, apierror.ErrCrypto.WithErr(err)
+	}
+	if privKey, err = u.sealCspSecret(privKey); err != nil {
+		return nil, err
+	}
+	key = &types.CspKey{
+		IntegratorID:  integratorID,
+		OrgEthAddress: orgEthAddress,
+		PubKey:        pubKey,
+		PrivKeyCipher: privKey,
+	}
+	if _, err := u.db.CreateCspKey(key); err != nil {
+		if errors.Is(err, apierror.ErrAlreadyExists) {
+			// created concurrently, keys are never replaced
+			return u.db.GetCspKey(integratorID, orgEthAddress)
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+// cspURLPrefix returns the url prefix of the hosted CSP, as set in integrators
+func (u *URLAPI) cspURLPrefix() string {
+	return u.config.PublicURL + u.BaseRoute + cspRoute
+}
+
+// censusRoot returns the census root of the elections of an organization: the
+//  key of the organization on the hosted CSP, if it has one, or the CSP public
+//  key of its integrator. hosted tells whether the key is on the hosted CSP.
+func (u *URLAPI) censusRoot(integrator *types.Integrator,
+	orgEthAddress []byte) (root []byte, hosted bool, err error) {
+	key, err := u.db.GetCspKey(integrator.ID, orgEthAddress)
+	if err == nil {
+		return key.PubKey, true, nil
+	}
+	if !errors.Is(err, apierror.ErrCspKeyNotFound) {
+		return nil, false, err
+	}
+	if _, err := u.db.GetCspKeyByPubKey(integrator.CspPubKey); err != nil {
+		if errors.Is(err, apierror.ErrCspKeyNotFound) {
+			return integrator.CspPubKey, false, nil
+		}
+		return nil, false, err
+	}
+	return integrator.CspPubKey, true, nil
+}
+
+// POST https://server/v1/priv/elections/<electionId>/voters
+// addCspVotersHandler adds voters to the list of an election authenticated by
+//  id list or email on the hosted CSP, replacing those already on it
+func (u *URLAPI) addCspVotersHandler(
+	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
+	processID, err := util.GetBytesID(ctx, "electionId")
+	if err != nil {
+		return err
+	}
+	integratorPrivKey, err := util.GetAuthToken(msg)
+	if err != nil {
+		return err
+	}
+	var req types.AddCspVotersRequest
+	if err := util.UnmarshalRequest(msg, &req); err != nil {
+		return err
+	}
+	process, err := u.vocClient.GetProcess(processID)
+	if err != nil {
+		return apierror.ErrElectionNotFound.WithErr(err)
+	}
+	election, err := u.db.GetElection(integratorPrivKey, process.EntityID, processID)
+	if err != nil {
+		return err
+	}
+	voters := make([]types.CspVoter, len(req.Voters))
+	for i, entry := range req.Voters {
+		switch election.CspAuth {
+		case csp.AuthIDList:
+			if entry.ID == "" || entry.Code == "" {
+				return apierror.ErrInvalidField.Withf("voters[%d] needs an id and a code", i)
+			}
+			voters[i] = types.CspVoter{VoterID: entry.ID,
+				SecretHash: csp.HashSecret(processID, entry.Code)}
+		case csp.AuthEmail:
+			if entry.Email == "" {
+				return apierror.ErrInvalidField.Withf("voters[%d] needs an email", i)
+			}
+			email := csp.NormalizeEmail(entry.Email)
+			voters[i] = types.CspVoter{VoterID: email, Email: email}
+		default:
+			return apierror.ErrInvalidField.Withf(
+				"election %x does not authenticate voters by a list", processID)
+		}
+	}
+	if err := u.db.AddCspVoters(processID, voters); err != nil {
+		return err
+	}
+	return sendResponse(types.APIResponse{Voters: len(voters)}, ctx)
+}
+
+// POST https://server/v1/pub/csp/elections/<electionId>/<signType>/auth
+// cspAuthHandler authenticates a voter of an election and returns the token of
+//  its signature request: the R point of blind signatures, or a random token
+func (u *URLAPI) cspAuthHandler(
+	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
+	var req types.CspMessage
+	if err := util.UnmarshalRequest(msg, &req); err != nil {
+		return err
+	}
+	election, _, err := u.cspElection(ctx)
+	if err != nil {
+		return err
+	}
+	signType, err := cspSignType(ctx, election)
+	if err != nil {
+		return err
+	}
+	voter, response, err := u.cspAuthenticate(election, req.AuthData)
+	if err != nil {
+		return err
+	}
+	if voter == "" {
+		// the handler needs another step
+		return sendResponse(types.CspMessage{Response: response}, ctx)
+	}
+	request := &types.CspRequest{ElectionID: election.ProcessID, Voter: voter, SignType: signType}
+	switch signType {
+	case csp.SignatureTypeBlind:
+		var secretK []byte
+		if request.Token, secretK, err = csp.NewBlindRequest(); err != nil {
+			return apierror.ErrCrypto.WithErr(err)
+		}
+		if request.SecretKCipher, err = u.sealCspSecret(secretK); err != nil {
+			return err
+		}
+	case csp.SignatureTypeECDSA:
+		if request.Token, err = csp.NewECDSARequest(); err != nil {
+			return apierror.ErrCrypto.WithErr(err)
+		}
+	}
+	if err := u.db.CreateCspRequest(request); err != nil {
+		return err
+	}
+	return sendResponse(types.CspMessage{Token: request.Token,
+		Response: "authenticated"}, ctx)
+}
+
+// POST https://server/v1/pub/csp/elections/<electionId>/<signType>/sign
+// cspSignHandler signs the payload of a voter with the token of its request.
+//  Each token signs a single payload.
+func (u *URLAPI) cspSignHandler(
+	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
+	var req types.CspMessage
+	if err := util.UnmarshalRequest(msg, &req); err != nil {
+		return err
+	}
+	if len(req.Token) == 0 {
+		return apierror.ErrInvalidField.With("tokenR is required")
+	}
+	if len(req.Payload) == 0 {
+		return apierror.ErrInvalidField.With("payload is required")
+	}
+	election, signer, err := u.cspElection(ctx)
+	if err != nil {
+		return err
+	}
+	signType, err := cspSignType(ctx, election)
+	if err != nil {
+		return err
+	}
+	var resp types.CspMessage
+	if err := u.db.UseCspRequest(election.ProcessID, req.Token, signType,
+		func(request *types.CspRequest) error {
+			var err error
+			if signType == csp.SignatureTypeECDSA {
+				resp.Signature, err = signer.SignECDSA(election.ProcessID, req.Payload)
+			} else {
+				var secretK []byte
+				if secretK, err = u.openCspSecret(request.SecretKCipher); err != nil {
+					return err
+				}
+				resp.Signature, err = signer.SignBlind(election.ProcessID, req.Payload, secretK)
+			}
+			if err != nil {
+				return apierror.ErrInvalidField.Withf("could not sign payload: %v", err)
+			}
+			return nil
+		}); err != nil {
+		return err
+	}
+	return sendResponse(resp, ctx)
+}
+
+// POST https://server/v1/pub/csp/elections/<electionId>/sharedkey
+// cspSharedKeyHandler returns the shared key of an election to its voters, the
+//  key to its confidential data (see verifyCspSharedSignature)
+func (u *URLAPI) cspSharedKeyHandler(
+	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
+	var req types.CspMessage
+	if err := util.UnmarshalRequest(msg, &req); err != nil {
+		return err
+	}
+	election, signer, err := u.cspElection(ctx)
+	if err != nil {
+		return err
+	}
+	voter, response, err := u.cspAuthenticate(election, req.AuthData)
+	if err != nil {
+		return err
+	}
+	resp := types.CspMessage{Response: response}
+	if voter == "" {
+		return sendResponse(resp, ctx)
+	}
+	if resp.SharedKey, err = signer.SharedKey(election.ProcessID); err != nil {
+		return apierror.ErrCrypto.WithErr(err)
+	}
+	return sendResponse(resp, ctx)
+}
+
+// cspElection returns the election of a hosted CSP request and the signer of
+//  the key set as its census root. Elections of other CSPs are not found.
+func (u *URLAPI) cspElection(ctx *httprouter.HTTPContext) (*types.Election, *csp.Signer, error) {
+	if u.config.PublicURL == "" {
+		return nil, nil, apierror.ErrUnimplemented.With("the hosted csp is disabled")
+	}
+	processID, err := util.GetBytesID(ctx, "electionId")
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(processID) != csp.ElectionIDSize {
+		return nil, nil, apierror.ErrInvalidURLParam.Withf("electionId %x: wrong length",
+			processID)
+	}
+	process, err := u.vocClient.GetProcess(processID)
+	if err != nil {
+		return nil, nil, apierror.ErrElectionNotFound.WithErr(err)
+	}
+	key, err := u.db.GetCspKeyByPubKey(process.CensusRoot)
+	if err != nil {
+		if errors.Is(err, apierror.ErrCspKeyNotFound) {
+			return nil, nil, apierror.ErrElectionNotFound.Withf(
+				"election %x is not signed by the hosted csp", processID)
+		}
+		return nil, nil, err
+	}
+	if len(key.OrgEthAddress) > 0 && !bytes.Equal(key.OrgEthAddress, process.EntityID) {
+		return nil, nil, apierror.ErrElectionNotFound.Withf(
+			"election %x is not signed by the hosted csp", processID)
+	}
+	election, err := u.db.GetElectionPrivate(process.EntityID, processID)
+	if err != nil {
+		return nil, nil, err
+	}
+	election.ProcessID = processID
+	election.OrgEthAddress = process.EntityID
+	privKey, err := u.openCspSecret(key.PrivKeyCipher)
+	if err != nil {
+		return nil, nil, err
+	}
+	signer, err := csp.NewSigner(privKey)
+	if err != nil {
+		return nil, nil, apierror.ErrCrypto.WithErr(err)
+	}
+	return election, signer, nil
+}
+
+// cspAuthenticate authenticates a voter of election with the handler chosen
+//  for it, the census by default
+func (u *URLAPI) cspAuthenticate(election *types.Election,
+	authData []string) (voterID, response string, err error) {
+	name := election.CspAuth
+	if name == "" {
+		name = csp.AuthCensus
+	}
+	handler, ok := u.cspAuth[name]
+	if !ok {
+		return "", "", apierror.ErrInternal.Withf("unknown csp auth handler %q", name)
+	}
+	return handler.Auth(election, authData)
+}
+
+// cspAuthHandlers returns the handlers the elections on the hosted CSP can
+//  choose from, by name
+func (u *URLAPI) cspAuthHandlers() map[string]csp.AuthHandler {
+	var mailer csp.Mailer = csp.LogMailer{}
+	if u.config.SMTP.Host != "" {
+		mailer = &csp.SMTPMailer{Host: u.config.SMTP.Host, Port: u.config.SMTP.Port,
+			User: u.config.SMTP.User, Password: u.config.SMTP.Password,
+			From: u.config.SMTP.From}
+	} else if u.config.PublicURL != "" {
+		log.Warnf("no smtp server set, the codes of email authentication are logged")
+	}
+	handlers := map[string]csp.AuthHandler{}
+	for _, handler := range []csp.AuthHandler{
+		&csp.CensusHandler{Census: u.db},
+		&csp.IDListHandler{Voters: u.db},
+		&csp.EmailHandler{Voters: u.db, Mailer: mailer},
+		&csp.JWTHandler{Secret: func(election *types.Election) ([]byte, error) {
+			if len(election.CspAuthSecret) == 0 {
+				return nil, apierror.ErrForbidden.Withf("election %x has no jwt secret",
+					election.ProcessID)
+			}
+			return u.openCspSecret(election.CspAuthSecret)
+		}},
+	} {
+		handlers[handler.Name()] = handler
+	}
+	return handlers
+}
+
+// cspSignType returns the signature type of a hosted CSP request, which must
+//  match the proof type of the election
+func cspSignType(ctx *httprouter.HTTPContext, election *types.Election) (string, error) {
+	signType := ctx.URLParam("signType")
+	switch signType {
+	case csp.SignatureTypeBlind, csp.SignatureTypeECDSA:
+	default:
+		return "", apierror.ErrInvalidURLParam.Withf("signType %q", signType)
+	}
+	if election.ProofType != "" && election.ProofType != signType {
+		return "", apierror.ErrInvalidProofType.Withf("election %x uses %s signatures",
+			election.ProcessID, election.ProofType)
+	}
+	return signType, nil
+}
+
+// sealCspSecret encrypts a CSP secret with the global organization key, if set
+func (u *URLAPI) sealCspSecret(secret []byte) ([]byte, error) {
+	if len(u.globalOrganizationKey) == 0 {
+		return secret, nil
+	}
+	sealed, err := util.EncryptSymmetric(secret, u.globalOrganizationKey)
+	if err != nil {
+		return nil, apierror.ErrCrypto.Withf("could not encrypt csp secret: %v", err)
+	}
+	return sealed, nil
+}
+
+// openCspSecret decrypts a CSP secret sealed with sealCspSecret
+func (u *URLAPI) openCspSecret(sealed []byte) ([]byte, error) {
+	if len(u.globalOrganizationKey) == 0 {
+		return sealed, nil
+	}
+	secret, ok := util.DecryptSymmetric(sealed, u.globalOrganizationKey)
+	if !ok {
+		return nil, apierror.ErrCrypto.With("could not decrypt csp secret")
+	}
+	return secret, nil
+}
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/csp"
 	"go.vocdoni.io/api/database/transactions"
 	"go.vocdoni.io/api/types"
 	"go.vocdoni.io/api/util"
@@ -24,155 +26,206 @@ import (
 const IMMEDIATE_PROCESS_CREATION_OFFSET = 3
 
 func (u *URLAPI) enableEntityHandlers() error {
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/priv/account/organizations",
 		"POST",
 		bearerstdapi.MethodAccessTypePrivate,
 		u.createOrganizationHandler,
+		routeDoc{Summary: "Create an organization", Tag: "organizations",
+			Action:  "organization.create",
+			Request: types.CreateOrganizationRequest{}, Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/priv/account/organizations",
 		"GET",
 		bearerstdapi.MethodAccessTypePrivate,
 		u.getOrganizationListHandler,
+		routeDoc{Summary: "List the integrator's organizations", Tag: "organizations",
+			Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/priv/account/organizations/{organizationId}",
 		"GET",
 		bearerstdapi.MethodAccessTypePrivate,
 		u.getOrganizationPrivateHandler,
+		routeDoc{Summary: "Get an organization", Tag: "organizations",
+			Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/priv/account/organizations/{organizationId}",
 		"DELETE",
 		bearerstdapi.MethodAccessTypePrivate,
 		u.deleteOrganizationHandler,
+		routeDoc{Summary: "Delete an organization", Tag: "organizations",
+			Action: "organization.delete", Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/priv/account/organizations/{organizationId}/key",
 		"PATCH",
 		bearerstdapi.MethodAccessTypePrivate,
 		u.resetOrganizationKeyHandler,
+		routeDoc{Summary: "Reset an organization API token", Tag: "organizations",
+			Action: "organization.resetKey", Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/priv/organizations/{organizationId}/metadata",
 		"PUT",
 		bearerstdapi.MethodAccessTypePrivate,
 		u.setOrganizationMetadataHandler,
+		routeDoc{Summary: "Set an organization's metadata", Tag: "organizations",
+			Action:  "organization.setMetadata",
+			Request: types.SetOrganizationMetadataRequest{}, Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/priv/organizations/{organizationId}/elections/{type}",
 		"POST",
 		bearerstdapi.MethodAccessTypePrivate,
 		u.createProcessHandler,
+		routeDoc{Summary: "Create an election", Tag: "elections",
+			Action:  "election.create",
+			Request: types.CreateElectionRequest{}, Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/priv/organizations/{organizationId}/elections/{type}",
 		"GET",
 		bearerstdapi.MethodAccessTypePrivate,
 		u.listProcessesPrivateHandler,
+		routeDoc{Summary: "List an organization's elections", Tag: "elections",
+			Response: []types.APIElectionSummary{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/priv/organizations/{organizationId}/elections",
 		"GET",
 		bearerstdapi.MethodAccessTypePrivate,
 		u.listProcessesPrivateHandler,
+		routeDoc{Summary: "List an organization's elections", Tag: "elections",
+			Response: []types.APIElectionSummary{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/priv/censuses",
 		"POST",
 		bearerstdapi.MethodAccessTypePrivate,
 		u.createCensusHandler,
+		routeDoc{Summary: "Create a census", Tag: "censuses",
+			Action: "census.create", Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/priv/censuses/{censusId}/tokens/*",
 		"POST",
 		bearerstdapi.MethodAccessTypePrivate,
 		u.addCensusTokensHandler,
+		routeDoc{Summary: "Add tokens to a census", Tag: "censuses",
+			Action: "census.addTokens", Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/priv/censuses/{censusId}/tokens/{tokenId}",
 		"GET",
 		bearerstdapi.MethodAccessTypePrivate,
 		u.getCensusTokenHandler,
+		routeDoc{Summary: "Get a census token", Tag: "censuses",
+			Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/priv/censuses/{censusId}/tokens/{tokenId}",
 		"DELETE",
 		bearerstdapi.MethodAccessTypePrivate,
 		u.deleteCensusTokenHandler,
+		routeDoc{Summary: "Delete a census token", Tag: "censuses",
+			Action: "census.deleteToken", Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/priv/censuses/{censusId}/tokens/{tokenId}",
 		"DELETE",
 		bearerstdapi.MethodAccessTypePrivate,
 		u.deleteCensusTokenHandler,
+		routeDoc{Summary: "Delete a census token", Tag: "censuses",
+			Action: "census.deleteToken", Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/priv/censuses/{censusId}/keys/{publicKey}",
 		"DELETE",
 		bearerstdapi.MethodAccessTypePrivate,
 		u.deletePublicKeyHandler,
+		routeDoc{Summary: "Delete a public key from a census", Tag: "censuses",
+			Action: "census.deleteKey", Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/priv/censuses/{censusId}/import/*",
 		"POST",
 		bearerstdapi.MethodAccessTypePrivate,
 		u.importPublicKeysHandler,
+		routeDoc{Summary: "Import public keys to a census", Tag: "censuses",
+			Action: "census.importKeys", Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/priv/elections/{electionId}/{status}",
 		"PUT",
 		bearerstdapi.MethodAccessTypePrivate,
 		u.setProcessStatusHandler,
+		routeDoc{Summary: "Set an election status", Tag: "elections",
+			Action: "election.setStatus", Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/priv/elections/{electionId}",
 		"GET",
 		bearerstdapi.MethodAccessTypePrivate,
 		u.getProcessHandler,
+		routeDoc{Summary: "Get an election", Tag: "elections",
+			Response: types.APIElectionInfo{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
+		"/priv/elections/{electionId}/results/export",
+		"GET",
+		bearerstdapi.MethodAccessTypePrivate,
+		u.exportResultsHandler,
+		routeDoc{Summary: "Export election results as csv, json or a signed bundle",
+			Tag: "elections", Response: types.ResultsBundle{}},
+	); err != nil {
+		return err
+	}
+	if err := u.registerMethod(
 		"/priv/transactions/{transactionHash}",
 		"GET",
 		bearerstdapi.MethodAccessTypePrivate,
 		u.getTxStatusHandler,
+		routeDoc{Summary: "Get a transaction status", Tag: "transactions",
+			Response: APIMined{}},
 	); err != nil {
 		return err
 	}
@@ -184,29 +237,26 @@ func (u *URLAPI) enableEntityHandlers() error {
 func (u *URLAPI) createOrganizationHandler(msg *bearerstdapi.BearerStandardAPIdata,
 	ctx *httprouter.HTTPContext) error {
 	// var organizationMetadataKey []byte
-	req, err := util.UnmarshalRequest(msg)
-	if err != nil {
+	var req types.CreateOrganizationRequest
+	if err := util.UnmarshalRequest(msg, &req); err != nil {
 		return err
 	}
 	integratorPrivKey, err := util.GetAuthToken(msg)
 	if err != nil {
 		return err
 	}
-	if req.Name == "" {
-		return fmt.Errorf("organization name is empty")
-	}
 	orgApiToken := util.GenerateBearerToken()
 
 	ethSignKeys := ethereum.NewSignKeys()
 	if err = ethSignKeys.Generate(); err != nil {
-		return fmt.Errorf("could not generate ethereum keys: %w", err)
+		return apierror.ErrCrypto.Withf("could not generate ethereum keys: %v", err)
 	}
 
 	// Encrypt private key to store in db
 	_, priv := ethSignKeys.HexString()
 	entityPrivKey, err := hex.DecodeString(priv)
 	if err != nil {
-		return fmt.Errorf("could not decode entity private key: %w", err)
+		return apierror.ErrCrypto.Withf("could not decode entity private key: %v", err)
 	}
 
 	// If there is a global entity encryption key that can be decoded,
@@ -215,7 +265,7 @@ func (u *URLAPI) createOrganizationHandler(msg *bearerstdapi.BearerStandardAPIda
 	if len(u.globalOrganizationKey) > 0 {
 		if encryptedPrivKey, err = util.EncryptSymmetric(
 			entityPrivKey, u.globalOrganizationKey); err != nil {
-			return fmt.Errorf("could not encrypt entity private key: %w", err)
+			return apierror.ErrCrypto.Withf("could not encrypt entity private key: %v", err)
 		}
 	}
 
@@ -241,7 +291,7 @@ func (u *URLAPI) createOrganizationHandler(msg *bearerstdapi.BearerStandardAPIda
 	}
 
 	if balance < u.vocClient.AcctTxCost*vocclient.DefaultFaucetMultiplier {
-		return fmt.Errorf("faucet does not have enough tokens. Balance is %d", balance)
+		return apierror.ErrFaucetFunds.Withf("balance is %d", balance)
 	}
 
 	// Create the new account on the Vochain
@@ -249,6 +299,7 @@ func (u *URLAPI) createOrganizationHandler(msg *bearerstdapi.BearerStandardAPIda
 		u.faucet, metaURI, 0); err != nil {
 		return fmt.Errorf("could not create account on the vochain: %w", err)
 	}
+	u.meterFaucet(msg.AuthToken, ethSignKeys.Address().Bytes())
 
 	// TODO fetch actual transaction hash
 	txHash := dvoteutil.RandomBytes(32)
@@ -268,7 +319,7 @@ func (u *URLAPI) createOrganizationHandler(msg *bearerstdapi.BearerStandardAPIda
 		},
 	}
 	if err = u.kv.StoreTx(txHash, queryTx); err != nil {
-		return err
+		return apierror.ErrTxCache.WithErr(err)
 	}
 
 	resp := types.APIResponse{
@@ -280,8 +331,8 @@ func (u *URLAPI) createOrganizationHandler(msg *bearerstdapi.BearerStandardAPIda
 	return sendResponse(resp, ctx)
 }
 
-// GET https://server/v1/priv/account/organizations
-// getOrganizationListHandler gets the list of organizations corresponding to the integrator API key
+// GET https://server/v1/priv/account/organizations?count=&cursor=&sortBy=&order=
+// getOrganizationListHandler gets a page of the integrator's organizations
 func (u *URLAPI) getOrganizationListHandler(msg *bearerstdapi.BearerStandardAPIdata,
 	ctx *httprouter.HTTPContext) error {
 
@@ -289,25 +340,31 @@ func (u *URLAPI) getOrganizationListHandler(msg *bearerstdapi.BearerStandardAPId
 	if err != nil {
 		return err
 	}
-	organizations, err := u.db.ListOrganizations(integratorPrivKey, &types.ListOptions{})
+	opts, err := util.GetListOptions(ctx)
+	if err != nil {
+		return err
+	}
+	organizations, next, err := u.db.ListOrganizations(integratorPrivKey, opts)
 	if err != nil {
 		return fmt.Errorf("could not get organization list: %w", err)
 	}
 
 	var resp types.APIResponse
-	for _, organization := range organizations {
-		// Fetch process from vochain
+	if len(organizations) > 0 {
+		resp.Organizations = make([]types.APIOrganizationInfo, len(organizations))
+	}
+	// the metadata of each organization is fetched from the vochain concurrently
+	if err := forEachConcurrently(len(organizations), func(i int) error {
+		organization := &organizations[i]
 		metaUri, _, _, err := u.vocClient.GetAccount(organization.EthAddress)
 		if err != nil {
 			return err
 		}
-
-		// Fetch metadata
 		organizationMetadata, err := u.vocClient.FetchOrganizationMetadata(metaUri)
 		if err != nil {
 			return fmt.Errorf("could not get organization metadata with URI\"%s\": %w", metaUri, err)
 		}
-		resp.Organizations = append(resp.Organizations, types.APIOrganizationInfo{
+		resp.Organizations[i] = types.APIOrganizationInfo{
 			CreatedAt:   organization.CreatedAt,
 			UpdatedAt:   organization.UpdatedAt,
 			ID:          fmt.Sprintf("%x", organization.EthAddress),
@@ -316,10 +373,13 @@ func (u *URLAPI) getOrganizationListHandler(msg *bearerstdapi.BearerStandardAPId
 			Description: organizationMetadata.Description["default"],
 			Avatar:      organizationMetadata.Media.Avatar,
 			Header:      organizationMetadata.Media.Header,
-		})
+		}
+		return nil
+	}); err != nil {
+		return err
 	}
 
-	return sendResponse(resp, ctx)
+	return sendPage(resp, next, ctx)
 }
 
 // GET https://server/v1/priv/account/organizations/<organizationId>
@@ -355,7 +415,8 @@ func (u *URLAPI) getOrganizationPrivateHandler(msg *bearerstdapi.BearerStandardA
 }
 
 // DELETE https://server/v1/priv/account/organizations/<organizationId>
-// deleteOrganizationHandler deletes an entity
+// deleteOrganizationHandler deletes an entity. The admin can restore it until the
+//  retention period expires.
 func (u *URLAPI) deleteOrganizationHandler(msg *bearerstdapi.BearerStandardAPIdata,
 	ctx *httprouter.HTTPContext) error {
 	// authenticate integrator has permission to edit this entity
@@ -365,9 +426,9 @@ func (u *URLAPI) deleteOrganizationHandler(msg *bearerstdapi.BearerStandardAPIda
 	}
 
 	if err = u.db.DeleteOrganization(orgInfo.integratorPrivKey, orgInfo.entityID); err != nil {
-		log.Warn(err)
-		return sendResponse(types.APIResponse{}, ctx)
+		return fmt.Errorf("could not delete organization: %w", err)
 	}
+	u.RevokeToken(orgInfo.organization.PublicAPIToken)
 	return sendResponse(types.APIResponse{}, ctx)
 }
 
@@ -399,8 +460,8 @@ func (u *URLAPI) setOrganizationMetadataHandler(msg *bearerstdapi.BearerStandard
 	if err != nil {
 		return err
 	}
-	req, err := util.UnmarshalRequest(msg)
-	if err != nil {
+	var req types.SetOrganizationMetadataRequest
+	if err = util.UnmarshalRequest(msg, &req); err != nil {
 		return err
 	}
 	// Post metadata to ipfs
@@ -436,12 +497,14 @@ func (u *URLAPI) setOrganizationMetadataHandler(msg *bearerstdapi.BearerStandard
 		if err := u.vocClient.CollectFaucet(entitySignKeys, u.faucet); err != nil {
 			return err
 		}
+		u.meterFaucet(msg.AuthToken, orgInfo.entityID)
 	}
 
 	if err := u.vocClient.SetAccountInfo(entitySignKeys, u.faucet, metaURI,
 		nonce); err != nil {
 		return fmt.Errorf("could not update account metadata uri: %w", err)
 	}
+	u.meterFaucet(msg.AuthToken, orgInfo.entityID)
 
 	// TODO fetch actual transaction hash
 	txHash := dvoteutil.RandomBytes(32)
@@ -457,7 +520,7 @@ func (u *URLAPI) setOrganizationMetadataHandler(msg *bearerstdapi.BearerStandard
 		},
 	}
 	if err = u.kv.StoreTx([]byte(txHash), queryTx); err != nil {
-		return err
+		return apierror.ErrTxCache.WithErr(err)
 	}
 	resp := types.APIResponse{
 		OrganizationID: orgInfo.entityID,
@@ -487,14 +550,39 @@ func (u *URLAPI) createProcessHandler(msg *bearerstdapi.BearerStandardAPIdata,
 	switch electionType {
 	case types.PROOF_TYPE_BLIND, types.PROOF_TYPE_ECDSA:
 	default:
-		return fmt.Errorf("election proof type %s is invalid", electionType)
+		return apierror.ErrInvalidProofType.Withf("%s", electionType)
 	}
 
-	req, err := util.UnmarshalRequest(msg)
+	var req types.CreateElectionRequest
+	if err = util.UnmarshalRequest(msg, &req); err != nil {
+		return err
+	}
+	definition, err := u.electionDefinition(orgInfo, electionType, &req)
 	if err != nil {
 		return err
 	}
 
+	integrator, err := u.db.GetIntegratorByKey(orgInfo.integratorPrivKey)
+	if err != nil {
+		return fmt.Errorf("could not retrieve integrator from db: %w", err)
+	}
+	censusRoot, hosted, err := u.censusRoot(integrator, orgInfo.entityID)
+	if err != nil {
+		return err
+	}
+	authSecret, authSecretCipher := []byte{}, []byte{}
+	if definition.AuthHandler != "" {
+		if !hosted {
+			return apierror.ErrInvalidField.With("authHandler needs the hosted csp")
+		}
+		if definition.AuthHandler == csp.AuthJWT {
+			authSecret = dvoteutil.RandomBytes(32)
+			if authSecretCipher, err = u.sealCspSecret(authSecret); err != nil {
+				return err
+			}
+		}
+	}
+
 	processID := dvoteutil.RandomBytes(32)
 	entitySignKeys, err := decryptEntityKeys(
 		orgInfo.organization.EthPrivKeyCipher, u.globalOrganizationKey)
@@ -503,54 +591,64 @@ func (u *URLAPI) createProcessHandler(msg *bearerstdapi.BearerStandardAPIdata,
 	}
 
 	var startBlock uint32
+	var startMargin time.Duration
 	startDate := time.Now()
 	// If start date is empty, do not attempt to parse it. Set startBlock to 0, starting the
 	//  process immediately. Otherwise, ensure the startBlock is in the future
 	if req.StartDate != "" {
-		if startDate, err = time.Parse("2006-01-02T15:04:05.000Z", req.StartDate); err != nil {
-			return fmt.Errorf("could not parse startDate: %w", err)
+		if startDate, err = time.Parse(types.DateLayout, req.StartDate); err != nil {
+			return apierror.ErrInvalidDate.Withf("could not parse startDate: %v", err)
 		}
-		if startBlock, err = u.estimateBlockHeight(startDate); err != nil {
+		if startBlock, startMargin, err = u.estimateBlockHeight(startDate); err != nil {
 			return fmt.Errorf("unable to estimate startDate block height: %w", err)
 		}
 	}
 
-	endDate, err := time.Parse("2006-01-02T15:04:05.000Z", req.EndDate)
+	endDate, err := time.Parse(types.DateLayout, req.EndDate)
 	if err != nil {
-		return fmt.Errorf("could not parse endDate: %w", err)
+		return apierror.ErrInvalidDate.Withf("could not parse endDate: %v", err)
 	}
 
 	if endDate.Before(time.Now()) {
-		return fmt.Errorf("election end date cannot be in the past")
+		return apierror.ErrInvalidDate.With("election end date cannot be in the past")
 	}
-	endBlock, err := u.estimateBlockHeight(endDate)
+	endBlock, endMargin, err := u.estimateBlockHeight(endDate)
 	if err != nil {
 		return fmt.Errorf("unable to estimate endDate block height: %w", err)
 	}
 	if endDate.Before(startDate) {
-		return fmt.Errorf("end date must be after start date")
+		return apierror.ErrInvalidDate.With("end date must be after start date")
+	}
+	// The Vochain cannot move the end block of a process, only end it earlier. To
+	//  end the election at endDate, its end block is the upper bound of the
+	//  estimate, and the scheduler ends it once the Vochain reaches endDate.
+	scheduleEnd := u.config.AdjustEndBlocks && endMargin > 0
+	if scheduleEnd {
+		if endBlock, _, err = u.estimateBlockHeight(endDate.Add(endMargin)); err != nil {
+			return fmt.Errorf("unable to estimate endDate block height: %w", err)
+		}
 	}
 
 	metadata := types.ProcessMetadata{
-		Description: map[string]string{"default": req.Description},
+		Description: definition.Description,
 		Media: types.ProcessMedia{
-			Header:    req.Header,
-			StreamURI: req.StreamURI,
+			Header:    definition.Header,
+			StreamURI: definition.StreamURI,
 		},
 		Meta:      nil,
-		Questions: []types.QuestionMeta{},
+		Questions: definition.Questions,
 		Results: types.ProcessResultsDetails{
 			Aggregation: "discrete-values",
 			Display:     "multiple-choice",
 		},
-		Title:   map[string]string{"default": req.Title},
+		Title:   definition.Title,
 		Version: "1.0",
 	}
 
 	envelopeType := &models.EnvelopeType{
 		Serial:         false,
 		Anonymous:      false,
-		EncryptedVotes: req.HiddenResults,
+		EncryptedVotes: definition.HiddenResults,
 		UniqueValues:   false,
 		CostFromWeight: false,
 	}
@@ -558,34 +656,22 @@ func (u *URLAPI) createProcessHandler(msg *bearerstdapi.BearerStandardAPIdata,
 		AutoStart:         false,
 		Interruptible:     true,
 		DynamicCensus:     false,
-		EncryptedMetaData: req.Confidential,
+		EncryptedMetaData: definition.Confidential,
 		PreRegister:       false,
 	}
 
 	maxChoiceValue := 0
-	for _, question := range req.Questions {
+	for _, question := range definition.Questions {
 		if len(question.Choices) > maxChoiceValue {
 			maxChoiceValue = len(question.Choices)
 		}
-		metaQuestion := types.QuestionMeta{
-			Choices:     []types.ChoiceMetadata{},
-			Description: map[string]string{"default": question.Description},
-			Title:       map[string]string{"default": question.Title},
-		}
-		for _, choice := range question.Choices {
-			metaQuestion.Choices = append(metaQuestion.Choices, types.ChoiceMetadata{
-				Title: map[string]string{"default": choice.Title},
-				Value: choice.Value,
-			})
-		}
-		metadata.Questions = append(metadata.Questions, metaQuestion)
 	}
 
 	voteOptions := &models.ProcessVoteOptions{
-		MaxCount:          uint32(len(req.Questions)),
+		MaxCount:          uint32(len(definition.Questions)),
 		MaxValue:          uint32(maxChoiceValue),
 		MaxVoteOverwrites: 0,
-		MaxTotalCost:      uint32(len(req.Questions) * maxChoiceValue),
+		MaxTotalCost:      uint32(len(definition.Questions) * maxChoiceValue),
 		CostExponent:      1,
 	}
 
@@ -593,7 +679,7 @@ func (u *URLAPI) createProcessHandler(msg *bearerstdapi.BearerStandardAPIdata,
 	var metaPrivKeyBytes []byte
 	// If election is confidential, generate a private metadata key and encrypt it.
 	// store this key with the election
-	if req.Confidential {
+	if definition.Confidential {
 		metaPrivKeyBytes = dvoteutil.RandomBytes(32)
 		// Encrypt and send the process metadata
 		if metaUri, err = u.vocClient.SetProcessMetadata(
@@ -605,7 +691,7 @@ func (u *URLAPI) createProcessHandler(msg *bearerstdapi.BearerStandardAPIdata,
 		if len(u.globalMetadataKey) > 0 {
 			if metaPrivKeyBytes, err = util.EncryptSymmetric(
 				metaPrivKeyBytes, u.globalMetadataKey); err != nil {
-				return fmt.Errorf("could not encrypt metadata private key: %w", err)
+				return apierror.ErrCrypto.Withf("could not encrypt metadata private key: %v", err)
 			}
 		}
 
@@ -615,14 +701,9 @@ func (u *URLAPI) createProcessHandler(msg *bearerstdapi.BearerStandardAPIdata,
 		}
 	}
 
-	integrator, err := u.db.GetIntegratorByKey(orgInfo.integratorPrivKey)
-	if err != nil {
-		return fmt.Errorf("could not retrieve integrator from db: %w", err)
-	}
-
 	currentBlockHeight, avgTimes, _ := u.vocClient.GetBlockTimes()
 	if startBlock > 1 && startBlock < currentBlockHeight+vocclient.VOCHAIN_BLOCK_MARGIN {
-		return fmt.Errorf("cannot create process: startDate needs to be at least %ds in the future",
+		return apierror.ErrInvalidDate.Withf("startDate needs to be at least %ds in the future",
 			vocclient.VOCHAIN_BLOCK_MARGIN*avgTimes[0]/1000)
 	}
 
@@ -647,6 +728,7 @@ func (u *URLAPI) createProcessHandler(msg *bearerstdapi.BearerStandardAPIdata,
 		if err := u.vocClient.CollectFaucet(entitySignKeys, u.faucet); err != nil {
 			return err
 		}
+		u.meterFaucet(msg.AuthToken, orgInfo.entityID)
 	}
 
 	if err = u.vocClient.CreateProcess(&models.Process{
@@ -654,7 +736,7 @@ func (u *URLAPI) createProcessHandler(msg *bearerstdapi.BearerStandardAPIdata,
 		EntityId:      orgInfo.entityID,
 		StartBlock:    startBlock,
 		BlockCount:    blockCount,
-		CensusRoot:    integrator.CspPubKey,
+		CensusRoot:    censusRoot,
 		CensusURI:     new(string),
 		Status:        models.ProcessStatus_READY,
 		EnvelopeType:  envelopeType,
@@ -666,6 +748,7 @@ func (u *URLAPI) createProcessHandler(msg *bearerstdapi.BearerStandardAPIdata,
 	}, entitySignKeys, nonce); err != nil {
 		return fmt.Errorf("could not create process on the vochain: %w", err)
 	}
+	u.usage.addIntegrator(msg.AuthToken, orgInfo.entityID, types.UsageElections, 1)
 
 	// If starting immediately, store current block height as startblock in db
 	if startBlock <= 1 {
@@ -683,25 +766,31 @@ func (u *URLAPI) createProcessHandler(msg *bearerstdapi.BearerStandardAPIdata,
 			EthAddress:        orgInfo.entityID,
 			EncryptedMetaKey:  metaPrivKeyBytes,
 			ElectionID:        processID,
-			Title:             req.Title,
+			Title:             definition.Title["default"],
 			ProofType:         electionType,
 			StartDate:         startDate,
 			EndDate:           endDate,
 			CensusID:          uuid.NullUUID{},
 			StartBlock:        startBlock,
 			EndBlock:          startBlock + blockCount,
-			Confidential:      req.Confidential,
-			HiddenResults:     req.HiddenResults,
+			Confidential:      definition.Confidential,
+			HiddenResults:     definition.HiddenResults,
+			CspAuth:           definition.AuthHandler,
+			CspAuthSecret:     authSecretCipher,
+			ScheduleEnd:       scheduleEnd,
 		},
 	}
 	if err = u.kv.StoreTx(txHash, queryTx); err != nil {
-		return err
+		return apierror.ErrTxCache.WithErr(err)
 	}
 
 	return sendResponse(
 		types.APIResponse{
-			ElectionID: processID,
-			TxHash:     txHash,
+			ElectionID:     processID,
+			TxHash:         txHash,
+			AuthSecret:     authSecret,
+			StartDateError: int64(startMargin / time.Second),
+			EndDateError:   int64(endMargin / time.Second),
 		},
 		ctx)
 }
@@ -713,7 +802,8 @@ func (u *URLAPI) createProcessHandler(msg *bearerstdapi.BearerStandardAPIdata,
 // GET https://server/v1/priv/organizations/<organizationId>/elections/active
 // GET https://server/v1/priv/organizations/<organizationId>/elections/upcoming
 // GET https://server/v1/priv/organizations/<organizationId>/elections/ended
-// listProcessesPrivateHandler' lists signed, blind, active, ended, or upcoming processes
+// listProcessesPrivateHandler' lists signed, blind, active, ended, or upcoming processes.
+//  A page is selected by the list query parameters (see getElectionFilter).
 func (u *URLAPI) listProcessesPrivateHandler(msg *bearerstdapi.BearerStandardAPIdata,
 	ctx *httprouter.HTTPContext) error {
 
@@ -722,12 +812,12 @@ func (u *URLAPI) listProcessesPrivateHandler(msg *bearerstdapi.BearerStandardAPI
 		return err
 	}
 
-	list, err := u.getProcessList(ctx.URLParam("type"),
+	list, next, err := u.getProcessList(ctx, ctx.URLParam("type"),
 		orgInfo.integratorPrivKey, orgInfo.entityID, true)
 	if err != nil {
 		return err
 	}
-	return sendResponse(list, ctx)
+	return sendPage(list, next, ctx)
 }
 
 // GET https://server/v1/priv/elections/<processId>
@@ -746,12 +836,12 @@ func (u *URLAPI) getProcessHandler(
 		return fmt.Errorf("unable to fetch process from the vochain: %w", err)
 	}
 	if vochainProcess == nil {
-		return fmt.Errorf("process does not exist")
+		return apierror.ErrElectionNotFound.Withf("%x", processId)
 	}
 
-	integratorApiKey, err := hex.DecodeString(msg.AuthToken)
+	integratorApiKey, err := util.GetAuthToken(msg)
 	if err != nil {
-		return fmt.Errorf("could not decode bearer token: %w", err)
+		return err
 	}
 
 	// Fetch election from database
@@ -760,20 +850,18 @@ func (u *URLAPI) getProcessHandler(
 		return fmt.Errorf("could not get election from the database: %w", err)
 	}
 
-	// Fetch results
-	var results *types.VochainResults
-	if vochainProcess.HaveResults {
-		if results, err = u.vocClient.GetResults(processId); err != nil {
-			return fmt.Errorf("could not get results: %w", err)
-		}
-	}
-
 	// Fetch metadata
 	processMetadata, err := u.getProcessMetadataPriv(
 		dbElection.Confidential, dbElection.MetadataPrivKey, vochainProcess.Metadata)
 	if err != nil {
 		return err
 	}
+
+	// Fetch results
+	results, err := u.getResults(vochainProcess, processMetadata)
+	if err != nil {
+		return err
+	}
 	// Parse all the information
 	resp, err := u.parseProcessInfo(vochainProcess, results, processMetadata, types.ProofType(dbElection.ProofType))
 	if err != nil {
@@ -791,7 +879,7 @@ func (u *URLAPI) getProcessHandler(
 // This prevents both the API and the integrator from gaining access to the private key.
 func (u *URLAPI) createCensusHandler(
 	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
-	return fmt.Errorf("endpoint %s unimplemented", ctx.Request.URL.String())
+	return apierror.ErrUnimplemented.Withf("endpoint %s", ctx.Request.URL.String())
 }
 
 // POST https://server/v1/priv/censuses/<censusId>/tokens/flat
@@ -800,7 +888,7 @@ func (u *URLAPI) createCensusHandler(
 //  census tokens for voters to register their public keys
 func (u *URLAPI) addCensusTokensHandler(
 	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
-	return fmt.Errorf("endpoint %s unimplemented", ctx.Request.URL.String())
+	return apierror.ErrUnimplemented.Withf("endpoint %s", ctx.Request.URL.String())
 }
 
 // GET https://server/v1/priv/censuses/<censusId>/tokens/<tokenId>
@@ -808,21 +896,21 @@ func (u *URLAPI) addCensusTokensHandler(
 //  token with weight and assigned public key, if applicable
 func (u *URLAPI) getCensusTokenHandler(
 	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
-	return fmt.Errorf("endpoint %s unimplemented", ctx.Request.URL.String())
+	return apierror.ErrUnimplemented.Withf("endpoint %s", ctx.Request.URL.String())
 }
 
 // DELETE https://server/v1/priv/censuses/<censusId>/tokens/<tokenId>
 // deleteCensusTokenHandler deletes the given token(s) from the given census
 func (u *URLAPI) deleteCensusTokenHandler(
 	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
-	return fmt.Errorf("endpoint %s unimplemented", ctx.Request.URL.String())
+	return apierror.ErrUnimplemented.Withf("endpoint %s", ctx.Request.URL.String())
 }
 
 // DELETE https://server/v1/priv/censuses/<censusId>/keys/<publicKey>
 // deletePublicKeyHandler deletes the given public key(s) from the given census
 func (u *URLAPI) deletePublicKeyHandler(
 	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
-	return fmt.Errorf("endpoint %s unimplemented", ctx.Request.URL.String())
+	return apierror.ErrUnimplemented.Withf("endpoint %s", ctx.Request.URL.String())
 }
 
 // POST https://server/v1/priv/censuses/<censusId>/import/flat
@@ -831,7 +919,7 @@ func (u *URLAPI) deletePublicKeyHandler(
 //  into the existing census, weighted or weight 1
 func (u *URLAPI) importPublicKeysHandler(
 	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
-	return fmt.Errorf("endpoint %s unimplemented", ctx.Request.URL.String())
+	return apierror.ErrUnimplemented.Withf("endpoint %s", ctx.Request.URL.String())
 }
 
 // PUT https://server/v1/priv/elections/<electionId>/status
@@ -850,23 +938,6 @@ func (u *URLAPI) setProcessStatusHandler(
 	if err != nil {
 		return fmt.Errorf("could not get integrator api token: %w", err)
 	}
-	organization, err := u.db.GetOrganization(integratorPrivKey, process.EntityID)
-	if err != nil {
-		return fmt.Errorf("organization %X could not be fetched from the db: %w",
-			organization.EthAddress, err)
-	}
-	entityPrivKey := organization.EthPrivKeyCipher
-	if len(u.globalOrganizationKey) > 0 {
-		var ok bool
-		if entityPrivKey, ok = util.DecryptSymmetric(
-			organization.EthPrivKeyCipher, u.globalOrganizationKey); !ok {
-			return fmt.Errorf("could not decrypt entity private key")
-		}
-	}
-	entitySignKeys := ethereum.NewSignKeys()
-	if err = entitySignKeys.AddHexKey(hex.EncodeToString(entityPrivKey)); err != nil {
-		return fmt.Errorf("could not convert entity private key to signKey: %w", err)
-	}
 
 	var status models.ProcessStatus
 	switch strings.ToUpper(ctx.URLParam("status")) {
@@ -878,33 +949,69 @@ func (u *URLAPI) setProcessStatusHandler(
 		status = models.ProcessStatus_ENDED
 	case "CANCELED":
 		status = models.ProcessStatus_CANCELED
+	default:
+		return apierror.ErrInvalidStatus.Withf("%s", ctx.URLParam("status"))
+	}
+	txHash, err := u.setElectionStatus(integratorPrivKey, process.EntityID, processID, status)
+	if err != nil {
+		return err
+	}
+	return sendResponse(types.APIResponse{TxHash: txHash}, ctx)
+}
+
+// setElectionStatus sends the transaction setting the status of an election of
+//  the integrator, signed by its organization, and returns its hash
+func (u *URLAPI) setElectionStatus(integratorPrivKey, organizationID, processID []byte,
+	status models.ProcessStatus) ([]byte, error) {
+	organization, err := u.db.GetOrganization(integratorPrivKey, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("organization %X could not be fetched from the db: %w",
+			organizationID, err)
+	}
+	entitySignKeys, err := decryptEntityKeys(
+		organization.EthPrivKeyCipher, u.globalOrganizationKey)
+	if err != nil {
+		return nil, err
 	}
 
 	// Fetch account transaction nonce
 	_, balance, nonce, err := u.vocClient.GetAccount(organization.EthAddress)
 	if err != nil {
-		return fmt.Errorf("could not get account info: %w", err)
+		return nil, fmt.Errorf("could not get account info: %w", err)
 	}
 
 	// If account balance is below threshold, allocate more tokens.
 	// This is for future uses, there should still be enough for this current process.
 	if balance < u.vocClient.AcctTxCost*vocclient.TxOperationsThreshold {
 		if err := u.vocClient.CollectFaucet(entitySignKeys, u.faucet); err != nil {
-			return err
+			return nil, err
 		}
+		u.meterFaucet(hex.EncodeToString(integratorPrivKey), organization.EthAddress)
 	}
 
 	if err = u.vocClient.SetProcessStatus(processID, &status, entitySignKeys, nonce); err != nil {
-		return fmt.Errorf("could not set process status %d: %w", status, err)
+		return nil, fmt.Errorf("could not set process status %d: %w", status, err)
 	}
 
 	// TODO fetch actual transaction hash
 	txHash := dvoteutil.RandomBytes(32)
 	if err = u.kv.StoreTxTime([]byte(txHash), time.Now()); err != nil {
-		return err
+		return nil, apierror.ErrTxCache.WithErr(err)
 	}
-
-	return sendResponse(types.APIResponse{TxHash: txHash}, ctx)
+	// store the status once mined, so lists show it before the reconciler syncs it
+	_, avgTimes, _ := u.vocClient.GetBlockTimes()
+	queryTx := transactions.SerializableTx{
+		Type:         transactions.SetElectionStatus,
+		CreationTime: time.Now().Add(time.Duration(2 * int(avgTimes[0]))),
+		Body: transactions.SetElectionStatusTx{
+			ElectionID: processID,
+			Status:     status.String(),
+		},
+	}
+	if err = u.kv.StoreTx(txHash, queryTx); err != nil {
+		return nil, apierror.ErrTxCache.WithErr(err)
+	}
+	return txHash, nil
 }
 
 func decryptEntityKeys(privKeyCipher, globalOrganizationKey []byte) (*ethereum.SignKeys, error) {
@@ -913,12 +1020,13 @@ func decryptEntityKeys(privKeyCipher, globalOrganizationKey []byte) (*ethereum.S
 		var ok bool
 		if entityPrivKey, ok = util.DecryptSymmetric(
 			privKeyCipher, globalOrganizationKey); !ok {
-			return nil, fmt.Errorf("could not decrypt entity private key")
+			return nil, apierror.ErrCrypto.With("could not decrypt entity private key")
 		}
 	}
 	entitySignKeys := ethereum.NewSignKeys()
 	if err := entitySignKeys.AddHexKey(hex.EncodeToString(entityPrivKey)); err != nil {
-		return nil, fmt.Errorf("could not convert entity private key to signKey: %w", err)
+		return nil, apierror.ErrCrypto.Withf(
+			"could not convert entity private key to signKey: %v", err)
 	}
 	return entitySignKeys, nil
 }
@@ -935,7 +1043,7 @@ func (u *URLAPI) getProcessMetadataPriv(confidential bool,
 			var ok bool
 			metadataPrivKey, ok = util.DecryptSymmetric(metadataPrivKey, u.globalMetadataKey)
 			if !ok {
-				return nil, fmt.Errorf("could not decrypt election private metadata key")
+				return nil, apierror.ErrCrypto.With("could not decrypt election private metadata key")
 			}
 		}
 		if processMetadata, err = u.vocClient.FetchProcessMetadataConfidential(
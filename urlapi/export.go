@@ -0,0 +1,189 @@
+package urlapi
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/api/util"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/bearerstdapi"
+)
+
+// GET https://server/v1/priv/elections/<processId>/results/export?format=csv|json|bundle
+// exportResultsHandler exports the results of an election as a CSV table, as JSON,
+//  or as a results bundle signed by the API signing key. JSON is the default.
+func (u *URLAPI) exportResultsHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	processID, err := util.GetBytesID(ctx, "electionId")
+	if err != nil {
+		return err
+	}
+	format := ctx.Request.URL.Query().Get("format")
+	switch format {
+	case "":
+		format = types.ResultsFormatJSON
+	case types.ResultsFormatCSV, types.ResultsFormatJSON, types.ResultsFormatBundle:
+	default:
+		return apierror.ErrInvalidFilter.Withf("unknown results format %q", format)
+	}
+	integratorPrivKey, err := util.GetAuthToken(msg)
+	if err != nil {
+		return err
+	}
+
+	process, err := u.vocClient.GetProcess(processID)
+	if err != nil {
+		return fmt.Errorf("unable to fetch process from the vochain: %w", err)
+	}
+	election, err := u.db.GetElection(integratorPrivKey, process.EntityID, processID)
+	if err != nil {
+		return fmt.Errorf("could not get election from the database: %w", err)
+	}
+	meta, err := u.getProcessMetadataPriv(election.Confidential, election.MetadataPrivKey,
+		process.Metadata)
+	if err != nil {
+		return err
+	}
+	results, err := u.getResults(process, meta)
+	if err != nil {
+		return err
+	}
+	if results == nil {
+		return apierror.ErrResultsNotFound.Withf("%x", processID)
+	}
+	aggregated, err := aggregateResults(meta, results)
+	if err != nil {
+		return fmt.Errorf("could not aggregate results: %v", err)
+	}
+	final := finalResults(process)
+	height, _, _ := u.vocClient.GetBlockTimes()
+	var hash []byte
+	if final {
+		snapshot, err := u.finalResultsSnapshot(process, meta)
+		if err != nil {
+			return err
+		}
+		height, hash = snapshot.Height, snapshot.Hash
+	}
+
+	filename := fmt.Sprintf("%x-results", processID)
+	switch format {
+	case types.ResultsFormatCSV:
+		data, err := resultsCSV(meta, results)
+		if err != nil {
+			return err
+		}
+		return sendFile(data, "text/csv; charset=utf-8", filename+".csv", ctx)
+	case types.ResultsFormatBundle:
+		bundle, err := u.signResultsBundle(&types.ResultsBundlePayload{
+			ChainID:        u.vocClient.ChainID,
+			OrganizationID: process.EntityID,
+			ElectionID:     processID,
+			Height:         height,
+			VoteCount:      results.VoteCount,
+			Final:          final,
+			Hash:           hash,
+			Process:        process,
+			Metadata:       meta,
+			Results:        results,
+			Aggregated:     aggregated,
+			CreatedAt:      time.Now().UTC(),
+		})
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(bundle)
+		if err != nil {
+			return apierror.ErrInternal.Withf("error marshaling JSON: %v", err)
+		}
+		return sendFile(data, "application/json", filename+".bundle.json", ctx)
+	}
+	data, err := json.Marshal(&types.APIElectionResults{
+		ElectionID: processID,
+		Height:     height,
+		VoteCount:  results.VoteCount,
+		Final:      final,
+		Results:    aggregated,
+		RawResults: results.Results,
+		Hash:       hash,
+	})
+	if err != nil {
+		return apierror.ErrInternal.Withf("error marshaling JSON: %v", err)
+	}
+	return sendFile(data, "application/json", filename+".json", ctx)
+}
+
+// signResultsBundle signs payload with the API signing key
+func (u *URLAPI) signResultsBundle(
+	payload *types.ResultsBundlePayload) (*types.ResultsBundle, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, apierror.ErrInternal.Withf("error marshaling JSON: %v", err)
+	}
+	signature, err := u.vocClient.SignMessage(data)
+	if err != nil {
+		return nil, apierror.ErrCrypto.Withf("could not sign results bundle: %v", err)
+	}
+	return &types.ResultsBundle{
+		Payload:   data,
+		Signer:    u.vocClient.SignerAddress(),
+		Signature: signature,
+	}, nil
+}
+
+// resultsCSV returns the results as a CSV table with one row per question choice
+func resultsCSV(meta *types.ProcessMetadata, results *types.VochainResults) ([]byte, error) {
+	if meta == nil || len(meta.Questions) != len(results.Results) {
+		return nil, fmt.Errorf("number of results does not match number of questions")
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"question", "questionTitle", "choice", "choiceTitle",
+		"votes"}); err != nil {
+		return nil, err
+	}
+	for i, question := range meta.Questions {
+		for _, choice := range question.Choices {
+			if int(choice.Value) >= len(results.Results[i]) {
+				return nil, fmt.Errorf("number of results does not match number of choices")
+			}
+			if err := w.Write([]string{
+				strconv.Itoa(i),
+				question.Title["default"],
+				strconv.FormatUint(uint64(choice.Value), 10),
+				choice.Title["default"],
+				results.Results[i][choice.Value],
+			}); err != nil {
+				return nil, err
+			}
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// contentTypeWriter replaces the JSON content type set by HTTPContext.Send
+type contentTypeWriter struct {
+	http.ResponseWriter
+	contentType string
+}
+
+func (w *contentTypeWriter) WriteHeader(statusCode int) {
+	w.Header().Set("Content-Type", w.contentType)
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// sendFile sends data as a download named filename with the given content type
+func sendFile(data []byte, contentType, filename string, ctx *httprouter.HTTPContext) error {
+	ctx.Writer.Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Writer = &contentTypeWriter{ResponseWriter: ctx.Writer, contentType: contentType}
+	return ctx.Send(data, http.StatusOK)
+}
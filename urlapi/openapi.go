@@ -0,0 +1,270 @@
+package urlapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/bearerstdapi"
+)
+
+const openAPIVersion = "3.0.3"
+
+// routeDoc describes a registered endpoint for the OpenAPI document.
+// Request and Response are sample values (usually zero structs) whose types are
+//  reflected into JSON schemas. A nil Request means the endpoint takes no body.
+// Action names the operation in the audit log; routes without one are not audited.
+type routeDoc struct {
+	Summary  string
+	Tag      string
+	Action   string
+	Request  interface{}
+	Response interface{}
+}
+
+// route is an endpoint registered through registerMethod
+type route struct {
+	pattern    string
+	method     string
+	accessType string
+	doc        routeDoc
+}
+
+var pathParamRgx = regexp.MustCompile(`{([^}]+)}`)
+
+// openAPIPath converts a router pattern to an OpenAPI path and returns its
+//  parameter names. A trailing wildcard is exposed as a "path" parameter.
+func openAPIPath(pattern string) (string, []string) {
+	if strings.HasSuffix(pattern, "/*") {
+		pattern = strings.TrimSuffix(pattern, "*") + "{path}"
+	}
+	params := []string{}
+	for _, m := range pathParamRgx.FindAllStringSubmatch(pattern, -1) {
+		params = append(params, m[1])
+	}
+	return pattern, params
+}
+
+// GET https://server/v1/openapi.json
+// openAPIHandler serves the OpenAPI document generated from the registered routes
+func (u *URLAPI) openAPIHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	return sendResponse(u.openAPIDocument(), ctx)
+}
+
+// openAPIDocument builds the OpenAPI 3 document for every route registered so far
+func (u *URLAPI) openAPIDocument() map[string]interface{} {
+	gen := &schemaGenerator{schemas: map[string]interface{}{}}
+	errorRef := gen.schema(reflect.TypeOf(apierror.Response{}))
+
+	u.routesLock.RLock()
+	routes := make([]route, len(u.routes))
+	copy(routes, u.routes)
+	u.routesLock.RUnlock()
+	sort.SliceStable(routes, func(i, j int) bool {
+		return routes[i].pattern < routes[j].pattern
+	})
+
+	paths := map[string]map[string]interface{}{}
+	for _, r := range routes {
+		path, params := openAPIPath(r.pattern)
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		op := map[string]interface{}{
+			"operationId": strings.ToLower(r.method) + operationName(path),
+			"summary":     r.doc.Summary,
+			"responses": map[string]interface{}{
+				"200": jsonContent("Success", gen.value(r.doc.Response)),
+				"default": jsonContent(
+					"Error, with a stable code listed in the API documentation", errorRef),
+			},
+		}
+		if r.doc.Tag != "" {
+			op["tags"] = []string{r.doc.Tag}
+		}
+		if len(params) > 0 {
+			parameters := []interface{}{}
+			for _, p := range params {
+				parameters = append(parameters, map[string]interface{}{
+					"name":     p,
+					"in":       "path",
+					"required": true,
+					"schema":   map[string]interface{}{"type": "string"},
+				})
+			}
+			op["parameters"] = parameters
+		}
+		if r.doc.Request != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{
+						"schema": gen.value(r.doc.Request),
+					},
+				},
+			}
+		}
+		if r.accessType != bearerstdapi.MethodAccessTypePublic {
+			op["security"] = []interface{}{map[string]interface{}{"bearerAuth": []string{}}}
+			op["x-access"] = r.accessType
+		}
+		if r.doc.Action != "" {
+			op["x-audit-action"] = r.doc.Action
+		}
+		paths[path][strings.ToLower(r.method)] = op
+	}
+
+	return map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":   "Vocdoni Voting as a Service API",
+			"version": apiVersion,
+		},
+		"servers": []interface{}{map[string]interface{}{"url": u.BaseRoute}},
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": gen.schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{
+					"type":   "http",
+					"scheme": "bearer",
+				},
+			},
+		},
+	}
+}
+
+// operationName returns a camel case name from the static segments of path
+func operationName(path string) string {
+	name := ""
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, "{") {
+			segment = "by-" + strings.Trim(segment, "{}")
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		}) {
+			name += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return name
+}
+
+func jsonContent(description string, schema interface{}) map[string]interface{} {
+	resp := map[string]interface{}{"description": description}
+	if schema != nil {
+		resp["content"] = map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		}
+	}
+	return resp
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaGenerator reflects Go types into OpenAPI schemas. Named structs are
+//  stored once under components/schemas and referenced from then on.
+type schemaGenerator struct {
+	schemas map[string]interface{}
+}
+
+func (g *schemaGenerator) value(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return g.schema(reflect.TypeOf(v))
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType),
+		t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		// custom encodings (such as HexBytes) are strings on the wire
+		return map[string]interface{}{"type": "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": g.schema(t.Elem()),
+		}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := schemaName(t)
+		if _, ok := g.schemas[name]; !ok {
+			// reserve the name first, so recursive types terminate
+			g.schemas[name] = map[string]interface{}{}
+			g.schemas[name] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	// interface{} and anything we cannot describe accept any value
+	return map[string]interface{}{}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+		properties[name] = g.schema(field.Type)
+	}
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+// schemaName returns a component name for t, prefixed with its package when
+//  it does not belong to this module's types, so names do not collide
+func schemaName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if pkg == "go.vocdoni.io/api/types" {
+		return t.Name()
+	}
+	pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+	return strings.ToUpper(pkg[:1]) + pkg[1:] + t.Name()
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"go.vocdoni.io/api/apierror"
 	"go.vocdoni.io/api/types"
 	"go.vocdoni.io/api/util"
 	"go.vocdoni.io/dvote/crypto/ethereum"
@@ -17,67 +18,93 @@ import (
 )
 
 func (u *URLAPI) enablePublicHandlers() error {
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/pub/censuses/{censusId}/token",
 		"POST",
 		bearerstdapi.MethodAccessTypePublic,
 		u.registerPublicKeyHandler,
+		routeDoc{Summary: "Register a public key with a census token", Tag: "censuses",
+			Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/pub/organizations/{organizationId}/elections/{type}",
 		"GET",
 		bearerstdapi.MethodAccessTypePublic,
 		u.listProcessesHandler,
+		routeDoc{Summary: "List an organization's public elections", Tag: "elections",
+			Response: []types.APIElectionSummary{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/pub/organizations/{organizationId}/elections",
 		"GET",
 		bearerstdapi.MethodAccessTypePublic,
 		u.listProcessesHandler,
+		routeDoc{Summary: "List an organization's public elections", Tag: "elections",
+			Response: []types.APIElectionSummary{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/pub/elections/{electionId}",
 		"GET",
 		bearerstdapi.MethodAccessTypePublic,
 		u.getProcessInfoPublicHandler,
+		routeDoc{Summary: "Get a public election", Tag: "elections",
+			Response: types.APIElectionInfo{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
+		"/pub/elections/{electionId}/results",
+		"GET",
+		bearerstdapi.MethodAccessTypePublic,
+		u.getResultsPublicHandler,
+		routeDoc{Summary: "Get the results of a public election", Tag: "elections",
+			Response: types.APIElectionResults{}},
+	); err != nil {
+		return err
+	}
+	if err := u.registerMethod(
 		"/pub/elections/{electionId}/vote",
 		"POST",
 		bearerstdapi.MethodAccessTypePublic,
 		u.submitVotePublicHandler,
+		routeDoc{Summary: "Submit a vote package", Tag: "votes",
+			Request: types.SubmitVoteRequest{}, Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/pub/elections/{electionId}/auth/{signature}",
 		"GET",
 		bearerstdapi.MethodAccessTypePublic,
 		u.getProcessInfoConfidentialHandler,
+		routeDoc{Summary: "Get a confidential election", Tag: "elections",
+			Response: types.APIElectionInfo{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/pub/organizations/{organizationId}",
 		"GET",
 		bearerstdapi.MethodAccessTypePublic,
 		u.getOrganizationHandler,
+		routeDoc{Summary: "Get a public organization", Tag: "organizations",
+			Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/pub/nullifiers/{nullifier}",
 		"GET",
 		bearerstdapi.MethodAccessTypePublic,
 		u.getVoteHandler,
+		routeDoc{Summary: "Get a vote by its nullifier", Tag: "votes",
+			Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
@@ -88,7 +115,7 @@ func (u *URLAPI) enablePublicHandlers() error {
 // registerPublicKeyHandler registers a voter's public key with a census token
 func (u *URLAPI) registerPublicKeyHandler(
 	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
-	return fmt.Errorf("endpoint %s unimplemented", ctx.Request.URL.String())
+	return apierror.ErrUnimplemented.Withf("endpoint %s", ctx.Request.URL.String())
 }
 
 // GET https://server/v1/pub/organizations/<organizationId>/elections/signed
@@ -98,19 +125,19 @@ func (u *URLAPI) registerPublicKeyHandler(
 // GET https://server/v1/pub/organizations/<organizationId>/elections/active
 // GET https://server/v1/pub/organizations/<organizationId>/elections/upcoming
 // GET https://server/v1/pub/organizations/<organizationId>/elections/ended
-// listProcessesHandler' lists signed, blind, active, ended, or upcoming processes
+// listProcessesHandler' lists signed, blind, active, ended, or upcoming processes.
+//  A page is selected by the list query parameters (see getElectionFilter).
 func (u *URLAPI) listProcessesHandler(msg *bearerstdapi.BearerStandardAPIdata,
 	ctx *httprouter.HTTPContext) error {
 	entityId, err := util.GetBytesID(ctx, "organizationId")
 	if err != nil {
 		return err
 	}
-	list, err :=
-		u.getProcessList(ctx.URLParam("type"), []byte{}, entityId, false)
+	list, next, err := u.getProcessList(ctx, ctx.URLParam("type"), []byte{}, entityId, false)
 	if err != nil {
 		return err
 	}
-	return sendResponse(list, ctx)
+	return sendPage(list, next, ctx)
 }
 
 // GET https://server/v1/pub/elections/<processId>
@@ -128,21 +155,13 @@ func (u *URLAPI) getProcessInfoPublicHandler(msg *bearerstdapi.BearerStandardAPI
 		return fmt.Errorf("unable to get process: %w", err)
 	}
 
-	// Fetch results
-	var results *types.VochainResults
-	if vochainProcess.HaveResults {
-		if results, err = u.vocClient.GetResults(processId); err != nil {
-			return fmt.Errorf("unable to get results %w", err)
-		}
-	}
-
 	dbElection, err := u.db.GetElectionPublic(vochainProcess.EntityID, processId)
 	if err != nil {
 		return fmt.Errorf("could not fetch election %x from db: %w", processId, err)
 	}
 
 	if dbElection.Confidential {
-		return fmt.Errorf("process %x is confidential, use authenticated API", processId)
+		return apierror.ErrElectionConfidential.Withf("%x", processId)
 	}
 
 	// Fetch metadata
@@ -151,6 +170,12 @@ func (u *URLAPI) getProcessInfoPublicHandler(msg *bearerstdapi.BearerStandardAPI
 		return fmt.Errorf("unable to get metadata: %w", err)
 	}
 
+	// Fetch results
+	results, err := u.getResults(vochainProcess, processMetadata)
+	if err != nil {
+		return fmt.Errorf("unable to get results %w", err)
+	}
+
 	// Parse all the information
 	resp, err := u.parseProcessInfo(vochainProcess, results, processMetadata, types.ProofType(dbElection.ProofType))
 	if err != nil {
@@ -180,21 +205,14 @@ func (u *URLAPI) getProcessInfoConfidentialHandler(msg *bearerstdapi.BearerStand
 		return fmt.Errorf("unable to get process: %w", err)
 	}
 
-	// Fetch results
-	var results *types.VochainResults
-	if vochainProcess.HaveResults {
-		if results, err = u.vocClient.GetResults(processId); err != nil {
-			return fmt.Errorf("unable to get results %w", err)
-		}
-	}
-
 	dbElection, err := u.db.GetElectionPrivate(vochainProcess.EntityID, processId)
 	if err != nil {
 		return fmt.Errorf("could not fetch election %x from db: %w", processId, err)
 	}
 
 	if err = verifyCspSharedSignature(processId, cspSignature, vochainProcess.CensusRoot); err != nil {
-		return fmt.Errorf("shared key not valid to decrypt process %x: %w", processId, err)
+		return apierror.ErrInvalidSignature.Withf(
+			"shared key not valid to decrypt process %x: %v", processId, err)
 	}
 
 	processMetadata, err := u.getProcessMetadataPriv(
@@ -203,6 +221,12 @@ func (u *URLAPI) getProcessInfoConfidentialHandler(msg *bearerstdapi.BearerStand
 		return err
 	}
 
+	// Fetch results
+	results, err := u.getResults(vochainProcess, processMetadata)
+	if err != nil {
+		return fmt.Errorf("unable to get results %w", err)
+	}
+
 	// Parse all the information
 	resp, err := u.parseProcessInfo(vochainProcess, results, processMetadata, types.ProofType(dbElection.ProofType))
 	if err != nil {
@@ -218,7 +242,7 @@ func (u *URLAPI) getOrganizationHandler(msg *bearerstdapi.BearerStandardAPIdata,
 	ctx *httprouter.HTTPContext) error {
 	ethAddress, err := hex.DecodeString(ctx.URLParam("organizationId"))
 	if err != nil {
-		return fmt.Errorf("invalid organizationId: %w", err)
+		return apierror.ErrInvalidURLParam.Withf("organizationId: %v", err)
 	}
 	// Fetch process from vochain
 	metaUri, _, _, err := u.vocClient.GetAccount(ethAddress)
@@ -244,17 +268,21 @@ func (u *URLAPI) getOrganizationHandler(msg *bearerstdapi.BearerStandardAPIdata,
 func (u *URLAPI) submitVotePublicHandler(
 	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
 	log.Debugf("query to submit vote for process %s", ctx.URLParam("electionId"))
-	req, err := util.UnmarshalRequest(msg)
+	processID, err := util.GetBytesID(ctx, "electionId")
 	if err != nil {
 		return err
 	}
-	var votePkg []byte
-	if votePkg, err = base64.StdEncoding.DecodeString(req.Vote); err != nil {
-		return fmt.Errorf("could not decode vote pkg to base64: %w", err)
+	var req types.SubmitVoteRequest
+	if err := util.UnmarshalRequest(msg, &req); err != nil {
+		return err
+	}
+	votePkg, err := base64.StdEncoding.DecodeString(req.Vote)
+	if err != nil {
+		return apierror.ErrInvalidVote.Withf("could not decode vote pkg from base64: %v", err)
 	}
 	var resp types.APIResponse
-	if resp.Nullifier, err = u.vocClient.RelayVote(votePkg); err != nil {
-		return fmt.Errorf("could not submit vote tx: %w", err)
+	if resp.Nullifier, err = u.relayVote(msg, processID, votePkg); err != nil {
+		return err
 	}
 
 	return sendResponse(resp, ctx)
@@ -0,0 +1,201 @@
+package urlapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/config"
+	"go.vocdoni.io/api/ratelimit"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/bearerstdapi"
+	"go.vocdoni.io/dvote/log"
+)
+
+const (
+	// planLimitTTL is the time the rate limit of an organization token, set by
+	//  its plan, is cached
+	planLimitTTL = time.Minute
+	// rateLimitIdle is the time unused buckets are kept. Buckets refilled
+	//  within it are full when dropped, so dropping them changes nothing.
+	rateLimitIdle = time.Hour
+)
+
+// rateLimiter limits the requests of integrator keys, organization tokens and,
+//  on public routes, client IPs, on top of the request budget of the bearer router
+type rateLimiter struct {
+	store        ratelimit.Store
+	integrator   ratelimit.Limit
+	organization ratelimit.Limit
+	ip           ratelimit.Limit
+	trustProxy   bool
+	lock         sync.Mutex
+	planLimits   map[string]cachedLimit
+}
+
+type cachedLimit struct {
+	limit   ratelimit.Limit
+	expires time.Time
+}
+
+// newRateLimiter returns a limiter keeping its buckets in store, with the
+//  default limits of cfg
+func newRateLimiter(cfg *config.API, store ratelimit.Store) *rateLimiter {
+	return &rateLimiter{
+		store:        store,
+		integrator:   ratelimit.Limit{Rate: cfg.IntegratorRateLimit, Burst: cfg.IntegratorRateBurst},
+		organization: ratelimit.Limit{Rate: cfg.OrganizationRateLimit, Burst: cfg.OrganizationRateBurst},
+		ip:           ratelimit.Limit{Rate: cfg.IPRateLimit, Burst: cfg.IPRateBurst},
+		trustProxy:   cfg.RateLimitTrustProxy,
+		planLimits:   make(map[string]cachedLimit),
+	}
+}
+
+// rateLimitStore returns the store of the rate limit buckets set in the config
+func (u *URLAPI) rateLimitStore() (ratelimit.Store, error) {
+	switch u.config.RateLimitStore {
+	case "", "memory":
+		return ratelimit.NewMemoryStore(), nil
+	case "postgres":
+		return u.db, nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", u.config.RateLimitStore)
+	}
+}
+
+// rateLimit takes a token from every bucket the request is limited by, and
+//  fails with ErrRateLimited if one of them is empty. The X-RateLimit-* headers
+//  describe the most restrictive bucket. If the store fails, requests are allowed.
+func (u *URLAPI) rateLimit(accessType string, msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	if u.limiter == nil {
+		return nil
+	}
+	type bucket struct {
+		key   string
+		limit ratelimit.Limit
+	}
+	var buckets []bucket
+	switch accessType {
+	case bearerstdapi.MethodAccessTypePrivate:
+		buckets = append(buckets, bucket{rateLimitKey("integrator", msg.AuthToken),
+			u.limiter.integrator})
+	case bearerstdapi.MethodAccessTypePublic, bearerstdapi.MethodAccessTypeQuota:
+		if msg.AuthToken != "" {
+			buckets = append(buckets, bucket{rateLimitKey("organization", msg.AuthToken),
+				u.organizationRateLimit(msg.AuthToken)})
+		}
+		buckets = append(buckets, bucket{"ip:" + clientIP(ctx.Request, u.limiter.trustProxy),
+			u.limiter.ip})
+	default:
+		return nil
+	}
+	now := time.Now()
+	var limiting *ratelimit.Result
+	for _, b := range buckets {
+		if !b.limit.Enabled() {
+			continue
+		}
+		result, err := u.limiter.store.TakeRateLimit(b.key, b.limit, now)
+		if err != nil {
+			log.Warnf("could not check rate limit: %v", err)
+			continue
+		}
+		if limiting == nil || (limiting.Allowed && !result.Allowed) ||
+			(limiting.Allowed == result.Allowed && result.Remaining < limiting.Remaining) {
+			limiting = result
+		}
+	}
+	if limiting == nil {
+		return nil
+	}
+	header := ctx.Writer.Header()
+	header.Set("X-RateLimit-Limit", strconv.Itoa(limiting.Limit))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(limiting.Remaining))
+	header.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(limiting.Reset)))
+	if limiting.Allowed {
+		return nil
+	}
+	retryAfter := ceilSeconds(limiting.RetryAfter)
+	header.Set("Retry-After", strconv.Itoa(retryAfter))
+	return apierror.ErrRateLimited.Withf("retry in %d seconds", retryAfter)
+}
+
+// organizationRateLimit returns the rate limit of an organization token, set by
+//  the plan of its organization or, if unset, by the config
+func (u *URLAPI) organizationRateLimit(token string) ratelimit.Limit {
+	now := time.Now()
+	u.limiter.lock.Lock()
+	cached, ok := u.limiter.planLimits[token]
+	u.limiter.lock.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.limit
+	}
+	limit := u.limiter.organization
+	organization, err := u.db.GetOrganizationByAPIToken(token)
+	if err == nil && organization.QuotaPlanID.Valid {
+		plan, err := u.db.GetPlan(organization.QuotaPlanID.UUID)
+		if err == nil && plan.RateLimit > 0 {
+			limit = ratelimit.Limit{Rate: plan.RateLimit, Burst: plan.RateBurst}
+		}
+	}
+	u.limiter.lock.Lock()
+	defer u.limiter.lock.Unlock()
+	for key, entry := range u.limiter.planLimits {
+		if now.After(entry.expires) {
+			delete(u.limiter.planLimits, key)
+		}
+	}
+	u.limiter.planLimits[token] = cachedLimit{limit: limit, expires: now.Add(planLimitTTL)}
+	return limit
+}
+
+// purgeRateLimits periodically drops the buckets not used for rateLimitIdle
+func (u *URLAPI) purgeRateLimits() {
+	for {
+		time.Sleep(rateLimitIdle / 6)
+		if err := u.limiter.store.PurgeRateLimits(time.Now().Add(-rateLimitIdle)); err != nil {
+			log.Warnf("could not purge rate limits: %v", err)
+		}
+	}
+}
+
+// rateLimitKey returns the bucket key of a token, hashed so the store does not
+//  keep credentials
+func rateLimitKey(kind, token string) string {
+	return kind + ":" + tokenHash(token)
+}
+
+// tokenHash returns the hex SHA-256 hash of a token, to identify it without
+//  storing it
+func tokenHash(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// clientIP returns the IP of the client of a request. Behind a trusted reverse
+//  proxy, it is the last address of X-Forwarded-For, added by the proxy.
+func clientIP(r *http.Request, trustProxy bool) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); trustProxy && forwarded != "" {
+		addresses := strings.Split(forwarded, ",")
+		return strings.TrimSpace(addresses[len(addresses)-1])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ceilSeconds returns d in whole seconds, rounded up
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,260 @@
+package urlapi
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/vochain/scrutinizer/indexertypes"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+const (
+	// reconcileInterval is the interval between syncs of the chain state
+	reconcileInterval = time.Minute
+	// reconcileBatchSize is the number of rows fetched from the database at once
+	reconcileBatchSize = 100
+)
+
+// reconcile keeps the elections and organizations stored in the database in sync
+//  with the Vochain, so lists are served with a single query, and records every
+//  change found as a chain event
+func (u *URLAPI) reconcile() {
+	for {
+		start := time.Now()
+		elections, err := u.reconcileElections(start)
+		if err != nil {
+			log.Warnf("could not reconcile elections: %v", err)
+		}
+		organizations, err := u.reconcileOrganizations(start)
+		if err != nil {
+			log.Warnf("could not reconcile organizations: %v", err)
+		}
+		log.Debugf("reconciled %d elections and %d organizations in %s",
+			elections, organizations, time.Since(start))
+		time.Sleep(reconcileInterval)
+	}
+}
+
+// reconcileElections syncs the elections not synced since syncedBefore whose
+//  state may still change, and returns how many were synced. It stops at the
+//  first gateway error, the remaining elections are synced on the next run.
+func (u *URLAPI) reconcileElections(syncedBefore time.Time) (int, error) {
+	synced := 0
+	for {
+		elections, err := u.db.ListElectionsToSync(syncedBefore, reconcileBatchSize)
+		if err != nil {
+			return synced, err
+		}
+		if len(elections) == 0 {
+			return synced, nil
+		}
+		for i := range elections {
+			stored := &elections[i]
+			election, err := u.fetchElectionState(stored)
+			if err != nil {
+				return synced, err
+			}
+			events := electionChanges(stored, election)
+			if err := u.db.SyncElection(election, events); err != nil {
+				return synced, err
+			}
+			u.sampleElection(stored, election)
+			logChainEvents(events)
+			synced++
+		}
+	}
+}
+
+// fetchElectionState returns a copy of the stored election with the state of its
+//  process on the Vochain. Elections not found keep their state and are marked
+//  missing. Final results are stored as a snapshot.
+func (u *URLAPI) fetchElectionState(stored *types.Election) (*types.Election, error) {
+	process, err := u.vocClient.GetProcess(stored.ProcessID)
+	if errors.Is(err, apierror.ErrElectionNotFound) {
+		election := *stored
+		if election.MissingSince == nil {
+			now := time.Now()
+			election.MissingSince = &now
+		}
+		return &election, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if finalResults(process) {
+		results, err := u.vocClient.GetResults(stored.ProcessID)
+		if err != nil {
+			return nil, err
+		}
+		u.snapshotElectionResults(stored, process, results)
+		return syncedElection(stored, process, results.VoteCount), nil
+	}
+	// the results of elections with encrypted votes are only known once final,
+	//  but their envelopes are counted live
+	voteCount, err := u.vocClient.GetEnvelopeHeight(stored.ProcessID)
+	if err != nil {
+		return nil, err
+	}
+	return syncedElection(stored, process, voteCount), nil
+}
+
+// syncedElection returns a copy of the stored election with the state of its
+//  process and its vote count
+func syncedElection(stored *types.Election, process *indexertypes.Process,
+	voteCount uint32) *types.Election {
+	election := *stored
+	election.MissingSince = nil
+	if process.Status != int32(models.ProcessStatus_PROCESS_UNKNOWN) {
+		election.Status = models.ProcessStatus(process.Status).String()
+	}
+	election.StartBlock = int(process.StartBlock)
+	election.EndBlock = int(process.EndBlock)
+	election.HasResults = process.HaveResults
+	election.VoteCount = int(voteCount)
+	return &election
+}
+
+// sampleElection stores the vote count of a synced election at the current
+//  height, for its turnout statistics, if it changed
+func (u *URLAPI) sampleElection(stored, synced *types.Election) {
+	height, _, _ := u.vocClient.GetBlockTimes()
+	sample := electionSample(stored, synced, height)
+	if sample == nil {
+		return
+	}
+	if err := u.db.AddElectionSample(sample); err != nil {
+		log.Warnf("could not sample the vote count of %x: %v", synced.ProcessID, err)
+	}
+}
+
+// electionSample returns the sample of the vote count of a synced election, or
+//  nil if it did not change. The count is the number of envelopes, so elections
+//  with hidden results are sampled while they run too.
+func electionSample(stored, synced *types.Election, height uint32) *types.ElectionSample {
+	if synced.VoteCount == stored.VoteCount {
+		return nil
+	}
+	return &types.ElectionSample{ProcessID: synced.ProcessID, Height: height,
+		VoteCount: synced.VoteCount}
+}
+
+// snapshotElectionResults stores the final results of an election found by the
+//  reconciler, so they are kept even if nobody asked for them before the gateway
+//  prunes its history
+func (u *URLAPI) snapshotElectionResults(stored *types.Election,
+	process *indexertypes.Process, results *types.VochainResults) {
+	meta, err := u.getProcessMetadataPriv(stored.Confidential, stored.MetadataPrivKey,
+		process.Metadata)
+	if err != nil {
+		// the raw results are stored anyway
+		log.Warnf("could not get the metadata of %x: %v", stored.ProcessID, err)
+	}
+	if _, err := u.storeResultsSnapshot(stored.ProcessID, results, meta); err != nil {
+		log.Warnf("could not store the results snapshot of %x: %v", stored.ProcessID, err)
+	}
+}
+
+// reconcileOrganizations syncs the accounts of the organizations not synced since
+//  syncedBefore, and returns how many were synced. It stops at the first gateway
+//  error, the remaining organizations are synced on the next run.
+func (u *URLAPI) reconcileOrganizations(syncedBefore time.Time) (int, error) {
+	synced := 0
+	for {
+		organizations, err := u.db.ListOrganizationsToSync(syncedBefore, reconcileBatchSize)
+		if err != nil {
+			return synced, err
+		}
+		if len(organizations) == 0 {
+			return synced, nil
+		}
+		for i := range organizations {
+			stored := &organizations[i]
+			organization := *stored
+			metadataURI, balance, nonce, err := u.vocClient.GetAccount(stored.EthAddress)
+			switch {
+			case err == nil:
+				organization.MissingSince = nil
+				organization.MetadataURI = metadataURI
+				organization.Balance = int64(balance)
+				organization.Nonce = int64(nonce)
+			case errors.Is(err, apierror.ErrOrganizationNotFound):
+				if organization.MissingSince == nil {
+					now := time.Now()
+					organization.MissingSince = &now
+				}
+			default:
+				return synced, err
+			}
+			events := organizationChanges(stored, &organization)
+			if err := u.db.SyncOrganization(&organization, events); err != nil {
+				return synced, err
+			}
+			logChainEvents(events)
+			synced++
+		}
+	}
+}
+
+// electionChanges returns the events of the changes from the stored election to
+//  the synced one
+func electionChanges(stored, synced *types.Election) []types.ChainEvent {
+	events := []types.ChainEvent{}
+	add := func(kind, oldValue, newValue string) {
+		if oldValue != newValue {
+			events = append(events, types.ChainEvent{OrgEthAddress: stored.OrgEthAddress,
+				ProcessID: stored.ProcessID, Kind: kind, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	add(types.ChainEventMissing, "", missingSince(stored.MissingSince == nil, synced.MissingSince))
+	add(types.ChainEventFound, "", missingSince(synced.MissingSince == nil, stored.MissingSince))
+	add(types.ChainEventStatus, stored.Status, synced.Status)
+	add(types.ChainEventStartBlock, strconv.Itoa(stored.StartBlock), strconv.Itoa(synced.StartBlock))
+	add(types.ChainEventEndBlock, strconv.Itoa(stored.EndBlock), strconv.Itoa(synced.EndBlock))
+	add(types.ChainEventResults, strconv.FormatBool(stored.HasResults),
+		strconv.FormatBool(synced.HasResults))
+	add(types.ChainEventVoteCount, strconv.Itoa(stored.VoteCount), strconv.Itoa(synced.VoteCount))
+	return events
+}
+
+// organizationChanges returns the events of the changes from the stored
+//  organization to the synced one. Nonces change with every transaction and
+//  are not recorded.
+func organizationChanges(stored, synced *types.Organization) []types.ChainEvent {
+	events := []types.ChainEvent{}
+	add := func(kind, oldValue, newValue string) {
+		if oldValue != newValue {
+			events = append(events, types.ChainEvent{OrgEthAddress: stored.EthAddress,
+				Kind: kind, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	add(types.ChainEventMissing, "", missingSince(stored.MissingSince == nil, synced.MissingSince))
+	add(types.ChainEventFound, "", missingSince(synced.MissingSince == nil, stored.MissingSince))
+	add(types.ChainEventMetadataURI, stored.MetadataURI, synced.MetadataURI)
+	add(types.ChainEventBalance, strconv.FormatInt(stored.Balance, 10),
+		strconv.FormatInt(synced.Balance, 10))
+	return events
+}
+
+// missingSince returns the time a row went missing as the value of a missing or
+//  found event, or an empty string, so no event is added, unless changed is set
+func missingSince(changed bool, since *time.Time) string {
+	if !changed || since == nil {
+		return ""
+	}
+	return since.UTC().Format(time.RFC3339)
+}
+
+func logChainEvents(events []types.ChainEvent) {
+	for _, event := range events {
+		if event.Kind == types.ChainEventMissing {
+			log.Warnf("%x %x not found on the vochain", event.OrgEthAddress, event.ProcessID)
+			continue
+		}
+		log.Infof("%x %x %s changed from %q to %q on the vochain", event.OrgEthAddress,
+			event.ProcessID, event.Kind, event.OldValue, event.NewValue)
+	}
+}
@@ -0,0 +1,256 @@
+package urlapi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/api/util"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/bearerstdapi"
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/vochain/scrutinizer/indexertypes"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+// resultsCache keeps the results of live elections for a while, so popular
+//  elections do not fetch them from the gateway on every request
+type resultsCache struct {
+	ttl     time.Duration
+	lock    sync.Mutex
+	entries map[string]cachedResults
+}
+
+type cachedResults struct {
+	results *types.VochainResults
+	expires time.Time
+}
+
+// newResultsCache returns a cache keeping results for ttl. A ttl of 0 disables it.
+func newResultsCache(ttl time.Duration) *resultsCache {
+	return &resultsCache{ttl: ttl, entries: make(map[string]cachedResults)}
+}
+
+// get returns the cached results of a process, or nil if they are missing or expired
+func (c *resultsCache) get(processID []byte) *types.VochainResults {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry, ok := c.entries[string(processID)]
+	if !ok || time.Now().After(entry.expires) {
+		return nil
+	}
+	return entry.results
+}
+
+// set caches the results of a process, and drops the expired ones
+func (c *resultsCache) set(processID []byte, results *types.VochainResults) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := time.Now()
+	for key, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	c.entries[string(processID)] = cachedResults{results: results, expires: now.Add(c.ttl)}
+}
+
+// finalResults tells whether the results of a process are published and will
+//  not change anymore
+func finalResults(process *indexertypes.Process) bool {
+	return process.FinalResults &&
+		process.Status == int32(models.ProcessStatus_RESULTS)
+}
+
+// getResults returns the results of a process, or nil if it has none yet. Final
+//  results are read from their snapshot, stored the first time they are fetched,
+//  and live results are cached for ResultsCacheSeconds.
+func (u *URLAPI) getResults(process *indexertypes.Process,
+	meta *types.ProcessMetadata) (*types.VochainResults, error) {
+	snapshot, err := u.db.GetResultsSnapshot(process.ID)
+	if err == nil {
+		return &types.VochainResults{VoteCount: snapshot.VoteCount, Results: snapshot.Results,
+			State: snapshot.State, Type: snapshot.Type}, nil
+	}
+	if !errors.Is(err, apierror.ErrResultsNotFound) {
+		return nil, err
+	}
+	if !process.HaveResults {
+		return nil, nil
+	}
+	if results := u.results.get(process.ID); results != nil {
+		return results, nil
+	}
+	results, err := u.vocClient.GetResults(process.ID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get results: %w", err)
+	}
+	if !finalResults(process) {
+		u.results.set(process.ID, results)
+		return results, nil
+	}
+	if _, err := u.storeResultsSnapshot(process.ID, results, meta); err != nil {
+		log.Warnf("could not store the results snapshot of %x: %v", process.ID, err)
+	}
+	return results, nil
+}
+
+// finalResultsSnapshot returns the snapshot of the final results of a process,
+//  storing it first if needed
+func (u *URLAPI) finalResultsSnapshot(process *indexertypes.Process,
+	meta *types.ProcessMetadata) (*types.ResultsSnapshot, error) {
+	snapshot, err := u.db.GetResultsSnapshot(process.ID)
+	if !errors.Is(err, apierror.ErrResultsNotFound) {
+		return snapshot, err
+	}
+	results, err := u.vocClient.GetResults(process.ID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get results: %w", err)
+	}
+	return u.storeResultsSnapshot(process.ID, results, meta)
+}
+
+// storeResultsSnapshot persists the final results of a process at the current
+//  block height, and returns the snapshot stored, which is the first one if
+//  another replica stored it before
+func (u *URLAPI) storeResultsSnapshot(processID []byte, results *types.VochainResults,
+	meta *types.ProcessMetadata) (*types.ResultsSnapshot, error) {
+	height, err := u.currentHeight()
+	if err != nil {
+		return nil, fmt.Errorf("could not get the block height: %w", err)
+	}
+	if err := u.db.CreateResultsSnapshot(newResultsSnapshot(processID, height, results,
+		meta)); err != nil {
+		return nil, err
+	}
+	log.Infof("stored the final results of %x at height %d", processID, height)
+	return u.db.GetResultsSnapshot(processID)
+}
+
+// newResultsSnapshot returns the snapshot of the final results of a process at a
+//  block height, aggregated with its metadata when possible
+func newResultsSnapshot(processID []byte, height uint32, results *types.VochainResults,
+	meta *types.ProcessMetadata) *types.ResultsSnapshot {
+	snapshot := &types.ResultsSnapshot{
+		ElectionID: processID,
+		Height:     height,
+		VoteCount:  results.VoteCount,
+		State:      results.State,
+		Type:       results.Type,
+		Results:    results.Results,
+		Hash:       resultsHash(processID, height, results.Results),
+	}
+	if meta != nil {
+		aggregated, err := aggregateResults(meta, results)
+		if err != nil {
+			log.Warnf("could not aggregate the results of %x: %v", processID, err)
+		}
+		snapshot.Aggregated = aggregated
+	}
+	return snapshot
+}
+
+// resultsHash returns the SHA-256 of the JSON object {"electionId","height","results"},
+//  with the election id in hex and the block height of the snapshot, so anyone can
+//  check a snapshot against the Vochain
+func resultsHash(electionID []byte, height uint32, results [][]string) []byte {
+	data, err := json.Marshal(struct {
+		ElectionID string     `json:"electionId"`
+		Height     uint32     `json:"height"`
+		Results    [][]string `json:"results"`
+	}{hex.EncodeToString(electionID), height, results})
+	if err != nil {
+		// strings and numbers always marshal
+		panic(err)
+	}
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
+// GET https://server/v1/pub/elections/<processId>/results
+// getResultsPublicHandler gets the results of a non-confidential election. Final
+//  results are served from their snapshot, without querying the gateway.
+func (u *URLAPI) getResultsPublicHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	processID, err := util.GetBytesID(ctx, "electionId")
+	if err != nil {
+		return err
+	}
+	snapshot, err := u.db.GetResultsSnapshot(processID)
+	if err != nil && !errors.Is(err, apierror.ErrResultsNotFound) {
+		return err
+	}
+	if snapshot != nil && len(snapshot.Aggregated) > 0 {
+		election, err := u.db.GetElectionPublic(snapshot.OrganizationID, processID)
+		if err != nil {
+			return err
+		}
+		if election.Confidential {
+			return apierror.ErrElectionConfidential.Withf("%x", processID)
+		}
+		return sendResponse(snapshotResults(snapshot), ctx)
+	}
+
+	process, err := u.vocClient.GetProcess(processID)
+	if err != nil {
+		return fmt.Errorf("unable to get process: %w", err)
+	}
+	election, err := u.db.GetElectionPublic(process.EntityID, processID)
+	if err != nil {
+		return fmt.Errorf("could not fetch election %x from db: %w", processID, err)
+	}
+	if election.Confidential {
+		return apierror.ErrElectionConfidential.Withf("%x", processID)
+	}
+	meta, err := u.vocClient.FetchProcessMetadata(process.Metadata)
+	if err != nil {
+		return fmt.Errorf("unable to get metadata: %w", err)
+	}
+	results, err := u.getResults(process, meta)
+	if err != nil {
+		return err
+	}
+	if results == nil {
+		return apierror.ErrResultsNotFound.Withf("%x", processID)
+	}
+	resp := &types.APIElectionResults{
+		ElectionID: processID,
+		VoteCount:  results.VoteCount,
+		RawResults: results.Results,
+	}
+	resp.Height, _, _ = u.vocClient.GetBlockTimes()
+	if resp.Results, err = aggregateResults(meta, results); err != nil {
+		return fmt.Errorf("could not aggregate results: %v", err)
+	}
+	if snapshot != nil || finalResults(process) {
+		if snapshot, err = u.finalResultsSnapshot(process, meta); err != nil {
+			return err
+		}
+		resp.Final = true
+		resp.Height = snapshot.Height
+		resp.Hash = snapshot.Hash
+	}
+	return sendResponse(resp, ctx)
+}
+
+// snapshotResults returns the response for the final results of a snapshot
+func snapshotResults(snapshot *types.ResultsSnapshot) *types.APIElectionResults {
+	return &types.APIElectionResults{
+		ElectionID: snapshot.ElectionID,
+		Height:     snapshot.Height,
+		VoteCount:  snapshot.VoteCount,
+		Final:      true,
+		Results:    snapshot.Aggregated,
+		RawResults: snapshot.Results,
+		Hash:       snapshot.Hash,
+	}
+}
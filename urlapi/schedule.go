@@ -0,0 +1,264 @@
+package urlapi
+
+import (
+	"fmt"
+	"time"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/api/util"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/bearerstdapi"
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+const (
+	// scheduleInterval is the interval between runs of the scheduler
+	scheduleInterval = 15 * time.Second
+	// scheduleLookahead is how long before their time the blocks of scheduled
+	//  actions are estimated again, as block times vary
+	scheduleLookahead = 15 * time.Minute
+	// scheduleClaimTimeout is how long an action may be running before it is run
+	//  again, as the replica running it may have stopped
+	scheduleClaimTimeout = 5 * time.Minute
+)
+
+func (u *URLAPI) enableScheduleHandlers() error {
+	if err := u.registerMethod(
+		"/priv/elections/{electionId}/schedule",
+		"POST",
+		bearerstdapi.MethodAccessTypePrivate,
+		u.scheduleActionHandler,
+		routeDoc{Summary: "Schedule a status change of an election", Tag: "elections",
+			Action:  "election.schedule",
+			Request: types.ScheduleActionRequest{}, Response: types.ScheduledAction{}},
+	); err != nil {
+		return err
+	}
+	if err := u.registerMethod(
+		"/priv/elections/{electionId}/schedule",
+		"GET",
+		bearerstdapi.MethodAccessTypePrivate,
+		u.listScheduledActionsHandler,
+		routeDoc{Summary: "List the scheduled status changes of an election",
+			Tag: "elections", Response: []types.ScheduledAction{}},
+	); err != nil {
+		return err
+	}
+	return u.registerMethod(
+		"/priv/elections/{electionId}/schedule/{actionId}",
+		"DELETE",
+		bearerstdapi.MethodAccessTypePrivate,
+		u.cancelScheduledActionHandler,
+		routeDoc{Summary: "Cancel a scheduled status change of an election",
+			Tag: "elections", Action: "election.cancelSchedule", Response: types.APIResponse{}},
+	)
+}
+
+// POST https://server/v1/priv/elections/<electionId>/schedule
+// scheduleActionHandler schedules a status change of an election of the integrator
+func (u *URLAPI) scheduleActionHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	processID, err := util.GetBytesID(ctx, "electionId")
+	if err != nil {
+		return err
+	}
+	integratorPrivKey, err := util.GetAuthToken(msg)
+	if err != nil {
+		return err
+	}
+	var req types.ScheduleActionRequest
+	if err := util.UnmarshalRequest(msg, &req); err != nil {
+		return err
+	}
+	if _, _, err := scheduledStatus(req.Action, models.ProcessStatus_READY); err != nil {
+		return err
+	}
+	executeAt, err := time.Parse(types.DateLayout, req.ExecuteAt)
+	if err != nil {
+		return apierror.ErrInvalidDate.Withf("could not parse executeAt: %v", err)
+	}
+	if executeAt.Before(time.Now()) {
+		return apierror.ErrInvalidDate.With("executeAt cannot be in the past")
+	}
+
+	process, err := u.vocClient.GetProcess(processID)
+	if err != nil {
+		return fmt.Errorf("could not fetch election %x from the vochain: %w", processID, err)
+	}
+	election, err := u.db.GetElection(integratorPrivKey, process.EntityID, processID)
+	if err != nil {
+		return err
+	}
+	if executeAt.After(election.EndDate) {
+		return apierror.ErrInvalidDate.With("executeAt cannot be after the election end date")
+	}
+	targetBlock, _, err := u.estimateBlockHeight(executeAt)
+	if err != nil {
+		return fmt.Errorf("unable to estimate executeAt block height: %w", err)
+	}
+	action := &types.ScheduledAction{
+		CreatedAt:        time.Now().UTC(),
+		IntegratorApiKey: integratorPrivKey,
+		OrgEthAddress:    process.EntityID,
+		ProcessID:        processID,
+		Action:           req.Action,
+		ExecuteAt:        executeAt.UTC(),
+		TargetBlock:      targetBlock,
+		Status:           types.ScheduledActionPending,
+	}
+	if action.ID, err = u.db.CreateScheduledAction(action); err != nil {
+		return err
+	}
+	return sendResponse(action, ctx)
+}
+
+// GET https://server/v1/priv/elections/<electionId>/schedule
+// listScheduledActionsHandler lists the actions scheduled on an election of the
+//  integrator, in order of execution, with the outcome of those executed
+func (u *URLAPI) listScheduledActionsHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	processID, err := util.GetBytesID(ctx, "electionId")
+	if err != nil {
+		return err
+	}
+	integratorPrivKey, err := util.GetAuthToken(msg)
+	if err != nil {
+		return err
+	}
+	actions, err := u.db.ListScheduledActions(integratorPrivKey, processID)
+	if err != nil {
+		return err
+	}
+	return sendResponse(actions, ctx)
+}
+
+// DELETE https://server/v1/priv/elections/<electionId>/schedule/<actionId>
+// cancelScheduledActionHandler cancels a pending action of an election of the
+//  integrator
+func (u *URLAPI) cancelScheduledActionHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	processID, err := util.GetBytesID(ctx, "electionId")
+	if err != nil {
+		return err
+	}
+	actionID, err := util.GetIntID(ctx, "actionId")
+	if err != nil {
+		return err
+	}
+	integratorPrivKey, err := util.GetAuthToken(msg)
+	if err != nil {
+		return err
+	}
+	if err := u.db.CancelScheduledAction(integratorPrivKey, processID, actionID); err != nil {
+		return err
+	}
+	return sendResponse(types.APIResponse{}, ctx)
+}
+
+// runScheduler executes the scheduled actions once the Vochain reaches their
+//  blocks. The blocks of the actions due within scheduleLookahead are
+//  estimated again on every run, so they follow the actual block times.
+func (u *URLAPI) runScheduler() {
+	for {
+		if err := u.runScheduledActions(time.Now()); err != nil {
+			log.Warnf("could not run scheduled actions: %v", err)
+		}
+		time.Sleep(scheduleInterval)
+	}
+}
+
+func (u *URLAPI) runScheduledActions(now time.Time) error {
+	claimedBefore := now.Add(-scheduleClaimTimeout)
+	actions, err := u.db.ListDueScheduledActions(now.Add(scheduleLookahead), claimedBefore)
+	if err != nil {
+		return err
+	}
+	height, _, _ := u.vocClient.GetBlockTimes()
+	if height == 0 {
+		// the block times are not known yet
+		return nil
+	}
+	for i := range actions {
+		action := &actions[i]
+		targetBlock, _, err := u.estimateBlockHeight(action.ExecuteAt)
+		if err != nil {
+			log.Warnf("could not estimate the block of scheduled action %d: %v", action.ID, err)
+			continue
+		}
+		if targetBlock != action.TargetBlock {
+			if err := u.db.SetScheduledActionTarget(action.ID, targetBlock); err != nil {
+				return err
+			}
+		}
+		if height < targetBlock {
+			continue
+		}
+		claimed, err := u.db.ClaimScheduledAction(action.ID, claimedBefore)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		if action.Status == types.ScheduledActionRunning {
+			log.Warnf("running scheduled action %d again, unfinished since %s", action.ID,
+				action.ClaimedAt)
+		}
+		status, errorMessage := types.ScheduledActionDone, ""
+		txHash, err := u.executeScheduledAction(action)
+		if err != nil {
+			status, errorMessage = types.ScheduledActionFailed, err.Error()
+			log.Warnf("scheduled action %d on %x failed: %v", action.ID, action.ProcessID, err)
+		} else {
+			log.Infof("executed scheduled action %d (%s) on %x at height %d", action.ID,
+				action.Action, action.ProcessID, height)
+		}
+		if err := u.db.FinishScheduledAction(action.ID, status, errorMessage,
+			txHash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// executeScheduledAction sets the status of the action on its election, and
+//  returns the hash of the transaction, or nil if the election already has it
+func (u *URLAPI) executeScheduledAction(action *types.ScheduledAction) ([]byte, error) {
+	process, err := u.vocClient.GetProcess(action.ProcessID)
+	if err != nil {
+		return nil, err
+	}
+	status, done, err := scheduledStatus(action.Action, models.ProcessStatus(process.Status))
+	if err != nil || done {
+		return nil, err
+	}
+	return u.setElectionStatus(action.IntegratorApiKey, action.OrgEthAddress,
+		action.ProcessID, status)
+}
+
+// scheduledStatus returns the status an action sets on an election with the
+//  current status, and whether the election already has it
+func scheduledStatus(action string,
+	current models.ProcessStatus) (models.ProcessStatus, bool, error) {
+	var status models.ProcessStatus
+	switch action {
+	case types.ScheduledActionPause:
+		status = models.ProcessStatus_PAUSED
+	case types.ScheduledActionEnd:
+		status = models.ProcessStatus_ENDED
+	default:
+		return 0, false, apierror.ErrInvalidField.Withf("unknown action %q", action)
+	}
+	switch current {
+	case models.ProcessStatus_ENDED, models.ProcessStatus_RESULTS:
+		if status == models.ProcessStatus_ENDED {
+			return status, true, nil
+		}
+		return 0, false, apierror.ErrInvalidStatus.With("the election has already ended")
+	case models.ProcessStatus_CANCELED:
+		return 0, false, apierror.ErrInvalidStatus.With("the election was canceled")
+	}
+	return status, current == status, nil
+}
@@ -0,0 +1,200 @@
+package urlapi
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/api/util"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/bearerstdapi"
+)
+
+const (
+	// defaultStatsBucket is the bucket of the turnout statistics by default
+	defaultStatsBucket = time.Hour
+	// minStatsBucket is the smallest bucket, the interval of the reconciler
+	//  taking the samples
+	minStatsBucket = reconcileInterval
+	// maxStatsBuckets bounds the number of buckets returned
+	maxStatsBuckets = 10000
+)
+
+func (u *URLAPI) enableStatsHandlers() error {
+	return u.registerMethod(
+		"/priv/elections/{electionId}/stats",
+		"GET",
+		bearerstdapi.MethodAccessTypePrivate,
+		u.getElectionStatsHandler,
+		routeDoc{Summary: "Get the turnout statistics of an election, as json or csv",
+			Tag: "elections", Response: types.ElectionStats{}},
+	)
+}
+
+// GET https://server/v1/priv/elections/<processId>/stats?bucket=&bucketBlocks=&format=csv|json
+// getElectionStatsHandler returns the turnout of an election over time, per bucket
+//  of time (1h by default) or of bucketBlocks blocks
+func (u *URLAPI) getElectionStatsHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	processID, err := util.GetBytesID(ctx, "electionId")
+	if err != nil {
+		return err
+	}
+	query := ctx.Request.URL.Query()
+	format := query.Get("format")
+	switch format {
+	case "":
+		format = types.ResultsFormatJSON
+	case types.ResultsFormatCSV, types.ResultsFormatJSON:
+	default:
+		return apierror.ErrInvalidFilter.Withf("unknown stats format %q", format)
+	}
+	bucket := defaultStatsBucket
+	if value := query.Get("bucket"); value != "" {
+		if bucket, err = time.ParseDuration(value); err != nil || bucket < minStatsBucket {
+			return apierror.ErrInvalidFilter.Withf("bucket must be a duration of at least %s",
+				minStatsBucket)
+		}
+	}
+	var bucketBlocks uint32
+	if value := query.Get("bucketBlocks"); value != "" {
+		blocks, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || blocks == 0 {
+			return apierror.ErrInvalidFilter.Withf("bucketBlocks must be a positive number")
+		}
+		bucketBlocks = uint32(blocks)
+	}
+	integratorPrivKey, err := util.GetAuthToken(msg)
+	if err != nil {
+		return err
+	}
+
+	process, err := u.vocClient.GetProcess(processID)
+	if err != nil {
+		return fmt.Errorf("unable to fetch process from the vochain: %w", err)
+	}
+	election, err := u.db.GetElection(integratorPrivKey, process.EntityID, processID)
+	if err != nil {
+		return fmt.Errorf("could not get election from the database: %w", err)
+	}
+	samples, err := u.db.ListElectionSamples(processID)
+	if err != nil {
+		return err
+	}
+	censusSize, err := u.db.GetElectionCensusSize(processID)
+	if err != nil {
+		return err
+	}
+	stats, err := electionStats(samples, bucket, bucketBlocks, censusSize)
+	if err != nil {
+		return err
+	}
+	stats.ElectionID = processID
+	if election.VoteCount > stats.VoteCount {
+		stats.VoteCount = election.VoteCount
+		stats.Turnout = turnout(stats.VoteCount, censusSize)
+	}
+
+	if format == types.ResultsFormatCSV {
+		data, err := statsCSV(stats)
+		if err != nil {
+			return err
+		}
+		return sendFile(data, "text/csv; charset=utf-8", fmt.Sprintf("%x-stats.csv", processID),
+			ctx)
+	}
+	return sendResponse(stats, ctx)
+}
+
+// electionStats returns the turnout of an election from its samples, oldest
+//  first, per bucket of time, or of bucketBlocks blocks when it is not 0. Empty
+//  buckets between samples are kept, so the timeline has no gaps.
+func electionStats(samples []types.ElectionSample, bucket time.Duration,
+	bucketBlocks uint32, censusSize int) (*types.ElectionStats, error) {
+	stats := &types.ElectionStats{CensusSize: censusSize, Buckets: []types.ElectionStatsBucket{}}
+	if len(samples) == 0 {
+		return stats, nil
+	}
+	seconds := int64(bucket / time.Second)
+	key := func(sample *types.ElectionSample) int64 {
+		if bucketBlocks > 0 {
+			return int64(sample.Height / bucketBlocks)
+		}
+		return sample.SampledAt.Unix() / seconds
+	}
+	first, last := key(&samples[0]), key(&samples[len(samples)-1])
+	if last-first >= maxStatsBuckets {
+		return nil, apierror.ErrInvalidFilter.Withf("more than %d buckets, use a larger bucket",
+			maxStatsBuckets)
+	}
+	voteCount, i := 0, 0
+	for k := first; k <= last; k++ {
+		b := types.ElectionStatsBucket{}
+		if bucketBlocks > 0 {
+			b.StartHeight = uint32(k) * bucketBlocks
+		} else {
+			start := time.Unix(k*seconds, 0).UTC()
+			b.Start = &start
+		}
+		for ; i < len(samples) && key(&samples[i]) == k; i++ {
+			sample := &samples[i]
+			if i > 0 && sample.SampledAt.After(samples[i-1].SampledAt) {
+				hours := sample.SampledAt.Sub(samples[i-1].SampledAt).Hours()
+				rate := float64(sample.VoteCount-samples[i-1].VoteCount) / hours
+				if rate > stats.PeakRate {
+					stats.PeakRate = rate
+					peakAt := sample.SampledAt.UTC()
+					stats.PeakAt = &peakAt
+				}
+			}
+			b.Votes += sample.VoteCount - voteCount
+			voteCount = sample.VoteCount
+		}
+		b.VoteCount = voteCount
+		b.Turnout = turnout(voteCount, censusSize)
+		stats.Buckets = append(stats.Buckets, b)
+	}
+	stats.VoteCount = voteCount
+	stats.Turnout = turnout(voteCount, censusSize)
+	return stats, nil
+}
+
+// turnout returns the percentage of the census that voted, or 0 if its size
+//  is unknown
+func turnout(voteCount, censusSize int) float64 {
+	if censusSize <= 0 {
+		return 0
+	}
+	return float64(voteCount) * 100 / float64(censusSize)
+}
+
+// statsCSV returns the buckets of the turnout statistics as a CSV table
+func statsCSV(stats *types.ElectionStats) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"start", "startHeight", "votes", "voteCount",
+		"turnout"}); err != nil {
+		return nil, err
+	}
+	for _, b := range stats.Buckets {
+		start := ""
+		if b.Start != nil {
+			start = b.Start.Format(time.RFC3339)
+		}
+		if err := w.Write([]string{
+			start,
+			strconv.FormatUint(uint64(b.StartHeight), 10),
+			strconv.Itoa(b.Votes),
+			strconv.Itoa(b.VoteCount),
+			strconv.FormatFloat(b.Turnout, 'f', 2, 64),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
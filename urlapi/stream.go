@@ -0,0 +1,366 @@
+package urlapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/api/util"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/bearerstdapi"
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/proto/build/go/models"
+)
+
+const (
+	// streamPollInterval is the interval between polls of a streamed election
+	streamPollInterval = 5 * time.Second
+	// streamDuration bounds each stream, as the router's write timeout cuts
+	//  longer responses. Clients reconnect after streamRetry and get the
+	//  current state of the election again.
+	streamDuration = 8 * time.Second
+	streamRetry    = time.Second
+	// streamIdleTimeout is how long pollers keep polling without subscribers,
+	//  so reconnecting clients find their state instead of polling from scratch
+	streamIdleTimeout = 3 * (streamDuration + streamRetry)
+	// streamBuffer is the number of updates queued for each subscriber. Updates
+	//  for slower subscribers are dropped.
+	streamBuffer = 16
+)
+
+func (u *URLAPI) enableStreamHandlers() error {
+	return u.registerMethod(
+		"/pub/elections/{electionId}/stream",
+		"GET",
+		bearerstdapi.MethodAccessTypePublic,
+		u.streamElectionHandler,
+		routeDoc{Summary: "Stream the updates of a non-confidential election", Tag: "elections",
+			Response: types.ElectionEvent{}},
+	)
+}
+
+// electionState is the state of a streamed election, as last polled
+type electionState struct {
+	confidential bool
+	meta         *types.ProcessMetadata
+	status       string
+	voteCount    uint32
+	// results are only set once they are final
+	results *types.APIElectionResults
+}
+
+// streamUpdate is sent to the subscribers of an election on every change. Err is
+//  only set when the first poll of the election fails.
+type streamUpdate struct {
+	state  *electionState
+	events []types.ElectionEvent
+	err    error
+}
+
+// electionStreams shares a single poller per election among all its subscribers
+type electionStreams struct {
+	interval    time.Duration
+	idleTimeout time.Duration
+	// poll returns the current state of an election, given the last one (nil
+	//  on the first poll)
+	poll    func(processID []byte, last *electionState) (*electionState, error)
+	lock    sync.Mutex
+	pollers map[string]*electionPoller
+}
+
+type electionPoller struct {
+	processID   []byte
+	state       *electionState
+	subscribers map[chan streamUpdate]struct{}
+	// idleSince is when the last subscriber left
+	idleSince time.Time
+}
+
+func newElectionStreams(interval, idleTimeout time.Duration,
+	poll func([]byte, *electionState) (*electionState, error)) *electionStreams {
+	return &electionStreams{interval: interval, idleTimeout: idleTimeout, poll: poll,
+		pollers: make(map[string]*electionPoller)}
+}
+
+// subscribe returns a channel receiving the updates of an election, starting its
+//  poller if it has none. Subscribers of an already polled election get its
+//  current state right away.
+func (s *electionStreams) subscribe(processID []byte) chan streamUpdate {
+	ch := make(chan streamUpdate, streamBuffer)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	p, ok := s.pollers[string(processID)]
+	if !ok {
+		p = &electionPoller{processID: processID,
+			subscribers: make(map[chan streamUpdate]struct{})}
+		s.pollers[string(processID)] = p
+		go s.run(p)
+	}
+	p.subscribers[ch] = struct{}{}
+	if p.state != nil {
+		ch <- streamUpdate{state: p.state, events: streamEvents(processID, nil, p.state)}
+	}
+	return ch
+}
+
+// unsubscribe removes a subscriber. Pollers stop once they have none for
+//  idleTimeout.
+func (s *electionStreams) unsubscribe(processID []byte, ch chan streamUpdate) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	p, ok := s.pollers[string(processID)]
+	if !ok {
+		return
+	}
+	delete(p.subscribers, ch)
+	if len(p.subscribers) == 0 {
+		p.idleSince = time.Now()
+	}
+}
+
+// run polls an election while it has subscribers, or had them in the last
+//  idleTimeout, and sends them its changes. Pollers whose first poll fails send
+//  the error and stop, so the next subscriber tries again.
+func (s *electionStreams) run(p *electionPoller) {
+	for {
+		s.lock.Lock()
+		if len(p.subscribers) == 0 && time.Since(p.idleSince) >= s.idleTimeout {
+			delete(s.pollers, string(p.processID))
+			s.lock.Unlock()
+			return
+		}
+		s.lock.Unlock()
+		state, err := s.poll(p.processID, p.state)
+		s.lock.Lock()
+		first := p.state == nil
+		var update streamUpdate
+		switch {
+		case err != nil && first:
+			update.err = err
+			delete(s.pollers, string(p.processID))
+		case err != nil:
+			log.Warnf("could not poll streamed election %x: %v", p.processID, err)
+		default:
+			update.state = state
+			update.events = streamEvents(p.processID, p.state, state)
+			p.state = state
+		}
+		if update.err != nil || (update.state != nil && first) || len(update.events) > 0 {
+			for ch := range p.subscribers {
+				select {
+				case ch <- update:
+				default:
+				}
+			}
+		}
+		s.lock.Unlock()
+		if update.err != nil {
+			return
+		}
+		time.Sleep(s.interval)
+	}
+}
+
+// streamEvents returns the events between two states of an election. Every event
+//  is returned when last is nil.
+func streamEvents(processID []byte, last, state *electionState) []types.ElectionEvent {
+	if last == nil {
+		last = &electionState{}
+	}
+	event := func(kind string) types.ElectionEvent {
+		return types.ElectionEvent{Type: kind, ElectionID: processID, Status: state.status,
+			VoteCount: state.voteCount}
+	}
+	var events []types.ElectionEvent
+	if state.status != last.status {
+		events = append(events, event(types.ElectionEventStatus))
+	}
+	if state.voteCount != last.voteCount {
+		events = append(events, event(types.ElectionEventVotes))
+	}
+	if state.results != nil && last.results == nil {
+		results := event(types.ElectionEventResults)
+		results.Results = state.results
+		events = append(events, results)
+	}
+	return events
+}
+
+// pollElection returns the state of a process on the Vochain. The metadata and
+//  confidentiality of the election are only fetched on the first poll.
+func (u *URLAPI) pollElection(processID []byte, last *electionState) (*electionState, error) {
+	if last != nil && (last.confidential || last.results != nil) {
+		return last, nil
+	}
+	process, err := u.vocClient.GetProcess(processID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get process: %w", err)
+	}
+	state := &electionState{}
+	if last != nil {
+		*state = *last
+	} else {
+		election, err := u.db.GetElectionPublic(process.EntityID, processID)
+		if err != nil {
+			return nil, fmt.Errorf("could not fetch election %x from db: %w", processID, err)
+		}
+		state.confidential = election.Confidential
+		if !state.confidential {
+			if state.meta, err = u.vocClient.FetchProcessMetadata(process.Metadata); err != nil {
+				return nil, fmt.Errorf("unable to get metadata: %w", err)
+			}
+		}
+	}
+	if state.confidential {
+		return state, nil
+	}
+	height, _, _ := u.vocClient.GetBlockTimes()
+	state.status = electionStatus(models.ProcessStatus(process.Status).String(),
+		process.StartBlock, process.EndBlock, height)
+	if !finalResults(process) {
+		// envelopes are counted live, also for elections with encrypted votes
+		if state.voteCount, err = u.vocClient.GetEnvelopeHeight(processID); err != nil {
+			return nil, fmt.Errorf("unable to get vote count: %w", err)
+		}
+		return state, nil
+	}
+	if !process.HaveResults {
+		return state, nil
+	}
+	snapshot, err := u.finalResultsSnapshot(process, state.meta)
+	if err != nil {
+		return nil, err
+	}
+	state.voteCount = snapshot.VoteCount
+	final := snapshotResults(snapshot)
+	if len(final.Results) == 0 {
+		if final.Results, err = aggregateResults(state.meta, &types.VochainResults{
+			VoteCount: snapshot.VoteCount, Results: snapshot.Results}); err != nil {
+			return nil, fmt.Errorf("could not aggregate results: %v", err)
+		}
+	}
+	state.results = final
+	return state, nil
+}
+
+// GET https://server/v1/pub/elections/<processId>/stream
+// streamElectionHandler streams the changes of a non-confidential election as
+//  Server-Sent Events: its status, its vote count and its final results. The
+//  current state is sent first, and the stream ends after streamDuration or
+//  once the final results are sent.
+func (u *URLAPI) streamElectionHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	processID, err := util.GetBytesID(ctx, "electionId")
+	if err != nil {
+		return err
+	}
+	updates := u.streams.subscribe(processID)
+	defer u.streams.unsubscribe(processID, updates)
+	timeout := time.NewTimer(streamDuration)
+	defer timeout.Stop()
+
+	var stream *eventStream
+	for done := false; !done; {
+		var update streamUpdate
+		select {
+		case update = <-updates:
+		case <-timeout.C:
+		case <-ctx.Request.Context().Done():
+		}
+		if update.err != nil {
+			return update.err
+		}
+		if update.state == nil {
+			break
+		}
+		if update.state.confidential {
+			return apierror.ErrElectionConfidential.Withf("%x", processID)
+		}
+		if stream == nil {
+			stream = newEventStream(ctx)
+		}
+		for _, event := range update.events {
+			if err := stream.send(event.Type, event); err != nil {
+				log.Debugf("could not stream election %x: %v", processID, err)
+				done = true
+				break
+			}
+			done = done || event.Type == types.ElectionEventResults
+		}
+	}
+	if stream == nil {
+		// no state was polled in time, the client retries
+		stream = newEventStream(ctx)
+	}
+	stream.close()
+	return nil
+}
+
+// eventStream writes Server-Sent Events to a response
+type eventStream struct {
+	ctx *httprouter.HTTPContext
+}
+
+// newEventStream starts a stream of events, asking clients to reconnect after
+//  streamRetry when it ends
+func newEventStream(ctx *httprouter.HTTPContext) *eventStream {
+	ctx.Writer = &eventStreamWriter{ResponseWriter: ctx.Writer}
+	ctx.Writer.Header().Set("Content-Type", "text/event-stream")
+	ctx.Writer.Header().Set("Cache-Control", "no-cache")
+	ctx.Writer.WriteHeader(http.StatusOK)
+	stream := &eventStream{ctx: ctx}
+	stream.write([]byte(fmt.Sprintf("retry: %d\n\n", streamRetry.Milliseconds())))
+	return stream
+}
+
+// send writes an event of the given kind with data as JSON
+func (s *eventStream) send(kind string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return apierror.ErrInternal.Withf("error marshaling JSON: %v", err)
+	}
+	return s.write([]byte(fmt.Sprintf("event: %s\ndata: %s\n\n", kind, payload)))
+}
+
+func (s *eventStream) write(data []byte) error {
+	if _, err := s.ctx.Writer.Write(data); err != nil {
+		return err
+	}
+	if flusher, ok := s.ctx.Writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// close ends the stream. Every request must be answered with HTTPContext.Send,
+//  which here only writes the final newline, or fails if the client is gone.
+func (s *eventStream) close() {
+	if err := s.ctx.Send(nil, http.StatusOK); err != nil {
+		log.Debugf("could not close event stream: %v", err)
+	}
+}
+
+// eventStreamWriter keeps the headers of an event stream, which are sent before
+//  HTTPContext.Send sets its own
+type eventStreamWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *eventStreamWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *eventStreamWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -2,8 +2,8 @@ package urlapi
 
 import (
 	"encoding/hex"
-	"fmt"
 
+	"go.vocdoni.io/api/apierror"
 	"go.vocdoni.io/api/types"
 	"go.vocdoni.io/api/util"
 	"go.vocdoni.io/dvote/httprouter"
@@ -18,43 +18,75 @@ const (
 
 func (u *URLAPI) enableSuperadminHandlers(adminToken string) error {
 	u.api.SetAdminToken(adminToken)
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/admin/accounts",
 		"POST",
 		bearerstdapi.MethodAccessTypeAdmin,
 		u.createIntegratorAccountHandler,
+		routeDoc{Summary: "Create an integrator account", Tag: "integrators",
+			Action:  "integrator.create",
+			Request: types.CreateIntegratorRequest{}, Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/admin/accounts/{id}",
 		"PUT",
 		bearerstdapi.MethodAccessTypeAdmin,
 		u.updateIntegratorAccountHandler,
+		routeDoc{Summary: "Update an integrator account", Tag: "integrators",
+			Action:  "integrator.update",
+			Request: types.UpdateIntegratorRequest{}, Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/admin/accounts/{id}/key",
 		"PATCH",
 		bearerstdapi.MethodAccessTypeAdmin,
 		u.resetIntegratorKeyHandler,
+		routeDoc{Summary: "Reset an integrator API key", Tag: "integrators",
+			Action: "integrator.resetKey", Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/admin/accounts/{id}",
 		"GET",
 		bearerstdapi.MethodAccessTypeAdmin,
 		u.getIntegratorAccountHandler,
+		routeDoc{Summary: "Get an integrator account", Tag: "integrators",
+			Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
-	if err := u.api.RegisterMethod(
+	if err := u.registerMethod(
 		"/admin/accounts/{id}",
 		"DELETE",
 		bearerstdapi.MethodAccessTypeAdmin,
 		u.deleteIntegratorAccountHandler,
+		routeDoc{Summary: "Delete an integrator account", Tag: "integrators",
+			Action: "integrator.delete", Response: types.APIResponse{}},
+	); err != nil {
+		return err
+	}
+	if err := u.registerMethod(
+		"/admin/accounts/{id}/restore",
+		"PUT",
+		bearerstdapi.MethodAccessTypeAdmin,
+		u.restoreIntegratorAccountHandler,
+		routeDoc{Summary: "Restore a deleted integrator account", Tag: "integrators",
+			Action: "integrator.restore", Response: types.APIResponse{}},
+	); err != nil {
+		return err
+	}
+	if err := u.registerMethod(
+		"/admin/accounts/{id}/organizations/{organizationId}/restore",
+		"PUT",
+		bearerstdapi.MethodAccessTypeAdmin,
+		u.restoreOrganizationHandler,
+		routeDoc{Summary: "Restore a deleted organization", Tag: "organizations",
+			Action: "organization.restore", Response: types.APIResponse{}},
 	); err != nil {
 		return err
 	}
@@ -65,16 +97,10 @@ func (u *URLAPI) enableSuperadminHandlers(adminToken string) error {
 // createIntegratorAccountHandler creates a new integrator account
 func (u *URLAPI) createIntegratorAccountHandler(
 	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
-	req, err := util.UnmarshalRequest(msg)
-	if err != nil {
+	var req types.CreateIntegratorRequest
+	if err := util.UnmarshalRequest(msg, &req); err != nil {
 		return err
 	}
-	if req.Name == "" {
-		return fmt.Errorf("integrator name is empty")
-	}
-	if req.Email == "" {
-		return fmt.Errorf("integrator email is empty")
-	}
 	resp := types.APIResponse{APIKey: util.GenerateBearerToken()}
 	apiKey, err := hex.DecodeString(resp.APIKey)
 	if err != nil {
@@ -83,7 +109,7 @@ func (u *URLAPI) createIntegratorAccountHandler(
 
 	cspPubKey, err := hex.DecodeString(dvoteUtil.TrimHex(req.CspPubKey))
 	if err != nil {
-		return fmt.Errorf("error decoding csp pub key %s", req.CspPubKey)
+		return apierror.ErrInvalidField.Withf("error decoding csp pub key %s", req.CspPubKey)
 	}
 	if resp.ID, err = u.db.CreateIntegrator(apiKey,
 		cspPubKey, req.CspUrlPrefix, req.Name, req.Email); err != nil {
@@ -100,14 +126,14 @@ func (u *URLAPI) updateIntegratorAccountHandler(
 	if err != nil {
 		return err
 	}
-	req, err := util.UnmarshalRequest(msg)
-	if err != nil {
+	var req types.UpdateIntegratorRequest
+	if err = util.UnmarshalRequest(msg, &req); err != nil {
 		return err
 	}
 
 	cspPubKey, err := hex.DecodeString(dvoteUtil.TrimHex(req.CspPubKey))
 	if err != nil {
-		return err
+		return apierror.ErrInvalidField.Withf("error decoding csp pub key %s", req.CspPubKey)
 	}
 	if _, err = u.db.UpdateIntegrator(id, cspPubKey, req.CspUrlPrefix, req.Name); err != nil {
 		return err
@@ -157,15 +183,71 @@ func (u *URLAPI) getIntegratorAccountHandler(
 }
 
 // DELETE https://server/v1/admin/accounts/<id>
-// deleteIntegratorAccountHandler deletes an integrator account
+// deleteIntegratorAccountHandler deletes an integrator account and its organizations.
+// They can be restored until the retention period expires.
 func (u *URLAPI) deleteIntegratorAccountHandler(
 	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
 	id, err := util.GetIntID(ctx, "id")
 	if err != nil {
 		return err
 	}
+	integrator, err := u.db.GetIntegrator(id)
+	if err != nil {
+		return err
+	}
+	orgs, _, err := u.db.ListOrganizations(integrator.SecretApiKey, nil)
+	if err != nil {
+		return err
+	}
+	// the integrator key is revoked by the database token notifier
 	if err = u.db.DeleteIntegrator(id); err != nil {
 		return err
 	}
+	for _, org := range orgs {
+		u.RevokeToken(org.PublicAPIToken)
+	}
 	return sendResponse(types.APIResponse{}, ctx)
 }
+
+// PUT https://server/v1/admin/accounts/<id>/restore
+// restoreIntegratorAccountHandler restores a deleted integrator account, along with
+//  the organizations deleted with it
+func (u *URLAPI) restoreIntegratorAccountHandler(
+	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
+	id, err := util.GetIntID(ctx, "id")
+	if err != nil {
+		return err
+	}
+	orgs, err := u.db.RestoreIntegrator(id)
+	if err != nil {
+		return err
+	}
+	for _, org := range orgs {
+		u.RegisterToken(org.PublicAPIToken, int64(org.PublicAPIQuota))
+	}
+	return sendResponse(types.APIResponse{ID: id}, ctx)
+}
+
+// PUT https://server/v1/admin/accounts/<id>/organizations/<organizationId>/restore
+// restoreOrganizationHandler restores a deleted organization of a live integrator
+func (u *URLAPI) restoreOrganizationHandler(
+	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
+	id, err := util.GetIntID(ctx, "id")
+	if err != nil {
+		return err
+	}
+	organizationID, err := util.GetBytesID(ctx, "organizationId")
+	if err != nil {
+		return err
+	}
+	integrator, err := u.db.GetIntegrator(id)
+	if err != nil {
+		return err
+	}
+	org, err := u.db.RestoreOrganization(integrator.SecretApiKey, organizationID)
+	if err != nil {
+		return err
+	}
+	u.RegisterToken(org.PublicAPIToken, int64(org.PublicAPIQuota))
+	return sendResponse(types.APIResponse{OrganizationID: org.EthAddress}, ctx)
+}
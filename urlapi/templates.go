@@ -0,0 +1,337 @@
+package urlapi
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/api/util"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/bearerstdapi"
+	dvoteutil "go.vocdoni.io/dvote/util"
+)
+
+func (u *URLAPI) enableTemplateHandlers() error {
+	if err := u.registerMethod(
+		"/priv/organizations/{organizationId}/templates",
+		"POST",
+		bearerstdapi.MethodAccessTypePrivate,
+		u.createTemplateHandler,
+		routeDoc{Summary: "Store an election template, or an election as a template",
+			Tag: "elections", Action: "template.create",
+			Request: types.CreateElectionTemplateRequest{}, Response: types.ElectionTemplate{}},
+	); err != nil {
+		return err
+	}
+	if err := u.registerMethod(
+		"/priv/organizations/{organizationId}/templates",
+		"GET",
+		bearerstdapi.MethodAccessTypePrivate,
+		u.listTemplatesHandler,
+		routeDoc{Summary: "List an organization's election templates", Tag: "elections",
+			Response: []types.ElectionTemplate{}},
+	); err != nil {
+		return err
+	}
+	if err := u.registerMethod(
+		"/priv/organizations/{organizationId}/templates/{templateId}",
+		"GET",
+		bearerstdapi.MethodAccessTypePrivate,
+		u.getTemplateHandler,
+		routeDoc{Summary: "Get an election template", Tag: "elections",
+			Response: types.ElectionTemplate{}},
+	); err != nil {
+		return err
+	}
+	return u.registerMethod(
+		"/priv/organizations/{organizationId}/templates/{templateId}",
+		"DELETE",
+		bearerstdapi.MethodAccessTypePrivate,
+		u.deleteTemplateHandler,
+		routeDoc{Summary: "Delete an election template", Tag: "elections",
+			Action: "template.delete", Response: types.APIResponse{}},
+	)
+}
+
+// POST https://server/v1/priv/organizations/<organizationId>/templates
+// createTemplateHandler stores an election definition of the organization, or the
+//  definition of one of its elections, to create elections from
+func (u *URLAPI) createTemplateHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	orgInfo, err := u.authEntityPermissions(msg, ctx)
+	if err != nil {
+		return err
+	}
+	var req types.CreateElectionTemplateRequest
+	if err := util.UnmarshalRequest(msg, &req); err != nil {
+		return err
+	}
+	var definition *types.ElectionDefinition
+	switch {
+	case (req.Definition == nil) == (req.ElectionID == ""):
+		return apierror.ErrInvalidField.With("either definition or electionId must be set")
+	case req.Definition != nil:
+		definition = req.Definition
+		if definition.ProofType == "signed" {
+			definition.ProofType = types.PROOF_TYPE_ECDSA
+		}
+	default:
+		processID, err := hex.DecodeString(dvoteutil.TrimHex(req.ElectionID))
+		if err != nil {
+			return apierror.ErrInvalidField.Withf("invalid electionId: %v", err)
+		}
+		if definition, err = u.cloneElectionDefinition(orgInfo.integratorPrivKey,
+			orgInfo.entityID, processID); err != nil {
+			return err
+		}
+	}
+	if err := u.validateElectionDefinition(definition); err != nil {
+		return err
+	}
+	template := &types.ElectionTemplate{
+		CreatedAt:        time.Now().UTC(),
+		IntegratorApiKey: orgInfo.integratorPrivKey,
+		OrgEthAddress:    orgInfo.entityID,
+		Name:             req.Name,
+		Definition:       *definition,
+	}
+	if template.ID, err = u.db.CreateElectionTemplate(template); err != nil {
+		return err
+	}
+	return sendResponse(template, ctx)
+}
+
+// GET https://server/v1/priv/organizations/<organizationId>/templates
+// listTemplatesHandler lists the election templates of the organization
+func (u *URLAPI) listTemplatesHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	orgInfo, err := u.authEntityPermissions(msg, ctx)
+	if err != nil {
+		return err
+	}
+	templates, err := u.db.ListElectionTemplates(orgInfo.integratorPrivKey, orgInfo.entityID)
+	if err != nil {
+		return err
+	}
+	return sendResponse(templates, ctx)
+}
+
+// GET https://server/v1/priv/organizations/<organizationId>/templates/<templateId>
+// getTemplateHandler returns an election template of the organization
+func (u *URLAPI) getTemplateHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	orgInfo, err := u.authEntityPermissions(msg, ctx)
+	if err != nil {
+		return err
+	}
+	templateID, err := util.GetIntID(ctx, "templateId")
+	if err != nil {
+		return err
+	}
+	template, err := u.db.GetElectionTemplate(orgInfo.integratorPrivKey, orgInfo.entityID,
+		templateID)
+	if err != nil {
+		return err
+	}
+	return sendResponse(template, ctx)
+}
+
+// DELETE https://server/v1/priv/organizations/<organizationId>/templates/<templateId>
+// deleteTemplateHandler deletes an election template of the organization
+func (u *URLAPI) deleteTemplateHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	orgInfo, err := u.authEntityPermissions(msg, ctx)
+	if err != nil {
+		return err
+	}
+	templateID, err := util.GetIntID(ctx, "templateId")
+	if err != nil {
+		return err
+	}
+	if err := u.db.DeleteElectionTemplate(orgInfo.integratorPrivKey, orgInfo.entityID,
+		templateID); err != nil {
+		return err
+	}
+	return sendResponse(types.APIResponse{}, ctx)
+}
+
+// electionDefinition returns the definition of an election to create: the
+//  definition of its template or of the election it clones, if any, with the
+//  overrides of the request
+func (u *URLAPI) electionDefinition(orgInfo orgPermissionsInfo, proofType types.ProofType,
+	req *types.CreateElectionRequest) (*types.ElectionDefinition, error) {
+	definition := &types.ElectionDefinition{ProofType: proofType}
+	switch {
+	case req.TemplateID != 0 && req.CloneFrom != "":
+		return nil, apierror.ErrInvalidField.With("templateId and cloneFrom cannot be both set")
+	case req.TemplateID != 0:
+		template, err := u.db.GetElectionTemplate(orgInfo.integratorPrivKey, orgInfo.entityID,
+			req.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+		definition = &template.Definition
+	case req.CloneFrom != "":
+		processID, err := hex.DecodeString(dvoteutil.TrimHex(req.CloneFrom))
+		if err != nil {
+			return nil, apierror.ErrInvalidField.Withf("invalid cloneFrom: %v", err)
+		}
+		if definition, err = u.cloneElectionDefinition(orgInfo.integratorPrivKey,
+			orgInfo.entityID, processID); err != nil {
+			return nil, err
+		}
+	}
+	if definition.ProofType != proofType {
+		return nil, apierror.ErrInvalidProofType.Withf("the election definition is for %s elections",
+			definition.ProofType)
+	}
+	overrideElectionDefinition(definition, req)
+	if err := u.validateElectionDefinition(definition); err != nil {
+		return nil, err
+	}
+	return definition, nil
+}
+
+// cloneElectionDefinition returns the definition of an election of an
+//  organization, from its metadata
+func (u *URLAPI) cloneElectionDefinition(integratorPrivKey, organizationID,
+	processID []byte) (*types.ElectionDefinition, error) {
+	process, err := u.vocClient.GetProcess(processID)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch election %x from the vochain: %w", processID, err)
+	}
+	election, err := u.db.GetElection(integratorPrivKey, organizationID, processID)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := u.getProcessMetadataPriv(election.Confidential, election.MetadataPrivKey,
+		process.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	return definitionFromMetadata(meta, election), nil
+}
+
+// definitionFromMetadata returns the definition of an election, from its metadata
+//  and its settings
+func definitionFromMetadata(meta *types.ProcessMetadata,
+	election *types.Election) *types.ElectionDefinition {
+	return &types.ElectionDefinition{
+		Title:         meta.Title,
+		Description:   meta.Description,
+		Header:        meta.Media.Header,
+		StreamURI:     meta.Media.StreamURI,
+		Questions:     meta.Questions,
+		ProofType:     types.ProofType(election.ProofType),
+		Confidential:  election.Confidential,
+		HiddenResults: election.HiddenResults,
+		AuthHandler:   election.CspAuth,
+	}
+}
+
+// overrideElectionDefinition replaces the fields of an election definition set by
+//  the request, in the default language. Its flags are only turned on.
+func overrideElectionDefinition(definition *types.ElectionDefinition,
+	req *types.CreateElectionRequest) {
+	if req.Title != "" {
+		definition.Title = types.LanguageString{"default": req.Title}
+	}
+	if req.Description != "" {
+		definition.Description = types.LanguageString{"default": req.Description}
+	}
+	if req.Header != "" {
+		definition.Header = req.Header
+	}
+	if req.StreamURI != "" {
+		definition.StreamURI = req.StreamURI
+	}
+	if len(req.Questions) > 0 {
+		definition.Questions = questionsMetadata(req.Questions)
+	}
+	if req.AuthHandler != "" {
+		definition.AuthHandler = req.AuthHandler
+	}
+	definition.Confidential = definition.Confidential || req.Confidential
+	definition.HiddenResults = definition.HiddenResults || req.HiddenResults
+}
+
+// questionsMetadata returns the metadata of the questions of a request, in the
+//  default language
+func questionsMetadata(questions []types.Question) []types.QuestionMeta {
+	metaQuestions := []types.QuestionMeta{}
+	for _, question := range questions {
+		metaQuestion := types.QuestionMeta{
+			Choices:     []types.ChoiceMetadata{},
+			Description: map[string]string{"default": question.Description},
+			Title:       map[string]string{"default": question.Title},
+		}
+		for _, choice := range question.Choices {
+			metaQuestion.Choices = append(metaQuestion.Choices, types.ChoiceMetadata{
+				Title: map[string]string{"default": choice.Title},
+				Value: choice.Value,
+			})
+		}
+		metaQuestions = append(metaQuestions, metaQuestion)
+	}
+	return metaQuestions
+}
+
+// validateElectionDefinition checks the rules of the election requests on a
+//  definition: every text needs its default language
+func (u *URLAPI) validateElectionDefinition(definition *types.ElectionDefinition) error {
+	var fields []apierror.FieldError
+	invalid := func(field, format string, args ...interface{}) {
+		fields = append(fields, apierror.FieldError{Field: field,
+			Message: fmt.Sprintf(format, args...)})
+	}
+	text := func(field string, s types.LanguageString) {
+		switch {
+		case strings.TrimSpace(s["default"]) == "":
+			invalid(field, "is required")
+		case len([]rune(s["default"])) > 256:
+			invalid(field, "must be at most 256 characters long")
+		}
+	}
+	text("title", definition.Title)
+	switch definition.ProofType {
+	case types.PROOF_TYPE_BLIND, types.PROOF_TYPE_ECDSA:
+	default:
+		invalid("proofType", "must be %s or %s", types.PROOF_TYPE_BLIND, types.PROOF_TYPE_ECDSA)
+	}
+	if definition.AuthHandler != "" {
+		if _, ok := u.cspAuth[definition.AuthHandler]; !ok {
+			invalid("authHandler", "is not a known handler")
+		}
+	}
+	switch {
+	case len(definition.Questions) == 0:
+		invalid("questions", "is required")
+	case len(definition.Questions) > 64:
+		invalid("questions", "must be at most 64 items")
+	}
+	for i, question := range definition.Questions {
+		path := fmt.Sprintf("questions[%d]", i)
+		text(path+".title", question.Title)
+		switch {
+		case len(question.Choices) == 0:
+			invalid(path+".choices", "is required")
+		case len(question.Choices) > 64:
+			invalid(path+".choices", "must be at most 64 items")
+		}
+		values := map[uint32]bool{}
+		for j, choice := range question.Choices {
+			text(fmt.Sprintf("%s.choices[%d].title", path, j), choice.Title)
+			if values[choice.Value] {
+				invalid(path+".choices", "has a repeated value %d", choice.Value)
+			}
+			values[choice.Value] = true
+		}
+	}
+	if len(fields) > 0 {
+		return apierror.ErrInvalidField.WithFields(fields)
+	}
+	return nil
+}
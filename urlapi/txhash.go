@@ -1,9 +1,9 @@
 package urlapi
 
 import (
-	"fmt"
 	"time"
 
+	"go.vocdoni.io/api/apierror"
 	"go.vocdoni.io/api/util"
 	"go.vocdoni.io/dvote/httprouter"
 	"go.vocdoni.io/dvote/httprouter/bearerstdapi"
@@ -24,10 +24,10 @@ func (u *URLAPI) getTxStatusHandler(msg *bearerstdapi.BearerStandardAPIdata,
 
 	txTime, err := u.kv.GetTxTime(txHash)
 	if err != nil {
-		return fmt.Errorf("transaction %x not found: %w", txHash, err)
+		return apierror.ErrTxCache.Withf("transaction %x: %v", txHash, err)
 	}
 	if txTime == nil {
-		return fmt.Errorf("transaction %x has no record", txHash)
+		return apierror.ErrTxNotFound.Withf("transaction %x has no record", txHash)
 	}
 	mined := txTime.Add(15 * time.Second).Before(time.Now())
 
@@ -44,7 +44,7 @@ func (u *URLAPI) getTxStatusHandler(msg *bearerstdapi.BearerStandardAPIdata,
 	// ONLY if the tx has been mined, try to get the "queryTx" from the map/kv
 	queryTx, err := u.kv.GetTx(txHash)
 	if err != nil {
-		return err
+		return apierror.ErrTxCache.WithErr(err)
 	}
 
 	// If queryTx exists on the kv, return false. The query still needs to be committed to the db
@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	"go.vocdoni.io/api/apierror"
 	"go.vocdoni.io/api/config"
+	"go.vocdoni.io/api/csp"
 	"go.vocdoni.io/api/database"
 	"go.vocdoni.io/api/database/transactions"
-	"go.vocdoni.io/api/types"
 	"go.vocdoni.io/api/vocclient"
 	"go.vocdoni.io/dvote/crypto/ethereum"
 	dvotedb "go.vocdoni.io/dvote/db"
@@ -23,8 +25,14 @@ import (
 const (
 	apiVersion = "v1"
 	txTimeout  = time.Minute
+	// purgeInterval is the interval between purges of deleted integrators and organizations
+	purgeInterval = time.Hour
 )
 
+// NextCursorHeader carries the cursor of the next page of a list response. It is
+//  not set on the last page.
+const NextCursorHeader = "X-Next-Cursor"
+
 type URLAPI struct {
 	PrivateCalls uint64
 	PublicCalls  uint64
@@ -40,6 +48,15 @@ type URLAPI struct {
 	kv                    *transactions.TxCacheDB
 	vocClient             *vocclient.Client
 	faucet                *ethereum.SignKeys
+	routes                []route
+	routesLock            sync.RWMutex
+	results               *resultsCache
+	usage                 *usageMeter
+	limiter               *rateLimiter
+	cspAuth               map[string]csp.AuthHandler
+	streams               *electionStreams
+	blockModel            *blockTimeModel
+	blockModelLock        sync.RWMutex
 }
 
 func NewURLAPI(router *httprouter.HTTProuter,
@@ -61,6 +78,8 @@ func NewURLAPI(router *httprouter.HTTProuter,
 		BaseRoute:    baseRoute,
 		router:       router,
 		metricsagent: metricsAgent,
+		results:      newResultsCache(time.Duration(cfg.ResultsCacheSeconds) * time.Second),
+		usage:        newUsageMeter(),
 	}
 	log.Infof("url api available with baseRoute %s", baseRoute)
 	if len(cfg.GlobalEntityKey) > 0 {
@@ -108,14 +127,29 @@ func (u *URLAPI) EnableVotingServiceHandlers(db database.Database,
 	u.db = db
 	u.vocClient = client
 	u.kv = transactions.NewTxKv(kv)
+	store, err := u.rateLimitStore()
+	if err != nil {
+		return err
+	}
+	u.limiter = newRateLimiter(u.config, store)
+	u.cspAuth = u.cspAuthHandlers()
+	u.streams = newElectionStreams(streamPollInterval, streamIdleTimeout, u.pollElection)
 
 	// Register auth tokens from the DB
-	err := u.syncAuthTokens()
+	err = u.syncAuthTokens()
 	if err != nil {
 		return fmt.Errorf("could not sync auth tokens with db: %v", err)
 	}
 
 	go u.monitorCachedTxs()
+	go u.reconcile()
+	go u.meterUsage()
+	go u.purgeRateLimits()
+	go u.runScheduler()
+	go u.sampleBlockTimes()
+	if u.config.DeletedRetentionDays > 0 {
+		go u.purgeDeleted()
+	}
 
 	if err := u.enableSuperadminHandlers(u.config.AdminToken); err != nil {
 		return err
@@ -126,7 +160,93 @@ func (u *URLAPI) EnableVotingServiceHandlers(db database.Database,
 	if err := u.enablePublicHandlers(); err != nil {
 		return err
 	}
-	return nil
+	if err := u.enableAuditHandlers(); err != nil {
+		return err
+	}
+	if err := u.enableChainHandlers(); err != nil {
+		return err
+	}
+	if err := u.enableUsageHandlers(); err != nil {
+		return err
+	}
+	if err := u.enableCspHandlers(); err != nil {
+		return err
+	}
+	if err := u.enableVoteHandlers(); err != nil {
+		return err
+	}
+	if err := u.enableStreamHandlers(); err != nil {
+		return err
+	}
+	if err := u.enableStatsHandlers(); err != nil {
+		return err
+	}
+	if err := u.enableScheduleHandlers(); err != nil {
+		return err
+	}
+	if err := u.enableBlockTimeHandlers(); err != nil {
+		return err
+	}
+	if err := u.enableTemplateHandlers(); err != nil {
+		return err
+	}
+	return u.registerMethod(
+		"/openapi.json",
+		"GET",
+		bearerstdapi.MethodAccessTypePublic,
+		u.openAPIHandler,
+		routeDoc{Summary: "Get the OpenAPI document of this API", Tag: "meta",
+			Response: map[string]interface{}{}},
+	)
+}
+
+// registerMethod registers handler on the bearer router and adds the route,
+//  described by doc, to the OpenAPI document. Errors returned by the
+//  handler are sent to the client with the HTTP status and code of the
+//  apierror.Error in their chain (see apierror.Send).
+// Every response carries a request id, calls authenticated by integrators and
+//  organizations are rate limited and metered, and routes with an audit action
+//  record an audit event once the handler returns.
+func (u *URLAPI) registerMethod(pattern, HTTPmethod, accessType string,
+	handler bearerstdapi.BearerStdAPIhandler, doc routeDoc) error {
+	r := route{
+		pattern:    pattern,
+		method:     HTTPmethod,
+		accessType: accessType,
+		doc:        doc,
+	}
+	u.routesLock.Lock()
+	registered := false
+	for _, r := range u.routes {
+		if r.pattern == pattern && r.method == HTTPmethod {
+			registered = true
+			break
+		}
+	}
+	if !registered {
+		u.routes = append(u.routes, r)
+	}
+	u.routesLock.Unlock()
+	return u.api.RegisterMethod(pattern, HTTPmethod, accessType,
+		func(msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
+			requestID := setRequestID(ctx)
+			err := u.rateLimit(accessType, msg, ctx)
+			if err == nil {
+				u.meterCall(accessType, msg, ctx)
+				err = handler(msg, ctx)
+			}
+			if doc.Action != "" {
+				u.audit(r, msg, ctx, requestID, err)
+			}
+			if err == nil {
+				return nil
+			}
+			log.Debugf("%s %s failed: %v", HTTPmethod, pattern, err)
+			if err := apierror.Send(err, ctx); err != nil {
+				log.Warn(err)
+			}
+			return nil
+		})
 }
 
 func (u *URLAPI) syncAuthTokens() error {
@@ -140,7 +260,7 @@ func (u *URLAPI) syncAuthTokens() error {
 		u.api.AddAuthToken(hex.EncodeToString(key), INTEGRATOR_MAX_REQUESTS)
 
 		// Fetch integrator's organizations from the db
-		orgs, err := u.db.ListOrganizations(key, &types.ListOptions{})
+		orgs, _, err := u.db.ListOrganizations(key, nil)
 		if err != nil {
 			return err
 		}
@@ -205,11 +325,36 @@ func (u *URLAPI) monitorCachedTxs() {
 	}
 }
 
+// purgeDeleted periodically removes the integrators and organizations deleted
+//  longer than the retention period ago
+func (u *URLAPI) purgeDeleted() {
+	retention := time.Duration(u.config.DeletedRetentionDays) * 24 * time.Hour
+	for {
+		integrators, organizations, err := u.db.PurgeDeleted(time.Now().Add(-retention))
+		if err != nil {
+			log.Errorf("could not purge deleted rows: %v", err)
+		} else if integrators > 0 || organizations > 0 {
+			log.Infof("purged %d deleted integrators and %d deleted organizations",
+				integrators, organizations)
+		}
+		time.Sleep(purgeInterval)
+	}
+}
+
+// sendPage sends a page of a list, with the cursor of the next page, if any,
+//  in the NextCursorHeader
+func sendPage(response interface{}, next string, ctx *httprouter.HTTPContext) error {
+	if next != "" {
+		ctx.Writer.Header().Set(NextCursorHeader, next)
+	}
+	return sendResponse(response, ctx)
+}
+
 func sendResponse(response interface{}, ctx *httprouter.HTTPContext) error {
 	data, err := json.Marshal(response)
 	if err != nil {
 		log.Errorf("error marshaling JSON: %v", err)
-		return fmt.Errorf("error marshaling JSON: %w", err)
+		return apierror.ErrInternal.Withf("error marshaling JSON: %v", err)
 	}
 	if err = ctx.Send(data, 200); err != nil {
 		log.Error(err)
@@ -0,0 +1,355 @@
+package urlapi
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/api/util"
+	"go.vocdoni.io/api/vocclient"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/bearerstdapi"
+	"go.vocdoni.io/dvote/log"
+	dvoteUtil "go.vocdoni.io/dvote/util"
+)
+
+// usageFlushInterval is the interval between writes of the usage counted in
+//  memory to the database
+const usageFlushInterval = time.Minute
+
+// usageKey identifies the usage of a metric during a day, by the integrator or
+//  the organization owning token. Tokens are attributed to their owner when flushed.
+type usageKey struct {
+	day time.Time
+	// integrator tells whether token is an integrator key or an organization
+	//  API token
+	integrator   bool
+	token        string
+	organization string
+	metric       string
+}
+
+// usageMeter counts the usage of the API in memory, so metering does not add
+//  a database write to every request
+type usageMeter struct {
+	lock    sync.Mutex
+	entries map[usageKey]int64
+}
+
+func newUsageMeter() *usageMeter {
+	return &usageMeter{entries: make(map[usageKey]int64)}
+}
+
+// addIntegrator counts n of metric for the integrator with the hex key and, if
+//  not empty, its organization
+func (m *usageMeter) addIntegrator(key string, organization []byte, metric string, n int64) {
+	m.add(usageKey{integrator: true, token: key, organization: string(organization),
+		metric: metric}, n)
+}
+
+// addOrganization counts n of metric for the organization with the API token
+func (m *usageMeter) addOrganization(token string, metric string, n int64) {
+	m.add(usageKey{token: token, metric: metric}, n)
+}
+
+func (m *usageMeter) add(key usageKey, n int64) {
+	if key.token == "" || n == 0 {
+		return
+	}
+	key.day = time.Now().UTC().Truncate(24 * time.Hour)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.entries[key] += n
+}
+
+// take returns the usage counted so far and resets the meter
+func (m *usageMeter) take() map[usageKey]int64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	entries := m.entries
+	m.entries = make(map[usageKey]int64)
+	return entries
+}
+
+// restore adds back usage taken from the meter that could not be stored
+func (m *usageMeter) restore(entries map[usageKey]int64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for key, n := range entries {
+		m.entries[key] += n
+	}
+}
+
+// meterUsage periodically adds the usage counted in memory to the database
+func (u *URLAPI) meterUsage() {
+	for {
+		time.Sleep(usageFlushInterval)
+		if err := u.flushUsage(); err != nil {
+			log.Warnf("could not store usage: %v", err)
+		}
+	}
+}
+
+// flushUsage attributes the usage counted in memory to its integrators and
+//  organizations and adds it to the database. Usage of unknown tokens is dropped,
+//  and usage that cannot be attributed or stored is kept for the next flush.
+func (u *URLAPI) flushUsage() error {
+	entries := u.usage.take()
+	if len(entries) == 0 {
+		return nil
+	}
+	integrators := make(map[string]int)
+	organizations := make(map[string]*types.Organization)
+	counters := make(map[usageKey]*types.UsageCounter)
+	// pending is the usage of the tokens that could not be looked up
+	pending := make(map[usageKey]int64)
+	var lookupErr error
+	for key, n := range entries {
+		counter := &types.UsageCounter{Day: key.day, Metric: key.metric, Count: n}
+		if key.integrator {
+			id, ok := integrators[key.token]
+			if !ok {
+				var err error
+				if id, err = u.integratorID(key.token); err != nil &&
+					!errors.Is(err, apierror.ErrIntegratorNotFound) {
+					pending[key], lookupErr = n, err
+					continue
+				}
+				integrators[key.token] = id
+			}
+			counter.IntegratorID = id
+			counter.OrgEthAddress = []byte(key.organization)
+		} else {
+			organization, ok := organizations[key.token]
+			if !ok {
+				var err error
+				if organization, err = u.db.GetOrganizationByAPIToken(key.token); err != nil &&
+					!errors.Is(err, apierror.ErrOrganizationNotFound) {
+					pending[key], lookupErr = n, err
+					continue
+				}
+				organizations[key.token] = organization
+			}
+			if organization != nil {
+				counter.IntegratorID = organization.IntegratorID
+				counter.OrgEthAddress = organization.EthAddress
+			}
+		}
+		if counter.IntegratorID == 0 {
+			log.Debugf("dropping the %s usage of an unknown token", key.metric)
+			continue
+		}
+		// different tokens of an owner add up to the same counter
+		owner := usageKey{day: key.day, token: strconv.Itoa(counter.IntegratorID),
+			organization: string(counter.OrgEthAddress), metric: key.metric}
+		if existing, ok := counters[owner]; ok {
+			existing.Count += n
+			continue
+		}
+		counters[owner] = counter
+	}
+	list := make([]types.UsageCounter, 0, len(counters))
+	for _, counter := range counters {
+		list = append(list, *counter)
+	}
+	if err := u.db.AddUsage(list); err != nil {
+		u.usage.restore(entries)
+		return err
+	}
+	if len(pending) > 0 {
+		u.usage.restore(pending)
+		return fmt.Errorf("could not attribute the usage of %d tokens: %w", len(pending),
+			lookupErr)
+	}
+	return nil
+}
+
+// integratorID returns the id of the integrator with the hex key
+func (u *URLAPI) integratorID(key string) (int, error) {
+	secretKey, err := hex.DecodeString(key)
+	if err != nil {
+		return 0, apierror.ErrIntegratorNotFound.WithErr(err)
+	}
+	integrator, err := u.db.GetIntegratorByKey(secretKey)
+	if err != nil {
+		return 0, err
+	}
+	return integrator.ID, nil
+}
+
+// meterCall counts a call to a private or public route, attributed to the
+//  organization in the route, if any
+func (u *URLAPI) meterCall(accessType string, msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) {
+	switch accessType {
+	case bearerstdapi.MethodAccessTypePrivate:
+		organization, _ := hex.DecodeString(dvoteUtil.TrimHex(ctx.URLParam("organizationId")))
+		u.usage.addIntegrator(msg.AuthToken, organization, types.UsageAPICalls, 1)
+	case bearerstdapi.MethodAccessTypePublic, bearerstdapi.MethodAccessTypeQuota:
+		u.usage.addOrganization(msg.AuthToken, types.UsageAPICalls, 1)
+	}
+}
+
+// meterFaucet counts the faucet tokens sent to an organization of the integrator
+//  authenticated by authToken
+func (u *URLAPI) meterFaucet(authToken string, organization []byte) {
+	if u.faucet == nil {
+		return
+	}
+	u.usage.addIntegrator(authToken, organization, types.UsageFaucetTokens,
+		int64(u.vocClient.AcctTxCost*vocclient.DefaultFaucetMultiplier))
+}
+
+func (u *URLAPI) enableUsageHandlers() error {
+	if err := u.registerMethod(
+		"/admin/usage",
+		"GET",
+		bearerstdapi.MethodAccessTypeAdmin,
+		u.listUsageHandler,
+		routeDoc{Summary: "List the daily usage of integrators", Tag: "usage",
+			Response: []types.UsageCounter{}},
+	); err != nil {
+		return err
+	}
+	if err := u.registerMethod(
+		"/priv/account/usage",
+		"GET",
+		bearerstdapi.MethodAccessTypePrivate,
+		u.listIntegratorUsageHandler,
+		routeDoc{Summary: "List the integrator's daily usage", Tag: "usage",
+			Response: []types.UsageCounter{}},
+	); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GET https://server/v1/admin/usage?integratorId=&organizationId=&metric=&from=&to=&count=&cursor=&format=
+// listUsageHandler lists the daily usage of every integrator, oldest day first,
+//  as a page of JSON or, with format=csv, as a CSV table of all of it
+func (u *URLAPI) listUsageHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	filter, err := usageFilter(ctx)
+	if err != nil {
+		return err
+	}
+	if filter.IntegratorID, err = util.GetQueryInt(ctx, "integratorId"); err != nil {
+		return err
+	}
+	return u.sendUsage(filter, ctx)
+}
+
+// GET https://server/v1/priv/account/usage?organizationId=&metric=&from=&to=&count=&cursor=&format=
+// listIntegratorUsageHandler lists the daily usage of the calling integrator,
+//  oldest day first, as a page of JSON or, with format=csv, as a CSV table of all of it
+func (u *URLAPI) listIntegratorUsageHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	integratorKey, err := util.GetAuthToken(msg)
+	if err != nil {
+		return err
+	}
+	integrator, err := u.db.GetIntegratorByKey(integratorKey)
+	if err != nil {
+		return err
+	}
+	filter, err := usageFilter(ctx)
+	if err != nil {
+		return err
+	}
+	filter.IntegratorID = integrator.ID
+	return u.sendUsage(filter, ctx)
+}
+
+// sendUsage sends a page of the usage matching filter, or all of it as CSV
+func (u *URLAPI) sendUsage(filter *types.UsageFilter, ctx *httprouter.HTTPContext) error {
+	switch ctx.Request.URL.Query().Get("format") {
+	case "", types.ResultsFormatJSON:
+		counters, next, err := u.db.ListUsage(filter)
+		if err != nil {
+			return err
+		}
+		return sendPage(counters, next, ctx)
+	case types.ResultsFormatCSV:
+	default:
+		return apierror.ErrInvalidFilter.Withf("unknown usage format %q",
+			ctx.Request.URL.Query().Get("format"))
+	}
+	var counters []types.UsageCounter
+	for {
+		page, next, err := u.db.ListUsage(filter)
+		if err != nil {
+			return err
+		}
+		counters = append(counters, page...)
+		if next == "" {
+			break
+		}
+		filter.Cursor = next
+	}
+	data, err := usageCSV(counters)
+	if err != nil {
+		return err
+	}
+	return sendFile(data, "text/csv; charset=utf-8", "usage.csv", ctx)
+}
+
+// usageFilter reads the usage filters shared by the admin and integrator views
+func usageFilter(ctx *httprouter.HTTPContext) (*types.UsageFilter, error) {
+	query := ctx.Request.URL.Query()
+	filter := &types.UsageFilter{
+		Metric: query.Get("metric"),
+		Cursor: query.Get("cursor"),
+	}
+	var err error
+	if filter.OrgEthAddress, err = util.GetQueryBytes(ctx, "organizationId"); err != nil {
+		return nil, err
+	}
+	if filter.From, err = util.GetQueryTime(ctx, "from"); err != nil {
+		return nil, err
+	}
+	if filter.To, err = util.GetQueryTime(ctx, "to"); err != nil {
+		return nil, err
+	}
+	if filter.Count, err = util.GetQueryInt(ctx, "count"); err != nil {
+		return nil, err
+	}
+	if filter.Skip, err = util.GetQueryInt(ctx, "skip"); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// usageCSV returns the usage counters as a CSV table
+func usageCSV(counters []types.UsageCounter) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"day", "integratorId", "organizationId", "planId", "metric",
+		"count"}); err != nil {
+		return nil, err
+	}
+	for _, counter := range counters {
+		plan := ""
+		if counter.PlanID.Valid {
+			plan = counter.PlanID.UUID.String()
+		}
+		if err := w.Write([]string{
+			counter.Day.Format("2006-01-02"),
+			strconv.Itoa(counter.IntegratorID),
+			hex.EncodeToString(counter.OrgEthAddress),
+			plan,
+			counter.Metric,
+			strconv.FormatInt(counter.Count, 10),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -1,13 +1,14 @@
 package urlapi
 
 import (
-	"encoding/hex"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/google/uuid"
+	"go.vocdoni.io/api/apierror"
 	"go.vocdoni.io/api/types"
 	"go.vocdoni.io/api/util"
 	"go.vocdoni.io/dvote/httprouter"
@@ -19,17 +20,19 @@ import (
 )
 
 const (
-	filterUnknown  = "UNKNOWN"
-	filterPaused   = "PAUSED"
-	filterCanceled = "CANCELED"
-	filterUpcoming = "UPCOMING"
-	filterActive   = "ACTIVE"
-	filterEnded    = "ENDED"
-	filterReady    = "READY"
+	filterUnknown  = types.ElectionStatusUnknown
+	filterPaused   = types.ElectionStatusPaused
+	filterCanceled = types.ElectionStatusCanceled
+	filterUpcoming = types.ElectionStatusUpcoming
+	filterActive   = types.ElectionStatusActive
+	filterEnded    = types.ElectionStatusEnded
 	filterBlind    = "BLIND"
 	filterSigned   = "SIGNED"
 )
 
+// maxConcurrentFetches is the number of gateway fetches a list request runs at once
+const maxConcurrentFetches = 8
+
 type orgPermissionsInfo struct {
 	integratorPrivKey []byte
 	entityID          []byte
@@ -111,43 +114,40 @@ func (u *URLAPI) parseProcessInfo(vc *indexertypes.Process,
 	}
 
 	// Digest status to something more usable by the client
-	switch vc.Status {
-	case int32(models.ProcessStatus_PROCESS_UNKNOWN):
-		process.Status = filterUnknown
-	case int32(models.ProcessStatus_PAUSED):
-		process.Status = filterPaused
-	case int32(models.ProcessStatus_CANCELED):
-		process.Status = filterCanceled
-	default:
-		blockHeight, _, _ := u.vocClient.GetBlockTimes()
-		if vc.StartBlock >= blockHeight {
-			process.Status = filterUpcoming
-		} else if vc.StartBlock < blockHeight && vc.EndBlock > blockHeight {
-			process.Status = filterActive
-		} else {
-			process.Status = filterEnded
-		}
-	}
+	blockHeight, _, _ := u.vocClient.GetBlockTimes()
+	process.Status = electionStatus(models.ProcessStatus(vc.Status).String(),
+		vc.StartBlock, vc.EndBlock, blockHeight)
 
 	var err error
 	if results != nil && vc.HaveResults {
-		process.VoteCount = results.Height
+		process.VoteCount = results.VoteCount
 		if process.Results, err = aggregateResults(meta, results); err != nil {
 			return process, fmt.Errorf("could not aggregate results: %v", err)
 		}
 	}
 
-	if process.StartDate, err = u.estimateBlockTime(vc.StartBlock); err != nil {
+	var margin time.Duration
+	if process.StartDate, margin, err = u.estimateBlockTime(vc.StartBlock); err != nil {
 		return process, fmt.Errorf("could not estimate startDate at %d: %w", vc.StartBlock, err)
 	}
+	process.StartDateError = int64(margin / time.Second)
 
-	if process.EndDate, err = u.estimateBlockTime(vc.EndBlock); err != nil {
+	if process.EndDate, margin, err = u.estimateBlockTime(vc.EndBlock); err != nil {
 		return process, fmt.Errorf("could not estimate endDate at %d: %w", vc.EndBlock, err)
 	}
+	process.EndDateError = int64(margin / time.Second)
 	return process, nil
 }
 
-func (u *URLAPI) estimateBlockTime(height uint32) (time.Time, error) {
+// estimateBlockTime returns the estimated date of a block, and its error bound
+//  (see blockTimeModel). Until the model has enough samples, the date is
+//  extrapolated from the rolling block time averages of the gateway, and the
+//  error bound is 0 as it is unknown.
+func (u *URLAPI) estimateBlockTime(height uint32) (time.Time, time.Duration, error) {
+	if model := u.blockTimeModel(); model != nil {
+		date, margin := model.time(height)
+		return date, margin, nil
+	}
 	currentHeight, times, _ := u.vocClient.GetBlockTimes()
 	diffHeight := int64(height) - int64(currentHeight)
 	inPast := diffHeight < 0
@@ -177,10 +177,20 @@ func (u *URLAPI) estimateBlockTime(height uint32) (time.Time, error) {
 	case absDiff >= 1000:
 		t = getMaxTimeFrom(4)
 	}
-	return time.Now().Add(time.Duration(diffHeight*int64(t)) * time.Millisecond), nil
+	return time.Now().Add(time.Duration(diffHeight*int64(t)) * time.Millisecond), 0, nil
 }
 
-func (u *URLAPI) estimateBlockHeight(target time.Time) (uint32, error) {
+// estimateBlockHeight returns the estimated block at a date, and the error bound
+//  of the date of that block, as estimateBlockTime
+func (u *URLAPI) estimateBlockHeight(target time.Time) (uint32, time.Duration, error) {
+	if model := u.blockTimeModel(); model != nil {
+		height, margin := model.height(target)
+		if height == 0 {
+			return 0, 0, apierror.ErrInvalidDate.Withf("target time %v is before Vochain origin",
+				target)
+		}
+		return height, margin, nil
+	}
 	currentHeight, times, _ := u.vocClient.GetBlockTimes()
 	currentTime := time.Now()
 	// diff time in seconds
@@ -217,103 +227,124 @@ func (u *URLAPI) estimateBlockHeight(target time.Time) (uint32, error) {
 	blockDiff := (uint32(absDiff*1000) / t)
 	if inPast {
 		if blockDiff > currentHeight {
-			return 0, fmt.Errorf("target time %v is before Vochain origin", target)
+			return 0, 0, apierror.ErrInvalidDate.Withf("target time %v is before Vochain origin",
+				target)
 		}
-		return currentHeight - uint32(blockDiff), nil
+		return currentHeight - uint32(blockDiff), 0, nil
 	}
-	return currentHeight + uint32(blockDiff), nil
+	return currentHeight + uint32(blockDiff), 0, nil
 }
 
-// getProcessList gets a list of process summaries for given filters.
-// if `private`, all processes are returned, including metadataPrivKeys, in the first return var.
-// otherwise, confidential processes are returned first and public ones second.
-func (u *URLAPI) getProcessList(filter string, integratorPrivKey, entityId []byte,
-	private bool) ([]types.APIElectionSummary, error) {
-	var electionList []types.APIElectionSummary
-	filter = strings.ToUpper(filter)
-
-	// translation of API filter to vochain/gateway filter
-	gwFilter := filter
-	if gwFilter == filterActive || gwFilter == filterUpcoming {
-		gwFilter = filterReady
-	} else if gwFilter == filterBlind || gwFilter == filterSigned {
-		gwFilter = ""
-	}
-
-	fullProcessList, err := u.fetchProcessList(entityId, gwFilter)
+// getProcessList gets a page of process summaries for the given status filter and
+//  the election filter query parameters, and the cursor of the next page.
+// if `private`, confidential processes are included, with their metadataPrivKeys.
+func (u *URLAPI) getProcessList(ctx *httprouter.HTTPContext, filter string,
+	integratorPrivKey, entityId []byte, private bool) ([]types.APIElectionSummary, string, error) {
+	electionFilter, err := getElectionFilter(ctx)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-
+	filter = strings.ToUpper(filter)
 	currentHeight, _, _ := u.vocClient.GetBlockTimes()
-
-	// fetch all processes from db
-	for _, processID := range fullProcessList {
-		processIDBytes, err := hex.DecodeString(processID)
-		if err != nil {
-			log.Errorf("cannot decode process id %s: %v", processID, err)
-			continue
+	switch filter {
+	case "":
+	case filterBlind, filterSigned:
+		proofType := string(types.PROOF_TYPE_BLIND)
+		if filter == filterSigned {
+			proofType = string(types.PROOF_TYPE_ECDSA)
 		}
-		var newProcess *types.Election
-		if private {
-			newProcess, err = u.db.GetElection(integratorPrivKey,
-				entityId, processIDBytes)
-		} else {
-			newProcess, err = u.db.GetElectionPublic(entityId, processIDBytes)
+		if electionFilter.ProofType != "" && electionFilter.ProofType != proofType {
+			return nil, "", apierror.ErrInvalidFilter.Withf("%s elections cannot have proof type %s",
+				strings.ToLower(filter), electionFilter.ProofType)
 		}
-		if err != nil {
-			log.Warnf("could not get election,"+
-				" process %x may no be in db: %v", processIDBytes, err)
-			continue
+		electionFilter.ProofType = proofType
+	case filterActive, filterUpcoming, filterEnded:
+		if currentHeight == 0 {
+			return nil, "", apierror.ErrGatewayUnavailable.Withf("vochain height not known yet")
 		}
-		newProcess.OrgEthAddress = entityId
-		newProcess.ProcessID = processIDBytes
+		electionFilter.Status = filter
+		electionFilter.Height = int(currentHeight)
+	case filterPaused, filterCanceled:
+		electionFilter.Status = filter
+	default:
+		return nil, "", apierror.ErrInvalidFilter.Withf("%s", filter)
+	}
 
-		switch filter {
-		case filterPaused, filterCanceled, filterEnded, "":
-			// if filtering already handled, append all processes
-			appendProcess(&electionList, newProcess, private, filter)
-		case filterActive:
-			// filter processes by date
-			if newProcess.StartBlock < int(currentHeight) && newProcess.EndBlock > int(currentHeight) {
-				appendProcess(&electionList, newProcess, private, filter)
-			}
-		case filterUpcoming:
-			// filter processes by date
-			if newProcess.StartBlock > int(currentHeight) {
-				appendProcess(&electionList, newProcess, private, filter)
-			}
-		case filterBlind:
-			// filter processes by proof type
-			if newProcess.ProofType == "blind" {
-				appendProcess(&electionList, newProcess, private, "")
-			}
-		case filterSigned:
-			// filter processes by proof type
-			if newProcess.ProofType == "ecdsa" {
-				appendProcess(&electionList, newProcess, private, "")
-			}
-		default:
-			return nil, fmt.Errorf("%s not a valid filter", filter)
-		}
+	var elections []types.Election
+	var next string
+	if private {
+		elections, next, err = u.db.ListElections(integratorPrivKey, entityId, electionFilter)
+	} else {
+		elections, next, err = u.db.ListElectionsPublic(entityId, electionFilter)
+	}
+	if err != nil {
+		return nil, "", err
 	}
-	return electionList, nil
+	var electionList []types.APIElectionSummary
+	for i := range elections {
+		elections[i].OrgEthAddress = entityId
+		appendProcess(&electionList, &elections[i], private, electionStatus(elections[i].Status,
+			uint32(elections[i].StartBlock), uint32(elections[i].EndBlock), currentHeight))
+	}
+	return electionList, next, nil
 }
 
-func (u *URLAPI) fetchProcessList(entityId []byte, status string) ([]string, error) {
-	var fullProcessList []string
-	for {
-		tempProcessList, err := u.vocClient.GetProcessList(entityId,
-			status, "", "", 0, false, len(fullProcessList), 64)
-		if err != nil {
-			return nil, fmt.Errorf("unable to get process list from vochain: %w", err)
+// getElectionFilter returns the election filter and page selected by the
+//  proofType, title, startAfter, startBefore, endAfter and endBefore query
+//  parameters and the list options
+func getElectionFilter(ctx *httprouter.HTTPContext) (*types.ElectionFilter, error) {
+	opts, err := util.GetListOptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := ctx.Request.URL.Query()
+	filter := &types.ElectionFilter{
+		ListOptions: *opts,
+		ProofType:   query.Get("proofType"),
+		Title:       query.Get("title"),
+	}
+	switch types.ProofType(filter.ProofType) {
+	case "", types.PROOF_TYPE_BLIND, types.PROOF_TYPE_ECDSA:
+	default:
+		return nil, apierror.ErrInvalidProofType.Withf("%s", filter.ProofType)
+	}
+	if filter.StartAfter, err = util.GetQueryTime(ctx, "startAfter"); err != nil {
+		return nil, err
+	}
+	if filter.StartBefore, err = util.GetQueryTime(ctx, "startBefore"); err != nil {
+		return nil, err
+	}
+	if filter.EndAfter, err = util.GetQueryTime(ctx, "endAfter"); err != nil {
+		return nil, err
+	}
+	if filter.EndBefore, err = util.GetQueryTime(ctx, "endBefore"); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// electionStatus returns the API status of an election with the given Vochain
+//  status and blocks at the block height. READY elections are upcoming, active
+//  or ended depending on the height, unless it is not known yet.
+func electionStatus(status string, startBlock, endBlock, height uint32) string {
+	switch status {
+	case types.ElectionStatusReady:
+		if height == 0 {
+			return status
+		}
+		if startBlock >= height {
+			return filterUpcoming
 		}
-		fullProcessList = append(fullProcessList, tempProcessList...)
-		if len(tempProcessList) < 64 {
-			break
+		if endBlock > height {
+			return filterActive
 		}
+		return filterEnded
+	case types.ElectionStatusEnded, types.ElectionStatusResults:
+		return filterEnded
+	case types.ElectionStatusPaused, types.ElectionStatusCanceled:
+		return status
 	}
-	return fullProcessList, nil
+	return filterUnknown
 }
 
 func aggregateResults(meta *types.ProcessMetadata,
@@ -408,3 +439,29 @@ func reflectElectionPublic(election types.Election) types.APIElectionSummary {
 	}
 	return newElection
 }
+
+// forEachConcurrently calls fn for every index below n, running up to
+//  maxConcurrentFetches calls at once, and returns the error of the lowest index
+func forEachConcurrently(n int, fn func(i int) error) error {
+	errs := make([]error, n)
+	sem := make(chan struct{}, maxConcurrentFetches)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = fn(i)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
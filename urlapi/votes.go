@@ -0,0 +1,446 @@
+package urlapi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/api/util"
+	"go.vocdoni.io/dvote/api"
+	"go.vocdoni.io/dvote/crypto"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+	"go.vocdoni.io/dvote/crypto/nacl"
+	"go.vocdoni.io/dvote/httprouter"
+	"go.vocdoni.io/dvote/httprouter/bearerstdapi"
+	dvoteutil "go.vocdoni.io/dvote/util"
+	"go.vocdoni.io/dvote/vochain"
+	"go.vocdoni.io/dvote/vochain/scrutinizer/indexertypes"
+	"go.vocdoni.io/proto/build/go/models"
+	"google.golang.org/protobuf/proto"
+)
+
+// voteNonceSize is the size of the nonces of vote envelopes and packages
+const voteNonceSize = 32
+
+// voteHeightLag is the number of blocks the cached block height may lag the
+//  vochain by, when checking that an election has started
+const voteHeightLag = 6
+
+func (u *URLAPI) enableVoteHandlers() error {
+	if err := u.registerMethod(
+		"/pub/elections/{electionId}/vote/package",
+		"POST",
+		bearerstdapi.MethodAccessTypePublic,
+		u.buildVoteHandler,
+		routeDoc{Summary: "Build a vote transaction for the voter to sign", Tag: "votes",
+			Request: types.BuildVoteRequest{}, Response: types.APIVotePackage{}},
+	); err != nil {
+		return err
+	}
+	if err := u.registerMethod(
+		"/pub/elections/{electionId}/vote/signed",
+		"POST",
+		bearerstdapi.MethodAccessTypePublic,
+		u.submitSignedVoteHandler,
+		routeDoc{Summary: "Submit a vote transaction signed by the voter", Tag: "votes",
+			Request: types.SubmitSignedVoteRequest{}, Response: types.APIResponse{}},
+	); err != nil {
+		return err
+	}
+	return u.registerMethod(
+		"/pub/nullifiers/{nullifier}/receipt",
+		"GET",
+		bearerstdapi.MethodAccessTypePublic,
+		u.getVoteReceiptHandler,
+		routeDoc{Summary: "Get the signed receipt of a vote", Tag: "votes",
+			Response: types.VoteReceipt{}},
+	)
+}
+
+// POST https://server/v1/pub/elections/<electionId>/vote/package
+// buildVoteHandler builds the vote of a voter, so that clients without the SDK
+//  only sign it. It returns the CSP bundle of the voter address, the payload
+//  the CSP signs, and, once the CSP signature is sent, the vote transaction with
+//  the vote package encrypted with the election keys, and the payload to sign.
+func (u *URLAPI) buildVoteHandler(
+	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
+	processID, err := util.GetBytesID(ctx, "electionId")
+	if err != nil {
+		return err
+	}
+	var req types.BuildVoteRequest
+	if err := util.UnmarshalRequest(msg, &req); err != nil {
+		return err
+	}
+	voter, err := hex.DecodeString(dvoteutil.TrimHex(req.VoterAddress))
+	if err != nil || len(voter) != ethcommon.AddressLength {
+		return apierror.ErrInvalidField.With("voterAddress must be an address")
+	}
+	process, err := u.vocClient.GetProcess(processID)
+	if err != nil {
+		return err
+	}
+	if process.CensusOrigin != int32(models.CensusOrigin_OFF_CHAIN_CA) {
+		return apierror.ErrInvalidProofType.Withf("election %x has no csp", processID)
+	}
+	election, err := u.db.GetElectionPublic(process.EntityID, processID)
+	if err != nil {
+		return err
+	}
+	proof := &models.ProofCA{
+		Type:   cspProofType(types.ProofType(election.ProofType)),
+		Bundle: &models.CAbundle{ProcessId: processID, Address: voter},
+	}
+	var resp types.APIVotePackage
+	if resp.CspBundle, err = proto.Marshal(proof.Bundle); err != nil {
+		return apierror.ErrInternal.Withf("could not marshal csp bundle: %v", err)
+	}
+	if process.Envelope.GetEncryptedVotes() {
+		if resp.EncryptionPubKeys, err = u.vocClient.GetProcessPubKeys(processID); err != nil {
+			return apierror.ErrGateway.WithErr(err)
+		}
+	}
+	if req.CspSignature == "" {
+		return sendResponse(resp, ctx)
+	}
+	if proof.Signature, err = hex.DecodeString(
+		dvoteutil.TrimHex(req.CspSignature)); err != nil {
+		return apierror.ErrInvalidField.Withf("cspSignature: %v", err)
+	}
+	encrypted := process.Envelope.GetEncryptedVotes()
+	if resp.VoteTx, err = buildVoteTx(processID, req.Votes, encrypted,
+		resp.EncryptionPubKeys, proof); err != nil {
+		return err
+	}
+	resp.SignPayload = string(ethereum.BuildVocdoniTransaction(resp.VoteTx,
+		u.vocClient.ChainID))
+	return sendResponse(resp, ctx)
+}
+
+// POST https://server/v1/pub/elections/<electionId>/vote/signed
+// submitSignedVoteHandler relays a vote transaction built by buildVoteHandler
+//  with the signature of the voter, which must be the address of its proof
+func (u *URLAPI) submitSignedVoteHandler(
+	msg *bearerstdapi.BearerStandardAPIdata, ctx *httprouter.HTTPContext) error {
+	processID, err := util.GetBytesID(ctx, "electionId")
+	if err != nil {
+		return err
+	}
+	var req types.SubmitSignedVoteRequest
+	if err := util.UnmarshalRequest(msg, &req); err != nil {
+		return err
+	}
+	stx := &models.SignedTx{}
+	if stx.Tx, err = hex.DecodeString(dvoteutil.TrimHex(req.VoteTx)); err != nil {
+		return apierror.ErrInvalidField.Withf("voteTx: %v", err)
+	}
+	if stx.Signature, err = hex.DecodeString(dvoteutil.TrimHex(req.Signature)); err != nil {
+		return apierror.ErrInvalidField.Withf("signature: %v", err)
+	}
+	if err := verifyVoteTx(processID, stx, u.vocClient.ChainID); err != nil {
+		return err
+	}
+	signedTx, err := proto.Marshal(stx)
+	if err != nil {
+		return apierror.ErrInternal.Withf("could not marshal signed tx: %v", err)
+	}
+	var resp types.APIResponse
+	if resp.Nullifier, err = u.relayVote(msg, processID, signedTx); err != nil {
+		return err
+	}
+	return sendResponse(resp, ctx)
+}
+
+// GET https://server/v1/pub/nullifiers/<nullifier>/receipt
+// getVoteReceiptHandler returns the receipt of a vote registered on the vochain,
+//  signed by the API signing key, so voters can prove their vote was counted
+//  without trusting the explorer
+func (u *URLAPI) getVoteReceiptHandler(msg *bearerstdapi.BearerStandardAPIdata,
+	ctx *httprouter.HTTPContext) error {
+	nullifier, err := util.GetBytesID(ctx, "nullifier")
+	if err != nil {
+		return err
+	}
+	envelope, err := u.vocClient.GetEnvelope(nullifier)
+	if err != nil {
+		return err
+	}
+	block, err := u.vocClient.GetBlock(envelope.Meta.Height)
+	if err != nil {
+		return apierror.ErrGateway.Withf("could not get block %d: %v", envelope.Meta.Height, err)
+	}
+	receipt, err := u.signVoteReceipt(voteReceiptPayload(u.vocClient.ChainID, envelope, block))
+	if err != nil {
+		return err
+	}
+	return sendResponse(receipt, ctx)
+}
+
+// signVoteReceipt signs payload with the API signing key
+func (u *URLAPI) signVoteReceipt(payload *types.VoteReceiptPayload) (*types.VoteReceipt, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, apierror.ErrInternal.Withf("error marshaling JSON: %v", err)
+	}
+	signature, err := u.vocClient.SignMessage(data)
+	if err != nil {
+		return nil, apierror.ErrCrypto.Withf("could not sign vote receipt: %v", err)
+	}
+	return &types.VoteReceipt{
+		Payload:   data,
+		Signer:    u.vocClient.SignerAddress(),
+		Signature: signature,
+	}, nil
+}
+
+// voteReceiptPayload returns the receipt of envelope, included in block. The
+//  votes of envelopes without encryption keys are decoded.
+func voteReceiptPayload(chainID string, envelope *indexertypes.EnvelopePackage,
+	block *indexertypes.BlockMetadata) *types.VoteReceiptPayload {
+	payload := &types.VoteReceiptPayload{
+		ChainID:              chainID,
+		ElectionID:           envelope.Meta.ProcessId,
+		Nullifier:            envelope.Meta.Nullifier,
+		Height:               envelope.Meta.Height,
+		TxIndex:              envelope.Meta.TxIndex,
+		TxHash:               envelope.Meta.TxHash,
+		Weight:               envelope.Weight,
+		EncryptionKeyIndexes: envelope.EncryptionKeyIndexes,
+		CreatedAt:            time.Now().UTC(),
+	}
+	if block != nil {
+		payload.Timestamp = block.Timestamp.UTC()
+	}
+	if len(envelope.EncryptionKeyIndexes) == 0 {
+		var votePackage vochain.VotePackage
+		if err := json.Unmarshal(envelope.VotePackage, &votePackage); err == nil {
+			payload.Votes = votePackage.Votes
+		}
+	}
+	return payload
+}
+
+// relayVote relays a signed vote transaction for processID to the vochain and
+//  returns its nullifier, once checked with checkVote. Votes are metered to the
+//  organization of the token of msg.
+func (u *URLAPI) relayVote(msg *bearerstdapi.BearerStandardAPIdata, processID,
+	signedTx []byte) (string, error) {
+	if err := u.checkVote(processID, signedTx); err != nil {
+		return "", err
+	}
+	nullifier, err := u.vocClient.RelayVote(signedTx)
+	if err != nil {
+		return "", fmt.Errorf("could not submit vote tx: %w", err)
+	}
+	u.usage.addOrganization(msg.AuthToken, types.UsageVotes, 1)
+	return nullifier, nil
+}
+
+// checkVote checks that signedTx is a vote envelope for processID that the
+//  vochain would accept, so that voters get precise errors before it is relayed
+func (u *URLAPI) checkVote(processID, signedTx []byte) error {
+	var stx models.SignedTx
+	if err := proto.Unmarshal(signedTx, &stx); err != nil {
+		return apierror.ErrInvalidVote.Withf("could not decode signed tx: %v", err)
+	}
+	var tx models.Tx
+	if err := proto.Unmarshal(stx.Tx, &tx); err != nil {
+		return apierror.ErrInvalidVote.Withf("could not decode tx: %v", err)
+	}
+	envelope := tx.GetVote()
+	if envelope == nil {
+		return apierror.ErrInvalidVote.With("tx is not a vote")
+	}
+	if !bytes.Equal(envelope.ProcessId, processID) {
+		return apierror.ErrInvalidVote.Withf("vote is for election %x, not %x",
+			envelope.ProcessId, processID)
+	}
+	process, err := u.vocClient.GetProcess(processID)
+	if err != nil {
+		return err
+	}
+	election, err := u.db.GetElectionPublic(process.EntityID, processID)
+	if err != nil {
+		return err
+	}
+	height, _, _ := u.vocClient.GetBlockTimes()
+	return validateVoteEnvelope(process, types.ProofType(election.ProofType), envelope, height)
+}
+
+// validateVoteEnvelope checks that envelope can be cast in process at height:
+//  the process is READY and within its blocks, the CSP proof is of the proof
+//  type of the election and, unless encrypted, the votes fit the vote options.
+//  As the vochain, it takes votes from the start block to the end block, both
+//  included, and allows for a height up to voteHeightLag blocks behind.
+func validateVoteEnvelope(process *indexertypes.Process, proofType types.ProofType,
+	envelope *models.VoteEnvelope, height uint32) error {
+	status := models.ProcessStatus(process.Status).String()
+	switch {
+	case status != types.ElectionStatusReady:
+		status = electionStatus(status, process.StartBlock, process.EndBlock, height)
+	case height > 0 && height+voteHeightLag < process.StartBlock:
+		status = filterUpcoming
+	case height > process.EndBlock:
+		status = filterEnded
+	}
+	if status != types.ElectionStatusReady {
+		return apierror.ErrElectionNotReady.Withf("election %x is %s", process.ID,
+			strings.ToLower(status))
+	}
+	if process.CensusOrigin == int32(models.CensusOrigin_OFF_CHAIN_CA) {
+		proof := envelope.GetProof().GetCa()
+		if proof == nil {
+			return apierror.ErrInvalidVote.With("vote has no csp proof")
+		}
+		if !matchesProofType(proof.Type, proofType) {
+			return apierror.ErrInvalidProofType.Withf("vote has a %s proof, election %x uses %s",
+				proof.Type, process.ID, proofType)
+		}
+	}
+	if process.Envelope.GetEncryptedVotes() {
+		if len(envelope.EncryptionKeyIndexes) == 0 {
+			return apierror.ErrInvalidVote.With("vote is not encrypted")
+		}
+		return nil
+	}
+	var votePackage vochain.VotePackage
+	if err := json.Unmarshal(envelope.VotePackage, &votePackage); err != nil {
+		return apierror.ErrInvalidVote.Withf("could not decode vote package: %v", err)
+	}
+	return validateVotes(votePackage.Votes, process.VoteOpts, process.Envelope)
+}
+
+// validateVotes checks votes against the vote options of a process, as the
+//  vochain counts them
+func validateVotes(votes []int, opts *models.ProcessVoteOptions,
+	envelopeType *models.EnvelopeType) error {
+	if opts == nil {
+		return nil
+	}
+	if len(votes) > int(opts.MaxCount) {
+		return apierror.ErrInvalidVote.Withf("%d votes, the election allows %d",
+			len(votes), opts.MaxCount)
+	}
+	seen := map[int]bool{}
+	cost := new(big.Int)
+	exponent := big.NewInt(int64(opts.CostExponent))
+	for i, v := range votes {
+		if v < 0 || (opts.MaxValue > 0 && uint32(v) > opts.MaxValue) {
+			return apierror.ErrInvalidVote.Withf("votes[%d] is %d, the maximum is %d",
+				i, v, opts.MaxValue)
+		}
+		if envelopeType.GetUniqueValues() && seen[v] {
+			return apierror.ErrInvalidVote.Withf("votes[%d] repeats the value %d", i, v)
+		}
+		seen[v] = true
+		cost.Add(cost, new(big.Int).Exp(big.NewInt(int64(v)), exponent, nil))
+	}
+	// the cost limit of weighted votes depends on the weight of the voter
+	if opts.MaxTotalCost > 0 && !envelopeType.GetCostFromWeight() &&
+		cost.Cmp(new(big.Int).SetUint64(uint64(opts.MaxTotalCost))) > 0 {
+		return apierror.ErrInvalidVote.Withf("votes cost %s, the maximum is %d",
+			cost, opts.MaxTotalCost)
+	}
+	return nil
+}
+
+// matchesProofType tells whether CSP proofs of type are of the elections of
+//  proofType, salted or not
+func matchesProofType(proofCA models.ProofCA_Type, proofType types.ProofType) bool {
+	switch proofCA {
+	case models.ProofCA_ECDSA, models.ProofCA_ECDSA_PIDSALTED:
+		return proofType == types.PROOF_TYPE_ECDSA
+	case models.ProofCA_ECDSA_BLIND, models.ProofCA_ECDSA_BLIND_PIDSALTED:
+		return proofType == types.PROOF_TYPE_BLIND
+	}
+	return false
+}
+
+// cspProofType returns the type of the CSP proofs of the elections of proofType
+func cspProofType(proofType types.ProofType) models.ProofCA_Type {
+	if proofType == types.PROOF_TYPE_ECDSA {
+		return models.ProofCA_ECDSA_PIDSALTED
+	}
+	return models.ProofCA_ECDSA_BLIND_PIDSALTED
+}
+
+// buildVoteTx returns the vote transaction of votes with proof. The vote package
+//  of encrypted elections is encrypted with each of keys, in order.
+func buildVoteTx(processID []byte, votes []int, encrypted bool, keys []api.Key,
+	proof *models.ProofCA) ([]byte, error) {
+	votePackage := &vochain.VotePackage{Votes: votes}
+	envelope := &models.VoteEnvelope{
+		Nonce:     dvoteutil.RandomBytes(voteNonceSize),
+		ProcessId: processID,
+		Proof:     &models.Proof{Payload: &models.Proof_Ca{Ca: proof}},
+	}
+	var ciphers []crypto.PublicKey
+	if encrypted {
+		for _, key := range keys {
+			if key.Key == "" {
+				continue
+			}
+			pub, err := nacl.DecodePublic(key.Key)
+			if err != nil {
+				return nil, apierror.ErrGateway.Withf("encryption key %d: %v", key.Idx, err)
+			}
+			ciphers = append(ciphers, pub)
+			envelope.EncryptionKeyIndexes = append(envelope.EncryptionKeyIndexes,
+				uint32(key.Idx))
+		}
+		if len(ciphers) == 0 {
+			return nil, apierror.ErrElectionNotReady.Withf(
+				"election %x has no encryption keys yet", processID)
+		}
+		votePackage.Nonce = hex.EncodeToString(dvoteutil.RandomBytes(voteNonceSize))
+	}
+	var err error
+	if envelope.VotePackage, err = json.Marshal(votePackage); err != nil {
+		return nil, apierror.ErrInternal.Withf("could not marshal vote package: %v", err)
+	}
+	for _, pub := range ciphers {
+		if envelope.VotePackage, err = nacl.Anonymous.Encrypt(envelope.VotePackage,
+			pub); err != nil {
+			return nil, apierror.ErrCrypto.Withf("could not encrypt vote package: %v", err)
+		}
+	}
+	tx, err := proto.Marshal(&models.Tx{Payload: &models.Tx_Vote{Vote: envelope}})
+	if err != nil {
+		return nil, apierror.ErrInternal.Withf("could not marshal vote tx: %v", err)
+	}
+	return tx, nil
+}
+
+// verifyVoteTx checks that stx is a vote for processID signed by the address of
+//  its CSP proof
+func verifyVoteTx(processID []byte, stx *models.SignedTx, chainID string) error {
+	var tx models.Tx
+	if err := proto.Unmarshal(stx.Tx, &tx); err != nil {
+		return apierror.ErrInvalidVote.Withf("could not decode vote tx: %v", err)
+	}
+	envelope := tx.GetVote()
+	if envelope == nil || !bytes.Equal(envelope.ProcessId, processID) {
+		return apierror.ErrInvalidVote.Withf("voteTx is not a vote for election %x", processID)
+	}
+	proof := envelope.GetProof().GetCa()
+	if proof == nil || proof.Bundle == nil {
+		return apierror.ErrInvalidVote.With("voteTx has no csp proof")
+	}
+	signer, err := ethereum.AddrFromSignature(
+		ethereum.BuildVocdoniTransaction(stx.Tx, chainID), stx.Signature)
+	if err != nil {
+		return apierror.ErrInvalidSignature.WithErr(err)
+	}
+	if !bytes.Equal(signer.Bytes(), proof.Bundle.Address) {
+		return apierror.ErrInvalidSignature.Withf("vote signed by %x, not by the voter %x",
+			signer.Bytes(), proof.Bundle.Address)
+	}
+	return nil
+}
@@ -3,32 +3,30 @@ package util
 import (
 	"encoding/hex"
 	"encoding/json"
-	"fmt"
 	"strconv"
+	"time"
 
+	"go.vocdoni.io/api/apierror"
 	"go.vocdoni.io/api/types"
 	"go.vocdoni.io/dvote/httprouter"
 	"go.vocdoni.io/dvote/httprouter/bearerstdapi"
 	"go.vocdoni.io/dvote/util"
 )
 
-func UnmarshalRequest(msg *bearerstdapi.BearerStandardAPIdata) (req types.APIRequest, err error) {
-	err = json.Unmarshal(msg.Data, &req)
-	if err != nil {
-		return req, fmt.Errorf("could not decode request body %s: %v", string(msg.Data), err)
-	}
-
-	if req.Questions == nil {
-		req.Questions = []types.Question{}
+// UnmarshalRequest decodes the request body into req, a pointer to one of the
+//  types request structs, and validates it (see Validate)
+func UnmarshalRequest(msg *bearerstdapi.BearerStandardAPIdata, req interface{}) error {
+	if err := json.Unmarshal(msg.Data, req); err != nil {
+		return apierror.ErrCantParseBody.Withf("%s: %v", string(msg.Data), err)
 	}
-	return
+	return Validate(req)
 }
 
 func GetBytesID(ctx *httprouter.HTTPContext, name string) ([]byte, error) {
 	organization := ctx.URLParam(name)
 	organizationID, err := hex.DecodeString(util.TrimHex(organization))
 	if err != nil {
-		return nil, fmt.Errorf("could not parse urlParam %s: %v", name, err)
+		return nil, apierror.ErrInvalidURLParam.Withf("%s: %v", name, err)
 	}
 	return organizationID, nil
 }
@@ -37,14 +35,74 @@ func GetIntID(ctx *httprouter.HTTPContext, name string) (int, error) {
 	id := ctx.URLParam(name)
 	intID, err := strconv.Atoi(id)
 	if err != nil {
-		return 0, fmt.Errorf("could not parse urlParam %s: %v", name, err)
+		return 0, apierror.ErrInvalidURLParam.Withf("%s: %v", name, err)
 	}
 	return intID, nil
 }
 
+// GetQueryInt returns the integer query parameter name, or 0 if it is not set
+func GetQueryInt(ctx *httprouter.HTTPContext, name string) (int, error) {
+	value := ctx.Request.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, apierror.ErrInvalidFilter.Withf("%s must be a positive integer: %s", name, value)
+	}
+	return n, nil
+}
+
+// GetQueryTime returns the RFC 3339 date query parameter name, or the zero time
+//  if it is not set
+func GetQueryTime(ctx *httprouter.HTTPContext, name string) (time.Time, error) {
+	value := ctx.Request.URL.Query().Get(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, apierror.ErrInvalidFilter.Withf("%s must be an RFC 3339 date: %s",
+			name, value)
+	}
+	return t, nil
+}
+
+// GetQueryBytes returns the hex query parameter name, or nil if it is not set
+func GetQueryBytes(ctx *httprouter.HTTPContext, name string) ([]byte, error) {
+	value := ctx.Request.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	b, err := hex.DecodeString(util.TrimHex(value))
+	if err != nil {
+		return nil, apierror.ErrInvalidFilter.Withf("%s must be hex: %s", name, value)
+	}
+	return b, nil
+}
+
+// GetListOptions returns the page selected by the count, skip, cursor, sortBy and
+//  order query parameters
+func GetListOptions(ctx *httprouter.HTTPContext) (*types.ListOptions, error) {
+	query := ctx.Request.URL.Query()
+	opts := &types.ListOptions{
+		Cursor: query.Get("cursor"),
+		SortBy: query.Get("sortBy"),
+		Order:  query.Get("order"),
+	}
+	var err error
+	if opts.Count, err = GetQueryInt(ctx, "count"); err != nil {
+		return nil, err
+	}
+	if opts.Skip, err = GetQueryInt(ctx, "skip"); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
 func GetAuthToken(msg *bearerstdapi.BearerStandardAPIdata) (token []byte, err error) {
 	if token, err = hex.DecodeString(msg.AuthToken); err != nil {
-		return []byte{}, fmt.Errorf("could not decode auth token: %v", err)
+		return []byte{}, apierror.ErrInvalidAuthToken.Withf("could not decode: %v", err)
 	}
 	return token, nil
 }
@@ -0,0 +1,185 @@
+package util
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+	"go.vocdoni.io/dvote/util"
+)
+
+// Validate checks the `validate` struct tags of v, which must be a pointer to a struct
+//  or a struct, and returns an apierror.ErrInvalidField listing every invalid field.
+// Nested structs and slices of structs are validated recursively.
+// The supported rules, separated by commas, are:
+//  required     the field cannot be empty (or only whitespace)
+//  required_without=F|G  required unless one of the sibling fields F or G is set
+//  min=N,max=N  length bounds for strings and slices, value bounds for numbers
+//  email        a plain email address
+//  date         a date in types.DateLayout
+//  hex          hex encoded bytes, with or without 0x prefix
+//  base64       standard base64 encoded bytes
+//  unique=F     the field F of the slice elements must not repeat
+// All rules but required are skipped for empty values.
+func Validate(v interface{}) error {
+	var fields []apierror.FieldError
+	validateStruct(reflect.Indirect(reflect.ValueOf(v)), "", &fields)
+	if len(fields) > 0 {
+		return apierror.ErrInvalidField.WithFields(fields)
+	}
+	return nil
+}
+
+func validateStruct(v reflect.Value, prefix string, fields *[]apierror.FieldError) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Name
+		if n := strings.Split(field.Tag.Get("json"), ",")[0]; n != "" && n != "-" {
+			name = n
+		}
+		path := prefix + name
+		value := v.Field(i)
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			if rule == "" {
+				continue
+			}
+			if strings.HasPrefix(rule, "required_without=") {
+				if setAny(v, strings.TrimPrefix(rule, "required_without=")) {
+					continue
+				}
+				rule = "required"
+			}
+			if msg := checkRule(rule, value); msg != "" {
+				*fields = append(*fields, apierror.FieldError{Field: path, Message: msg})
+				// one message per field is enough
+				break
+			}
+		}
+		validateNested(value, path, fields)
+	}
+}
+
+// setAny tells whether any of the fields of v, separated by |, is not empty
+func setAny(v reflect.Value, names string) bool {
+	for _, name := range strings.Split(names, "|") {
+		field := v.FieldByName(name)
+		if !field.IsValid() {
+			panic(fmt.Sprintf("unknown field %q in validate rule", name))
+		}
+		if !isEmpty(reflect.Indirect(field)) {
+			return true
+		}
+	}
+	return false
+}
+
+func validateNested(v reflect.Value, path string, fields *[]apierror.FieldError) {
+	v = reflect.Indirect(v)
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() != reflect.TypeOf(time.Time{}) {
+			validateStruct(v, path+".", fields)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateNested(v.Index(i), fmt.Sprintf("%s[%d]", path, i), fields)
+		}
+	}
+}
+
+// checkRule returns why v does not satisfy rule, or an empty string if it does
+func checkRule(rule string, v reflect.Value) string {
+	name, arg := rule, ""
+	if i := strings.Index(rule, "="); i >= 0 {
+		name, arg = rule[:i], rule[i+1:]
+	}
+	v = reflect.Indirect(v)
+	if name == "required" {
+		if isEmpty(v) {
+			return "is required"
+		}
+		return ""
+	}
+	if isEmpty(v) {
+		return ""
+	}
+	switch name {
+	case "min", "max":
+		limit, err := strconv.Atoi(arg)
+		if err != nil {
+			panic(fmt.Sprintf("invalid validate rule %q", rule))
+		}
+		size, unit := measure(v)
+		if name == "min" && size < limit {
+			return fmt.Sprintf("must be at least %d%s", limit, unit)
+		}
+		if name == "max" && size > limit {
+			return fmt.Sprintf("must be at most %d%s", limit, unit)
+		}
+	case "email":
+		if addr, err := mail.ParseAddress(v.String()); err != nil || addr.Address != v.String() {
+			return "is not a valid email address"
+		}
+	case "date":
+		if _, err := time.Parse(types.DateLayout, v.String()); err != nil {
+			return fmt.Sprintf("is not a date in the format %s", types.DateLayout)
+		}
+	case "hex":
+		if _, err := hex.DecodeString(util.TrimHex(v.String())); err != nil {
+			return "is not valid hex"
+		}
+	case "base64":
+		if _, err := base64.StdEncoding.DecodeString(v.String()); err != nil {
+			return "is not valid base64"
+		}
+	case "unique":
+		seen := map[interface{}]bool{}
+		for i := 0; i < v.Len(); i++ {
+			key := reflect.Indirect(v.Index(i)).FieldByName(arg).Interface()
+			if seen[key] {
+				return fmt.Sprintf("has a repeated %s %v", strings.ToLower(arg), key)
+			}
+			seen[key] = true
+		}
+	default:
+		panic(fmt.Sprintf("unknown validate rule %q", rule))
+	}
+	return ""
+}
+
+func isEmpty(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	if v.Kind() == reflect.String {
+		return strings.TrimSpace(v.String()) == ""
+	}
+	return v.IsZero() || ((v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0)
+}
+
+// measure returns the size of v that min and max are compared to, and its unit
+func measure(v reflect.Value) (int, string) {
+	switch v.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(v.String()), " characters long"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len(), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(v.Int()), ""
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(v.Uint()), ""
+	}
+	panic(fmt.Sprintf("min/max not supported for %s", v.Kind()))
+}
@@ -0,0 +1,57 @@
+package util
+
+import (
+	"errors"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/api/apierror"
+	"go.vocdoni.io/api/types"
+)
+
+func TestValidate(t *testing.T) {
+	election := types.CreateElectionRequest{
+		EndDate: "2030-01-02T15:04:05.000Z",
+		Title:   "Election",
+		Questions: []types.Question{{
+			Title:   "Question",
+			Choices: []types.Choice{{Title: "Yes", Value: 0}, {Title: "No", Value: 1}},
+		}},
+	}
+	qt.Assert(t, Validate(&election), qt.IsNil)
+
+	// every invalid field is reported, with its JSON path
+	election.StartDate = "tomorrow"
+	election.Title = "  "
+	election.Questions[0].Choices[1].Value = 0
+	election.Questions = append(election.Questions, types.Question{Title: "Empty"})
+	err := Validate(&election)
+	qt.Assert(t, errors.Is(err, apierror.ErrInvalidField), qt.IsTrue)
+	fields := apierror.From(err).Fields
+	qt.Assert(t, fields, qt.HasLen, 4)
+	qt.Assert(t, fields[0].Field, qt.Equals, "questions[0].choices")
+	qt.Assert(t, fields[0].Message, qt.Equals, "has a repeated value 0")
+	qt.Assert(t, fields[1].Field, qt.Equals, "questions[1].choices")
+	qt.Assert(t, fields[1].Message, qt.Equals, "is required")
+	qt.Assert(t, fields[2].Field, qt.Equals, "startDate")
+	qt.Assert(t, fields[3].Field, qt.Equals, "title")
+
+	// zero questions
+	election = types.CreateElectionRequest{EndDate: "2030-01-02T15:04:05.000Z", Title: "E"}
+	fields = apierror.From(Validate(&election)).Fields
+	qt.Assert(t, fields, qt.HasLen, 1)
+	qt.Assert(t, fields[0].Field, qt.Equals, "questions")
+	// the title and questions of a template are optional
+	election = types.CreateElectionRequest{EndDate: "2030-01-02T15:04:05.000Z", TemplateID: 1}
+	qt.Assert(t, Validate(&election), qt.IsNil)
+
+	integrator := types.CreateIntegratorRequest{Name: "name", Email: "not an email"}
+	fields = apierror.From(Validate(&integrator)).Fields
+	qt.Assert(t, fields, qt.HasLen, 1)
+	qt.Assert(t, fields[0].Field, qt.Equals, "email")
+	integrator.Email = "admin@vocdoni.io"
+	integrator.CspPubKey = "0xzz"
+	fields = apierror.From(Validate(&integrator)).Fields
+	qt.Assert(t, fields, qt.HasLen, 1)
+	qt.Assert(t, fields[0].Field, qt.Equals, "cspPubKey")
+}
@@ -0,0 +1,71 @@
+package vocclient
+
+import (
+	"errors"
+	"strings"
+
+	dvotedb "go.vocdoni.io/dvote/db"
+	"go.vocdoni.io/dvote/db/lru"
+	"go.vocdoni.io/dvote/log"
+)
+
+const (
+	// ipfsPrefix is the scheme of content-addressed URIs, whose content never changes
+	ipfsPrefix = "ipfs://"
+	// filePrefix is the key prefix of the files persisted by the file cache
+	filePrefix = "file"
+)
+
+// fileCache keeps the content of the IPFS files fetched through the gateway, keyed
+//  by URI. The most recently used files are kept in memory, and every file is
+//  persisted to the key-value store so the cache survives restarts.
+// A nil fileCache caches nothing.
+type fileCache struct {
+	lru *lru.Cache
+	kv  dvotedb.Database
+}
+
+// newFileCache returns a cache keeping size files in memory on top of kv
+func newFileCache(kv dvotedb.Database, size int) *fileCache {
+	return &fileCache{lru: lru.New(size), kv: kv}
+}
+
+// get returns the cached content of a file, or nil if it is not cached
+func (fc *fileCache) get(uri string) []byte {
+	if fc == nil || !strings.HasPrefix(uri, ipfsPrefix) {
+		return nil
+	}
+	if content, ok := fc.lru.Get(uri).([]byte); ok {
+		return content
+	}
+	tx := fc.kv.ReadTx()
+	defer tx.Discard()
+	content, err := tx.Get(append([]byte(filePrefix), uri...))
+	if err != nil {
+		if !errors.Is(err, dvotedb.ErrKeyNotFound) {
+			log.Warnf("could not read %s from the file cache: %v", uri, err)
+		}
+		return nil
+	}
+	// the value is only valid until the tx is discarded
+	content = append([]byte{}, content...)
+	fc.lru.Add(uri, content)
+	return content
+}
+
+// set caches the content of a file, replacing the one cached for its URI if any
+func (fc *fileCache) set(uri string, content []byte) {
+	if fc == nil || !strings.HasPrefix(uri, ipfsPrefix) {
+		return
+	}
+	fc.lru.Add(uri, content)
+	tx := fc.kv.WriteTx()
+	defer tx.Discard()
+	if err := tx.Set(append([]byte(filePrefix), uri...), content); err != nil {
+		log.Warnf("could not write %s to the file cache: %v", uri, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Warnf("could not write %s to the file cache: %v", uri, err)
+	}
+}
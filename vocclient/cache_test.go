@@ -0,0 +1,33 @@
+package vocclient
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"go.vocdoni.io/dvote/db/metadb"
+)
+
+func TestFileCache(t *testing.T) {
+	kv := metadb.NewTest(t)
+	cache := newFileCache(kv, 2)
+	uri := "ipfs://QmTest"
+	qt.Assert(t, cache.get(uri), qt.IsNil)
+	cache.set(uri, []byte("content"))
+	qt.Assert(t, cache.get(uri), qt.DeepEquals, []byte("content"))
+
+	// new content replaces the cached one
+	cache.set(uri, []byte("updated"))
+	qt.Assert(t, cache.get(uri), qt.DeepEquals, []byte("updated"))
+
+	// files evicted from memory, or cached before a restart, are read from kv
+	restarted := newFileCache(kv, 2)
+	qt.Assert(t, restarted.get(uri), qt.DeepEquals, []byte("updated"))
+
+	// only content-addressed files are cached
+	cache.set("https://example.com/meta.json", []byte("content"))
+	qt.Assert(t, cache.get("https://example.com/meta.json"), qt.IsNil)
+
+	var disabled *fileCache
+	disabled.set(uri, []byte("content"))
+	qt.Assert(t, disabled.get(uri), qt.IsNil)
+}
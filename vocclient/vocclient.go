@@ -4,16 +4,19 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/rand"
 	"sync"
 	"time"
 
+	"go.vocdoni.io/api/apierror"
 	"go.vocdoni.io/api/types"
 	apiUtil "go.vocdoni.io/api/util"
 	"go.vocdoni.io/dvote/api"
 	"go.vocdoni.io/dvote/client"
 	"go.vocdoni.io/dvote/crypto/ethereum"
+	dvotedb "go.vocdoni.io/dvote/db"
 	"go.vocdoni.io/dvote/log"
 	dvoteTypes "go.vocdoni.io/dvote/types"
 	"go.vocdoni.io/dvote/util"
@@ -50,6 +53,7 @@ type Client struct {
 	gw          *client.Client
 	signingKey  *ethereum.SignKeys
 	blockHeight *vocBlockHeight
+	files       *fileCache
 }
 
 // New initializes a new gatewayPool with the gatewayUrls, in order of health
@@ -94,6 +98,26 @@ func New(gatewayUrl string, signingKey *ethereum.SignKeys) (*Client, error) {
 	return c, nil
 }
 
+// EnableFileCache caches the IPFS files fetched and added through the gateway,
+//  keeping size of them in memory and all of them in kv. A size of 0 disables it.
+func (c *Client) EnableFileCache(kv dvotedb.Database, size int) {
+	if size <= 0 {
+		c.files = nil
+		return
+	}
+	c.files = newFileCache(kv, size)
+}
+
+// SignerAddress returns the address of the API signing key
+func (c *Client) SignerAddress() []byte {
+	return c.signingKey.Address().Bytes()
+}
+
+// SignMessage returns the Ethereum signature of message by the API signing key
+func (c *Client) SignMessage(message []byte) ([]byte, error) {
+	return c.signingKey.SignEthereum(message)
+}
+
 // ActiveEndpoint returns the address of the current active endpoint, if one exists
 func (c *Client) ActiveEndpoint() string {
 	if c.gw == nil {
@@ -102,18 +126,29 @@ func (c *Client) ActiveEndpoint() string {
 	return c.gw.Addr
 }
 
+// request sends req to the gateway. Transport failures are returned as
+//  apierror.ErrGatewayUnavailable, and requests the gateway rejects as apierror.ErrGateway
 func (c *Client) request(req api.APIrequest,
 	signer *ethereum.SignKeys) (*api.APIresponse, error) {
 	resp, err := c.gw.Request(req, signer)
 	if err != nil {
-		return nil, err
+		return nil, apierror.ErrGatewayUnavailable.WithErr(err)
 	}
 	if !resp.Ok {
-		return nil, fmt.Errorf(resp.Message)
+		return nil, apierror.ErrGateway.Withf("%s: %s", req.Method, resp.Message)
 	}
 	return resp, nil
 }
 
+// notFound translates a rejected gateway request into the given not-found error.
+// Transport failures are returned untouched, as they say nothing about existence.
+func notFound(err error, notFoundErr apierror.Error) error {
+	if errors.Is(err, apierror.ErrGateway) {
+		return notFoundErr.WithErr(err)
+	}
+	return err
+}
+
 // FETCHING INFO APIS
 
 // GetChainID gets the chain ID for the gateway
@@ -140,7 +175,7 @@ func (c *Client) GetVoteStatus(nullifier []byte) ([]byte, bool, error) {
 	}
 	resp, err := c.request(req, c.signingKey)
 	if err != nil {
-		return nil, false, err
+		return nil, false, notFound(err, apierror.ErrVoteNotFound)
 	}
 	if !resp.Ok {
 		return nil, false, fmt.Errorf("could not get vote status: %s", resp.Message)
@@ -151,6 +186,20 @@ func (c *Client) GetVoteStatus(nullifier []byte) ([]byte, bool, error) {
 	return resp.ProcessID, *resp.Registered, nil
 }
 
+// GetEnvelope returns the vote envelope registered with the given nullifier,
+//  with the block and transaction it was included in
+func (c *Client) GetEnvelope(nullifier []byte) (*indexertypes.EnvelopePackage, error) {
+	req := api.APIrequest{Method: "getEnvelope", Nullifier: nullifier}
+	resp, err := c.request(req, c.signingKey)
+	if err != nil {
+		return nil, notFound(err, apierror.ErrVoteNotFound)
+	}
+	if !resp.Ok || resp.Envelope == nil {
+		return nil, apierror.ErrVoteNotFound.Withf("cannot getEnvelope: %v", resp.Message)
+	}
+	return resp.Envelope, nil
+}
+
 // GetCurrentBlock returns the height of the current vochain block
 func (c *Client) GetCurrentBlock() (uint32, error) {
 	req := api.APIrequest{Method: "getBlockHeight"}
@@ -193,13 +242,13 @@ func (c *Client) GetProcess(pid []byte) (*indexertypes.Process, error) {
 	req := api.APIrequest{Method: "getProcessInfo", ProcessID: pid}
 	resp, err := c.request(req, c.signingKey)
 	if err != nil {
-		return nil, err
+		return nil, notFound(err, apierror.ErrElectionNotFound)
 	}
 	if !resp.Ok || resp.Process == nil {
-		return nil, fmt.Errorf("cannot getProcessInfo: %v", resp.Message)
+		return nil, apierror.ErrElectionNotFound.Withf("cannot getProcessInfo: %v", resp.Message)
 	}
 	if resp.Process.Metadata == "" {
-		return nil, fmt.Errorf("election metadata not yet set")
+		return nil, apierror.ErrElectionNotFound.With("election metadata not yet set")
 	}
 	return resp.Process, nil
 }
@@ -223,7 +272,7 @@ func (c *Client) GetAccount(entityId []byte) (string, uint64, uint32, error) {
 	req := api.APIrequest{Method: "getAccount", EntityId: entityId}
 	resp, err := c.request(req, c.signingKey)
 	if err != nil {
-		return "", 0, 0, err
+		return "", 0, 0, notFound(err, apierror.ErrOrganizationNotFound)
 	}
 	if !resp.Ok {
 		return "", 0, 0, fmt.Errorf("could not get account: %s", resp.Message)
@@ -235,7 +284,7 @@ func (c *Client) GetAccount(entityId []byte) (string, uint64, uint32, error) {
 		resp.Nonce = new(uint32)
 	}
 	if resp.InfoURI == "" {
-		return "", 0, 0, fmt.Errorf("account info URI not yet set")
+		return "", 0, 0, apierror.ErrOrganizationNotFound.With("account info URI not yet set")
 	}
 	return resp.InfoURI, *resp.Balance, *resp.Nonce, nil
 }
@@ -254,14 +303,31 @@ func (c *Client) GetResults(pid []byte) (*types.VochainResults, error) {
 		resp.Height = new(uint32)
 	}
 	results := &types.VochainResults{
-		Results: resp.Results,
-		State:   resp.State,
-		Type:    resp.Type,
-		Height:  *resp.Height,
+		Results:   resp.Results,
+		State:     resp.State,
+		Type:      resp.Type,
+		VoteCount: *resp.Height,
 	}
 	return results, nil
 }
 
+// GetEnvelopeHeight returns the number of vote envelopes cast in a process, also
+//  for processes with encrypted votes
+func (c *Client) GetEnvelopeHeight(pid []byte) (uint32, error) {
+	req := api.APIrequest{Method: "getEnvelopeHeight", ProcessID: pid}
+	resp, err := c.request(req, c.signingKey)
+	if err != nil {
+		return 0, err
+	}
+	if !resp.Ok {
+		return 0, fmt.Errorf("could not get envelope height: %s", resp.Message)
+	}
+	if resp.Height == nil {
+		return 0, fmt.Errorf("height is nil")
+	}
+	return *resp.Height, nil
+}
+
 // GetProcessList queries for a list of process ids from the vochain.
 // filters include entityID, status ("READY", "ENDED", "CANCELED", "PAUSED", "RESULTS"),
 //  source network ID (for processes created on ethereum or other source-of-truth blockchains),
@@ -363,11 +429,13 @@ func (c *Client) AddFile(content []byte, contentType, name string) (string, erro
 		Name:    name,
 	}, c.signingKey)
 	if err != nil {
-		return "", fmt.Errorf("could not AddFile %s: %v", name, err)
+		return "", apierror.ErrMetadata.Withf("could not AddFile %s: %v", name, err)
 	}
 	if !resp.Ok {
 		return "", fmt.Errorf("could not AddFile %s: %s", name, resp.Message)
 	}
+	// the published content replaces whatever was cached for its URI
+	c.files.set(resp.URI, content)
 	return resp.URI, nil
 }
 
@@ -399,7 +467,7 @@ func (c *Client) FetchProcessMetadataConfidential(URI string,
 	}
 	decrypted, ok := apiUtil.DecryptSymmetric(file.Payload, metadataPrivKey)
 	if !ok {
-		return nil, fmt.Errorf("could not decrypt private metadata")
+		return nil, apierror.ErrCrypto.With("could not decrypt private metadata")
 	}
 	var process types.ProcessMetadata
 	return &process, json.Unmarshal(decrypted, &process)
@@ -419,18 +487,23 @@ func (c *Client) FetchOrganizationMetadata(URI string) (*types.EntityMetadata, e
 	return &entity, nil
 }
 
-// FetchFile fetches and returns a raw file from the given URI, via the gateway
+// FetchFile fetches and returns a raw file from the given URI, via the gateway.
+//  IPFS files are served from the file cache, if enabled.
 func (c *Client) FetchFile(URI string) ([]byte, error) {
+	if content := c.files.get(URI); content != nil {
+		return content, nil
+	}
 	resp, err := c.request(api.APIrequest{
 		Method: "fetchFile",
 		URI:    URI,
 	}, c.signingKey)
 	if err != nil {
-		return []byte{}, fmt.Errorf("could not fetch file %s: %v", URI, err)
+		return []byte{}, apierror.ErrMetadata.Withf("could not fetch file %s: %v", URI, err)
 	}
 	if !resp.Ok {
 		return []byte{}, fmt.Errorf(resp.Message)
 	}
+	c.files.set(URI, resp.Content)
 	return resp.Content, nil
 }
 
@@ -720,7 +793,7 @@ func (c *Client) CollectFaucet(signer *ethereum.SignKeys,
 		return fmt.Errorf("collectFaucet: could not get faucet account: %v", err)
 	}
 	if balance < c.AcctTxCost*DefaultFaucetMultiplier {
-		return fmt.Errorf("collectFaucet: faucet balance is %d, expect at least %d",
+		return apierror.ErrFaucetFunds.Withf("collectFaucet: faucet balance is %d, expect at least %d",
 			balance, c.AcctTxCost*DefaultFaucetMultiplier)
 	}
 
@@ -772,6 +845,10 @@ func (c *Client) RelayVote(signedTx []byte) (string, error) {
 		Payload: signedTx,
 	}, nil)
 	if err != nil {
+		// A vote rejected by the gateway is the voter's fault, not an upstream failure
+		if errors.Is(err, apierror.ErrGateway) {
+			return "", apierror.ErrInvalidVote.WithErr(err)
+		}
 		return "", err
 	}
 	if !resp.Ok {